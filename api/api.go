@@ -10,6 +10,7 @@ import (
 	_ "net/http/pprof"
 
 	"github.com/grafana/metrictank/idx"
+	"github.com/grafana/metrictank/input"
 	"github.com/grafana/metrictank/mdata"
 	"github.com/grafana/metrictank/mdata/cache"
 	"github.com/grafana/metrictank/stats"
@@ -46,6 +47,10 @@ type Server struct {
 	Cache        cache.Cache
 	shutdown     chan struct{}
 	Tracer       opentracing.Tracer
+	queries      *queryList
+
+	PrometheusHandler input.Handler // processes the series ingested via prometheus remote write
+	OpenTSDBHandler   input.Handler // processes the series ingested via the opentsdb put api
 }
 
 func (s *Server) BindMetricIndex(i idx.MetricIndex) {
@@ -66,6 +71,14 @@ func (s *Server) BindTracer(tracer opentracing.Tracer) {
 	s.Tracer = tracer
 }
 
+func (s *Server) BindPrometheusHandler(handler input.Handler) {
+	s.PrometheusHandler = handler
+}
+
+func (s *Server) BindOpenTSDBHandler(handler input.Handler) {
+	s.OpenTSDBHandler = handler
+}
+
 func NewServer() (*Server, error) {
 
 	m := macaron.New()
@@ -75,7 +88,8 @@ func NewServer() (*Server, error) {
 	m.Use(func(ctx *macaron.Context) {
 		if strings.HasPrefix(ctx.Req.URL.Path, "/debug/") &&
 			!strings.HasPrefix(ctx.Req.URL.Path, "/debug/pprof/block") &&
-			!strings.HasPrefix(ctx.Req.URL.Path, "/debug/pprof/mutex") {
+			!strings.HasPrefix(ctx.Req.URL.Path, "/debug/pprof/mutex") &&
+			!strings.HasPrefix(ctx.Req.URL.Path, "/debug/queries") {
 			http.DefaultServeMux.ServeHTTP(ctx.Resp, ctx.Req.Request)
 		}
 	})
@@ -88,6 +102,7 @@ func NewServer() (*Server, error) {
 		shutdown: make(chan struct{}),
 		Macaron:  m,
 		Tracer:   opentracing.NoopTracer{},
+		queries:  newQueryList(),
 	}, nil
 }
 
@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/metrictank/api/models"
 	"github.com/grafana/metrictank/api/response"
 	"github.com/grafana/metrictank/cluster"
+	"github.com/grafana/metrictank/idx"
 	"github.com/raintank/worldping-api/pkg/log"
 	"github.com/tinylib/msgp/msgp"
 )
@@ -94,11 +95,12 @@ func (s *Server) indexFind(ctx *middleware.Context, req models.IndexFind) {
 		req.From -= 86400
 	}
 	resp := models.NewIndexFindResp()
+	limits := idx.FindLimits{MaxSeries: req.MaxSeries, MaxExpansions: req.MaxExpansions}
 
 	for _, pattern := range req.Patterns {
-		nodes, err := s.MetricIndex.Find(req.OrgId, pattern, req.From)
+		nodes, err := s.MetricIndex.Find(req.OrgId, pattern, req.From, limits)
 		if err != nil {
-			response.Write(ctx, response.NewError(http.StatusBadRequest, err.Error()))
+			response.Write(ctx, response.WrapError(findError(err, pattern, limits)))
 			return
 		}
 		resp.Nodes[pattern] = nodes
@@ -129,7 +131,8 @@ func (s *Server) indexList(ctx *middleware.Context, req models.IndexList) {
 }
 
 func (s *Server) getData(ctx *middleware.Context, request models.GetData) {
-	series, err := s.getTargetsLocal(ctx.Req.Context(), request.Requests)
+	var ss models.StorageStats
+	series, err := s.getTargetsLocal(withStorageStats(ctx.Req.Context(), &ss), request.Requests)
 	if err != nil {
 		// the only errors returned are from us catching panics, so we should treat them
 		// all as internalServerErrors
@@ -137,7 +140,7 @@ func (s *Server) getData(ctx *middleware.Context, request models.GetData) {
 		response.Write(ctx, response.WrapError(err))
 		return
 	}
-	response.Write(ctx, response.NewMsgp(200, &models.GetDataResp{Series: series}))
+	response.Write(ctx, response.NewMsgp(200, &models.GetDataResp{Series: series, Stats: ss}))
 }
 
 func (s *Server) indexDelete(ctx *middleware.Context, req models.IndexDelete) {
@@ -153,3 +156,29 @@ func (s *Server) indexDelete(ctx *middleware.Context, req models.IndexDelete) {
 	}
 	response.Write(ctx, response.NewMsgp(200, &resp))
 }
+
+// IndexTags returns a msgp encoded list of all tag keys of the given org
+func (s *Server) indexTags(ctx *middleware.Context, req models.IndexTags) {
+	resp := models.IndexTagsResp{
+		Tags: s.MetricIndex.TagList(req.OrgId),
+	}
+	response.Write(ctx, response.NewMsgp(200, &resp))
+}
+
+// IndexTagDetails returns a msgp encoded map of the values of the given tag and their series counts
+func (s *Server) indexTagDetails(ctx *middleware.Context, req models.IndexTagDetails) {
+	resp := models.IndexTagDetailsResp{
+		Values: s.MetricIndex.Tag(req.OrgId, req.Tag, req.From),
+	}
+	response.Write(ctx, response.NewMsgp(200, &resp))
+}
+
+// IndexFindByTag returns a sequence of msgp encoded idx.Node's of the series matching the tag expressions
+func (s *Server) indexFindByTag(ctx *middleware.Context, req models.IndexFindByTag) {
+	nodes, err := s.findByTagLocal(req.OrgId, req.Expr, req.From, idx.FindLimits{MaxSeries: req.MaxSeries})
+	if err != nil {
+		response.Write(ctx, response.WrapError(err))
+		return
+	}
+	response.Write(ctx, response.NewMsgp(200, &models.IndexFindByTagResp{Nodes: nodes}))
+}
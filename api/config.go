@@ -27,6 +27,9 @@ var (
 	fallbackGraphite string
 	timeZoneStr      string
 
+	PrometheusWriteEnabled bool
+	OpenTSDBPutEnabled     bool
+
 	graphiteProxy *httputil.ReverseProxy
 	timeZone      *time.Location
 )
@@ -35,6 +38,11 @@ func ConfigSetup() {
 	apiCfg := flag.NewFlagSet("http", flag.ExitOnError)
 	apiCfg.IntVar(&maxPointsPerReqSoft, "max-points-per-req-soft", 1000000, "lower resolution rollups will be used to try and keep requests below this number of datapoints. (0 disables limit)")
 	apiCfg.IntVar(&maxPointsPerReqHard, "max-points-per-req-hard", 20000000, "limit of number of datapoints a request can return. Requests that exceed this limit will be rejected. (0 disables limit)")
+	apiCfg.IntVar(&defaultLimits.maxSeriesPerTarget, "max-series-per-target", 0, "limit of number of series the patterns and seriesByTag queries of a single target may resolve to, together. Also applies to find, tag and prometheus/opentsdb queries. Requests that exceed this limit will be rejected with a 413. (0 disables limit)")
+	apiCfg.IntVar(&defaultLimits.maxPointsFetch, "max-points-fetch", 0, "limit of number of datapoints a request may fetch. Requests that exceed this limit will be rejected. (0 disables limit)")
+	apiCfg.IntVar(&defaultLimits.maxExpansions, "max-pattern-expansions", 0, "limit of number of patterns a pattern may expand into via {a,b} lists. Requests that exceed this limit will be rejected with a 400. (0 disables limit)")
+	apiCfg.IntVar(&defaultLimits.maxExprDepth, "max-expr-depth", 0, "limit of nesting depth of the expression of a target, e.g. sum(foo.*) has a depth of 2. Requests that exceed this limit will be rejected. (0 disables limit)")
+	apiCfg.StringVar(&orgLimitsStr, "org-limits", "", "space separated per-org overrides of the above limits, in the form <orgId>:<limit>=<value>,<limit>=<value> e.g. '10:max-series-per-target=1000,max-expr-depth=10 20:max-points-fetch=0'")
 	apiCfg.StringVar(&logMinDurStr, "log-min-dur", "5min", "only log incoming requests if their timerange is at least this duration. Use 0 to disable")
 
 	apiCfg.StringVar(&Addr, "listen", ":6060", "http listener address.")
@@ -45,14 +53,22 @@ func ConfigSetup() {
 	apiCfg.BoolVar(&multiTenant, "multi-tenant", true, "require x-org-id authentication to auth as a specific org. otherwise orgId 1 is assumed")
 	apiCfg.StringVar(&fallbackGraphite, "fallback-graphite-addr", "http://localhost:8080", "in case our /render endpoint does not support the requested processing, proxy the request to this graphite")
 	apiCfg.StringVar(&timeZoneStr, "time-zone", "local", "timezone for interpreting from/until values when needed, specified using [zoneinfo name](https://en.wikipedia.org/wiki/Tz_database#Names_of_time_zones) e.g. 'America/New_York', 'UTC' or 'local' to use local server timezone")
+	apiCfg.BoolVar(&PrometheusWriteEnabled, "prometheus-write-enabled", false, "accept data via prometheus remote write requests on /prometheus/write. the series are indexed under the partitions of this node, so in a cluster, each replica needs to receive the data")
+	apiCfg.BoolVar(&OpenTSDBPutEnabled, "opentsdb-put-enabled", false, "accept data via opentsdb put requests on /api/put. the series are indexed under the partitions of this node, so in a cluster, each replica needs to receive the data")
 	globalconf.Register("http", apiCfg)
 }
 
 func ConfigProcess() {
 	logMinDur = dur.MustParseDuration("log-min-dur", logMinDurStr)
 
+	var err error
+	orgLimits, err = parseOrgLimits(orgLimitsStr, defaultLimits)
+	if err != nil {
+		log.Fatal(4, "API Cannot parse org-limits: %s", err)
+	}
+
 	//validate the addr
-	_, err := net.ResolveTCPAddr("tcp", Addr)
+	_, err = net.ResolveTCPAddr("tcp", Addr)
 	if err != nil {
 		log.Fatal(4, "API listen address is not a valid TCP address.")
 	}
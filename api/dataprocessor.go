@@ -39,6 +39,19 @@ func doRecover(errp *error) {
 	return
 }
 
+type storageStatsKey struct{}
+
+// withStorageStats returns a context that collects storage stats of the requests made with it into ss
+func withStorageStats(ctx context.Context, ss *models.StorageStats) context.Context {
+	return context.WithValue(ctx, storageStatsKey{}, ss)
+}
+
+// storageStatsFromContext returns the storage stats to collect into, if any
+func storageStatsFromContext(ctx context.Context) *models.StorageStats {
+	ss, _ := ctx.Value(storageStatsKey{}).(*models.StorageStats)
+	return ss
+}
+
 // Fix assures all points are nicely aligned (quantized) and padded with nulls in case there's gaps in data
 // graphite does this quantization before storing, we may want to do that as well at some point
 // note: values are quantized to the right because we can't lie about the future:
@@ -195,6 +208,9 @@ func (s *Server) getTargetsRemote(ctx context.Context, remoteReqs map[string][]m
 				return
 			}
 			log.Debug("DP getTargetsRemote: %s returned %d series", node.Name, len(resp.Series))
+			if ss := storageStatsFromContext(ctx); ss != nil {
+				ss.Add(&resp.Stats)
+			}
 			seriesChan <- resp.Series
 		}(ctx, nodeReqs)
 	}
@@ -244,7 +260,9 @@ func (s *Server) getTargetsLocal(ctx context.Context, reqs []models.Req) ([]mode
 					QueryFrom:    req.From,
 					QueryTo:      req.To,
 					QueryCons:    req.ConsReq,
+					PlanReq:      req.PlanReq,
 					Consolidator: req.Consolidator,
+					XFilesFactor: mdata.Aggregations.Get(req.AggId).XFilesFactor,
 				}
 			}
 			wg.Done()
@@ -271,10 +289,15 @@ func (s *Server) getTargetsLocal(ctx context.Context, reqs []models.Req) ([]mode
 
 func (s *Server) getTarget(ctx context.Context, req models.Req) (points []schema.Point, interval uint32, err error) {
 	defer doRecover(&err)
+	// the request may have been canceled while it was waiting for other targets
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
 	readRollup := req.Archive != 0 // do we need to read from a downsampled series?
 	normalize := req.AggNum > 1    // do we need to normalize points at runtime?
 	// normalize is runtime consolidation but only for the purpose of bringing high-res
 	// series to the same resolution of lower res series.
+	xff := mdata.Aggregations.Get(req.AggId).XFilesFactor
 
 	if LogLevel < 2 {
 		if normalize {
@@ -287,7 +310,7 @@ func (s *Server) getTarget(ctx context.Context, req models.Req) (points []schema
 	if !readRollup && !normalize {
 		return s.getSeriesFixed(ctx, req, consolidation.None), req.OutInterval, nil
 	} else if !readRollup && normalize {
-		return consolidation.Consolidate(s.getSeriesFixed(ctx, req, consolidation.None), req.AggNum, req.Consolidator), req.OutInterval, nil
+		return consolidation.Consolidate(s.getSeriesFixed(ctx, req, consolidation.None), req.AggNum, req.Consolidator, xff), req.OutInterval, nil
 	} else if readRollup && !normalize {
 		if req.Consolidator == consolidation.Avg {
 			return divide(
@@ -301,12 +324,12 @@ func (s *Server) getTarget(ctx context.Context, req models.Req) (points []schema
 		// readRollup && normalize
 		if req.Consolidator == consolidation.Avg {
 			return divide(
-				consolidation.Consolidate(s.getSeriesFixed(ctx, req, consolidation.Sum), req.AggNum, consolidation.Sum),
-				consolidation.Consolidate(s.getSeriesFixed(ctx, req, consolidation.Cnt), req.AggNum, consolidation.Sum),
+				consolidation.Consolidate(s.getSeriesFixed(ctx, req, consolidation.Sum), req.AggNum, consolidation.Sum, xff),
+				consolidation.Consolidate(s.getSeriesFixed(ctx, req, consolidation.Cnt), req.AggNum, consolidation.Sum, xff),
 			), req.OutInterval, nil
 		} else {
 			return consolidation.Consolidate(
-				s.getSeriesFixed(ctx, req, req.Consolidator), req.AggNum, req.Consolidator), req.OutInterval, nil
+				s.getSeriesFixed(ctx, req, req.Consolidator), req.AggNum, req.Consolidator, xff), req.OutInterval, nil
 		}
 	}
 }
@@ -325,6 +348,9 @@ func (s *Server) getSeriesFixed(ctx context.Context, req models.Req, consolidato
 	rctx := newRequestContext(ctx, &req, consolidator)
 	res := s.getSeries(rctx)
 	res.Points = append(s.itersToPoints(rctx, res.Iters), res.Points...)
+	if ss := storageStatsFromContext(ctx); ss != nil {
+		ss.IncPointsFetch(len(res.Points))
+	}
 	return Fix(res.Points, req.From, req.To, req.ArchInterval)
 }
 
@@ -415,6 +441,10 @@ func (s *Server) getSeriesCachedStore(ctx *requestContext, until uint32) []chunk
 	log.Debug("cache: searching query key %s, from %d, until %d", key, ctx.From, until)
 	cacheRes := s.Cache.Search(ctx.ctx, key, ctx.From, until)
 	log.Debug("cache: result start %d, end %d", len(cacheRes.Start), len(cacheRes.End))
+	ss := storageStatsFromContext(ctx.ctx)
+	if ss != nil {
+		ss.IncChunksCache(len(cacheRes.Start) + len(cacheRes.End))
+	}
 
 	for _, itgen := range cacheRes.Start {
 		iter, err := itgen.Get()
@@ -435,6 +465,9 @@ func (s *Server) getSeriesCachedStore(ctx *requestContext, until uint32) []chunk
 			if err != nil {
 				panic(err)
 			}
+			if ss != nil {
+				ss.IncChunksStore(len(storeIterGens))
+			}
 
 			for _, itgen := range storeIterGens {
 				it, err := itgen.Get()
@@ -475,6 +508,7 @@ func mergeSeries(in []models.Series) []models.Series {
 		from   uint32
 		to     uint32
 		con    consolidation.Consolidator
+		plan   int
 	}
 	seriesByTarget := make(map[segment][]models.Series)
 	for _, series := range in {
@@ -484,6 +518,7 @@ func mergeSeries(in []models.Series) []models.Series {
 			series.QueryFrom,
 			series.QueryTo,
 			series.Consolidator,
+			series.PlanReq,
 		}
 		seriesByTarget[s] = append(seriesByTarget[s], series)
 	}
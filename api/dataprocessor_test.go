@@ -377,6 +377,31 @@ func TestGetSeriesFixed(t *testing.T) {
 	}
 }
 
+func TestGetSeriesFixedPointsFetch(t *testing.T) {
+	cluster.Init("default", "test", time.Now(), "http", 6060)
+	store := mdata.NewDevnullStore()
+
+	mdata.SetSingleAgg(conf.Avg, conf.Min, conf.Max)
+	mdata.SetSingleSchema(conf.NewRetentionMT(10, 100, 600, 10, true))
+
+	metrics := mdata.NewAggMetrics(store, &cache.MockCache{}, false, 0, 0, 0)
+	srv, _ := NewServer()
+	srv.BindBackendStore(store)
+	srv.BindMemoryStore(metrics)
+
+	metric := metrics.GetOrCreate("points.fetch", "points.fetch", 0, 0)
+	for ts := uint32(10); ts <= 50; ts += 10 {
+		metric.Add(ts, float64(ts))
+	}
+	req := models.NewReq("points.fetch", "points.fetch", "points.fetch", 20, 41, 1000, 10, consolidation.Avg, 0, cluster.Manager.ThisNode(), 0, 0)
+	req.ArchInterval = 10
+	var ss models.StorageStats
+	srv.getSeriesFixed(withStorageStats(test.NewContext(), &ss), req, consolidation.None)
+	if ss.PointsFetch != 3 {
+		t.Fatalf("expected 3 points fetched, got %d", ss.PointsFetch)
+	}
+}
+
 func reqRaw(key string, from, to, maxPoints, rawInterval uint32, consolidator consolidation.Consolidator, schemaId, aggId uint16) models.Req {
 	req := models.NewReq(key, key, key, from, to, maxPoints, rawInterval, consolidator, 0, cluster.Manager.ThisNode(), schemaId, aggId)
 	return req
@@ -478,6 +503,18 @@ func TestMergeSeries(t *testing.T) {
 	}
 }
 
+// TestMergeSeriesPlanReqs tests that the same series fetched for different plan requests is not merged
+func TestMergeSeriesPlanReqs(t *testing.T) {
+	out := []models.Series{
+		{Target: "foo", QueryPatt: "foo", QueryFrom: 700, QueryTo: 2000, PlanReq: 0},
+		{Target: "foo", QueryPatt: "foo", QueryFrom: 700, QueryTo: 2000, PlanReq: 1},
+	}
+	merged := mergeSeries(out)
+	if len(merged) != 2 {
+		t.Fatalf("expected the series of both plan requests to remain, got %d series", len(merged))
+	}
+}
+
 func TestRequestContextWithoutConsolidator(t *testing.T) {
 	metric := "metric1"
 	archInterval := uint32(10)
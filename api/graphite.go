@@ -3,8 +3,10 @@ package api
 import (
 	"context"
 	"errors"
-	"math"
+	"fmt"
 	"net/http"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -52,7 +54,9 @@ type Series struct {
 	Node    cluster.Node
 }
 
-func (s *Server) findSeries(ctx context.Context, orgId int, patterns []string, seenAfter int64) ([]Series, error) {
+// findSeries returns the series matching the patterns, across the cluster. if they resolve to more series
+// than the MaxSeries limit, in the index of any of the nodes or all together, it returns errFindMaxSeries
+func (s *Server) findSeries(ctx context.Context, orgId int, patterns []string, seenAfter int64, limits idx.FindLimits) ([]Series, error) {
 	peers, err := cluster.MembersForQuery()
 	if err != nil {
 		log.Error(3, "HTTP findSeries unable to get peers, %s", err)
@@ -69,7 +73,7 @@ func (s *Server) findSeries(ctx context.Context, orgId int, patterns []string, s
 		wg.Add(1)
 		if peer.IsLocal() {
 			go func() {
-				result, err := s.findSeriesLocal(ctx, orgId, patterns, seenAfter)
+				result, err := s.findSeriesLocal(ctx, orgId, patterns, seenAfter, limits)
 				mu.Lock()
 				if err != nil {
 					errors = append(errors, err)
@@ -80,7 +84,7 @@ func (s *Server) findSeries(ctx context.Context, orgId int, patterns []string, s
 			}()
 		} else {
 			go func(peer cluster.Node) {
-				result, err := s.findSeriesRemote(ctx, orgId, patterns, seenAfter, peer)
+				result, err := s.findSeriesRemote(ctx, orgId, patterns, seenAfter, limits, peer)
 				mu.Lock()
 				if err != nil {
 					errors = append(errors, err)
@@ -93,23 +97,26 @@ func (s *Server) findSeries(ctx context.Context, orgId int, patterns []string, s
 	}
 	wg.Wait()
 	if len(errors) > 0 {
-		err = errors[0]
+		return nil, errors[0]
+	}
+	if limits.MaxSeries > 0 && countSeries(series) > limits.MaxSeries {
+		return nil, errFindMaxSeries
 	}
 
-	return series, err
+	return series, nil
 }
 
-func (s *Server) findSeriesLocal(ctx context.Context, orgId int, patterns []string, seenAfter int64) ([]Series, error) {
+func (s *Server) findSeriesLocal(ctx context.Context, orgId int, patterns []string, seenAfter int64, limits idx.FindLimits) ([]Series, error) {
 	result := make([]Series, 0)
 	for _, pattern := range patterns {
 		_, span := tracing.NewSpan(ctx, s.Tracer, "findSeriesLocal")
 		span.SetTag("org", orgId)
 		span.SetTag("pattern", pattern)
 		defer span.Finish()
-		nodes, err := s.MetricIndex.Find(orgId, pattern, seenAfter)
+		nodes, err := s.MetricIndex.Find(orgId, pattern, seenAfter, limits)
 		if err != nil {
 			tags.Error.Set(span, true)
-			return nil, response.NewError(http.StatusBadRequest, err.Error())
+			return nil, findError(err, pattern, limits)
 		}
 		result = append(result, Series{
 			Pattern: pattern,
@@ -121,12 +128,14 @@ func (s *Server) findSeriesLocal(ctx context.Context, orgId int, patterns []stri
 	return result, nil
 }
 
-func (s *Server) findSeriesRemote(ctx context.Context, orgId int, patterns []string, seenAfter int64, peer cluster.Node) ([]Series, error) {
+func (s *Server) findSeriesRemote(ctx context.Context, orgId int, patterns []string, seenAfter int64, limits idx.FindLimits, peer cluster.Node) ([]Series, error) {
 	log.Debug("HTTP Render querying %s/index/find for %d:%q", peer.Name, orgId, patterns)
 	data := models.IndexFind{
-		Patterns: patterns,
-		OrgId:    orgId,
-		From:     seenAfter,
+		Patterns:      patterns,
+		OrgId:         orgId,
+		From:          seenAfter,
+		MaxSeries:     limits.MaxSeries,
+		MaxExpansions: limits.MaxExpansions,
 	}
 	buf, err := peer.Post(ctx, "findSeriesRemote", "/index/find", data)
 	if err != nil {
@@ -181,6 +190,11 @@ func (s *Server) renderMetrics(ctx *middleware.Context, request models.GraphiteR
 		response.Write(ctx, response.NewError(http.StatusBadRequest, InvalidTimeRangeErr.Error()))
 		return
 	}
+	// the pickle format is a plain list of series, it has no place for the metadata
+	if request.Meta && request.Format == "pickle" {
+		response.Write(ctx, response.NewError(http.StatusBadRequest, "meta is not supported for the pickle format"))
+		return
+	}
 
 	span.SetTag("fromUnix", fromUnix)
 	span.SetTag("toUnix", toUnix)
@@ -201,6 +215,10 @@ func (s *Server) renderMetrics(ctx *middleware.Context, request models.GraphiteR
 	reqRenderTargetCount.Value(len(request.Targets))
 
 	if request.Process == "none" {
+		if request.Explain {
+			response.Write(ctx, response.NewJson(200, renderExplain{Proxy: "process=none"}, ""))
+			return
+		}
 		ctx.Req.Request.Body = ctx.Body
 		graphiteProxy.ServeHTTP(ctx.Resp, ctx.Req.Request)
 		renderReqProxied.Inc()
@@ -214,13 +232,19 @@ func (s *Server) renderMetrics(ctx *middleware.Context, request models.GraphiteR
 		// as graphite needs high-res data to perform its processing.
 		mdp = 0
 	}
-	plan, err := expr.NewPlan(exprs, fromUnix, toUnix, mdp, stable, nil)
+	// the timezone was already validated by getFromTo
+	loc, _ := getLocation(request.FromTo.Tz)
+	plan, err := expr.NewPlan(exprs, fromUnix, toUnix, mdp, stable, loc, nil)
 	if err != nil {
 		if fun, ok := err.(expr.ErrUnknownFunction); ok {
 			if request.NoProxy {
 				ctx.Error(http.StatusBadRequest, "localOnly requested, but the request cant be handled locally")
 				return
 			}
+			if request.Explain {
+				response.Write(ctx, response.NewJson(200, renderExplain{Proxy: err.Error()}, ""))
+				return
+			}
 			newctx, span := tracing.NewSpan(ctx.Req.Context(), s.Tracer, "graphiteproxy")
 			tags.SpanKindRPCClient.Set(span)
 			tags.PeerService.Set(span, "graphite")
@@ -238,13 +262,28 @@ func (s *Server) renderMetrics(ctx *middleware.Context, request models.GraphiteR
 		return
 	}
 
+	if request.Explain {
+		explain, err := s.explainPlan(ctx.Req.Context(), ctx.OrgId, plan)
+		if err != nil {
+			response.Write(ctx, response.WrapError(err))
+			return
+		}
+		response.Write(ctx, response.NewJson(200, explain, ""))
+		return
+	}
+
 	newctx, span := tracing.NewSpan(ctx.Req.Context(), s.Tracer, "executePlan")
 	defer span.Finish()
+	newctx, done := s.queries.add(newctx, "render", ctx.OrgId, request.Targets)
+	defer done()
 	ctx.Req = macaron.Request{ctx.Req.WithContext(newctx)}
-	out, err := s.executePlan(ctx.Req.Context(), ctx.OrgId, plan)
+	out, meta, err := s.executePlan(ctx.Req.Context(), ctx.OrgId, plan)
 	if err != nil {
 		tracing.Failure(span)
 		tracing.Error(span, err)
+		if newctx.Err() == context.Canceled {
+			err = errQueryCanceled
+		}
 		response.Write(ctx, response.WrapError(err))
 		return
 	}
@@ -261,11 +300,19 @@ func (s *Server) renderMetrics(ctx *middleware.Context, request models.GraphiteR
 
 	switch request.Format {
 	case "msgp":
-		response.Write(ctx, response.NewMsgp(200, models.SeriesByTarget(out)))
+		if request.Meta {
+			response.Write(ctx, response.NewMsgp(200, &models.SeriesWithMeta{Meta: meta, Series: out}))
+		} else {
+			response.Write(ctx, response.NewMsgp(200, models.SeriesByTarget(out)))
+		}
 	case "pickle":
 		response.Write(ctx, response.NewPickle(200, models.SeriesByTarget(out)))
 	default:
-		response.Write(ctx, response.NewFastJson(200, models.SeriesByTarget(out)))
+		if request.Meta {
+			response.Write(ctx, response.NewFastJson(200, models.SeriesWithMeta{Meta: meta, Series: out}))
+		} else {
+			response.Write(ctx, response.NewFastJson(200, models.SeriesByTarget(out)))
+		}
 	}
 	plan.Clean()
 }
@@ -279,8 +326,18 @@ func (s *Server) metricsFind(ctx *middleware.Context, request models.GraphiteFin
 		return
 	}
 	nodes := make([]idx.Node, 0)
-	series, err := s.findSeries(ctx.Req.Context(), ctx.OrgId, []string{request.Query}, int64(fromUnix))
+	findCtx, done := s.queries.add(ctx.Req.Context(), "find", ctx.OrgId, []string{request.Query})
+	defer done()
+	setQueryPhase(findCtx, "index")
+	limits := getLimits(ctx.OrgId)
+	series, err := s.findSeries(findCtx, ctx.OrgId, []string{request.Query}, int64(fromUnix), limits.findLimits(limits.maxSeriesPerTarget))
+	if isMaxSeries(err) {
+		err = errMaxSeriesPerTarget(request.Query, limits.maxSeriesPerTarget)
+	}
 	if err != nil {
+		if findCtx.Err() == context.Canceled {
+			err = errQueryCanceled
+		}
 		response.Write(ctx, response.WrapError(err))
 		return
 	}
@@ -334,11 +391,22 @@ func (s *Server) listRemote(ctx context.Context, orgId int, peer cluster.Node) (
 }
 
 func (s *Server) metricsIndex(ctx *middleware.Context) {
-	peers, err := cluster.MembersForQuery()
+	series, err := s.listSeries(ctx.Req.Context(), ctx.OrgId)
 	if err != nil {
+		log.Error(3, "HTTP IndexJson() %s", err.Error())
 		response.Write(ctx, response.WrapError(err))
 		return
 	}
+
+	response.Write(ctx, response.NewFastJson(200, models.MetricNames(series)))
+}
+
+// listSeries returns the metric definitions of all series of the org, across the cluster
+func (s *Server) listSeries(ctx context.Context, orgId int) ([]idx.Archive, error) {
+	peers, err := cluster.MembersForQuery()
+	if err != nil {
+		return nil, err
+	}
 	errors := make([]error, 0)
 	series := make([]idx.Archive, 0)
 	seenDefs := make(map[string]struct{})
@@ -348,7 +416,7 @@ func (s *Server) metricsIndex(ctx *middleware.Context) {
 		wg.Add(1)
 		if peer.IsLocal() {
 			go func() {
-				result := s.listLocal(ctx.OrgId)
+				result := s.listLocal(orgId)
 				mu.Lock()
 				for _, def := range result {
 					if _, ok := seenDefs[def.Id]; !ok {
@@ -361,7 +429,7 @@ func (s *Server) metricsIndex(ctx *middleware.Context) {
 			}()
 		} else {
 			go func(peer cluster.Node) {
-				result, err := s.listRemote(ctx.Req.Context(), ctx.OrgId, peer)
+				result, err := s.listRemote(ctx, orgId, peer)
 				mu.Lock()
 				if err != nil {
 					errors = append(errors, err)
@@ -382,13 +450,454 @@ func (s *Server) metricsIndex(ctx *middleware.Context) {
 		err = errors[0]
 	}
 
+	return series, err
+}
+
+func (s *Server) tagList(ctx context.Context, orgId int) ([]string, error) {
+	peers, err := cluster.MembersForQuery()
+	if err != nil {
+		log.Error(3, "HTTP tagList unable to get peers, %s", err)
+		return nil, err
+	}
+	errors := make([]error, 0)
+	tags := make([]string, 0)
+	seen := make(map[string]struct{})
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer cluster.Node) {
+			var result []string
+			var err error
+			if peer.IsLocal() {
+				result = s.MetricIndex.TagList(orgId)
+			} else {
+				result, err = s.tagListRemote(ctx, orgId, peer)
+			}
+			mu.Lock()
+			if err != nil {
+				errors = append(errors, err)
+			}
+			for _, tag := range result {
+				if _, ok := seen[tag]; !ok {
+					tags = append(tags, tag)
+					seen[tag] = struct{}{}
+				}
+			}
+			mu.Unlock()
+			wg.Done()
+		}(peer)
+	}
+	wg.Wait()
+	if len(errors) > 0 {
+		err = errors[0]
+	}
+
+	return tags, err
+}
+
+func (s *Server) tagListRemote(ctx context.Context, orgId int, peer cluster.Node) ([]string, error) {
+	log.Debug("HTTP tagList querying %s/index/tags for %d", peer.Name, orgId)
+	buf, err := peer.Post(ctx, "tagListRemote", "/index/tags", models.IndexTags{OrgId: orgId})
+	if err != nil {
+		log.Error(4, "HTTP tagList error querying %s/index/tags: %q", peer.Name, err)
+		return nil, err
+	}
+	resp := models.IndexTagsResp{}
+	_, err = resp.UnmarshalMsg(buf)
+	if err != nil {
+		log.Error(4, "HTTP tagList error unmarshaling body from %s/index/tags: %q", peer.Name, err)
+		return nil, err
+	}
+	return resp.Tags, nil
+}
+
+// tagDetails returns all values of the given tag and the number of series that have them.
+// note that if multiple peers have the same series in their index, it will be counted multiple times.
+func (s *Server) tagDetails(ctx context.Context, orgId int, tag string, from int64) (map[string]uint32, error) {
+	peers, err := cluster.MembersForQuery()
+	if err != nil {
+		log.Error(3, "HTTP tagDetails unable to get peers, %s", err)
+		return nil, err
+	}
+	errors := make([]error, 0)
+	values := make(map[string]uint32)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer cluster.Node) {
+			var result map[string]uint32
+			var err error
+			if peer.IsLocal() {
+				result = s.MetricIndex.Tag(orgId, tag, from)
+			} else {
+				result, err = s.tagDetailsRemote(ctx, orgId, tag, from, peer)
+			}
+			mu.Lock()
+			if err != nil {
+				errors = append(errors, err)
+			}
+			for value, count := range result {
+				values[value] += count
+			}
+			mu.Unlock()
+			wg.Done()
+		}(peer)
+	}
+	wg.Wait()
+	if len(errors) > 0 {
+		err = errors[0]
+	}
+
+	return values, err
+}
+
+func (s *Server) tagDetailsRemote(ctx context.Context, orgId int, tag string, from int64, peer cluster.Node) (map[string]uint32, error) {
+	log.Debug("HTTP tagDetails querying %s/index/tag_details for %d:%q", peer.Name, orgId, tag)
+	data := models.IndexTagDetails{
+		OrgId: orgId,
+		Tag:   tag,
+		From:  from,
+	}
+	buf, err := peer.Post(ctx, "tagDetailsRemote", "/index/tag_details", data)
+	if err != nil {
+		log.Error(4, "HTTP tagDetails error querying %s/index/tag_details: %q", peer.Name, err)
+		return nil, err
+	}
+	resp := models.IndexTagDetailsResp{}
+	_, err = resp.UnmarshalMsg(buf)
+	if err != nil {
+		log.Error(4, "HTTP tagDetails error unmarshaling body from %s/index/tag_details: %q", peer.Name, err)
+		return nil, err
+	}
+	return resp.Values, nil
+}
+
+// findByTag resolves the given tag expressions into series, across the cluster.
+// the returned Series have the expressions, joined by ";", as their Pattern.
+// if they resolve to more series than the MaxSeries limit, in the index of any of the nodes
+// or all together, it returns errFindMaxSeries
+func (s *Server) findByTag(ctx context.Context, orgId int, expressions []string, from int64, limits idx.FindLimits) ([]Series, error) {
+	peers, err := cluster.MembersForQuery()
+	if err != nil {
+		log.Error(3, "HTTP findByTag unable to get peers, %s", err)
+		return nil, err
+	}
+	log.Debug("HTTP findByTag for %v across %d instances", expressions, len(peers))
+	pattern := strings.Join(expressions, ";")
+	errors := make([]error, 0)
+	series := make([]Series, 0)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer cluster.Node) {
+			var result []idx.Node
+			var err error
+			if peer.IsLocal() {
+				result, err = s.findByTagLocal(orgId, expressions, from, limits)
+			} else {
+				result, err = s.findByTagRemote(ctx, orgId, expressions, from, limits, peer)
+			}
+			mu.Lock()
+			if err != nil {
+				errors = append(errors, err)
+			}
+			series = append(series, Series{
+				Pattern: pattern,
+				Node:    peer,
+				Series:  result,
+			})
+			mu.Unlock()
+			wg.Done()
+		}(peer)
+	}
+	wg.Wait()
+	if len(errors) > 0 {
+		return nil, errors[0]
+	}
+	if limits.MaxSeries > 0 && countSeries(series) > limits.MaxSeries {
+		return nil, errFindMaxSeries
+	}
+
+	return series, nil
+}
+
+// findByTagLimited is findByTag for the tag queries of a request of the given org, within its max-series-per-target limit
+func (s *Server) findByTagLimited(ctx context.Context, orgId int, expressions []string, from int64) ([]Series, error) {
+	limits := getLimits(orgId)
+	series, err := s.findByTag(ctx, orgId, expressions, from, limits.findLimits(limits.maxSeriesPerTarget))
+	if isMaxSeries(err) {
+		return nil, errMaxSeriesPerTarget(strings.Join(expressions, ";"), limits.maxSeriesPerTarget)
+	}
+	return series, err
+}
+
+// findByTagLocal returns a leaf node for each distinct tagged name (see taggedName) of the series in the
+// local index that match the tag expressions
+func (s *Server) findByTagLocal(orgId int, expressions []string, from int64, limits idx.FindLimits) ([]idx.Node, error) {
+	ids, err := s.MetricIndex.FindByTag(orgId, expressions, from, limits)
+	if err != nil {
+		return nil, findError(err, strings.Join(expressions, ";"), limits)
+	}
+	byPath := make(map[string]int)
+	nodes := make([]idx.Node, 0)
+	for id := range ids {
+		def, ok := s.MetricIndex.Get(id.String())
+		if !ok {
+			// the series got deleted since we ran the query
+			continue
+		}
+		path := taggedName(def.Name, def.Tags)
+		if i, ok := byPath[path]; ok {
+			nodes[i].Defs = append(nodes[i].Defs, def)
+			continue
+		}
+		byPath[path] = len(nodes)
+		nodes = append(nodes, idx.Node{
+			Path: path,
+			Leaf: true,
+			Defs: []idx.Archive{def},
+		})
+	}
+	log.Debug("HTTP findByTag %d matches for %v found locally", len(nodes), expressions)
+	return nodes, nil
+}
+
+func (s *Server) findByTagRemote(ctx context.Context, orgId int, expressions []string, from int64, limits idx.FindLimits, peer cluster.Node) ([]idx.Node, error) {
+	log.Debug("HTTP findByTag querying %s/index/find_by_tag for %d:%q", peer.Name, orgId, expressions)
+	data := models.IndexFindByTag{
+		OrgId:     orgId,
+		Expr:      expressions,
+		From:      from,
+		MaxSeries: limits.MaxSeries,
+	}
+	buf, err := peer.Post(ctx, "findByTagRemote", "/index/find_by_tag", data)
+	if err != nil {
+		log.Error(4, "HTTP findByTag error querying %s/index/find_by_tag: %q", peer.Name, err)
+		return nil, err
+	}
+	resp := models.IndexFindByTagResp{}
+	_, err = resp.UnmarshalMsg(buf)
+	if err != nil {
+		log.Error(4, "HTTP findByTag error unmarshaling body from %s/index/find_by_tag: %q", peer.Name, err)
+		return nil, err
+	}
+	log.Debug("HTTP findByTag %d matches for %v found on %s", len(resp.Nodes), expressions, peer.Name)
+	return resp.Nodes, nil
+}
+
+func (s *Server) graphiteTags(ctx *middleware.Context, request models.GraphiteTags) {
+	var filter *regexp.Regexp
+	if request.Filter != "" {
+		var err error
+		filter, err = regexp.Compile(request.Filter)
+		if err != nil {
+			response.Write(ctx, response.NewError(http.StatusBadRequest, err.Error()))
+			return
+		}
+	}
+	tags, err := s.tagList(ctx.Req.Context(), ctx.OrgId)
 	if err != nil {
-		log.Error(3, "HTTP IndexJson() %s", err.Error())
 		response.Write(ctx, response.WrapError(err))
 		return
 	}
+	sort.Strings(tags)
 
-	response.Write(ctx, response.NewFastJson(200, models.MetricNames(series)))
+	resp := make(models.GraphiteTagsResp, 0, len(tags))
+	for _, tag := range tags {
+		if filter != nil && !filter.MatchString(tag) {
+			continue
+		}
+		resp = append(resp, models.GraphiteTagResp{Tag: tag})
+	}
+	response.Write(ctx, response.NewJson(200, resp, ""))
+}
+
+func (s *Server) graphiteTagDetails(ctx *middleware.Context, request models.GraphiteTagDetails) {
+	tag := ctx.Params(":tag")
+	var filter *regexp.Regexp
+	if request.Filter != "" {
+		var err error
+		filter, err = regexp.Compile(request.Filter)
+		if err != nil {
+			response.Write(ctx, response.NewError(http.StatusBadRequest, err.Error()))
+			return
+		}
+	}
+	values, err := s.tagDetails(ctx.Req.Context(), ctx.OrgId, tag, request.From)
+	if err != nil {
+		response.Write(ctx, response.WrapError(err))
+		return
+	}
+
+	resp := models.GraphiteTagDetailsResp{
+		Tag:    tag,
+		Values: make([]models.GraphiteTagDetailsValueResp, 0, len(values)),
+	}
+	for value, count := range values {
+		if filter != nil && !filter.MatchString(value) {
+			continue
+		}
+		resp.Values = append(resp.Values, models.GraphiteTagDetailsValueResp{
+			Count: count,
+			Value: value,
+		})
+	}
+	sort.Slice(resp.Values, func(i, j int) bool { return resp.Values[i].Value < resp.Values[j].Value })
+	response.Write(ctx, response.NewJson(200, resp, ""))
+}
+
+func (s *Server) graphiteTagFindSeries(ctx *middleware.Context, request models.GraphiteTagFindSeries) {
+	series, err := s.findByTagLimited(ctx.Req.Context(), ctx.OrgId, request.Expr, request.From)
+	if err != nil {
+		response.Write(ctx, response.WrapError(err))
+		return
+	}
+	response.Write(ctx, response.NewJson(200, seriesNames(series), ""))
+}
+
+func (s *Server) graphiteAutoCompleteTags(ctx *middleware.Context, request models.GraphiteAutoCompleteTags) {
+	var tags []string
+	if len(request.Expr) == 0 {
+		var err error
+		tags, err = s.tagList(ctx.Req.Context(), ctx.OrgId)
+		if err != nil {
+			response.Write(ctx, response.WrapError(err))
+			return
+		}
+	} else {
+		series, err := s.findByTagLimited(ctx.Req.Context(), ctx.OrgId, request.Expr, request.From)
+		if err != nil {
+			response.Write(ctx, response.WrapError(err))
+			return
+		}
+		// like graphite, we don't suggest tags that are already used in the expressions
+		exclude := make(map[string]struct{})
+		for _, expr := range request.Expr {
+			exclude[tagExpressionKey(expr)] = struct{}{}
+		}
+		seen := make(map[string]struct{})
+		for _, tag := range seriesTags(series) {
+			key := strings.SplitN(tag, "=", 2)[0]
+			if _, ok := exclude[key]; ok {
+				continue
+			}
+			if _, ok := seen[key]; !ok {
+				tags = append(tags, key)
+				seen[key] = struct{}{}
+			}
+		}
+	}
+	response.Write(ctx, response.NewJson(200, autoComplete(tags, request.TagPrefix, request.Limit), ""))
+}
+
+func (s *Server) graphiteAutoCompleteTagValues(ctx *middleware.Context, request models.GraphiteAutoCompleteTagValues) {
+	var values []string
+	if len(request.Expr) == 0 {
+		result, err := s.tagDetails(ctx.Req.Context(), ctx.OrgId, request.Tag, request.From)
+		if err != nil {
+			response.Write(ctx, response.WrapError(err))
+			return
+		}
+		for value := range result {
+			values = append(values, value)
+		}
+	} else {
+		series, err := s.findByTagLimited(ctx.Req.Context(), ctx.OrgId, request.Expr, request.From)
+		if err != nil {
+			response.Write(ctx, response.WrapError(err))
+			return
+		}
+		prefix := request.Tag + "="
+		seen := make(map[string]struct{})
+		for _, tag := range seriesTags(series) {
+			if !strings.HasPrefix(tag, prefix) {
+				continue
+			}
+			value := tag[len(prefix):]
+			if _, ok := seen[value]; !ok {
+				values = append(values, value)
+				seen[value] = struct{}{}
+			}
+		}
+	}
+	response.Write(ctx, response.NewJson(200, autoComplete(values, request.ValuePrefix, request.Limit), ""))
+}
+
+// seriesNames returns the sorted, distinct names of the given series
+func seriesNames(series []Series) []string {
+	names := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, s := range series {
+		for _, n := range s.Series {
+			if _, ok := seen[n.Path]; !ok {
+				names = append(names, n.Path)
+				seen[n.Path] = struct{}{}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// seriesTags returns all tags of all the metric definitions of the given series.
+// the result may contain duplicates.
+func seriesTags(series []Series) []string {
+	var tags []string
+	for _, s := range series {
+		for _, n := range s.Series {
+			for _, def := range n.Defs {
+				tags = append(tags, def.Tags...)
+			}
+		}
+	}
+	return tags
+}
+
+// taggedName returns the name of a series including its tags, like name;key=value;...
+// this is how graphite identifies tagged series, so that series with the same name but different tags remain distinct.
+func taggedName(name string, tags []string) string {
+	return strings.Join(append([]string{name}, tags...), ";")
+}
+
+// tagsMap returns the tags of a metric definition as a map, including its name as the name tag.
+func tagsMap(name string, tags []string) map[string]string {
+	m := make(map[string]string, len(tags)+1)
+	for _, tag := range tags {
+		if i := strings.Index(tag, "="); i != -1 {
+			m[tag[:i]] = tag[i+1:]
+		}
+	}
+	m["name"] = name
+	return m
+}
+
+// tagExpressionKey returns the tag key of a tag expression like key=value or key!=~value
+func tagExpressionKey(expr string) string {
+	if i := strings.IndexAny(expr, "!="); i != -1 {
+		return expr[:i]
+	}
+	return expr
+}
+
+// autoComplete returns the sorted items that have the given prefix, up to limit of them.
+func autoComplete(items []string, prefix string, limit int) []string {
+	result := make([]string, 0)
+	for _, item := range items {
+		if strings.HasPrefix(item, prefix) {
+			result = append(result, item)
+		}
+	}
+	sort.Strings(result)
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
 }
 
 func findCompleter(nodes []idx.Node) models.SeriesCompleter {
@@ -552,30 +1061,62 @@ func (s *Server) metricsDeleteRemote(ctx context.Context, orgId int, query strin
 	return resp.DeletedDefs, nil
 }
 
-// executePlan looks up the needed data, retrieves it, and then invokes the processing
-// note if you do something like sum(foo.*) and all of those metrics happen to be on another node,
-// we will collect all the indidividual series from the peer, and then sum here. that could be optimized
-func (s *Server) executePlan(ctx context.Context, orgId int, plan expr.Plan) ([]models.Series, error) {
+// fetchPlan describes how we fetch the data needed by a plan
+type fetchPlan struct {
+	reqs         []models.Req                 // the requests for each of the series, aligned so they can be fetched
+	tagsByTarget map[string]map[string]string // tags of each requested series, by target (see taggedName)
+	pointsFetch  uint32
+	pointsReturn uint32
+	warnings     []string // problems with the request that don't prevent us from executing it
+}
 
-	minFrom := uint32(math.MaxUint32)
-	var maxTo uint32
+// getFetchPlan looks up the series requested by the plan, and determines how to fetch them
+func (s *Server) getFetchPlan(ctx context.Context, orgId int, plan expr.Plan) (fetchPlan, error) {
 	var reqs []models.Req
+	tagsByTarget := make(map[string]map[string]string) // tags of each requested series, by target
+
+	limits := getLimits(orgId)
+	if limits.maxExprDepth > 0 {
+		if depth := plan.Depth(); depth > limits.maxExprDepth {
+			return fetchPlan{}, errMaxExprDepth(depth, limits.maxExprDepth)
+		}
+	}
 
 	// note that different patterns to query can have different from / to, so they require different index lookups
 	// e.g. target=movingAvg(foo.*, "1h")&target=foo.*
 	// note that in this case we fetch foo.* twice. can be optimized later
-	for _, r := range plan.Reqs {
-		series, err := s.findSeries(ctx, orgId, []string{r.Query}, int64(r.From))
+	seriesByTarget := make(map[int]int) // number of series resolved so far, by index of the target
+	for i, r := range plan.Reqs {
+		var series []Series
+		var err error
+		// the index stops searching once a search resolves to more series than its target has left
+		target := plan.ReqTarget(i)
+		findLimits := limits.findLimits(limits.maxSeriesPerTarget - seriesByTarget[target])
+		expressions, tagged := r.TagExpressions()
+		if tagged {
+			series, err = s.findByTag(ctx, orgId, expressions, int64(r.From), findLimits)
+		} else {
+			series, err = s.findSeries(ctx, orgId, []string{r.Query}, int64(r.From), findLimits)
+		}
+		if isMaxSeries(err) {
+			return fetchPlan{}, errMaxSeriesPerTarget(r.Query, limits.maxSeriesPerTarget)
+		}
 		if err != nil {
-			return nil, err
+			return fetchPlan{}, err
+		}
+		seriesByTarget[target] += countSeries(series)
+		if limits.maxSeriesPerTarget > 0 && seriesByTarget[target] > limits.maxSeriesPerTarget {
+			return fetchPlan{}, errMaxSeriesPerTarget(r.Query, limits.maxSeriesPerTarget)
 		}
-
-		minFrom = util.Min(minFrom, r.From)
-		maxTo = util.Max(maxTo, r.To)
 
 		for _, s := range series {
 			for _, metric := range s.Series {
 				for _, archive := range metric.Defs {
+					// series found by tags are identified by their name and tags, see findByTagLocal
+					target := archive.Name
+					if tagged {
+						target = metric.Path
+					}
 					cons := r.Cons
 					consReq := r.Cons
 					if consReq == 0 {
@@ -589,8 +1130,12 @@ func (s *Server) executePlan(ctx context.Context, orgId int, plan expr.Plan) ([]
 						cons = consolidation.Consolidator(fn) // we use the same number assignments so we can cast them
 					}
 					newReq := models.NewReq(
-						archive.Id, archive.Name, r.Query, r.From, r.To, plan.MaxDataPoints, uint32(archive.Interval), cons, consReq, s.Node, archive.SchemaId, archive.AggId)
+						archive.Id, target, r.Query, r.From, r.To, plan.MaxDataPoints, uint32(archive.Interval), cons, consReq, s.Node, archive.SchemaId, archive.AggId)
+					newReq.PlanReq = i
 					reqs = append(reqs, newReq)
+					if _, ok := tagsByTarget[target]; !ok {
+						tagsByTarget[target] = tagsMap(archive.Name, archive.Tags)
+					}
 				}
 			}
 		}
@@ -598,45 +1143,204 @@ func (s *Server) executePlan(ctx context.Context, orgId int, plan expr.Plan) ([]
 
 	reqRenderSeriesCount.Value(len(reqs))
 	if len(reqs) == 0 {
-		return nil, nil
+		return fetchPlan{}, nil
+	}
+
+	// some functions need a number of points before the requested range. we extend the range of their requests
+	// before choosing the archives, so that the TTL of the chosen archive covers these points as well.
+	// we only know the raw interval at this point. if the output interval turns out to be larger, we extend it further below.
+	for i := range reqs {
+		req := &reqs[i]
+		if r := plan.Reqs[req.PlanReq]; r.Bootstrap > 0 {
+			req.From -= util.Min(r.Bootstrap*req.RawInterval, req.From)
+		}
 	}
 
 	// note: if 1 series has a movingAvg that requires a long time range extension, it may push other reqs into another archive. can be optimized later
-	reqs, pointsFetch, pointsReturn, err := alignRequests(uint32(time.Now().Unix()), minFrom, maxTo, reqs)
+	// note: reqs may have different time ranges, e.g. timeShift fetches the same series over a shifted range
+	now := uint32(time.Now().Unix())
+	reqs, _, pointsReturn, err := alignRequests(now, reqs)
 	if err != nil {
 		log.Error(3, "HTTP Render alignReq error: %s", err)
-		return nil, err
+		return fetchPlan{}, err
+	}
+
+	var warnings []string
+	var beyondRetention int
+	for _, req := range reqs {
+		// we don't warn about the points we fetch on top of the requested range
+		if plan.Reqs[req.PlanReq].From+req.TTL < now {
+			beyondRetention++
+		}
 	}
+	if beyondRetention > 0 {
+		warnings = append(warnings, fmt.Sprintf("from is beyond retention for %d of %d series", beyondRetention, len(reqs)))
+	}
+
+	// now that we know the output interval, we can extend the range by the needed number of points at that interval.
+	// note: this only extends the range further if we read from a rollup or consolidate at runtime.
+	// so we only know the number of points to fetch after this.
+	var pointsFetch uint32
+	for i := range reqs {
+		req := &reqs[i]
+		if r := plan.Reqs[req.PlanReq]; r.Bootstrap > 0 {
+			req.From = r.From - util.Min(r.Bootstrap*req.OutInterval, r.From)
+		}
+		pointsFetch += (req.To - req.From) / req.ArchInterval
+	}
+	if limits.maxPointsFetch > 0 && int(pointsFetch) > limits.maxPointsFetch {
+		return fetchPlan{}, errMaxPointsFetch(pointsFetch, limits.maxPointsFetch)
+	}
+
+	return fetchPlan{
+		reqs:         reqs,
+		tagsByTarget: tagsByTarget,
+		pointsFetch:  pointsFetch,
+		pointsReturn: pointsReturn,
+		warnings:     warnings,
+	}, nil
+}
+
+// executePlan looks up the needed data, retrieves it, and then invokes the processing
+// it also returns the stats and warnings of the execution
+// note if you do something like sum(foo.*) and all of those metrics happen to be on another node,
+// we will collect all the indidividual series from the peer, and then sum here. that could be optimized
+func (s *Server) executePlan(ctx context.Context, orgId int, plan expr.Plan) ([]models.Series, models.RenderMeta, error) {
+	meta := models.RenderMeta{
+		Warnings: make([]string, 0),
+	}
+
+	setQueryPhase(ctx, "index")
+	preIndex := time.Now()
+	fp, err := s.getFetchPlan(ctx, orgId, plan)
+	meta.Stats.TimeIndex = time.Since(preIndex).Seconds()
+	if err != nil {
+		return nil, meta, err
+	}
+	meta.Stats.SeriesFetch = uint32(len(fp.reqs))
+	meta.Warnings = append(meta.Warnings, fp.warnings...)
+
+	if len(fp.reqs) == 0 {
+		// there is nothing to fetch, but the plan may have functions that generate their own series,
+		// like target=constantLine(100) or sumSeries(nonexistent.*, constantLine(1))
+		setQueryPhase(ctx, "expr")
+		preRun := time.Now()
+		out, err := plan.Run(make(map[expr.Req][]models.Series))
+		meta.Stats.TimeExpr = time.Since(preRun).Seconds()
+		meta.Stats.PointsReturn = countPoints(out)
+		return out, meta, err
+	}
+
 	span := opentracing.SpanFromContext(ctx)
-	span.SetTag("points_fetch", pointsFetch)
-	span.SetTag("points_return", pointsReturn)
+	span.SetTag("points_fetch", fp.pointsFetch)
+	span.SetTag("points_return", fp.pointsReturn)
 
 	if LogLevel < 2 {
-		for _, req := range reqs {
+		for _, req := range fp.reqs {
 			log.Debug("HTTP Render %s - arch:%d archI:%d outI:%d aggN: %d from %s", req, req.Archive, req.ArchInterval, req.OutInterval, req.AggNum, req.Node.Name)
 		}
 	}
 
-	out, err := s.getTargets(ctx, reqs)
+	setQueryPhase(ctx, "fetch")
+	var ss models.StorageStats
+	preStore := time.Now()
+	out, err := s.getTargets(withStorageStats(ctx, &ss), fp.reqs)
+	meta.Stats.TimeStore = time.Since(preStore).Seconds()
+	meta.Stats.ChunksCache = ss.ChunksCache
+	meta.Stats.ChunksStore = ss.ChunksStore
+	meta.Stats.PointsFetch = ss.PointsFetch
 	if err != nil {
 		log.Error(3, "HTTP Render %s", err.Error())
-		return nil, err
+		return nil, meta, err
 	}
 	out = mergeSeries(out)
+	for i := range out {
+		out[i].Tags = fp.tagsByTarget[out[i].Target]
+	}
 
 	// instead of waiting for all data to come in and then start processing everything, we could consider starting processing earlier, at the risk of doing needless work
 	// if we need to cancel the request due to a fetch error
 
 	data := make(map[expr.Req][]models.Series)
 	for _, serie := range out {
-		q := expr.NewReq(serie.QueryPatt, serie.QueryFrom, serie.QueryTo, serie.QueryCons)
+		// the fetched series are tied back to the plan request they are for by its index, as different plan requests
+		// may fetch the same series over the same range, e.g. movingSum(foo,'5min') and movingSum(foo,5) at a 60s interval
+		q := plan.Reqs[serie.PlanReq]
 		data[q] = append(data[q], serie)
 	}
 
+	// don't bother processing the data if the query was canceled while we were fetching it
+	if err := ctx.Err(); err != nil {
+		return nil, meta, err
+	}
+
+	setQueryPhase(ctx, "expr")
 	preRun := time.Now()
 	out, err = plan.Run(data)
-	planRunDuration.Value(time.Since(preRun))
-	return out, err
+	runDuration := time.Since(preRun)
+	planRunDuration.Value(runDuration)
+	meta.Stats.TimeExpr = runDuration.Seconds()
+	meta.Stats.PointsReturn = countPoints(out)
+	return out, meta, err
+}
+
+// countPoints returns the number of points of the given series
+func countPoints(series []models.Series) uint32 {
+	var points uint32
+	for _, serie := range series {
+		points += uint32(len(serie.Datapoints))
+	}
+	return points
+}
+
+// renderExplain is the response to a render request with explain=true
+type renderExplain struct {
+	Exprs        []expr.ExprTree `json:"exprs"`    // the parsed targets
+	PlanReqs     []expr.Req      `json:"planReqs"` // the series patterns requested by the plan
+	Reqs         []explainReq    `json:"reqs"`     // how we would fetch each of the series
+	PointsFetch  uint32          `json:"pointsFetch"`
+	PointsReturn uint32          `json:"pointsReturn"`
+	Proxy        string          `json:"proxy,omitempty"`    // why the request would be proxied to graphite, if it would
+	Warnings     []string        `json:"warnings,omitempty"` // problems with the request that don't prevent us from executing it
+}
+
+// explainReq explains how we would fetch a series
+type explainReq struct {
+	models.Req
+	ArchiveName          string `json:"archiveName"`          // raw, or the interval of the rollup, e.g. rollup 600s
+	Consolidation        string `json:"consolidation"`        // the consolidation function of the rollup archive, and of runtime consolidation
+	RuntimeConsolidation bool   `json:"runtimeConsolidation"` // whether we would consolidate at runtime, AggNum points at a time
+	NodeName             string `json:"node"`                 // the cluster node the request would be routed to
+}
+
+// explainPlan explains how we would execute the plan, without fetching any data
+func (s *Server) explainPlan(ctx context.Context, orgId int, plan expr.Plan) (renderExplain, error) {
+	fp, err := s.getFetchPlan(ctx, orgId, plan)
+	if err != nil {
+		return renderExplain{}, err
+	}
+	explain := renderExplain{
+		Exprs:        plan.ExprTrees(),
+		PlanReqs:     plan.Reqs,
+		Reqs:         make([]explainReq, 0, len(fp.reqs)),
+		PointsFetch:  fp.pointsFetch,
+		PointsReturn: fp.pointsReturn,
+		Warnings:     fp.warnings,
+	}
+	for _, req := range fp.reqs {
+		archiveName := "raw"
+		if req.Archive > 0 {
+			archiveName = fmt.Sprintf("rollup %ds", req.ArchInterval)
+		}
+		explain.Reqs = append(explain.Reqs, explainReq{
+			Req:                  req,
+			ArchiveName:          archiveName,
+			Consolidation:        req.Consolidator.String(),
+			RuntimeConsolidation: req.AggNum > 1,
+			NodeName:             req.Node.Name,
+		})
+	}
+	return explain, nil
 }
 
 func getFromTo(ft models.FromTo, now time.Time, defaultFrom, defaultTo uint32) (uint32, uint32, error) {
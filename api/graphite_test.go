@@ -0,0 +1,483 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/api/response"
+	"github.com/grafana/metrictank/cluster"
+	"github.com/grafana/metrictank/conf"
+	"github.com/grafana/metrictank/expr"
+	"github.com/grafana/metrictank/idx"
+	"github.com/grafana/metrictank/mdata"
+	opentracing "github.com/opentracing/opentracing-go"
+	"gopkg.in/macaron.v1"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestTagExpressionKey(t *testing.T) {
+	cases := map[string]string{
+		"dc=us-east":     "dc",
+		"dc!=us-east":    "dc",
+		"dc=~us-.*":      "dc",
+		"dc!=~us-.*":     "dc",
+		"dc=":            "dc",
+		"invalidnoequal": "invalidnoequal",
+	}
+	for in, exp := range cases {
+		if got := tagExpressionKey(in); got != exp {
+			t.Fatalf("tagExpressionKey(%q): expected %q, got %q", in, exp, got)
+		}
+	}
+}
+
+func TestAutoComplete(t *testing.T) {
+	items := []string{"server", "dc", "datacenter", "direction", "host"}
+	cases := []struct {
+		prefix string
+		limit  int
+		exp    []string
+	}{
+		{"", 0, []string{"datacenter", "dc", "direction", "host", "server"}},
+		{"d", 0, []string{"datacenter", "dc", "direction"}},
+		{"d", 2, []string{"datacenter", "dc"}},
+		{"da", 100, []string{"datacenter"}},
+		{"x", 100, []string{}},
+	}
+	for i, c := range cases {
+		got := autoComplete(items, c.prefix, c.limit)
+		if !reflect.DeepEqual(got, c.exp) {
+			t.Fatalf("case %d: expected %v, got %v", i, c.exp, got)
+		}
+	}
+}
+
+// tagIndex is a minimal tag index for testing, that only supports key=value expressions
+type tagIndex struct {
+	idx.MetricIndex
+	defs []idx.Archive
+}
+
+func newTagIndex(defs ...schema.MetricDefinition) *tagIndex {
+	ti := &tagIndex{}
+	for _, def := range defs {
+		def.SetId()
+		ti.defs = append(ti.defs, idx.Archive{MetricDefinition: def})
+	}
+	return ti
+}
+
+func (ti *tagIndex) Get(id string) (idx.Archive, bool) {
+	for _, def := range ti.defs {
+		if def.Id == id {
+			return def, true
+		}
+	}
+	return idx.Archive{}, false
+}
+
+func (ti *tagIndex) TagList(orgId int) []string {
+	var tags []string
+	seen := make(map[string]struct{})
+	for _, def := range ti.defs {
+		for _, tag := range def.Tags {
+			key := strings.SplitN(tag, "=", 2)[0]
+			if _, ok := seen[key]; !ok && def.OrgId == orgId {
+				tags = append(tags, key)
+				seen[key] = struct{}{}
+			}
+		}
+	}
+	return tags
+}
+
+func (ti *tagIndex) Tag(orgId int, tag string, from int64) map[string]uint32 {
+	values := make(map[string]uint32)
+	for _, def := range ti.defs {
+		for _, t := range def.Tags {
+			if def.OrgId == orgId && def.LastUpdate >= from && strings.HasPrefix(t, tag+"=") {
+				values[t[len(tag)+1:]]++
+			}
+		}
+	}
+	return values
+}
+
+func (ti *tagIndex) FindByTag(orgId int, expressions []string, from int64, limits idx.FindLimits) (map[idx.MetricID]struct{}, error) {
+	ids := make(map[idx.MetricID]struct{})
+DEFS:
+	for _, def := range ti.defs {
+		if def.OrgId != orgId || def.LastUpdate < from {
+			continue
+		}
+		tags := append([]string{"name=" + def.Name}, def.Tags...)
+		for _, expr := range expressions {
+			if !contains(tags, expr) {
+				continue DEFS
+			}
+		}
+		id, err := idx.NewMetricIDFromString(def.Id)
+		if err != nil {
+			return nil, err
+		}
+		ids[id] = struct{}{}
+		if limits.MaxSeries > 0 && len(ids) > limits.MaxSeries {
+			return nil, idx.ErrMaxSeries
+		}
+	}
+	return ids, nil
+}
+
+// testCluster is a cluster of this node and the given peers, which are all ready
+type testCluster struct {
+	cluster.ClusterManager
+	peers []cluster.Node
+}
+
+func (c testCluster) MemberList() []cluster.Node {
+	return append([]cluster.Node{c.ThisNode()}, c.peers...)
+}
+
+// newTestCluster sets up a cluster of a local and a remote node, each with their own index.
+// it returns the server of the local node, and a function to restore the cluster state.
+func newTestCluster(local, remote idx.MetricIndex) (*Server, func()) {
+	origMode, origManager := cluster.Mode, cluster.Manager
+	cluster.Mode = cluster.ModeMulti
+	cluster.Tracer = opentracing.NoopTracer{}
+	cluster.Init("local", "test", time.Now(), "http", 6060)
+	cluster.Manager.SetPartitions([]int32{0})
+	cluster.Manager.SetPriority(0)
+	cluster.Manager.SetReady()
+
+	remoteServer := &Server{Macaron: macaron.New(), MetricIndex: remote, Tracer: opentracing.NoopTracer{}}
+	remoteServer.RegisterRoutes()
+	ts := httptest.NewServer(remoteServer.Macaron)
+	addr := ts.Listener.Addr().(*net.TCPAddr)
+	cluster.Manager = testCluster{
+		ClusterManager: cluster.Manager,
+		peers: []cluster.Node{{
+			Name:       "remote",
+			RemoteAddr: addr.IP.String(),
+			ApiPort:    addr.Port,
+			ApiScheme:  "http",
+			Partitions: []int32{1},
+			State:      cluster.NodeReady,
+		}},
+	}
+
+	s := &Server{Macaron: macaron.New(), MetricIndex: local, Tracer: opentracing.NoopTracer{}}
+	s.RegisterRoutes()
+	return s, func() {
+		ts.Close()
+		cluster.Mode, cluster.Manager = origMode, origManager
+	}
+}
+
+func getJson(t *testing.T, s *Server, url string, resp interface{}) {
+	req, _ := http.NewRequest("GET", url, nil)
+	rec := httptest.NewRecorder()
+	s.Macaron.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Fatalf("%s: expected status 200, got %d: %s", url, rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), resp); err != nil {
+		t.Fatalf("%s: failed to decode response %q: %s", url, rec.Body.String(), err)
+	}
+}
+
+func TestGraphiteTagsFanOut(t *testing.T) {
+	local := newTagIndex(
+		schema.MetricDefinition{OrgId: 1, Name: "cpu", Tags: []string{"dc=us-east", "host=a"}, LastUpdate: 100},
+		schema.MetricDefinition{OrgId: 2, Name: "cpu", Tags: []string{"env=prod"}, LastUpdate: 100},
+	)
+	remote := newTagIndex(
+		schema.MetricDefinition{OrgId: 1, Name: "cpu", Tags: []string{"dc=us-west", "host=b"}, LastUpdate: 200},
+		schema.MetricDefinition{OrgId: 1, Name: "mem", Tags: []string{"dc=us-west", "rack=1"}, LastUpdate: 200},
+	)
+	s, restore := newTestCluster(local, remote)
+	defer restore()
+
+	var tags models.GraphiteTagsResp
+	getJson(t, s, "/tags", &tags)
+	exp := models.GraphiteTagsResp{{Tag: "dc"}, {Tag: "host"}, {Tag: "rack"}}
+	if !reflect.DeepEqual(tags, exp) {
+		t.Fatalf("/tags: expected %v, got %v", exp, tags)
+	}
+	getJson(t, s, "/tags?filter=^(dc|rack)$", &tags)
+	exp = models.GraphiteTagsResp{{Tag: "dc"}, {Tag: "rack"}}
+	if !reflect.DeepEqual(tags, exp) {
+		t.Fatalf("/tags with filter: expected %v, got %v", exp, tags)
+	}
+
+	var details models.GraphiteTagDetailsResp
+	getJson(t, s, "/tags/dc", &details)
+	expDetails := models.GraphiteTagDetailsResp{
+		Tag: "dc",
+		Values: []models.GraphiteTagDetailsValueResp{
+			{Count: 1, Value: "us-east"},
+			{Count: 2, Value: "us-west"},
+		},
+	}
+	if !reflect.DeepEqual(details, expDetails) {
+		t.Fatalf("/tags/dc: expected %v, got %v", expDetails, details)
+	}
+	getJson(t, s, "/tags/dc?from=150", &details)
+	expDetails.Values = expDetails.Values[1:]
+	if !reflect.DeepEqual(details, expDetails) {
+		t.Fatalf("/tags/dc with from: expected %v, got %v", expDetails, details)
+	}
+
+	var names []string
+	getJson(t, s, "/tags/findSeries?expr=dc=us-west", &names)
+	expNames := []string{"cpu;dc=us-west;host=b", "mem;dc=us-west;rack=1"}
+	if !reflect.DeepEqual(names, expNames) {
+		t.Fatalf("/tags/findSeries: expected %v, got %v", expNames, names)
+	}
+
+	getJson(t, s, "/tags/autoComplete/tags?expr=dc=us-west", &names)
+	expNames = []string{"host", "rack"}
+	if !reflect.DeepEqual(names, expNames) {
+		t.Fatalf("/tags/autoComplete/tags: expected %v, got %v", expNames, names)
+	}
+	getJson(t, s, "/tags/autoComplete/values?tag=dc&valuePrefix=us-", &names)
+	expNames = []string{"us-east", "us-west"}
+	if !reflect.DeepEqual(names, expNames) {
+		t.Fatalf("/tags/autoComplete/values: expected %v, got %v", expNames, names)
+	}
+}
+
+func TestGetFetchPlanTagged(t *testing.T) {
+	mdata.SetSingleSchema(conf.NewRetentionMT(10, 3600, 600, 2, true))
+	mdata.SetSingleAgg(conf.Avg)
+	now := uint32(time.Now().Unix())
+	local := newTagIndex(
+		schema.MetricDefinition{OrgId: 1, Name: "cpu", Interval: 10, LastUpdate: int64(now), Tags: []string{"dc=us-east"}},
+		schema.MetricDefinition{OrgId: 1, Name: "mem", Interval: 10, LastUpdate: int64(now), Tags: []string{"dc=us-east"}},
+	)
+	remote := newTagIndex(
+		schema.MetricDefinition{OrgId: 1, Name: "cpu", Interval: 10, LastUpdate: int64(now), Tags: []string{"dc=us-west"}},
+	)
+	s, restore := newTestCluster(local, remote)
+	defer restore()
+
+	exprs, err := expr.ParseMany([]string{"seriesByTag('name=cpu')"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := expr.NewPlan(exprs, now-600, now, 800, true, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := opentracing.ContextWithSpan(context.Background(), opentracing.NoopTracer{}.StartSpan("test"))
+	fp, err := s.getFetchPlan(ctx, 1, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// series with the same name but different tags must remain distinct, rather than being merged by name
+	var targets []string
+	for _, req := range fp.reqs {
+		targets = append(targets, req.Target)
+		if req.PlanReq != 0 {
+			t.Fatalf("expected request %v to be for plan request 0, got %d", req, req.PlanReq)
+		}
+	}
+	sort.Strings(targets)
+	expTargets := []string{"cpu;dc=us-east", "cpu;dc=us-west"}
+	if !reflect.DeepEqual(targets, expTargets) {
+		t.Fatalf("expected targets %v, got %v", expTargets, targets)
+	}
+	for _, target := range expTargets {
+		exp := map[string]string{"name": "cpu", "dc": target[len("cpu;dc="):]}
+		if !reflect.DeepEqual(fp.tagsByTarget[target], exp) {
+			t.Fatalf("expected tags %v for %s, got %v", exp, target, fp.tagsByTarget[target])
+		}
+	}
+}
+
+// TestGetFetchPlanSameRange tests that plan requests that fetch the same series over the same range each get their own requests
+func TestGetFetchPlanSameRange(t *testing.T) {
+	mdata.SetSingleSchema(conf.NewRetentionMT(60, 3600, 600, 2, true))
+	mdata.SetSingleAgg(conf.Avg)
+	now := uint32(time.Now().Unix())
+	local := newTagIndex(
+		schema.MetricDefinition{OrgId: 1, Name: "cpu", Interval: 60, LastUpdate: int64(now)},
+	)
+	s, restore := newTestCluster(local, newTagIndex())
+	defer restore()
+
+	// both extend the range by 5min: via the Context and via bootstrap points at the 60s interval
+	exprs, err := expr.ParseMany([]string{"movingSum(seriesByTag('name=cpu'),'5min')", "movingSum(seriesByTag('name=cpu'),5)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := now - 600
+	plan, err := expr.NewPlan(exprs, from, now, 800, true, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := opentracing.ContextWithSpan(context.Background(), opentracing.NoopTracer{}.StartSpan("test"))
+	fp, err := s.getFetchPlan(ctx, 1, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fp.reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(fp.reqs))
+	}
+	for i, req := range fp.reqs {
+		if req.PlanReq != i || req.From != from-300 {
+			t.Fatalf("expected request %d to be for plan request %d from %d, got plan request %d from %d", i, i, from-300, req.PlanReq, req.From)
+		}
+	}
+}
+
+// TestGetFetchPlanPointsFetch tests that the points to fetch are counted, and limited, after extending the range
+// by the bootstrap points at the output interval
+func TestGetFetchPlanPointsFetch(t *testing.T) {
+	mdata.SetSingleSchema(conf.NewRetentionMT(10, 3600, 600, 2, true))
+	mdata.SetSingleAgg(conf.Avg)
+	now := uint32(time.Now().Unix())
+	// the different intervals make us consolidate cpu to 60s at runtime
+	local := newTagIndex(
+		schema.MetricDefinition{OrgId: 1, Name: "cpu", Interval: 10, LastUpdate: int64(now), Tags: []string{"dc=a"}},
+		schema.MetricDefinition{OrgId: 1, Name: "mem", Interval: 60, LastUpdate: int64(now), Tags: []string{"dc=a"}},
+	)
+	s, restore := newTestCluster(local, newTagIndex())
+	defer restore()
+	origLimits := defaultLimits
+	defer func() { defaultLimits = origLimits }()
+
+	exprs, err := expr.ParseMany([]string{"movingSum(seriesByTag('dc=a'),5)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := expr.NewPlan(exprs, now-600, now, 800, true, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := opentracing.ContextWithSpan(context.Background(), opentracing.NoopTracer{}.StartSpan("test"))
+	fp, err := s.getFetchPlan(ctx, 1, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 5 points of 60s before the range: 900s at 10s for cpu and at 60s for mem
+	if fp.pointsFetch != 105 {
+		t.Fatalf("expected 105 points to fetch, got %d", fp.pointsFetch)
+	}
+
+	defaultLimits = queryLimits{maxPointsFetch: 100}
+	_, err = s.getFetchPlan(ctx, 1, plan)
+	if e, ok := err.(response.Error); !ok || e.Code() != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected a 413 error, got %v", err)
+	}
+}
+
+func TestGetFetchPlanMaxSeriesPerTarget(t *testing.T) {
+	mdata.SetSingleSchema(conf.NewRetentionMT(10, 3600, 600, 2, true))
+	mdata.SetSingleAgg(conf.Avg)
+	now := uint32(time.Now().Unix())
+	local := newTagIndex(
+		schema.MetricDefinition{OrgId: 1, Name: "cpu", Interval: 10, LastUpdate: int64(now), Tags: []string{"dc=us-east"}},
+		schema.MetricDefinition{OrgId: 1, Name: "mem", Interval: 10, LastUpdate: int64(now), Tags: []string{"dc=us-east"}},
+	)
+	remote := newTagIndex(
+		schema.MetricDefinition{OrgId: 1, Name: "cpu", Interval: 10, LastUpdate: int64(now), Tags: []string{"dc=us-west"}},
+	)
+	s, restore := newTestCluster(local, remote)
+	defer restore()
+	origLimits := defaultLimits
+	defer func() { defaultLimits = origLimits }()
+
+	cases := []struct {
+		targets   []string
+		maxSeries int
+		expErr    bool
+	}{
+		{[]string{"seriesByTag('name=cpu')"}, 2, false},
+		// each node has 1 series, but together they exceed the limit
+		{[]string{"seriesByTag('name=cpu')"}, 1, true},
+		// the limit is per target
+		{[]string{"seriesByTag('name=cpu')", "seriesByTag('name=mem')"}, 2, false},
+		// each query stays within the limit, but the target doesn't
+		{[]string{"sumSeries(seriesByTag('name=cpu'),seriesByTag('name=mem'))"}, 2, true},
+	}
+	for i, c := range cases {
+		defaultLimits = queryLimits{maxSeriesPerTarget: c.maxSeries}
+		exprs, err := expr.ParseMany(c.targets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan, err := expr.NewPlan(exprs, now-600, now, 800, true, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := opentracing.ContextWithSpan(context.Background(), opentracing.NoopTracer{}.StartSpan("test"))
+		_, err = s.getFetchPlan(ctx, 1, plan)
+		if c.expErr {
+			if e, ok := err.(response.Error); !ok || e.Code() != http.StatusRequestEntityTooLarge {
+				t.Fatalf("case %d: expected a 413 error, got %v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d: unexpected error %s", i, err)
+		}
+	}
+
+	defaultLimits = queryLimits{maxSeriesPerTarget: 1}
+	req, _ := http.NewRequest("GET", "/tags/findSeries?expr=name=cpu", nil)
+	rec := httptest.NewRecorder()
+	s.Macaron.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("/tags/findSeries: expected status 413, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestExecutePlanGenerators(t *testing.T) {
+	s, restore := newTestCluster(newTagIndex(), newTagIndex())
+	defer restore()
+
+	cases := []struct {
+		targets    []string
+		expTargets []string
+	}{
+		{[]string{"constantLine(1)"}, []string{"1"}},
+		{[]string{"constantLine(1)", "seriesByTag('name=nonexistent')"}, []string{"1"}},
+		{[]string{"sumSeries(seriesByTag('name=nonexistent'),constantLine(1))"}, []string{"sumSeries(1)"}},
+	}
+	for i, c := range cases {
+		exprs, err := expr.ParseMany(c.targets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan, err := expr.NewPlan(exprs, 1000, 2000, 800, true, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := opentracing.ContextWithSpan(context.Background(), opentracing.NoopTracer{}.StartSpan("test"))
+		out, meta, err := s.executePlan(ctx, 1, plan)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error %s", i, err)
+		}
+		var targets []string
+		for _, serie := range out {
+			targets = append(targets, serie.Target)
+		}
+		if !reflect.DeepEqual(targets, c.expTargets) {
+			t.Fatalf("case %d: expected targets %v, got %v", i, c.expTargets, targets)
+		}
+		// constantLine generates 3 points
+		if exp := uint32(3 * len(c.expTargets)); meta.Stats.PointsReturn != exp {
+			t.Fatalf("case %d: expected %d points returned, got %d", i, exp, meta.Stats.PointsReturn)
+		}
+	}
+}
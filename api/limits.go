@@ -0,0 +1,139 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/metrictank/api/response"
+	"github.com/grafana/metrictank/idx"
+)
+
+// queryLimits are the limits that requests must stay within. 0 disables a limit
+type queryLimits struct {
+	maxSeriesPerTarget int // max number of series the patterns and tag queries of a single target may resolve to
+	maxPointsFetch     int // max number of points a request may fetch
+	maxExpansions      int // max number of patterns a pattern may expand into via {a,b} lists
+	maxExprDepth       int // max depth of the expression of a target
+}
+
+var (
+	defaultLimits queryLimits
+	orgLimitsStr  string
+	orgLimits     map[int]queryLimits // limits of the orgs that have overrides
+)
+
+// getLimits returns the limits that apply to the requests of the given org
+func getLimits(orgId int) queryLimits {
+	if l, ok := orgLimits[orgId]; ok {
+		return l
+	}
+	return defaultLimits
+}
+
+// parseOrgLimits parses space separated per-org overrides of the default limits,
+// each in the form <orgId>:<limit>=<value>,<limit>=<value>
+// e.g. "10:max-series-per-target=1000,max-expr-depth=10 20:max-points-fetch=0"
+// limits that an org doesn't override are taken from the defaults.
+func parseOrgLimits(str string, defaults queryLimits) (map[int]queryLimits, error) {
+	out := make(map[int]queryLimits)
+	for _, org := range strings.Fields(str) {
+		parts := strings.SplitN(org, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid org limits %q: expected <orgId>:<limit>=<value>,...", org)
+		}
+		orgId, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid org limits %q: bad orgId: %s", org, err)
+		}
+		limits := defaults
+		for _, kv := range strings.Split(parts[1], ",") {
+			pair := strings.SplitN(kv, "=", 2)
+			if len(pair) != 2 {
+				return nil, fmt.Errorf("invalid org limits %q: expected <limit>=<value>, got %q", org, kv)
+			}
+			val, err := strconv.Atoi(pair[1])
+			if err != nil || val < 0 {
+				return nil, fmt.Errorf("invalid org limits %q: bad value for %s: %q", org, pair[0], pair[1])
+			}
+			switch pair[0] {
+			case "max-series-per-target":
+				limits.maxSeriesPerTarget = val
+			case "max-points-fetch":
+				limits.maxPointsFetch = val
+			case "max-pattern-expansions":
+				limits.maxExpansions = val
+			case "max-expr-depth":
+				limits.maxExprDepth = val
+			default:
+				return nil, fmt.Errorf("invalid org limits %q: unknown limit %q", org, pair[0])
+			}
+		}
+		out[orgId] = limits
+	}
+	return out, nil
+}
+
+// findLimits returns the limits for an index search that may resolve to at most maxSeries more series
+// for its target. the index can only tell us if a search resolves to more series than a limit,
+// so if the target has no series left, the callers have to check that the search resolved to none.
+func (l queryLimits) findLimits(maxSeries int) idx.FindLimits {
+	limits := idx.FindLimits{MaxExpansions: l.maxExpansions}
+	if l.maxSeriesPerTarget > 0 {
+		// 0 would disable the limit
+		limits.MaxSeries = maxSeries
+		if limits.MaxSeries < 1 {
+			limits.MaxSeries = 1
+		}
+	}
+	return limits
+}
+
+// errFindMaxSeries is returned by index searches, local and of peers, that resolve to more series than their limit.
+// as a search doesn't know what it resolves series for, the callers replace it with errMaxSeriesPerTarget
+var errFindMaxSeries = response.NewError(http.StatusRequestEntityTooLarge, idx.ErrMaxSeries.Error())
+
+// isMaxSeries returns whether the error of a search is errFindMaxSeries, as returned by the index of this node or of a peer
+func isMaxSeries(err error) bool {
+	e, ok := err.(response.Error)
+	return ok && e.Code() == http.StatusRequestEntityTooLarge
+}
+
+// findError converts an error of an index search for the given query into the error to return
+func findError(err error, query string, limits idx.FindLimits) error {
+	switch err {
+	case idx.ErrMaxSeries:
+		return errFindMaxSeries
+	case idx.ErrMaxExpansions:
+		return errMaxExpansions(query, limits.MaxExpansions)
+	}
+	return response.NewError(http.StatusBadRequest, err.Error())
+}
+
+// countSeries returns the number of series the given search results resolve to
+func countSeries(series []Series) int {
+	var count int
+	for _, s := range series {
+		for _, n := range s.Series {
+			count += len(n.Defs)
+		}
+	}
+	return count
+}
+
+func errMaxSeriesPerTarget(query string, limit int) error {
+	return response.NewError(http.StatusRequestEntityTooLarge, fmt.Sprintf("target resolves to more than %d series (max-series-per-target), at %q. Use more specific patterns or ask your admin to increase the limit.", limit, query))
+}
+
+func errMaxPointsFetch(points uint32, limit int) error {
+	return response.NewError(http.StatusRequestEntityTooLarge, fmt.Sprintf("request would fetch %d points, exceeding the limit of %d (max-points-fetch). Reduce the time range or number of targets or ask your admin to increase the limit.", points, limit))
+}
+
+func errMaxExpansions(pattern string, limit int) error {
+	return response.NewError(http.StatusBadRequest, fmt.Sprintf("pattern %q expands into more than %d patterns (max-pattern-expansions). Use fewer {a,b} lists or ask your admin to increase the limit.", pattern, limit))
+}
+
+func errMaxExprDepth(depth, limit int) error {
+	return response.NewError(http.StatusBadRequest, fmt.Sprintf("expression depth %d exceeds the limit of %d (max-expr-depth). Use fewer nested functions or ask your admin to increase the limit.", depth, limit))
+}
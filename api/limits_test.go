@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/grafana/metrictank/api/response"
+	"github.com/grafana/metrictank/idx"
+)
+
+func TestParseOrgLimits(t *testing.T) {
+	defaults := queryLimits{
+		maxSeriesPerTarget: 100,
+		maxPointsFetch:     1000,
+	}
+	cases := []struct {
+		in     string
+		exp    map[int]queryLimits
+		expErr bool
+	}{
+		{
+			in:  "",
+			exp: map[int]queryLimits{},
+		},
+		{
+			in: "10:max-series-per-target=1000,max-expr-depth=10 20:max-points-fetch=0,max-pattern-expansions=5",
+			exp: map[int]queryLimits{
+				10: {maxSeriesPerTarget: 1000, maxPointsFetch: 1000, maxExprDepth: 10},
+				20: {maxSeriesPerTarget: 100, maxExpansions: 5},
+			},
+		},
+		{in: "10", expErr: true},
+		{in: "foo:max-expr-depth=10", expErr: true},
+		{in: "10:max-expr-depth", expErr: true},
+		{in: "10:max-expr-depth=-1", expErr: true},
+		{in: "10:max-foo=1", expErr: true},
+	}
+	for i, c := range cases {
+		got, err := parseOrgLimits(c.in, defaults)
+		if c.expErr {
+			if err == nil {
+				t.Fatalf("case %d: %q: expected error, got %v", i, c.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d: %q: unexpected error %s", i, c.in, err)
+		}
+		if !reflect.DeepEqual(got, c.exp) {
+			t.Fatalf("case %d: %q: expected %v, got %v", i, c.in, c.exp, got)
+		}
+	}
+}
+
+func TestQueryLimitsFindLimits(t *testing.T) {
+	cases := []struct {
+		limits    queryLimits
+		maxSeries int
+		exp       idx.FindLimits
+	}{
+		{queryLimits{}, 0, idx.FindLimits{}},
+		{queryLimits{maxExpansions: 5}, 10, idx.FindLimits{MaxExpansions: 5}},
+		{queryLimits{maxSeriesPerTarget: 100}, 40, idx.FindLimits{MaxSeries: 40}},
+		// no series left for the target: any series exceeds the limit
+		{queryLimits{maxSeriesPerTarget: 100}, 0, idx.FindLimits{MaxSeries: 1}},
+	}
+	for i, c := range cases {
+		if got := c.limits.findLimits(c.maxSeries); got != c.exp {
+			t.Fatalf("case %d: expected %v, got %v", i, c.exp, got)
+		}
+	}
+}
+
+func TestFindError(t *testing.T) {
+	limits := idx.FindLimits{MaxSeries: 10, MaxExpansions: 5}
+	cases := []struct {
+		err     error
+		expCode int
+	}{
+		{idx.ErrMaxSeries, http.StatusRequestEntityTooLarge},
+		{idx.ErrMaxExpansions, http.StatusBadRequest},
+		{errors.New("invalid pattern"), http.StatusBadRequest},
+	}
+	for i, c := range cases {
+		err := findError(c.err, "foo.*", limits)
+		if e, ok := err.(response.Error); !ok || e.Code() != c.expCode {
+			t.Fatalf("case %d: expected an error with code %d, got %v", i, c.expCode, err)
+		}
+		if got := isMaxSeries(err); got != (c.err == idx.ErrMaxSeries) {
+			t.Fatalf("case %d: expected isMaxSeries to be %t, got %t", i, !got, got)
+		}
+	}
+}
@@ -92,5 +92,9 @@ func pathSlug(p string) string {
 	if slug == "" {
 		slug = "root"
 	}
+	// tag names are user input, we don't want to create a set of metrics for each of them
+	if strings.HasPrefix(slug, "tags/") && strings.Count(slug, "/") == 1 && slug != "tags/findSeries" {
+		slug = "tags/tag"
+	}
 	return strings.Replace(slug, "/", "_", -1)
 }
@@ -19,6 +19,22 @@ func NewIndexFindResp() *IndexFindResp {
 //go:generate msgp
 type GetDataResp struct {
 	Series []Series
+	Stats  StorageStats
+}
+
+//go:generate msgp
+type IndexTagsResp struct {
+	Tags []string
+}
+
+//go:generate msgp
+type IndexTagDetailsResp struct {
+	Values map[string]uint32
+}
+
+//go:generate msgp
+type IndexFindByTagResp struct {
+	Nodes []idx.Node
 }
 
 type MetricsDeleteResp struct {
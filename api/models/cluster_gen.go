@@ -42,6 +42,11 @@ func (z *GetDataResp) DecodeMsg(dc *msgp.Reader) (err error) {
 					return
 				}
 			}
+		case "Stats":
+			err = z.Stats.DecodeMsg(dc)
+			if err != nil {
+				return
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -54,9 +59,9 @@ func (z *GetDataResp) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *GetDataResp) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 1
+	// map header, size 2
 	// write "Series"
-	err = en.Append(0x81, 0xa6, 0x53, 0x65, 0x72, 0x69, 0x65, 0x73)
+	err = en.Append(0x82, 0xa6, 0x53, 0x65, 0x72, 0x69, 0x65, 0x73)
 	if err != nil {
 		return err
 	}
@@ -70,15 +75,24 @@ func (z *GetDataResp) EncodeMsg(en *msgp.Writer) (err error) {
 			return
 		}
 	}
+	// write "Stats"
+	err = en.Append(0xa5, 0x53, 0x74, 0x61, 0x74, 0x73)
+	if err != nil {
+		return err
+	}
+	err = z.Stats.EncodeMsg(en)
+	if err != nil {
+		return
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *GetDataResp) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 1
+	// map header, size 2
 	// string "Series"
-	o = append(o, 0x81, 0xa6, 0x53, 0x65, 0x72, 0x69, 0x65, 0x73)
+	o = append(o, 0x82, 0xa6, 0x53, 0x65, 0x72, 0x69, 0x65, 0x73)
 	o = msgp.AppendArrayHeader(o, uint32(len(z.Series)))
 	for zxvk := range z.Series {
 		o, err = z.Series[zxvk].MarshalMsg(o)
@@ -86,6 +100,12 @@ func (z *GetDataResp) MarshalMsg(b []byte) (o []byte, err error) {
 			return
 		}
 	}
+	// string "Stats"
+	o = append(o, 0xa5, 0x53, 0x74, 0x61, 0x74, 0x73)
+	o, err = z.Stats.MarshalMsg(o)
+	if err != nil {
+		return
+	}
 	return
 }
 
@@ -122,6 +142,11 @@ func (z *GetDataResp) UnmarshalMsg(bts []byte) (o []byte, err error) {
 					return
 				}
 			}
+		case "Stats":
+			bts, err = z.Stats.UnmarshalMsg(bts)
+			if err != nil {
+				return
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -139,6 +164,140 @@ func (z *GetDataResp) Msgsize() (s int) {
 	for zxvk := range z.Series {
 		s += z.Series[zxvk].Msgsize()
 	}
+	s += 6 + z.Stats.Msgsize()
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *IndexFindByTagResp) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zrpi uint32
+	zrpi, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zrpi > 0 {
+		zrpi--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Nodes":
+			var zqrd uint32
+			zqrd, err = dc.ReadArrayHeader()
+			if err != nil {
+				return
+			}
+			if cap(z.Nodes) >= int(zqrd) {
+				z.Nodes = (z.Nodes)[:zqrd]
+			} else {
+				z.Nodes = make([]idx.Node, zqrd)
+			}
+			for zfmc := range z.Nodes {
+				err = z.Nodes[zfmc].DecodeMsg(dc)
+				if err != nil {
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *IndexFindByTagResp) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "Nodes"
+	err = en.Append(0x81, 0xa5, 0x4e, 0x6f, 0x64, 0x65, 0x73)
+	if err != nil {
+		return err
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Nodes)))
+	if err != nil {
+		return
+	}
+	for zfmc := range z.Nodes {
+		err = z.Nodes[zfmc].EncodeMsg(en)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *IndexFindByTagResp) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "Nodes"
+	o = append(o, 0x81, 0xa5, 0x4e, 0x6f, 0x64, 0x65, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Nodes)))
+	for zfmc := range z.Nodes {
+		o, err = z.Nodes[zfmc].MarshalMsg(o)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *IndexFindByTagResp) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zyfb uint32
+	zyfb, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zyfb > 0 {
+		zyfb--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Nodes":
+			var znkg uint32
+			znkg, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			if cap(z.Nodes) >= int(znkg) {
+				z.Nodes = (z.Nodes)[:znkg]
+			} else {
+				z.Nodes = make([]idx.Node, znkg)
+			}
+			for zfmc := range z.Nodes {
+				bts, err = z.Nodes[zfmc].UnmarshalMsg(bts)
+				if err != nil {
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *IndexFindByTagResp) Msgsize() (s int) {
+	s = 1 + 6 + msgp.ArrayHeaderSize
+	for zfmc := range z.Nodes {
+		s += z.Nodes[zfmc].Msgsize()
+	}
 	return
 }
 
@@ -339,6 +498,294 @@ func (z *IndexFindResp) Msgsize() (s int) {
 	return
 }
 
+// DecodeMsg implements msgp.Decodable
+func (z *IndexTagDetailsResp) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zmfb uint32
+	zmfb, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zmfb > 0 {
+		zmfb--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Values":
+			var zses uint32
+			zses, err = dc.ReadMapHeader()
+			if err != nil {
+				return
+			}
+			if z.Values == nil && zses > 0 {
+				z.Values = make(map[string]uint32, zses)
+			} else if len(z.Values) > 0 {
+				for key := range z.Values {
+					delete(z.Values, key)
+				}
+			}
+			for zses > 0 {
+				zses--
+				var zeid string
+				var zujd uint32
+				zeid, err = dc.ReadString()
+				if err != nil {
+					return
+				}
+				zujd, err = dc.ReadUint32()
+				if err != nil {
+					return
+				}
+				z.Values[zeid] = zujd
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *IndexTagDetailsResp) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "Values"
+	err = en.Append(0x81, 0xa6, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73)
+	if err != nil {
+		return err
+	}
+	err = en.WriteMapHeader(uint32(len(z.Values)))
+	if err != nil {
+		return
+	}
+	for zeid, zujd := range z.Values {
+		err = en.WriteString(zeid)
+		if err != nil {
+			return
+		}
+		err = en.WriteUint32(zujd)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *IndexTagDetailsResp) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "Values"
+	o = append(o, 0x81, 0xa6, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73)
+	o = msgp.AppendMapHeader(o, uint32(len(z.Values)))
+	for zeid, zujd := range z.Values {
+		o = msgp.AppendString(o, zeid)
+		o = msgp.AppendUint32(o, zujd)
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *IndexTagDetailsResp) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zdis uint32
+	zdis, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zdis > 0 {
+		zdis--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Values":
+			var zxhy uint32
+			zxhy, bts, err = msgp.ReadMapHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			if z.Values == nil && zxhy > 0 {
+				z.Values = make(map[string]uint32, zxhy)
+			} else if len(z.Values) > 0 {
+				for key := range z.Values {
+					delete(z.Values, key)
+				}
+			}
+			for zxhy > 0 {
+				var zeid string
+				var zujd uint32
+				zxhy--
+				zeid, bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					return
+				}
+				zujd, bts, err = msgp.ReadUint32Bytes(bts)
+				if err != nil {
+					return
+				}
+				z.Values[zeid] = zujd
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *IndexTagDetailsResp) Msgsize() (s int) {
+	s = 1 + 7 + msgp.MapHeaderSize
+	if z.Values != nil {
+		for zeid, zujd := range z.Values {
+			_ = zujd
+			s += msgp.StringPrefixSize + len(zeid) + msgp.Uint32Size
+		}
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *IndexTagsResp) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zecd uint32
+	zecd, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zecd > 0 {
+		zecd--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Tags":
+			var zzww uint32
+			zzww, err = dc.ReadArrayHeader()
+			if err != nil {
+				return
+			}
+			if cap(z.Tags) >= int(zzww) {
+				z.Tags = (z.Tags)[:zzww]
+			} else {
+				z.Tags = make([]string, zzww)
+			}
+			for zuum := range z.Tags {
+				z.Tags[zuum], err = dc.ReadString()
+				if err != nil {
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *IndexTagsResp) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "Tags"
+	err = en.Append(0x81, 0xa4, 0x54, 0x61, 0x67, 0x73)
+	if err != nil {
+		return err
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Tags)))
+	if err != nil {
+		return
+	}
+	for zuum := range z.Tags {
+		err = en.WriteString(z.Tags[zuum])
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *IndexTagsResp) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "Tags"
+	o = append(o, 0x81, 0xa4, 0x54, 0x61, 0x67, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Tags)))
+	for zuum := range z.Tags {
+		o = msgp.AppendString(o, z.Tags[zuum])
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *IndexTagsResp) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var znvm uint32
+	znvm, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for znvm > 0 {
+		znvm--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Tags":
+			var zjsw uint32
+			zjsw, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			if cap(z.Tags) >= int(zjsw) {
+				z.Tags = (z.Tags)[:zjsw]
+			} else {
+				z.Tags = make([]string, zjsw)
+			}
+			for zuum := range z.Tags {
+				z.Tags[zuum], bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *IndexTagsResp) Msgsize() (s int) {
+	s = 1 + 5 + msgp.ArrayHeaderSize
+	for zuum := range z.Tags {
+		s += msgp.StringPrefixSize + len(z.Tags[zuum])
+	}
+	return
+}
+
 // DecodeMsg implements msgp.Decodable
 func (z *MetricsDeleteResp) DecodeMsg(dc *msgp.Reader) (err error) {
 	var field []byte
@@ -26,6 +26,8 @@ type GraphiteRender struct {
 	Format        string   `json:"format" form:"format" binding:"In(,json,msgp,pickle)"`
 	NoProxy       bool     `json:"local" form:"local"` //this is set to true by graphite-web when it passes request to cluster servers
 	Process       string   `json:"process" form:"process" binding:"In(,none,stable,any);Default(stable)"`
+	Explain       bool     `json:"explain" form:"explain"` // explain how the request would be executed, rather than returning data
+	Meta          bool     `json:"meta" form:"meta"`       // return the series along with the stats and warnings of the request. only for json and msgp formats
 }
 
 func (gr GraphiteRender) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
@@ -151,3 +153,48 @@ type SeriesTreeItem struct {
 	Text          string         `json:"text"`
 	Context       map[string]int `json:"context"` // unused
 }
+
+type GraphiteTags struct {
+	Filter string `json:"filter" form:"filter"`
+}
+
+type GraphiteTagsResp []GraphiteTagResp
+
+type GraphiteTagResp struct {
+	Tag string `json:"tag"`
+}
+
+type GraphiteTagDetails struct {
+	Filter string `json:"filter" form:"filter"`
+	From   int64  `json:"from" form:"from"`
+}
+
+type GraphiteTagDetailsResp struct {
+	Tag    string                        `json:"tag"`
+	Values []GraphiteTagDetailsValueResp `json:"values"`
+}
+
+type GraphiteTagDetailsValueResp struct {
+	Count uint32 `json:"count"`
+	Value string `json:"value"`
+}
+
+type GraphiteTagFindSeries struct {
+	Expr []string `json:"expr" form:"expr" binding:"Required"`
+	From int64    `json:"from" form:"from"`
+}
+
+type GraphiteAutoCompleteTags struct {
+	TagPrefix string   `json:"tagPrefix" form:"tagPrefix"`
+	Expr      []string `json:"expr" form:"expr"`
+	From      int64    `json:"from" form:"from"`
+	Limit     int      `json:"limit" form:"limit" binding:"Default(100)"`
+}
+
+type GraphiteAutoCompleteTagValues struct {
+	Tag         string   `json:"tag" form:"tag" binding:"Required"`
+	ValuePrefix string   `json:"valuePrefix" form:"valuePrefix"`
+	Expr        []string `json:"expr" form:"expr"`
+	From        int64    `json:"from" form:"from"`
+	Limit       int      `json:"limit" form:"limit" binding:"Default(100)"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"sync/atomic"
+)
+
+//go:generate msgp
+
+// StorageStats counts the chunks and points that were read from storage to satisfy requests.
+// it is safe for concurrent use
+type StorageStats struct {
+	ChunksCache uint32 `json:"chunksCache"` // number of chunks served from the chunk cache
+	ChunksStore uint32 `json:"chunksStore"` // number of chunks read from the backend store
+	PointsFetch uint32 `json:"pointsFetch"` // number of points read from memory, the chunk cache and the backend store
+}
+
+func (ss *StorageStats) IncChunksCache(n int) {
+	atomic.AddUint32(&ss.ChunksCache, uint32(n))
+}
+
+func (ss *StorageStats) IncChunksStore(n int) {
+	atomic.AddUint32(&ss.ChunksStore, uint32(n))
+}
+
+func (ss *StorageStats) IncPointsFetch(n int) {
+	atomic.AddUint32(&ss.PointsFetch, uint32(n))
+}
+
+// Add adds the counts of other to ss
+func (ss *StorageStats) Add(other *StorageStats) {
+	atomic.AddUint32(&ss.ChunksCache, atomic.LoadUint32(&other.ChunksCache))
+	atomic.AddUint32(&ss.ChunksStore, atomic.LoadUint32(&other.ChunksStore))
+	atomic.AddUint32(&ss.PointsFetch, atomic.LoadUint32(&other.PointsFetch))
+}
+
+// RenderStats describes the work done to execute a render request.
+// durations are in seconds
+type RenderStats struct {
+	SeriesFetch  uint32  `json:"seriesFetch"`  // number of series the patterns resolved to
+	ChunksCache  uint32  `json:"chunksCache"`  // number of chunks served from the chunk cache
+	ChunksStore  uint32  `json:"chunksStore"`  // number of chunks read from the backend store
+	PointsFetch  uint32  `json:"pointsFetch"`  // number of points read from memory, the chunk cache and the backend store
+	PointsReturn uint32  `json:"pointsReturn"` // number of points of the returned series
+	TimeIndex    float64 `json:"timeIndex"`    // time spent looking up series in the index and planning the fetches
+	TimeStore    float64 `json:"timeStore"`    // time spent fetching data from memory, cache, store and cluster peers
+	TimeExpr     float64 `json:"timeExpr"`     // time spent processing functions
+}
+
+// RenderMeta is the metadata of a render response
+type RenderMeta struct {
+	Stats    RenderStats `json:"stats"`
+	Warnings []string    `json:"warnings"`
+}
+
+// SeriesWithMeta is the response to a render request with meta=true:
+// the regular series, along with the metadata of the request
+type SeriesWithMeta struct {
+	Meta   RenderMeta
+	Series SeriesByTarget
+}
+
+// MarshalJSONFast renders {"meta": {...}, "series": [regular graphite output]}
+func (s SeriesWithMeta) MarshalJSONFast(b []byte) ([]byte, error) {
+	meta, err := json.Marshal(s.Meta)
+	if err != nil {
+		return b, err
+	}
+	b = append(b, `{"meta":`...)
+	b = append(b, meta...)
+	b = append(b, `,"series":`...)
+	b, err = s.Series.MarshalJSONFast(b)
+	if err != nil {
+		return b, err
+	}
+	b = append(b, '}')
+	return b, nil
+}
+
+func (s SeriesWithMeta) MarshalJSON() ([]byte, error) {
+	return s.MarshalJSONFast(nil)
+}
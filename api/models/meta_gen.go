@@ -0,0 +1,775 @@
+package models
+
+// NOTE: THIS FILE WAS PRODUCED BY THE
+// MSGP CODE GENERATION TOOL (github.com/tinylib/msgp)
+// DO NOT EDIT
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// DecodeMsg implements msgp.Decodable
+func (z *RenderMeta) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zthe uint32
+	zthe, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zthe > 0 {
+		zthe--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Stats":
+			err = z.Stats.DecodeMsg(dc)
+			if err != nil {
+				return
+			}
+		case "Warnings":
+			var zaek uint32
+			zaek, err = dc.ReadArrayHeader()
+			if err != nil {
+				return
+			}
+			if cap(z.Warnings) >= int(zaek) {
+				z.Warnings = (z.Warnings)[:zaek]
+			} else {
+				z.Warnings = make([]string, zaek)
+			}
+			for zuoa := range z.Warnings {
+				z.Warnings[zuoa], err = dc.ReadString()
+				if err != nil {
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *RenderMeta) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "Stats"
+	err = en.Append(0x82, 0xa5, 0x53, 0x74, 0x61, 0x74, 0x73)
+	if err != nil {
+		return err
+	}
+	err = z.Stats.EncodeMsg(en)
+	if err != nil {
+		return
+	}
+	// write "Warnings"
+	err = en.Append(0xa8, 0x57, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x73)
+	if err != nil {
+		return err
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Warnings)))
+	if err != nil {
+		return
+	}
+	for zuoa := range z.Warnings {
+		err = en.WriteString(z.Warnings[zuoa])
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *RenderMeta) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "Stats"
+	o = append(o, 0x82, 0xa5, 0x53, 0x74, 0x61, 0x74, 0x73)
+	o, err = z.Stats.MarshalMsg(o)
+	if err != nil {
+		return
+	}
+	// string "Warnings"
+	o = append(o, 0xa8, 0x57, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Warnings)))
+	for zuoa := range z.Warnings {
+		o = msgp.AppendString(o, z.Warnings[zuoa])
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *RenderMeta) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zbwo uint32
+	zbwo, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zbwo > 0 {
+		zbwo--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Stats":
+			bts, err = z.Stats.UnmarshalMsg(bts)
+			if err != nil {
+				return
+			}
+		case "Warnings":
+			var ztiv uint32
+			ztiv, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			if cap(z.Warnings) >= int(ztiv) {
+				z.Warnings = (z.Warnings)[:ztiv]
+			} else {
+				z.Warnings = make([]string, ztiv)
+			}
+			for zuoa := range z.Warnings {
+				z.Warnings[zuoa], bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *RenderMeta) Msgsize() (s int) {
+	s = 1 + 6 + z.Stats.Msgsize() + 9 + msgp.ArrayHeaderSize
+	for zuoa := range z.Warnings {
+		s += msgp.StringPrefixSize + len(z.Warnings[zuoa])
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *RenderStats) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zfgv uint32
+	zfgv, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zfgv > 0 {
+		zfgv--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "SeriesFetch":
+			z.SeriesFetch, err = dc.ReadUint32()
+			if err != nil {
+				return
+			}
+		case "ChunksCache":
+			z.ChunksCache, err = dc.ReadUint32()
+			if err != nil {
+				return
+			}
+		case "ChunksStore":
+			z.ChunksStore, err = dc.ReadUint32()
+			if err != nil {
+				return
+			}
+		case "PointsFetch":
+			z.PointsFetch, err = dc.ReadUint32()
+			if err != nil {
+				return
+			}
+		case "PointsReturn":
+			z.PointsReturn, err = dc.ReadUint32()
+			if err != nil {
+				return
+			}
+		case "TimeIndex":
+			z.TimeIndex, err = dc.ReadFloat64()
+			if err != nil {
+				return
+			}
+		case "TimeStore":
+			z.TimeStore, err = dc.ReadFloat64()
+			if err != nil {
+				return
+			}
+		case "TimeExpr":
+			z.TimeExpr, err = dc.ReadFloat64()
+			if err != nil {
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *RenderStats) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 8
+	// write "SeriesFetch"
+	err = en.Append(0x88, 0xab, 0x53, 0x65, 0x72, 0x69, 0x65, 0x73, 0x46, 0x65, 0x74, 0x63, 0x68)
+	if err != nil {
+		return err
+	}
+	err = en.WriteUint32(z.SeriesFetch)
+	if err != nil {
+		return
+	}
+	// write "ChunksCache"
+	err = en.Append(0xab, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x73, 0x43, 0x61, 0x63, 0x68, 0x65)
+	if err != nil {
+		return err
+	}
+	err = en.WriteUint32(z.ChunksCache)
+	if err != nil {
+		return
+	}
+	// write "ChunksStore"
+	err = en.Append(0xab, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x73, 0x53, 0x74, 0x6f, 0x72, 0x65)
+	if err != nil {
+		return err
+	}
+	err = en.WriteUint32(z.ChunksStore)
+	if err != nil {
+		return
+	}
+	// write "PointsFetch"
+	err = en.Append(0xab, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x46, 0x65, 0x74, 0x63, 0x68)
+	if err != nil {
+		return err
+	}
+	err = en.WriteUint32(z.PointsFetch)
+	if err != nil {
+		return
+	}
+	// write "PointsReturn"
+	err = en.Append(0xac, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x74, 0x75, 0x72, 0x6e)
+	if err != nil {
+		return err
+	}
+	err = en.WriteUint32(z.PointsReturn)
+	if err != nil {
+		return
+	}
+	// write "TimeIndex"
+	err = en.Append(0xa9, 0x54, 0x69, 0x6d, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78)
+	if err != nil {
+		return err
+	}
+	err = en.WriteFloat64(z.TimeIndex)
+	if err != nil {
+		return
+	}
+	// write "TimeStore"
+	err = en.Append(0xa9, 0x54, 0x69, 0x6d, 0x65, 0x53, 0x74, 0x6f, 0x72, 0x65)
+	if err != nil {
+		return err
+	}
+	err = en.WriteFloat64(z.TimeStore)
+	if err != nil {
+		return
+	}
+	// write "TimeExpr"
+	err = en.Append(0xa8, 0x54, 0x69, 0x6d, 0x65, 0x45, 0x78, 0x70, 0x72)
+	if err != nil {
+		return err
+	}
+	err = en.WriteFloat64(z.TimeExpr)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *RenderStats) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 8
+	// string "SeriesFetch"
+	o = append(o, 0x88, 0xab, 0x53, 0x65, 0x72, 0x69, 0x65, 0x73, 0x46, 0x65, 0x74, 0x63, 0x68)
+	o = msgp.AppendUint32(o, z.SeriesFetch)
+	// string "ChunksCache"
+	o = append(o, 0xab, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x73, 0x43, 0x61, 0x63, 0x68, 0x65)
+	o = msgp.AppendUint32(o, z.ChunksCache)
+	// string "ChunksStore"
+	o = append(o, 0xab, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x73, 0x53, 0x74, 0x6f, 0x72, 0x65)
+	o = msgp.AppendUint32(o, z.ChunksStore)
+	// string "PointsFetch"
+	o = append(o, 0xab, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x46, 0x65, 0x74, 0x63, 0x68)
+	o = msgp.AppendUint32(o, z.PointsFetch)
+	// string "PointsReturn"
+	o = append(o, 0xac, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x74, 0x75, 0x72, 0x6e)
+	o = msgp.AppendUint32(o, z.PointsReturn)
+	// string "TimeIndex"
+	o = append(o, 0xa9, 0x54, 0x69, 0x6d, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78)
+	o = msgp.AppendFloat64(o, z.TimeIndex)
+	// string "TimeStore"
+	o = append(o, 0xa9, 0x54, 0x69, 0x6d, 0x65, 0x53, 0x74, 0x6f, 0x72, 0x65)
+	o = msgp.AppendFloat64(o, z.TimeStore)
+	// string "TimeExpr"
+	o = append(o, 0xa8, 0x54, 0x69, 0x6d, 0x65, 0x45, 0x78, 0x70, 0x72)
+	o = msgp.AppendFloat64(o, z.TimeExpr)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *RenderStats) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zwap uint32
+	zwap, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zwap > 0 {
+		zwap--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "SeriesFetch":
+			z.SeriesFetch, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "ChunksCache":
+			z.ChunksCache, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "ChunksStore":
+			z.ChunksStore, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "PointsFetch":
+			z.PointsFetch, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "PointsReturn":
+			z.PointsReturn, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "TimeIndex":
+			z.TimeIndex, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "TimeStore":
+			z.TimeStore, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "TimeExpr":
+			z.TimeExpr, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *RenderStats) Msgsize() (s int) {
+	s = 1 + 12 + msgp.Uint32Size + 12 + msgp.Uint32Size + 12 + msgp.Uint32Size + 12 + msgp.Uint32Size + 13 + msgp.Uint32Size + 10 + msgp.Float64Size + 10 + msgp.Float64Size + 9 + msgp.Float64Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *SeriesWithMeta) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zwcr uint32
+	zwcr, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zwcr > 0 {
+		zwcr--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Meta":
+			var zzww uint32
+			zzww, err = dc.ReadMapHeader()
+			if err != nil {
+				return
+			}
+			for zzww > 0 {
+				zzww--
+				field, err = dc.ReadMapKeyPtr()
+				if err != nil {
+					return
+				}
+				switch msgp.UnsafeString(field) {
+				case "Stats":
+					err = z.Meta.Stats.DecodeMsg(dc)
+					if err != nil {
+						return
+					}
+				case "Warnings":
+					var ztve uint32
+					ztve, err = dc.ReadArrayHeader()
+					if err != nil {
+						return
+					}
+					if cap(z.Meta.Warnings) >= int(ztve) {
+						z.Meta.Warnings = (z.Meta.Warnings)[:ztve]
+					} else {
+						z.Meta.Warnings = make([]string, ztve)
+					}
+					for zisw := range z.Meta.Warnings {
+						z.Meta.Warnings[zisw], err = dc.ReadString()
+						if err != nil {
+							return
+						}
+					}
+				default:
+					err = dc.Skip()
+					if err != nil {
+						return
+					}
+				}
+			}
+		case "Series":
+			err = z.Series.DecodeMsg(dc)
+			if err != nil {
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *SeriesWithMeta) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "Meta"
+	// map header, size 2
+	// write "Stats"
+	err = en.Append(0x82, 0xa4, 0x4d, 0x65, 0x74, 0x61, 0x82, 0xa5, 0x53, 0x74, 0x61, 0x74, 0x73)
+	if err != nil {
+		return err
+	}
+	err = z.Meta.Stats.EncodeMsg(en)
+	if err != nil {
+		return
+	}
+	// write "Warnings"
+	err = en.Append(0xa8, 0x57, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x73)
+	if err != nil {
+		return err
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Meta.Warnings)))
+	if err != nil {
+		return
+	}
+	for zisw := range z.Meta.Warnings {
+		err = en.WriteString(z.Meta.Warnings[zisw])
+		if err != nil {
+			return
+		}
+	}
+	// write "Series"
+	err = en.Append(0xa6, 0x53, 0x65, 0x72, 0x69, 0x65, 0x73)
+	if err != nil {
+		return err
+	}
+	err = z.Series.EncodeMsg(en)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *SeriesWithMeta) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "Meta"
+	// map header, size 2
+	// string "Stats"
+	o = append(o, 0x82, 0xa4, 0x4d, 0x65, 0x74, 0x61, 0x82, 0xa5, 0x53, 0x74, 0x61, 0x74, 0x73)
+	o, err = z.Meta.Stats.MarshalMsg(o)
+	if err != nil {
+		return
+	}
+	// string "Warnings"
+	o = append(o, 0xa8, 0x57, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Meta.Warnings)))
+	for zisw := range z.Meta.Warnings {
+		o = msgp.AppendString(o, z.Meta.Warnings[zisw])
+	}
+	// string "Series"
+	o = append(o, 0xa6, 0x53, 0x65, 0x72, 0x69, 0x65, 0x73)
+	o, err = z.Series.MarshalMsg(o)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *SeriesWithMeta) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zoar uint32
+	zoar, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zoar > 0 {
+		zoar--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Meta":
+			var zxyq uint32
+			zxyq, bts, err = msgp.ReadMapHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			for zxyq > 0 {
+				zxyq--
+				field, bts, err = msgp.ReadMapKeyZC(bts)
+				if err != nil {
+					return
+				}
+				switch msgp.UnsafeString(field) {
+				case "Stats":
+					bts, err = z.Meta.Stats.UnmarshalMsg(bts)
+					if err != nil {
+						return
+					}
+				case "Warnings":
+					var zovf uint32
+					zovf, bts, err = msgp.ReadArrayHeaderBytes(bts)
+					if err != nil {
+						return
+					}
+					if cap(z.Meta.Warnings) >= int(zovf) {
+						z.Meta.Warnings = (z.Meta.Warnings)[:zovf]
+					} else {
+						z.Meta.Warnings = make([]string, zovf)
+					}
+					for zisw := range z.Meta.Warnings {
+						z.Meta.Warnings[zisw], bts, err = msgp.ReadStringBytes(bts)
+						if err != nil {
+							return
+						}
+					}
+				default:
+					bts, err = msgp.Skip(bts)
+					if err != nil {
+						return
+					}
+				}
+			}
+		case "Series":
+			bts, err = z.Series.UnmarshalMsg(bts)
+			if err != nil {
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *SeriesWithMeta) Msgsize() (s int) {
+	s = 1 + 5 + 1 + 6 + z.Meta.Stats.Msgsize() + 9 + msgp.ArrayHeaderSize
+	for zisw := range z.Meta.Warnings {
+		s += msgp.StringPrefixSize + len(z.Meta.Warnings[zisw])
+	}
+	s += 7 + z.Series.Msgsize()
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *StorageStats) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zdoq uint32
+	zdoq, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zdoq > 0 {
+		zdoq--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "ChunksCache":
+			z.ChunksCache, err = dc.ReadUint32()
+			if err != nil {
+				return
+			}
+		case "ChunksStore":
+			z.ChunksStore, err = dc.ReadUint32()
+			if err != nil {
+				return
+			}
+		case "PointsFetch":
+			z.PointsFetch, err = dc.ReadUint32()
+			if err != nil {
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z StorageStats) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "ChunksCache"
+	err = en.Append(0x83, 0xab, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x73, 0x43, 0x61, 0x63, 0x68, 0x65)
+	if err != nil {
+		return err
+	}
+	err = en.WriteUint32(z.ChunksCache)
+	if err != nil {
+		return
+	}
+	// write "ChunksStore"
+	err = en.Append(0xab, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x73, 0x53, 0x74, 0x6f, 0x72, 0x65)
+	if err != nil {
+		return err
+	}
+	err = en.WriteUint32(z.ChunksStore)
+	if err != nil {
+		return
+	}
+	// write "PointsFetch"
+	err = en.Append(0xab, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x46, 0x65, 0x74, 0x63, 0x68)
+	if err != nil {
+		return err
+	}
+	err = en.WriteUint32(z.PointsFetch)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z StorageStats) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "ChunksCache"
+	o = append(o, 0x83, 0xab, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x73, 0x43, 0x61, 0x63, 0x68, 0x65)
+	o = msgp.AppendUint32(o, z.ChunksCache)
+	// string "ChunksStore"
+	o = append(o, 0xab, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x73, 0x53, 0x74, 0x6f, 0x72, 0x65)
+	o = msgp.AppendUint32(o, z.ChunksStore)
+	// string "PointsFetch"
+	o = append(o, 0xab, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x46, 0x65, 0x74, 0x63, 0x68)
+	o = msgp.AppendUint32(o, z.PointsFetch)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *StorageStats) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zomb uint32
+	zomb, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zomb > 0 {
+		zomb--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "ChunksCache":
+			z.ChunksCache, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "ChunksStore":
+			z.ChunksStore, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				return
+			}
+		case "PointsFetch":
+			z.PointsFetch, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z StorageStats) Msgsize() (s int) {
+	s = 1 + 12 + msgp.Uint32Size + 12 + msgp.Uint32Size + 12 + msgp.Uint32Size
+	return
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"testing"
+
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestSeriesWithMetaJsonMarshal(t *testing.T) {
+	in := SeriesWithMeta{
+		Meta: RenderMeta{
+			Stats: RenderStats{
+				SeriesFetch:  1,
+				ChunksCache:  2,
+				ChunksStore:  3,
+				PointsFetch:  4,
+				PointsReturn: 5,
+				TimeIndex:    0.5,
+				TimeStore:    1,
+				TimeExpr:     0.25,
+			},
+			Warnings: []string{"from is beyond retention for 1 of 1 series"},
+		},
+		Series: []Series{
+			{
+				Target: "a",
+				Datapoints: []schema.Point{
+					{Val: 1, Ts: 60},
+				},
+				Interval: 60,
+			},
+		},
+	}
+	exp := `{"meta":{"stats":{"seriesFetch":1,"chunksCache":2,"chunksStore":3,"pointsFetch":4,"pointsReturn":5,"timeIndex":0.5,"timeStore":1,"timeExpr":0.25},"warnings":["from is beyond retention for 1 of 1 series"]},"series":[{"target":"a","datapoints":[[1.000,60]]}]}`
+	got, err := in.MarshalJSONFast(nil)
+	if err != nil {
+		t.Fatalf("failed to marshal: %s", err)
+	}
+	if string(got) != exp {
+		t.Fatalf("bad json output.\nexpected:%s\ngot:     %s", exp, got)
+	}
+}
+
+func TestStorageStatsAdd(t *testing.T) {
+	var ss StorageStats
+	ss.IncChunksCache(2)
+	ss.IncChunksStore(1)
+	ss.IncPointsFetch(10)
+	ss.Add(&StorageStats{ChunksCache: 3, ChunksStore: 4, PointsFetch: 20})
+	if ss.ChunksCache != 5 || ss.ChunksStore != 5 || ss.PointsFetch != 30 {
+		t.Fatalf("expected 5 cache and 5 store chunks and 30 points, got %d, %d and %d", ss.ChunksCache, ss.ChunksStore, ss.PointsFetch)
+	}
+}
@@ -40,15 +40,19 @@ type IndexGet struct {
 }
 
 type IndexFind struct {
-	Patterns []string `json:"patterns" form:"patterns" binding:"Required"`
-	OrgId    int      `json:"orgId" form:"orgId" binding:"Required"`
-	From     int64    `json:"from" form:"from"`
+	Patterns      []string `json:"patterns" form:"patterns" binding:"Required"`
+	OrgId         int      `json:"orgId" form:"orgId" binding:"Required"`
+	From          int64    `json:"from" form:"from"`
+	MaxSeries     int      `json:"maxSeries" form:"maxSeries"`
+	MaxExpansions int      `json:"maxExpansions" form:"maxExpansions"`
 }
 
 func (i IndexFind) Trace(span opentracing.Span) {
 	span.SetTag("q", i.Patterns)
 	span.SetTag("org", i.OrgId)
 	span.SetTag("from", i.From)
+	span.SetTag("maxSeries", i.MaxSeries)
+	span.SetTag("maxExpansions", i.MaxExpansions)
 }
 
 func (i IndexFind) TraceDebug(span opentracing.Span) {
@@ -86,3 +90,46 @@ func (i IndexDelete) Trace(span opentracing.Span) {
 
 func (i IndexDelete) TraceDebug(span opentracing.Span) {
 }
+
+type IndexTags struct {
+	OrgId int `json:"orgId" form:"orgId" binding:"Required"`
+}
+
+func (i IndexTags) Trace(span opentracing.Span) {
+	span.SetTag("org", i.OrgId)
+}
+
+func (i IndexTags) TraceDebug(span opentracing.Span) {
+}
+
+type IndexTagDetails struct {
+	OrgId int    `json:"orgId" form:"orgId" binding:"Required"`
+	Tag   string `json:"tag" form:"tag" binding:"Required"`
+	From  int64  `json:"from" form:"from"`
+}
+
+func (i IndexTagDetails) Trace(span opentracing.Span) {
+	span.SetTag("org", i.OrgId)
+	span.SetTag("tag", i.Tag)
+	span.SetTag("from", i.From)
+}
+
+func (i IndexTagDetails) TraceDebug(span opentracing.Span) {
+}
+
+type IndexFindByTag struct {
+	OrgId     int      `json:"orgId" form:"orgId" binding:"Required"`
+	Expr      []string `json:"expressions" form:"expressions" binding:"Required"`
+	From      int64    `json:"from" form:"from"`
+	MaxSeries int      `json:"maxSeries" form:"maxSeries"`
+}
+
+func (i IndexFindByTag) Trace(span opentracing.Span) {
+	span.SetTag("org", i.OrgId)
+	span.SetTag("expressions", i.Expr)
+	span.SetTag("from", i.From)
+	span.SetTag("maxSeries", i.MaxSeries)
+}
+
+func (i IndexFindByTag) TraceDebug(span opentracing.Span) {
+}
@@ -0,0 +1,80 @@
+package models
+
+// OpenTSDBPoint is a point of an opentsdb /api/put request
+type OpenTSDBPoint struct {
+	Metric    string            `json:"metric"`
+	Timestamp int64             `json:"timestamp"` // in seconds, or milliseconds if it has more than 10 digits
+	Value     interface{}       `json:"value"`     // a number, or a string holding a number
+	Tags      map[string]string `json:"tags"`
+}
+
+type OpenTSDBPutError struct {
+	Datapoint OpenTSDBPoint `json:"datapoint"`
+	Error     string        `json:"error"`
+}
+
+// OpenTSDBPutSummary is the response to an /api/put request with the summary or details parameter
+type OpenTSDBPutSummary struct {
+	Success int                `json:"success"`
+	Failed  int                `json:"failed"`
+	Errors  []OpenTSDBPutError `json:"errors,omitempty"` // only with the details parameter
+}
+
+// OpenTSDBQueryForm is an opentsdb /api/query request via query parameters. each m is a sub query like
+// sum:1h-avg:rate:sys.cpu.user{host=*}{dc=lga}, see http://opentsdb.net/docs/build/html/api_http/query/
+type OpenTSDBQueryForm struct {
+	Start string   `form:"start"`
+	End   string   `form:"end"`
+	M     []string `form:"m"`
+}
+
+// OpenTSDBQuery is an opentsdb /api/query request. times are relative, like 1h-ago,
+// absolute, like 2017/12/31-23:59:00, or unix timestamps in seconds or milliseconds
+type OpenTSDBQuery struct {
+	Start        interface{}        `json:"start"`
+	End          interface{}        `json:"end"`
+	Queries      []OpenTSDBSubQuery `json:"queries"`
+	MsResolution bool               `json:"msResolution"`
+}
+
+type OpenTSDBSubQuery struct {
+	Aggregator  string              `json:"aggregator"`
+	Metric      string              `json:"metric"`
+	Rate        bool                `json:"rate"`
+	RateOptions OpenTSDBRateOptions `json:"rateOptions"`
+	Downsample  string              `json:"downsample"` // like 1h-avg
+	Tags        map[string]string   `json:"tags"`       // group by filters, in the tag=value notation of opentsdb 2.1
+	Filters     []OpenTSDBFilter    `json:"filters"`
+}
+
+type OpenTSDBRateOptions struct {
+	Counter    bool    `json:"counter"`
+	CounterMax float64 `json:"counterMax"`
+	ResetValue float64 `json:"resetValue"`
+	DropResets bool    `json:"dropResets"`
+}
+
+type OpenTSDBFilter struct {
+	Type    string `json:"type"` // literal_or, iliteral_or, not_literal_or, wildcard or regexp
+	Tagk    string `json:"tagk"`
+	Filter  string `json:"filter"`
+	GroupBy bool   `json:"groupBy"`
+}
+
+// OpenTSDBSeries is a series in the response to an /api/query request
+type OpenTSDBSeries struct {
+	Metric        string             `json:"metric"`
+	Tags          map[string]string  `json:"tags"`          // the tags that all aggregated series have in common
+	AggregateTags []string           `json:"aggregateTags"` // the tags that differ between the aggregated series
+	Dps           map[string]float64 `json:"dps"`           // by timestamp
+}
+
+// OpenTSDBError is the body of the error responses of the opentsdb api
+type OpenTSDBError struct {
+	Error OpenTSDBErrorDetails `json:"error"`
+}
+
+type OpenTSDBErrorDetails struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
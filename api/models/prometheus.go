@@ -0,0 +1,40 @@
+package models
+
+// the time parameters of the prometheus query api are either unix timestamps in seconds, or RFC3339 timestamps.
+// steps are either a number of seconds, or a duration like 15s
+
+type PrometheusRangeQuery struct {
+	Query string `json:"query" form:"query" binding:"Required"`
+	Start string `json:"start" form:"start" binding:"Required"`
+	End   string `json:"end" form:"end" binding:"Required"`
+	Step  string `json:"step" form:"step" binding:"Required"`
+}
+
+type PrometheusQuery struct {
+	Query string `json:"query" form:"query" binding:"Required"`
+	Time  string `json:"time" form:"time"` // defaults to now
+}
+
+type PrometheusSeriesQuery struct {
+	Match []string `json:"match[]" form:"match[]" binding:"Required"`
+	Start string   `json:"start" form:"start"`
+	End   string   `json:"end" form:"end"`
+}
+
+type PrometheusLabelsQuery struct {
+	Start string `json:"start" form:"start"`
+	End   string `json:"end" form:"end"`
+}
+
+// PrometheusResponse is the envelope of all responses of the prometheus query api
+type PrometheusResponse struct {
+	Status    string      `json:"status"` // success or error
+	Data      interface{} `json:"data,omitempty"`
+	ErrorType string      `json:"errorType,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+type PrometheusQueryData struct {
+	ResultType string      `json:"resultType"` // matrix, vector or scalar
+	Result     interface{} `json:"result"`
+}
@@ -27,6 +27,7 @@ type Req struct {
 	Node     cluster.Node               `json:"-"`
 	SchemaId uint16                     `json:"schemaId"`
 	AggId    uint16                     `json:"aggId"`
+	PlanReq  int                        `json:"planReq"` // index of the request of the expr.Plan that this request is for. to tie the fetched series back to it
 
 	// these fields need some more coordination and are typically set later
 	Archive      int    `json:"archive"`      // 0 means original data, 1 means first agg level, 2 means 2nd, etc.
@@ -50,6 +51,7 @@ func NewReq(key, target, patt string, from, to, maxPoints, rawInterval uint32, c
 		node,
 		schemaId,
 		aggId,
+		0,  // set by the caller if the request is for an expr.Plan
 		-1, // this is supposed to be updated still!
 		0,  // this is supposed to be updated still
 		0,  // this is supposed to be updated still
@@ -82,6 +84,7 @@ func (r Req) Trace(span opentracing.Span) {
 	span.SetTag("consReq", r.ConsReq)
 	span.SetTag("schemaId", r.SchemaId)
 	span.SetTag("aggId", r.AggId)
+	span.SetTag("planReq", r.PlanReq)
 	span.SetTag("archive", r.Archive)
 	span.SetTag("archInterval", r.ArchInterval)
 	span.SetTag("TTL", r.TTL)
@@ -107,6 +110,7 @@ func (r Req) TraceLog(span opentracing.Span) {
 		log.String("consReq", r.ConsReq.String()),
 		log.Int("schemaId", int(r.SchemaId)),
 		log.Int("aggId", int(r.AggId)),
+		log.Int("planReq", r.PlanReq),
 		log.Int("archive", r.Archive),
 		log.Int("archInterval", int(r.ArchInterval)),
 		log.Int("TTL", int(r.TTL)),
@@ -19,7 +19,10 @@ type Series struct {
 	QueryFrom    uint32                     // to tie series back to request it came from
 	QueryTo      uint32                     // to tie series back to request it came from
 	QueryCons    consolidation.Consolidator // to tie series back to request it came from (may be 0 to mean use configured default)
+	PlanReq      int                        // for fetched data, the index of the plan request it came from, see Req.PlanReq
 	Consolidator consolidation.Consolidator // consolidator to actually use (for fetched series this may not be 0, default must be resolved. if series created by function, may be 0)
+	Tags         map[string]string          // for fetched data, the tags of the metric definition, including its name. may be nil for function output
+	XFilesFactor float64                    // minimum ratio of non-null points for a consolidated point to be non-null (for fetched series, set from storage-aggregation.conf)
 }
 
 type SeriesByTarget []Series
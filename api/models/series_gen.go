@@ -13,13 +13,13 @@ import (
 func (z *Series) DecodeMsg(dc *msgp.Reader) (err error) {
 	var field []byte
 	_ = field
-	var zbzg uint32
-	zbzg, err = dc.ReadMapHeader()
+	var zdbw uint32
+	zdbw, err = dc.ReadMapHeader()
 	if err != nil {
 		return
 	}
-	for zbzg > 0 {
-		zbzg--
+	for zdbw > 0 {
+		zdbw--
 		field, err = dc.ReadMapKeyPtr()
 		if err != nil {
 			return
@@ -31,18 +31,18 @@ func (z *Series) DecodeMsg(dc *msgp.Reader) (err error) {
 				return
 			}
 		case "Datapoints":
-			var zbai uint32
-			zbai, err = dc.ReadArrayHeader()
+			var zmup uint32
+			zmup, err = dc.ReadArrayHeader()
 			if err != nil {
 				return
 			}
-			if cap(z.Datapoints) >= int(zbai) {
-				z.Datapoints = (z.Datapoints)[:zbai]
+			if cap(z.Datapoints) >= int(zmup) {
+				z.Datapoints = (z.Datapoints)[:zmup]
 			} else {
-				z.Datapoints = make([]schema.Point, zbai)
+				z.Datapoints = make([]schema.Point, zmup)
 			}
-			for zxvk := range z.Datapoints {
-				err = z.Datapoints[zxvk].DecodeMsg(dc)
+			for zfzr := range z.Datapoints {
+				err = z.Datapoints[zfzr].DecodeMsg(dc)
 				if err != nil {
 					return
 				}
@@ -72,11 +72,48 @@ func (z *Series) DecodeMsg(dc *msgp.Reader) (err error) {
 			if err != nil {
 				return
 			}
+		case "PlanReq":
+			z.PlanReq, err = dc.ReadInt()
+			if err != nil {
+				return
+			}
 		case "Consolidator":
 			err = z.Consolidator.DecodeMsg(dc)
 			if err != nil {
 				return
 			}
+		case "Tags":
+			var zljt uint32
+			zljt, err = dc.ReadMapHeader()
+			if err != nil {
+				return
+			}
+			if z.Tags == nil && zljt > 0 {
+				z.Tags = make(map[string]string, zljt)
+			} else if len(z.Tags) > 0 {
+				for key := range z.Tags {
+					delete(z.Tags, key)
+				}
+			}
+			for zljt > 0 {
+				zljt--
+				var zguu string
+				var zeux string
+				zguu, err = dc.ReadString()
+				if err != nil {
+					return
+				}
+				zeux, err = dc.ReadString()
+				if err != nil {
+					return
+				}
+				z.Tags[zguu] = zeux
+			}
+		case "XFilesFactor":
+			z.XFilesFactor, err = dc.ReadFloat64()
+			if err != nil {
+				return
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -89,9 +126,9 @@ func (z *Series) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *Series) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 8
+	// map header, size 11
 	// write "Target"
-	err = en.Append(0x88, 0xa6, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74)
+	err = en.Append(0x8b, 0xa6, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74)
 	if err != nil {
 		return err
 	}
@@ -108,8 +145,8 @@ func (z *Series) EncodeMsg(en *msgp.Writer) (err error) {
 	if err != nil {
 		return
 	}
-	for zxvk := range z.Datapoints {
-		err = z.Datapoints[zxvk].EncodeMsg(en)
+	for zfzr := range z.Datapoints {
+		err = z.Datapoints[zfzr].EncodeMsg(en)
 		if err != nil {
 			return
 		}
@@ -159,6 +196,15 @@ func (z *Series) EncodeMsg(en *msgp.Writer) (err error) {
 	if err != nil {
 		return
 	}
+	// write "PlanReq"
+	err = en.Append(0xa7, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x65, 0x71)
+	if err != nil {
+		return err
+	}
+	err = en.WriteInt(z.PlanReq)
+	if err != nil {
+		return
+	}
 	// write "Consolidator"
 	err = en.Append(0xac, 0x43, 0x6f, 0x6e, 0x73, 0x6f, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72)
 	if err != nil {
@@ -168,21 +214,49 @@ func (z *Series) EncodeMsg(en *msgp.Writer) (err error) {
 	if err != nil {
 		return
 	}
+	// write "Tags"
+	err = en.Append(0xa4, 0x54, 0x61, 0x67, 0x73)
+	if err != nil {
+		return err
+	}
+	err = en.WriteMapHeader(uint32(len(z.Tags)))
+	if err != nil {
+		return
+	}
+	for zguu, zeux := range z.Tags {
+		err = en.WriteString(zguu)
+		if err != nil {
+			return
+		}
+		err = en.WriteString(zeux)
+		if err != nil {
+			return
+		}
+	}
+	// write "XFilesFactor"
+	err = en.Append(0xac, 0x58, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x46, 0x61, 0x63, 0x74, 0x6f, 0x72)
+	if err != nil {
+		return err
+	}
+	err = en.WriteFloat64(z.XFilesFactor)
+	if err != nil {
+		return
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *Series) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 8
+	// map header, size 11
 	// string "Target"
-	o = append(o, 0x88, 0xa6, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74)
+	o = append(o, 0x8b, 0xa6, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74)
 	o = msgp.AppendString(o, z.Target)
 	// string "Datapoints"
 	o = append(o, 0xaa, 0x44, 0x61, 0x74, 0x61, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73)
 	o = msgp.AppendArrayHeader(o, uint32(len(z.Datapoints)))
-	for zxvk := range z.Datapoints {
-		o, err = z.Datapoints[zxvk].MarshalMsg(o)
+	for zfzr := range z.Datapoints {
+		o, err = z.Datapoints[zfzr].MarshalMsg(o)
 		if err != nil {
 			return
 		}
@@ -205,12 +279,25 @@ func (z *Series) MarshalMsg(b []byte) (o []byte, err error) {
 	if err != nil {
 		return
 	}
+	// string "PlanReq"
+	o = append(o, 0xa7, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x65, 0x71)
+	o = msgp.AppendInt(o, z.PlanReq)
 	// string "Consolidator"
 	o = append(o, 0xac, 0x43, 0x6f, 0x6e, 0x73, 0x6f, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72)
 	o, err = z.Consolidator.MarshalMsg(o)
 	if err != nil {
 		return
 	}
+	// string "Tags"
+	o = append(o, 0xa4, 0x54, 0x61, 0x67, 0x73)
+	o = msgp.AppendMapHeader(o, uint32(len(z.Tags)))
+	for zguu, zeux := range z.Tags {
+		o = msgp.AppendString(o, zguu)
+		o = msgp.AppendString(o, zeux)
+	}
+	// string "XFilesFactor"
+	o = append(o, 0xac, 0x58, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x46, 0x61, 0x63, 0x74, 0x6f, 0x72)
+	o = msgp.AppendFloat64(o, z.XFilesFactor)
 	return
 }
 
@@ -218,13 +305,13 @@ func (z *Series) MarshalMsg(b []byte) (o []byte, err error) {
 func (z *Series) UnmarshalMsg(bts []byte) (o []byte, err error) {
 	var field []byte
 	_ = field
-	var zcmr uint32
-	zcmr, bts, err = msgp.ReadMapHeaderBytes(bts)
+	var zpta uint32
+	zpta, bts, err = msgp.ReadMapHeaderBytes(bts)
 	if err != nil {
 		return
 	}
-	for zcmr > 0 {
-		zcmr--
+	for zpta > 0 {
+		zpta--
 		field, bts, err = msgp.ReadMapKeyZC(bts)
 		if err != nil {
 			return
@@ -236,18 +323,18 @@ func (z *Series) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				return
 			}
 		case "Datapoints":
-			var zajw uint32
-			zajw, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			var zvvo uint32
+			zvvo, bts, err = msgp.ReadArrayHeaderBytes(bts)
 			if err != nil {
 				return
 			}
-			if cap(z.Datapoints) >= int(zajw) {
-				z.Datapoints = (z.Datapoints)[:zajw]
+			if cap(z.Datapoints) >= int(zvvo) {
+				z.Datapoints = (z.Datapoints)[:zvvo]
 			} else {
-				z.Datapoints = make([]schema.Point, zajw)
+				z.Datapoints = make([]schema.Point, zvvo)
 			}
-			for zxvk := range z.Datapoints {
-				bts, err = z.Datapoints[zxvk].UnmarshalMsg(bts)
+			for zfzr := range z.Datapoints {
+				bts, err = z.Datapoints[zfzr].UnmarshalMsg(bts)
 				if err != nil {
 					return
 				}
@@ -277,11 +364,48 @@ func (z *Series) UnmarshalMsg(bts []byte) (o []byte, err error) {
 			if err != nil {
 				return
 			}
+		case "PlanReq":
+			z.PlanReq, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				return
+			}
 		case "Consolidator":
 			bts, err = z.Consolidator.UnmarshalMsg(bts)
 			if err != nil {
 				return
 			}
+		case "Tags":
+			var zzyg uint32
+			zzyg, bts, err = msgp.ReadMapHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			if z.Tags == nil && zzyg > 0 {
+				z.Tags = make(map[string]string, zzyg)
+			} else if len(z.Tags) > 0 {
+				for key := range z.Tags {
+					delete(z.Tags, key)
+				}
+			}
+			for zzyg > 0 {
+				var zguu string
+				var zeux string
+				zzyg--
+				zguu, bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					return
+				}
+				zeux, bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Tags[zguu] = zeux
+			}
+		case "XFilesFactor":
+			z.XFilesFactor, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				return
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -296,27 +420,34 @@ func (z *Series) UnmarshalMsg(bts []byte) (o []byte, err error) {
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z *Series) Msgsize() (s int) {
 	s = 1 + 7 + msgp.StringPrefixSize + len(z.Target) + 11 + msgp.ArrayHeaderSize
-	for zxvk := range z.Datapoints {
-		s += z.Datapoints[zxvk].Msgsize()
+	for zfzr := range z.Datapoints {
+		s += z.Datapoints[zfzr].Msgsize()
+	}
+	s += 9 + msgp.Uint32Size + 10 + msgp.StringPrefixSize + len(z.QueryPatt) + 10 + msgp.Uint32Size + 8 + msgp.Uint32Size + 10 + z.QueryCons.Msgsize() + 8 + msgp.IntSize + 13 + z.Consolidator.Msgsize() + 5 + msgp.MapHeaderSize
+	if z.Tags != nil {
+		for zguu, zeux := range z.Tags {
+			_ = zeux
+			s += msgp.StringPrefixSize + len(zguu) + msgp.StringPrefixSize + len(zeux)
+		}
 	}
-	s += 9 + msgp.Uint32Size + 10 + msgp.StringPrefixSize + len(z.QueryPatt) + 10 + msgp.Uint32Size + 8 + msgp.Uint32Size + 10 + z.QueryCons.Msgsize() + 13 + z.Consolidator.Msgsize()
+	s += 13 + msgp.Float64Size
 	return
 }
 
 // DecodeMsg implements msgp.Decodable
 func (z *SeriesByTarget) DecodeMsg(dc *msgp.Reader) (err error) {
-	var zcua uint32
-	zcua, err = dc.ReadArrayHeader()
+	var zrny uint32
+	zrny, err = dc.ReadArrayHeader()
 	if err != nil {
 		return
 	}
-	if cap((*z)) >= int(zcua) {
-		(*z) = (*z)[:zcua]
+	if cap((*z)) >= int(zrny) {
+		(*z) = (*z)[:zrny]
 	} else {
-		(*z) = make(SeriesByTarget, zcua)
+		(*z) = make(SeriesByTarget, zrny)
 	}
-	for zhct := range *z {
-		err = (*z)[zhct].DecodeMsg(dc)
+	for zmlb := range *z {
+		err = (*z)[zmlb].DecodeMsg(dc)
 		if err != nil {
 			return
 		}
@@ -330,8 +461,8 @@ func (z SeriesByTarget) EncodeMsg(en *msgp.Writer) (err error) {
 	if err != nil {
 		return
 	}
-	for zxhx := range z {
-		err = z[zxhx].EncodeMsg(en)
+	for zynj := range z {
+		err = z[zynj].EncodeMsg(en)
 		if err != nil {
 			return
 		}
@@ -343,8 +474,8 @@ func (z SeriesByTarget) EncodeMsg(en *msgp.Writer) (err error) {
 func (z SeriesByTarget) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
 	o = msgp.AppendArrayHeader(o, uint32(len(z)))
-	for zxhx := range z {
-		o, err = z[zxhx].MarshalMsg(o)
+	for zynj := range z {
+		o, err = z[zynj].MarshalMsg(o)
 		if err != nil {
 			return
 		}
@@ -354,18 +485,18 @@ func (z SeriesByTarget) MarshalMsg(b []byte) (o []byte, err error) {
 
 // UnmarshalMsg implements msgp.Unmarshaler
 func (z *SeriesByTarget) UnmarshalMsg(bts []byte) (o []byte, err error) {
-	var zdaf uint32
-	zdaf, bts, err = msgp.ReadArrayHeaderBytes(bts)
+	var zrls uint32
+	zrls, bts, err = msgp.ReadArrayHeaderBytes(bts)
 	if err != nil {
 		return
 	}
-	if cap((*z)) >= int(zdaf) {
-		(*z) = (*z)[:zdaf]
+	if cap((*z)) >= int(zrls) {
+		(*z) = (*z)[:zrls]
 	} else {
-		(*z) = make(SeriesByTarget, zdaf)
+		(*z) = make(SeriesByTarget, zrls)
 	}
-	for zlqf := range *z {
-		bts, err = (*z)[zlqf].UnmarshalMsg(bts)
+	for zykp := range *z {
+		bts, err = (*z)[zykp].UnmarshalMsg(bts)
 		if err != nil {
 			return
 		}
@@ -377,8 +508,8 @@ func (z *SeriesByTarget) UnmarshalMsg(bts []byte) (o []byte, err error) {
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z SeriesByTarget) Msgsize() (s int) {
 	s = msgp.ArrayHeaderSize
-	for zpks := range z {
-		s += z[zpks].Msgsize()
+	for znqq := range z {
+		s += z[znqq].Msgsize()
 	}
 	return
 }
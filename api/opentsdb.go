@@ -0,0 +1,965 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/metrictank/api/middleware"
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/api/prompb"
+	"github.com/grafana/metrictank/api/response"
+	"github.com/grafana/metrictank/conf"
+	"github.com/grafana/metrictank/consolidation"
+	"github.com/grafana/metrictank/mdata"
+	"github.com/grafana/metrictank/stats"
+	"github.com/raintank/worldping-api/pkg/log"
+	"gopkg.in/raintank/schema.v1"
+)
+
+// metric input.opentsdb.metrics_decode_err is a count of times an opentsdb put request or one of its datapoints failed to decode
+var openTSDBMetricsDecodeErr = stats.NewCounter32("input.opentsdb.metrics_decode_err")
+
+// metric input.opentsdb.metrics_per_message is how many metrics per opentsdb put request were seen
+var openTSDBMetricsPerMessage = stats.NewMeter32("input.opentsdb.metrics_per_message", false)
+
+// opentsdb timestamps with more than 10 digits are in milliseconds
+const openTSDBMaxSeconds = 9999999999
+
+// openTSDBPut ingests the datapoints of an opentsdb /api/put request, which holds a single datapoint or an array of them.
+// we store the series under their metric name, with their tags as tags
+func (s *Server) openTSDBPut(ctx *middleware.Context) {
+	if s.OpenTSDBHandler == nil {
+		openTSDBError(ctx, response.NewError(http.StatusServiceUnavailable, "opentsdb input not initialized"))
+		return
+	}
+	var body io.Reader = ctx.Req.Request.Body
+	if ctx.Req.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			openTSDBError(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("failed to gunzip request body: %s", err)))
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+	buf, err := ioutil.ReadAll(body)
+	if err != nil {
+		openTSDBError(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("failed to read request body: %s", err)))
+		return
+	}
+	points, err := decodeOpenTSDBPoints(buf)
+	if err != nil {
+		openTSDBMetricsDecodeErr.Inc()
+		openTSDBError(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("failed to decode datapoints: %s", err)))
+		return
+	}
+
+	params := ctx.Req.URL.Query()
+	_, summary := params["summary"]
+	_, details := params["details"]
+	var result models.OpenTSDBPutSummary
+	for _, point := range points {
+		md, err := openTSDBMetricData(ctx.OrgId, point)
+		if err != nil {
+			log.Debug("HTTP openTSDBPut: %s", err)
+			openTSDBMetricsDecodeErr.Inc()
+			result.Failed++
+			if details {
+				result.Errors = append(result.Errors, models.OpenTSDBPutError{Datapoint: point, Error: err.Error()})
+			}
+			continue
+		}
+		s.OpenTSDBHandler.Process(md, ingestPartition(md))
+		result.Success++
+	}
+	openTSDBMetricsPerMessage.ValueUint32(uint32(result.Success))
+
+	// like opentsdb, we report failure if any of the datapoints failed, even though we stored the others
+	code := http.StatusNoContent
+	if result.Failed > 0 {
+		code = http.StatusBadRequest
+	}
+	if summary || details {
+		if code == http.StatusNoContent {
+			code = http.StatusOK
+		}
+		response.Write(ctx, response.NewJson(code, result, ""))
+		return
+	}
+	if result.Failed > 0 {
+		openTSDBError(ctx, response.NewError(code, fmt.Sprintf("%d of %d datapoints failed to be stored, use the details parameter to see why", result.Failed, len(points))))
+		return
+	}
+	ctx.Resp.WriteHeader(code)
+}
+
+func decodeOpenTSDBPoints(buf []byte) ([]models.OpenTSDBPoint, error) {
+	buf = bytes.TrimSpace(buf)
+	if len(buf) > 0 && buf[0] == '[' {
+		var points []models.OpenTSDBPoint
+		err := json.Unmarshal(buf, &points)
+		return points, err
+	}
+	var point models.OpenTSDBPoint
+	if err := json.Unmarshal(buf, &point); err != nil {
+		return nil, err
+	}
+	return []models.OpenTSDBPoint{point}, nil
+}
+
+// openTSDBMetricData returns the MetricData for the datapoint. like for prometheus, the tags are sorted,
+// so that the same tags always result in the same id
+func openTSDBMetricData(orgId int, point models.OpenTSDBPoint) (*schema.MetricData, error) {
+	if point.Metric == "" {
+		return nil, errors.New("datapoint without metric name")
+	}
+	value, err := openTSDBValue(point.Value)
+	if err != nil {
+		return nil, err
+	}
+	if point.Timestamp <= 0 {
+		return nil, fmt.Errorf("invalid timestamp %d", point.Timestamp)
+	}
+	tags := make([]string, 0, len(point.Tags))
+	for k, v := range point.Tags {
+		tags = append(tags, k+"="+v)
+	}
+	sort.Strings(tags)
+	_, s := mdata.MatchSchema(point.Metric, 0)
+	md := &schema.MetricData{
+		OrgId:    orgId,
+		Name:     point.Metric,
+		Metric:   point.Metric,
+		Interval: s.Retentions[0].SecondsPerPoint,
+		Value:    value,
+		Unit:     "unknown",
+		Time:     openTSDBTimestamp(point.Timestamp) / 1000,
+		Mtype:    "gauge",
+		Tags:     tags,
+	}
+	if err := md.Validate(); err != nil {
+		return nil, err
+	}
+	md.SetId()
+	return md, nil
+}
+
+// openTSDBValue returns the value of a datapoint, which opentsdb accepts both as a number and as a string
+func openTSDBValue(v interface{}) (float64, error) {
+	var value float64
+	switch v := v.(type) {
+	case float64:
+		value = v
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %q", v)
+		}
+		value = f
+	default:
+		return 0, fmt.Errorf("invalid value %v, must be a number", v)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("invalid value %v", value)
+	}
+	return value, nil
+}
+
+// openTSDBTimestamp returns the opentsdb timestamp, in seconds or milliseconds, in milliseconds
+func openTSDBTimestamp(ts int64) int64 {
+	if ts > openTSDBMaxSeconds {
+		return ts
+	}
+	return ts * 1000
+}
+
+// openTSDBQueryGet answers an /api/query request whose sub queries are given via the m parameter
+func (s *Server) openTSDBQueryGet(ctx *middleware.Context, request models.OpenTSDBQueryForm) {
+	queries := make([]models.OpenTSDBSubQuery, 0, len(request.M))
+	for _, m := range request.M {
+		q, err := parseOpenTSDBSubQuery(m)
+		if err != nil {
+			openTSDBError(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("invalid sub query %q: %s", m, err)))
+			return
+		}
+		queries = append(queries, q)
+	}
+	query := models.OpenTSDBQuery{
+		Queries: queries,
+	}
+	// start and end are optional, and ms is a flag without value
+	if request.Start != "" {
+		query.Start = request.Start
+	}
+	if request.End != "" {
+		query.End = request.End
+	}
+	_, query.MsResolution = ctx.Req.URL.Query()["ms"]
+	s.openTSDBQuery(ctx, query)
+}
+
+// openTSDBQueryPost answers an /api/query request with a json body
+func (s *Server) openTSDBQueryPost(ctx *middleware.Context) {
+	var query models.OpenTSDBQuery
+	if err := json.NewDecoder(ctx.Req.Request.Body).Decode(&query); err != nil {
+		openTSDBError(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("failed to decode query: %s", err)))
+		return
+	}
+	s.openTSDBQuery(ctx, query)
+}
+
+func (s *Server) openTSDBQuery(ctx *middleware.Context, query models.OpenTSDBQuery) {
+	now := time.Now()
+	if query.Start == nil {
+		openTSDBError(ctx, response.NewError(http.StatusBadRequest, "missing start time"))
+		return
+	}
+	start, err := parseOpenTSDBTime(query.Start, now)
+	if err != nil {
+		openTSDBError(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("invalid start: %s", err)))
+		return
+	}
+	end := now.UnixNano() / int64(time.Millisecond)
+	if query.End != nil {
+		end, err = parseOpenTSDBTime(query.End, now)
+		if err != nil {
+			openTSDBError(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("invalid end: %s", err)))
+			return
+		}
+	}
+	if end <= start {
+		openTSDBError(ctx, response.NewError(http.StatusBadRequest, "start time must be before end time"))
+		return
+	}
+	if len(query.Queries) == 0 {
+		openTSDBError(ctx, response.NewError(http.StatusBadRequest, "missing sub queries"))
+		return
+	}
+
+	targets := make([]string, 0, len(query.Queries))
+	for _, q := range query.Queries {
+		targets = append(targets, q.Metric)
+	}
+	newctx, done := s.queries.add(ctx.Req.Context(), "opentsdb", ctx.OrgId, targets)
+	defer done()
+	out := make([]models.OpenTSDBSeries, 0)
+	for _, q := range query.Queries {
+		series, err := s.openTSDBSubQuery(newctx, ctx.OrgId, q, uint32(start/1000), uint32(end/1000)+1, query.MsResolution)
+		if err != nil {
+			if newctx.Err() == context.Canceled {
+				err = errQueryCanceled
+			}
+			openTSDBError(ctx, err)
+			return
+		}
+		out = append(out, series...)
+	}
+	response.Write(ctx, response.NewJson(200, out, ""))
+}
+
+// openTSDBSubQuery returns the result of a sub query over the range from (inclusive) - to (exclusive):
+// the series matching its metric and filters are fetched, downsampled and turned into rates if requested,
+// and then aggregated per combination of the values of the tags the query groups by
+func (s *Server) openTSDBSubQuery(ctx context.Context, orgId int, q models.OpenTSDBSubQuery, from, to uint32, ms bool) ([]models.OpenTSDBSeries, error) {
+	aggregator, ok := openTSDBAggregators[q.Aggregator]
+	if !ok {
+		return nil, response.NewError(http.StatusBadRequest, fmt.Sprintf("unknown aggregator %q", q.Aggregator))
+	}
+	var interval uint32
+	var consolidator consolidation.Consolidator
+	if q.Downsample != "" {
+		var err error
+		interval, consolidator, err = parseOpenTSDBDownsample(q.Downsample)
+		if err != nil {
+			return nil, response.NewError(http.StatusBadRequest, fmt.Sprintf("invalid downsample %q: %s", q.Downsample, err))
+		}
+	}
+	matchers, groupBy, err := openTSDBMatchers(q)
+	if err != nil {
+		return nil, response.NewError(http.StatusBadRequest, err.Error())
+	}
+	pms, err := newPromMatchers(matchers)
+	if err != nil {
+		return nil, response.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	series, err := s.promFind(ctx, orgId, pms, from)
+	if err != nil {
+		return nil, err
+	}
+	if len(series) == 0 {
+		return nil, nil
+	}
+
+	if interval > 0 && from > 0 {
+		// start at the beginning of the bucket that from falls in
+		from = mdata.AggBoundary(from, interval) - interval + 1
+	}
+	now := uint32(time.Now().Unix())
+	reqs := make([]models.Req, 0, len(series))
+	seriesByTarget := make(map[string]promSerie, len(series))
+	for _, serie := range series {
+		seriesByTarget[serie.target] = serie
+		def := serie.def
+		fn := consolidation.Consolidator(mdata.Aggregations.Get(def.AggId).AggregationMethod[0])
+		if interval > 0 {
+			fn = consolidator
+		}
+		reqs = append(reqs, models.NewReq(def.Id, serie.target, serie.pattern, from, to, 0, uint32(def.Interval), fn, 0, serie.node, def.SchemaId, def.AggId))
+	}
+	if interval > 0 {
+		if maxPointsPerReqHard > 0 && uint64((to-from)/interval+1)*uint64(len(reqs)) > uint64(maxPointsPerReqHard) {
+			return nil, errMaxPointsPerReq
+		}
+		for i := range reqs {
+			if err := alignDownsampleRequest(now, &reqs[i], interval); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		// without downsampling, we still need the series at the same interval, to aggregate them
+		reqs, _, _, err = alignRequests(now, reqs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := s.getTargets(ctx, reqs)
+	if err != nil {
+		return nil, err
+	}
+	out = mergeSeries(out)
+	sort.Sort(models.SeriesByTarget(out))
+
+	groups := make(map[string]*openTSDBGroup)
+	var keys []string
+	for _, serie := range out {
+		points := serie.Datapoints
+		if interval > 0 {
+			if serie.Interval != interval {
+				points = openTSDBDownsample(points, interval, consolidator)
+			}
+			// our buckets are labeled with their end, like our rollups, but opentsdb labels them with their start
+			shifted := make([]schema.Point, 0, len(points))
+			for _, p := range points {
+				shifted = append(shifted, schema.Point{Val: p.Val, Ts: p.Ts - interval})
+			}
+			points = shifted
+		}
+		if q.Rate {
+			points = openTSDBRate(points, q.RateOptions)
+		}
+
+		labels := seriesByTarget[serie.Target].labels
+		key := serie.Target
+		if aggregator != nil {
+			values := make([]string, 0, len(groupBy))
+			for _, tagk := range groupBy {
+				for _, l := range labels {
+					if l.Name == tagk {
+						values = append(values, l.Value)
+					}
+				}
+			}
+			key = strings.Join(values, ";")
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &openTSDBGroup{}
+			groups[key] = group
+			keys = append(keys, key)
+		}
+		group.labels = append(group.labels, labels)
+		group.series = append(group.series, points)
+	}
+
+	sort.Strings(keys)
+	result := make([]models.OpenTSDBSeries, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, groups[key].aggregate(q.Metric, aggregator, ms))
+	}
+	return result, nil
+}
+
+// openTSDBGroup is a group of series that get aggregated into a single series
+type openTSDBGroup struct {
+	labels [][]prompb.Label
+	series [][]schema.Point
+}
+
+// aggregate aggregates the points of the series of the group that have the same timestamp. we don't interpolate,
+// as the series we fetch all have the same interval. the tags of the result are those that all series have in common
+func (g *openTSDBGroup) aggregate(metric string, aggregator func([]float64) float64, ms bool) models.OpenTSDBSeries {
+	out := models.OpenTSDBSeries{
+		Metric:        metric,
+		Tags:          make(map[string]string),
+		AggregateTags: make([]string, 0),
+		Dps:           make(map[string]float64),
+	}
+
+	values := make(map[string][]string)
+	for _, labels := range g.labels {
+		for _, l := range labels {
+			if l.Name != promNameLabel {
+				values[l.Name] = append(values[l.Name], l.Value)
+			}
+		}
+	}
+	for tagk, vals := range values {
+		common := len(vals) == len(g.labels)
+		for _, v := range vals {
+			if v != vals[0] {
+				common = false
+			}
+		}
+		if common {
+			out.Tags[tagk] = vals[0]
+		} else {
+			out.AggregateTags = append(out.AggregateTags, tagk)
+		}
+	}
+	sort.Strings(out.AggregateTags)
+
+	byTs := make(map[uint32][]float64)
+	for _, points := range g.series {
+		for _, p := range points {
+			if !math.IsNaN(p.Val) {
+				byTs[p.Ts] = append(byTs[p.Ts], p.Val)
+			}
+		}
+	}
+	for ts, vals := range byTs {
+		value := vals[0]
+		if aggregator != nil {
+			value = aggregator(vals)
+		}
+		key := strconv.FormatUint(uint64(ts), 10)
+		if ms {
+			key = strconv.FormatUint(uint64(ts)*1000, 10)
+		}
+		out.Dps[key] = value
+	}
+	return out
+}
+
+// openTSDBAggregators are the supported aggregators of series. as we don't interpolate,
+// the variants that differ in how they interpolate are the same as their plain versions.
+// none doesn't aggregate at all
+var openTSDBAggregators = map[string]func([]float64) float64{
+	"sum":    openTSDBSum,
+	"zimsum": openTSDBSum,
+	"avg": func(vals []float64) float64 {
+		return openTSDBSum(vals) / float64(len(vals))
+	},
+	"min":    openTSDBMin,
+	"mimmin": openTSDBMin,
+	"max":    openTSDBMax,
+	"mimmax": openTSDBMax,
+	"count": func(vals []float64) float64 {
+		return float64(len(vals))
+	},
+	"dev": func(vals []float64) float64 {
+		var mean, sum float64
+		for i, v := range vals {
+			delta := v - mean
+			mean += delta / float64(i+1)
+			sum += delta * (v - mean)
+		}
+		return math.Sqrt(sum / float64(len(vals)))
+	},
+	"none": nil,
+}
+
+func openTSDBSum(vals []float64) float64 {
+	var sum float64
+	for _, v := range vals {
+		sum += v
+	}
+	return sum
+}
+
+func openTSDBMin(vals []float64) float64 {
+	min := vals[0]
+	for _, v := range vals {
+		min = math.Min(min, v)
+	}
+	return min
+}
+
+func openTSDBMax(vals []float64) float64 {
+	max := vals[0]
+	for _, v := range vals {
+		max = math.Max(max, v)
+	}
+	return max
+}
+
+// openTSDBDownsamplers maps the supported downsample functions onto our consolidators
+var openTSDBDownsamplers = map[string]consolidation.Consolidator{
+	"avg":    consolidation.Avg,
+	"sum":    consolidation.Sum,
+	"zimsum": consolidation.Sum,
+	"min":    consolidation.Min,
+	"mimmin": consolidation.Min,
+	"max":    consolidation.Max,
+	"mimmax": consolidation.Max,
+	"count":  consolidation.Cnt,
+	"last":   consolidation.Lst,
+}
+
+// parseOpenTSDBDownsample parses a downsample specification like 1h-avg, and returns its interval in seconds and its consolidator.
+// we only support the none fill policy, i.e. buckets without data are left out
+func parseOpenTSDBDownsample(s string) (uint32, consolidation.Consolidator, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, 0, errors.New("expected interval-function[-fill policy]")
+	}
+	d, err := parseOpenTSDBDuration(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	if d < time.Second || d%time.Second != 0 {
+		return 0, 0, errors.New("interval must be a whole number of seconds")
+	}
+	consolidator, ok := openTSDBDownsamplers[parts[1]]
+	if !ok {
+		return 0, 0, fmt.Errorf("unsupported function %q", parts[1])
+	}
+	if len(parts) == 3 && parts[2] != "none" {
+		return 0, 0, fmt.Errorf("unsupported fill policy %q", parts[2])
+	}
+	return uint32(d / time.Second), consolidator, nil
+}
+
+// alignDownsampleRequest sets up req to return one point per interval, consolidated with its consolidator.
+// we pick the lowest resolution archive that retains the data since req.From, has the rollup we need, and whose
+// interval divides the downsample interval, so that runtime consolidation of its points lines up with the buckets.
+// if there is none, we read the raw data, and leave the downsampling to openTSDBDownsample
+func alignDownsampleRequest(now uint32, req *models.Req, interval uint32) error {
+	retentions := mdata.Schemas.Get(req.SchemaId).Retentions
+	methods := mdata.Aggregations.Get(req.AggId).AggregationMethod
+	minTTL := now - req.From
+	for i := len(retentions) - 1; i >= 0; i-- {
+		ret := retentions[i]
+		archInterval := uint32(ret.SecondsPerPoint)
+		if i == 0 {
+			archInterval = req.RawInterval
+		}
+		if !ret.Ready || uint32(ret.MaxRetention()) < minTTL || archInterval == 0 || interval%archInterval != 0 {
+			continue
+		}
+		if i > 0 && !rollupAvailable(methods, req.Consolidator, archInterval == interval) {
+			continue
+		}
+		req.Archive = i
+		req.ArchInterval = archInterval
+		req.TTL = uint32(ret.MaxRetention())
+		req.OutInterval = interval
+		req.AggNum = interval / archInterval
+		return nil
+	}
+	if !retentions[0].Ready {
+		return errUnSatisfiable
+	}
+	req.Archive = 0
+	req.ArchInterval = req.RawInterval
+	req.TTL = uint32(retentions[0].MaxRetention())
+	req.OutInterval = req.RawInterval
+	req.AggNum = 1
+	return nil
+}
+
+// rollupAvailable returns whether the rollups stored for the aggregation methods can be consolidated with the consolidator.
+// counts are stored along with the sums for avg, but they can only be read as is: consolidating them further
+// at runtime would count the rollup points, rather than sum their counts
+func rollupAvailable(methods []conf.Method, consolidator consolidation.Consolidator, exact bool) bool {
+	for _, m := range methods {
+		switch {
+		case consolidation.Consolidator(m) == consolidator:
+			return true
+		case m == conf.Avg && consolidator == consolidation.Sum:
+			return true
+		case m == conf.Avg && consolidator == consolidation.Cnt && exact:
+			return true
+		}
+	}
+	return false
+}
+
+// openTSDBDownsample consolidates the points into buckets of interval, for when we couldn't fetch them at that interval.
+// like for our rollups, a bucket holds the points after the previous multiple of interval, up to and including the next one
+func openTSDBDownsample(points []schema.Point, interval uint32, consolidator consolidation.Consolidator) []schema.Point {
+	aggFunc := consolidation.GetAggFunc(consolidator)
+	var out []schema.Point
+	for i := 0; i < len(points); {
+		boundary := mdata.AggBoundary(points[i].Ts, interval)
+		j := i
+		for j < len(points) && points[j].Ts <= boundary {
+			j++
+		}
+		out = append(out, schema.Point{Val: aggFunc(points[i:j]), Ts: boundary})
+		i = j
+	}
+	return out
+}
+
+// openTSDBRate returns the per-second rate of change between the points. for counters, a decrease is a reset,
+// after which the counter either wrapped around at CounterMax, or the point is dropped
+func openTSDBRate(points []schema.Point, opts models.OpenTSDBRateOptions) []schema.Point {
+	counterMax := opts.CounterMax
+	if counterMax == 0 {
+		counterMax = math.MaxInt64
+	}
+	out := make([]schema.Point, 0, len(points))
+	var prev schema.Point
+	var seen bool
+	for _, p := range points {
+		if math.IsNaN(p.Val) {
+			continue
+		}
+		if !seen {
+			prev, seen = p, true
+			continue
+		}
+		delta := p.Val - prev.Val
+		if opts.Counter && delta < 0 {
+			if opts.DropResets {
+				prev = p
+				continue
+			}
+			delta = counterMax - prev.Val + p.Val
+		}
+		rate := delta / float64(p.Ts-prev.Ts)
+		if opts.Counter && opts.ResetValue > 0 && rate > opts.ResetValue {
+			rate = 0
+		}
+		out = append(out, schema.Point{Val: rate, Ts: p.Ts})
+		prev = p
+	}
+	return out
+}
+
+// openTSDBMatchers translates the metric and filters of the sub query into label matchers,
+// and returns the tags it groups by. like in opentsdb, a series only passes a filter if it has the tag
+func openTSDBMatchers(q models.OpenTSDBSubQuery) ([]prompb.LabelMatcher, []string, error) {
+	if q.Metric == "" {
+		return nil, nil, errors.New("missing metric")
+	}
+	filters := q.Filters
+	// the tags are the notation of filters before opentsdb 2.2, in which all filters group
+	tagks := make([]string, 0, len(q.Tags))
+	for tagk := range q.Tags {
+		tagks = append(tagks, tagk)
+	}
+	sort.Strings(tagks)
+	for _, tagk := range tagks {
+		filters = append(filters, parseOpenTSDBFilter(tagk, q.Tags[tagk], true))
+	}
+
+	matchers := []prompb.LabelMatcher{{Type: prompb.LabelMatcherEQ, Name: promNameLabel, Value: q.Metric}}
+	var groupBy []string
+	for _, f := range filters {
+		if f.Tagk == "" {
+			return nil, nil, errors.New("filter without tagk")
+		}
+		m, err := openTSDBMatcher(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		matchers = append(matchers, m)
+		if m.Type == prompb.LabelMatcherNRE || f.Type == "regexp" {
+			matchers = append(matchers, prompb.LabelMatcher{Type: prompb.LabelMatcherRE, Name: f.Tagk, Value: ".+"})
+		}
+		if f.GroupBy && !contains(groupBy, f.Tagk) {
+			groupBy = append(groupBy, f.Tagk)
+		}
+	}
+	return matchers, groupBy, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// openTSDBMatcher translates an opentsdb filter into a label matcher
+func openTSDBMatcher(f models.OpenTSDBFilter) (prompb.LabelMatcher, error) {
+	m := prompb.LabelMatcher{Type: prompb.LabelMatcherRE, Name: f.Tagk}
+	switch f.Type {
+	case "literal_or", "iliteral_or", "not_literal_or":
+		values := strings.Split(f.Filter, "|")
+		for i := range values {
+			values[i] = regexp.QuoteMeta(values[i])
+		}
+		m.Value = strings.Join(values, "|")
+		if f.Type == "iliteral_or" {
+			m.Value = "(?i)" + m.Value
+		}
+		if f.Type == "not_literal_or" {
+			m.Type = prompb.LabelMatcherNRE
+		}
+	case "wildcard", "iwildcard":
+		if f.Filter == "*" {
+			m.Value = ".+"
+		} else {
+			parts := strings.Split(f.Filter, "*")
+			for i := range parts {
+				parts[i] = regexp.QuoteMeta(parts[i])
+			}
+			m.Value = strings.Join(parts, ".*")
+		}
+		if f.Type == "iwildcard" {
+			m.Value = "(?i)" + m.Value
+		}
+	case "regexp":
+		// opentsdb regexes aren't anchored
+		m.Value = ".*(?:" + f.Filter + ").*"
+	default:
+		return m, fmt.Errorf("unsupported filter type %q for tag %s", f.Type, f.Tagk)
+	}
+	return m, nil
+}
+
+var openTSDBFilterTypes = []string{"literal_or", "iliteral_or", "not_literal_or", "wildcard", "iwildcard", "regexp"}
+
+// parseOpenTSDBFilter parses a filter in the tags notation of sub queries: either a function like regexp(web.*),
+// or a value, which is a wildcard if it contains a *, and a literal_or like web01|web02 otherwise
+func parseOpenTSDBFilter(tagk, value string, groupBy bool) models.OpenTSDBFilter {
+	f := models.OpenTSDBFilter{Type: "literal_or", Tagk: tagk, Filter: value, GroupBy: groupBy}
+	if i := strings.Index(value, "("); i > 0 && strings.HasSuffix(value, ")") && contains(openTSDBFilterTypes, value[:i]) {
+		f.Type = value[:i]
+		f.Filter = value[i+1 : len(value)-1]
+	} else if strings.Contains(value, "*") {
+		f.Type = "wildcard"
+	}
+	return f
+}
+
+var openTSDBDownsampleRe = regexp.MustCompile(`^[0-9]+[a-z]+-[a-z]+(-[a-z]+)?$`)
+
+// parseOpenTSDBSubQuery parses a sub query in the notation of the m parameter:
+// aggregator:[downsample:][rate[{counter[,counterMax[,resetValue]]}]:]metric[{group by filters}[{other filters}]]
+func parseOpenTSDBSubQuery(m string) (models.OpenTSDBSubQuery, error) {
+	var q models.OpenTSDBSubQuery
+	parts := strings.SplitN(m, ":", 2)
+	if len(parts) != 2 {
+		return q, errors.New("expected aggregator:[downsample:][rate:]metric")
+	}
+	q.Aggregator, m = parts[0], parts[1]
+
+options:
+	for {
+		i := strings.Index(m, ":")
+		if i == -1 {
+			break
+		}
+		option := m[:i]
+		switch {
+		case option == "rate":
+			q.Rate = true
+		case strings.HasPrefix(option, "rate{") && strings.HasSuffix(option, "}"):
+			q.Rate = true
+			opts, err := parseOpenTSDBRateOptions(option[len("rate{") : len(option)-1])
+			if err != nil {
+				return q, err
+			}
+			q.RateOptions = opts
+		case openTSDBDownsampleRe.MatchString(option):
+			q.Downsample = option
+		default:
+			// a colon in the metric or its filters
+			break options
+		}
+		m = m[i+1:]
+	}
+
+	i := strings.Index(m, "{")
+	if i == -1 {
+		q.Metric = m
+		return q, nil
+	}
+	q.Metric = m[:i]
+	m = m[i:]
+	for groupBy := true; m != ""; groupBy = false {
+		inner, rest, err := openTSDBBraces(m)
+		if err != nil {
+			return q, err
+		}
+		for _, filter := range splitOutsideParens(inner, ',') {
+			if filter == "" {
+				continue
+			}
+			kv := strings.SplitN(filter, "=", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				return q, fmt.Errorf("invalid filter %q, expected tagk=filter", filter)
+			}
+			q.Filters = append(q.Filters, parseOpenTSDBFilter(kv[0], kv[1], groupBy))
+		}
+		if !groupBy && rest != "" {
+			return q, errors.New("too many filter groups")
+		}
+		m = rest
+	}
+	return q, nil
+}
+
+// openTSDBBraces returns what is between the braces s starts with, and what follows them
+func openTSDBBraces(s string) (string, string, error) {
+	if s[0] != '{' {
+		return "", "", fmt.Errorf("expected { at %q", s)
+	}
+	var depth int
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '}':
+			if depth == 0 {
+				return s[1:i], s[i+1:], nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("missing } in %q", s)
+}
+
+// splitOutsideParens splits s on sep, except where it is within parentheses
+func splitOutsideParens(s string, sep byte) []string {
+	var parts []string
+	var depth, start int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseOpenTSDBRateOptions parses the rate options of the m parameter, like counter,,1000
+func parseOpenTSDBRateOptions(s string) (models.OpenTSDBRateOptions, error) {
+	var opts models.OpenTSDBRateOptions
+	parts := strings.Split(s, ",")
+	if len(parts) > 3 {
+		return opts, fmt.Errorf("invalid rate options %q", s)
+	}
+	switch parts[0] {
+	case "counter":
+		opts.Counter = true
+	case "":
+	default:
+		return opts, fmt.Errorf("invalid rate options %q", s)
+	}
+	for i, dest := range []*float64{&opts.CounterMax, &opts.ResetValue} {
+		if len(parts) <= i+1 || parts[i+1] == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(parts[i+1], 64)
+		if err != nil {
+			return opts, fmt.Errorf("invalid rate options %q", s)
+		}
+		*dest = f
+	}
+	return opts, nil
+}
+
+// openTSDBUnits are the units of opentsdb durations. months and years are 30 and 365 days
+var openTSDBUnits = map[string]time.Duration{
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+	"n":  30 * 24 * time.Hour,
+	"y":  365 * 24 * time.Hour,
+}
+
+// parseOpenTSDBDuration parses a duration like 5m
+func parseOpenTSDBDuration(s string) (time.Duration, error) {
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	n, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	unit, ok := openTSDBUnits[s[i:]]
+	if !ok {
+		return 0, fmt.Errorf("invalid unit in duration %q", s)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// the absolute time formats of opentsdb, which are interpreted in the local timezone
+var openTSDBTimeLayouts = []string{
+	"2006/01/02-15:04:05",
+	"2006/01/02 15:04:05",
+	"2006/01/02-15:04",
+	"2006/01/02 15:04",
+	"2006/01/02",
+}
+
+// parseOpenTSDBTime parses a time of the opentsdb api into milliseconds. it is either relative, like 1h-ago,
+// absolute, like 2017/12/31-23:59:00, or a unix timestamp in seconds or milliseconds, as a string or a number
+func parseOpenTSDBTime(v interface{}, now time.Time) (int64, error) {
+	switch v := v.(type) {
+	case float64:
+		return openTSDBTimestamp(int64(v)), nil
+	case string:
+		if v == "now" {
+			return now.UnixNano() / int64(time.Millisecond), nil
+		}
+		if strings.HasSuffix(v, "-ago") {
+			d, err := parseOpenTSDBDuration(strings.TrimSuffix(v, "-ago"))
+			if err != nil {
+				return 0, err
+			}
+			return now.Add(-d).UnixNano() / int64(time.Millisecond), nil
+		}
+		if ts, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return openTSDBTimestamp(ts), nil
+		}
+		for _, layout := range openTSDBTimeLayouts {
+			if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+				return t.UnixNano() / int64(time.Millisecond), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("cannot parse %v to a valid time", v)
+}
+
+// openTSDBError responds with the error, in the format of the opentsdb api
+func openTSDBError(ctx *middleware.Context, err error) {
+	code := http.StatusInternalServerError
+	if e, ok := err.(response.Error); ok {
+		code = e.Code()
+	}
+	response.Write(ctx, response.NewJson(code, models.OpenTSDBError{Error: models.OpenTSDBErrorDetails{Code: code, Message: err.Error()}}, ""))
+}
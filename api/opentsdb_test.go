@@ -0,0 +1,359 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/grafana/metrictank/api/middleware"
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/api/prompb"
+	"github.com/grafana/metrictank/cluster"
+	"github.com/grafana/metrictank/conf"
+	"github.com/grafana/metrictank/consolidation"
+	"github.com/grafana/metrictank/mdata"
+	"gopkg.in/macaron.v1"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestOpenTSDBPut(t *testing.T) {
+	cluster.Init("default", "test", time.Now(), "http", 6060)
+	mdata.SetSingleSchema(conf.NewRetentionMT(10, 3600, 600, 2, true))
+	handler := &mockHandler{}
+	s := &Server{OpenTSDBHandler: handler}
+	m := macaron.New()
+	m.Use(macaron.Renderer())
+	m.Post("/api/put", func(c *macaron.Context) {
+		s.openTSDBPut(&middleware.Context{Context: c, OrgId: 5})
+	})
+
+	single := `{"metric": "sys.cpu.nice", "timestamp": 1346846400, "value": 18, "tags": {"host": "web01", "dc": "lga"}}`
+	req, _ := http.NewRequest("POST", "/api/put", bytes.NewBufferString(single))
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+	if resp.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d: %s", resp.Code, resp.Body.String())
+	}
+	if len(handler.metrics) != 1 {
+		t.Fatalf("expected 1 metric, got %d", len(handler.metrics))
+	}
+	md := handler.metrics[0]
+	if md.Name != "sys.cpu.nice" || md.OrgId != 5 || md.Time != 1346846400 || md.Value != 18 || md.Interval != 10 {
+		t.Fatalf("unexpected metric data %v", md)
+	}
+	if !reflect.DeepEqual(md.Tags, []string{"dc=lga", "host=web01"}) {
+		t.Fatalf("unexpected tags %v", md.Tags)
+	}
+
+	// a batch, gzipped, with a timestamp in ms, a value as string, and an invalid point
+	batch := `[
+		{"metric": "sys.cpu.nice", "timestamp": 1346846410500, "value": "19.5", "tags": {"dc": "lga", "host": "web01"}},
+		{"metric": "sys.cpu.nice", "timestamp": 1346846420, "value": "foo", "tags": {"host": "web01"}}
+	]`
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(batch))
+	gz.Close()
+	req, _ = http.NewRequest("POST", "/api/put?details", &buf)
+	req.Header.Set("Content-Encoding", "gzip")
+	resp = httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var summary models.OpenTSDBPutSummary
+	if err := json.Unmarshal(resp.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	if summary.Success != 1 || summary.Failed != 1 || len(summary.Errors) != 1 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	if len(handler.metrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %d", len(handler.metrics))
+	}
+	md2 := handler.metrics[1]
+	if md2.Time != 1346846410 || md2.Value != 19.5 || md2.Id != md.Id {
+		t.Fatalf("unexpected metric data %v, expected id %s", md2, md.Id)
+	}
+
+	req, _ = http.NewRequest("POST", "/api/put", bytes.NewBufferString("{"))
+	resp = httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for a bad body, got %d", resp.Code)
+	}
+}
+
+func TestParseOpenTSDBSubQuery(t *testing.T) {
+	cases := []struct {
+		in     string
+		exp    models.OpenTSDBSubQuery
+		expErr bool
+	}{
+		{
+			in:  "sum:sys.cpu.user",
+			exp: models.OpenTSDBSubQuery{Aggregator: "sum", Metric: "sys.cpu.user"},
+		},
+		{
+			in: "avg:1h-avg:rate{counter,,1000}:sys.cpu.user{host=web01|web02,dc=*}{env=regexp(prod-(eu|us))}",
+			exp: models.OpenTSDBSubQuery{
+				Aggregator:  "avg",
+				Metric:      "sys.cpu.user",
+				Rate:        true,
+				RateOptions: models.OpenTSDBRateOptions{Counter: true, ResetValue: 1000},
+				Downsample:  "1h-avg",
+				Filters: []models.OpenTSDBFilter{
+					{Type: "literal_or", Tagk: "host", Filter: "web01|web02", GroupBy: true},
+					{Type: "wildcard", Tagk: "dc", Filter: "*", GroupBy: true},
+					{Type: "regexp", Tagk: "env", Filter: "prod-(eu|us)", GroupBy: false},
+				},
+			},
+		},
+		{
+			in: "max:rate:sys.if.bytes{}{iface=not_literal_or(lo)}",
+			exp: models.OpenTSDBSubQuery{
+				Aggregator: "max",
+				Metric:     "sys.if.bytes",
+				Rate:       true,
+				Filters: []models.OpenTSDBFilter{
+					{Type: "not_literal_or", Tagk: "iface", Filter: "lo", GroupBy: false},
+				},
+			},
+		},
+		{in: "sys.cpu.user", expErr: true},
+		{in: "sum:sys.cpu.user{host}", expErr: true},
+		{in: "sum:sys.cpu.user{host=a}{dc=b}{env=c}", expErr: true},
+		{in: "sum:sys.cpu.user{host=a", expErr: true},
+		{in: "sum:rate{gauge}:sys.cpu.user", expErr: true},
+	}
+	for _, c := range cases {
+		q, err := parseOpenTSDBSubQuery(c.in)
+		if c.expErr {
+			if err == nil {
+				t.Errorf("%q: expected error, got %+v", c.in, q)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %s", c.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(q, c.exp) {
+			t.Errorf("%q:\nexpected %+v\n     got %+v", c.in, c.exp, q)
+		}
+	}
+}
+
+func TestOpenTSDBMatchers(t *testing.T) {
+	q := models.OpenTSDBSubQuery{
+		Metric: "sys.cpu.user",
+		Tags:   map[string]string{"host": "web*"},
+		Filters: []models.OpenTSDBFilter{
+			{Type: "iliteral_or", Tagk: "dc", Filter: "LGA|sjc.1"},
+			{Type: "not_literal_or", Tagk: "env", Filter: "dev"},
+			{Type: "regexp", Tagk: "rack", Filter: "^r[0-9]", GroupBy: true},
+		},
+	}
+	matchers, groupBy, err := openTSDBMatchers(q)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if !reflect.DeepEqual(groupBy, []string{"rack", "host"}) {
+		t.Fatalf("unexpected group by %v", groupBy)
+	}
+	pms, err := newPromMatchers(matchers)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	cases := []struct {
+		tags []string
+		exp  bool
+	}{
+		{[]string{"host=web01", "dc=lga", "env=prod", "rack=r1a"}, true},
+		{[]string{"host=web01", "dc=SJC.1", "env=prod", "rack=r2"}, true},
+		{[]string{"host=db01", "dc=lga", "env=prod", "rack=r1"}, false},    // wildcards are anchored
+		{[]string{"host=web01", "dc=sjcx1", "env=prod", "rack=r1"}, false}, // literals are quoted
+		{[]string{"host=web01", "dc=lga", "env=dev", "rack=r1"}, false},
+		{[]string{"host=web01", "dc=lga", "rack=r1"}, false}, // series need the tags of all filters
+		{[]string{"host=web01", "dc=lga", "env=prod", "rack=xr1"}, false},
+	}
+	for _, c := range cases {
+		if got := promMatch(pms, promLabels("sys.cpu.user", c.tags)); got != c.exp {
+			t.Errorf("%v: expected match %t, got %t", c.tags, c.exp, got)
+		}
+	}
+	if promMatch(pms, promLabels("sys.cpu.nice", cases[0].tags)) {
+		t.Errorf("expected no match for another metric")
+	}
+}
+
+func TestParseOpenTSDBTime(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	cases := []struct {
+		in     interface{}
+		exp    int64
+		expErr bool
+	}{
+		{in: "now", exp: 1500000000000},
+		{in: "1h-ago", exp: 1499996400000},
+		{in: "2w-ago", exp: 1498790400000},
+		{in: "1499990000", exp: 1499990000000},
+		{in: "1499990000123", exp: 1499990000123},
+		{in: float64(1499990000), exp: 1499990000000},
+		{in: time.Unix(1499990000, 0).Local().Format("2006/01/02-15:04:05"), exp: 1499990000000},
+		{in: "1x-ago", expErr: true},
+		{in: "yesterday", expErr: true},
+		{in: true, expErr: true},
+	}
+	for _, c := range cases {
+		got, err := parseOpenTSDBTime(c.in, now)
+		if c.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error, got %d", c.in, got)
+			}
+			continue
+		}
+		if err != nil || got != c.exp {
+			t.Errorf("%v: expected %d, got %d (err: %v)", c.in, c.exp, got, err)
+		}
+	}
+}
+
+func TestParseOpenTSDBDownsample(t *testing.T) {
+	interval, consolidator, err := parseOpenTSDBDownsample("5m-zimsum-none")
+	if err != nil || interval != 300 || consolidator != consolidation.Sum {
+		t.Fatalf("unexpected result %d %s %v", interval, consolidator, err)
+	}
+	for _, in := range []string{"500ms-avg", "1h", "1h-p99", "1h-avg-zero", "1q-avg"} {
+		if _, _, err := parseOpenTSDBDownsample(in); err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+	}
+}
+
+func TestAlignDownsampleRequest(t *testing.T) {
+	mdata.SetSingleSchema(
+		conf.NewRetentionMT(10, 86400, 600, 2, true),
+		conf.NewRetentionMT(60, 7*86400, 600, 2, true),
+		conf.NewRetentionMT(3600, 365*86400, 600, 2, true),
+	)
+	mdata.SetSingleAgg(conf.Avg, conf.Max)
+	now := uint32(400 * 86400)
+	cases := []struct {
+		from         uint32
+		interval     uint32
+		consolidator consolidation.Consolidator
+		archive      int
+		archInterval uint32
+		aggNum       uint32
+	}{
+		{now - 3600, 3600, consolidation.Avg, 2, 3600, 1},   // lowest resolution that fits
+		{now - 3600, 1800, consolidation.Max, 1, 60, 30},    // 1h doesn't divide 30m
+		{now - 3600, 1800, consolidation.Sum, 1, 60, 30},    // sums are stored for avg
+		{now - 3600, 1800, consolidation.Lst, 0, 10, 180},   // no lst rollup
+		{now - 3600, 1800, consolidation.Cnt, 0, 10, 180},   // counts can't be consolidated further
+		{now - 3600, 3600, consolidation.Cnt, 2, 3600, 1},   // but can be read as is
+		{now - 2*86400, 300, consolidation.Avg, 1, 60, 5},   // raw data doesn't go back far enough
+		{now - 3600, 45, consolidation.Avg, 0, 10, 1},       // nothing divides 45s, so we downsample the raw data ourselves
+		{now - 30*86400, 1800, consolidation.Avg, 0, 10, 1}, // nothing retains the data, so we make do with raw
+	}
+	for i, c := range cases {
+		req := models.NewReq("a", "a", "a", c.from, now, 0, 10, c.consolidator, 0, cluster.Manager.ThisNode(), 0, 0)
+		if err := alignDownsampleRequest(now, &req, c.interval); err != nil {
+			t.Errorf("case %d: unexpected error %s", i, err)
+			continue
+		}
+		if req.Archive != c.archive || req.ArchInterval != c.archInterval || req.AggNum != c.aggNum {
+			t.Errorf("case %d: expected archive %d, archInterval %d and aggNum %d, got %s", i, c.archive, c.archInterval, c.aggNum, req.DebugString())
+		}
+	}
+}
+
+func TestOpenTSDBDownsample(t *testing.T) {
+	in := []schema.Point{
+		{Val: 1, Ts: 45},
+		{Val: 2, Ts: 90},
+		{Val: math.NaN(), Ts: 135},
+		{Val: 4, Ts: 180},
+		{Val: 5, Ts: 225},
+	}
+	exp := []schema.Point{
+		{Val: 1.5, Ts: 90},
+		{Val: 4, Ts: 180},
+		{Val: 5, Ts: 270},
+	}
+	got := openTSDBDownsample(in, 90, consolidation.Avg)
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+}
+
+func TestOpenTSDBRate(t *testing.T) {
+	in := []schema.Point{
+		{Val: 10, Ts: 10},
+		{Val: 30, Ts: 20},
+		{Val: math.NaN(), Ts: 30},
+		{Val: 50, Ts: 40},
+		{Val: 5, Ts: 50},
+	}
+	cases := []struct {
+		opts models.OpenTSDBRateOptions
+		exp  []schema.Point
+	}{
+		{
+			models.OpenTSDBRateOptions{},
+			[]schema.Point{{Val: 2, Ts: 20}, {Val: 1, Ts: 40}, {Val: -4.5, Ts: 50}},
+		},
+		{
+			models.OpenTSDBRateOptions{Counter: true, CounterMax: 100},
+			[]schema.Point{{Val: 2, Ts: 20}, {Val: 1, Ts: 40}, {Val: 5.5, Ts: 50}},
+		},
+		{
+			models.OpenTSDBRateOptions{Counter: true, ResetValue: 3},
+			[]schema.Point{{Val: 2, Ts: 20}, {Val: 1, Ts: 40}, {Val: 0, Ts: 50}},
+		},
+		{
+			models.OpenTSDBRateOptions{Counter: true, DropResets: true},
+			[]schema.Point{{Val: 2, Ts: 20}, {Val: 1, Ts: 40}},
+		},
+	}
+	for i, c := range cases {
+		got := openTSDBRate(in, c.opts)
+		if !reflect.DeepEqual(got, c.exp) {
+			t.Errorf("case %d: expected %v, got %v", i, c.exp, got)
+		}
+	}
+}
+
+func TestOpenTSDBGroupAggregate(t *testing.T) {
+	g := openTSDBGroup{
+		labels: [][]prompb.Label{
+			promLabels("sys.cpu.user", []string{"dc=lga", "host=web01"}),
+			promLabels("sys.cpu.user", []string{"dc=lga", "host=web02", "rack=r1"}),
+		},
+		series: [][]schema.Point{
+			{{Val: 1, Ts: 10}, {Val: 2, Ts: 20}, {Val: math.NaN(), Ts: 30}},
+			{{Val: 3, Ts: 10}, {Val: 5, Ts: 30}},
+		},
+	}
+	got := g.aggregate("sys.cpu.user", openTSDBAggregators["sum"], false)
+	exp := models.OpenTSDBSeries{
+		Metric:        "sys.cpu.user",
+		Tags:          map[string]string{"dc": "lga"},
+		AggregateTags: []string{"host", "rack"},
+		Dps:           map[string]float64{"10": 4, "20": 2, "30": 5},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected %+v, got %+v", exp, got)
+	}
+	got = g.aggregate("sys.cpu.user", openTSDBAggregators["count"], true)
+	if !reflect.DeepEqual(got.Dps, map[string]float64{"10000": 2, "20000": 1, "30000": 1}) {
+		t.Fatalf("unexpected dps %v", got.Dps)
+	}
+}
@@ -0,0 +1,424 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/grafana/metrictank/api/middleware"
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/api/prompb"
+	"github.com/grafana/metrictank/api/response"
+	"github.com/grafana/metrictank/cluster"
+	"github.com/grafana/metrictank/cluster/partitioner"
+	"github.com/grafana/metrictank/consolidation"
+	"github.com/grafana/metrictank/idx"
+	"github.com/grafana/metrictank/mdata"
+	"github.com/grafana/metrictank/stats"
+	"github.com/raintank/worldping-api/pkg/log"
+	"gopkg.in/raintank/schema.v1"
+)
+
+// metric input.prometheus.metrics_decode_err is a count of times a prometheus remote write request failed to decode
+var promMetricsDecodeErr = stats.NewCounter32("input.prometheus.metrics_decode_err")
+
+// metric input.prometheus.metrics_per_message is how many metrics per prometheus remote write request were seen
+var promMetricsPerMessage = stats.NewMeter32("input.prometheus.metrics_per_message", false)
+
+// promNameLabel is the label holding the name of a prometheus series
+const promNameLabel = "__name__"
+
+// prometheusWrite ingests the samples of a prometheus remote write request.
+// we store the series under the name from their __name__ label, with their other labels as tags
+func (s *Server) prometheusWrite(ctx *middleware.Context) {
+	if s.PrometheusHandler == nil {
+		response.Write(ctx, response.NewError(http.StatusServiceUnavailable, "prometheus input not initialized"))
+		return
+	}
+	compressed, err := ioutil.ReadAll(ctx.Req.Request.Body)
+	if err != nil {
+		response.Write(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("failed to read request body: %s", err)))
+		return
+	}
+	buf, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		promMetricsDecodeErr.Inc()
+		response.Write(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("failed to snappy-decode request body: %s", err)))
+		return
+	}
+	var req prompb.WriteRequest
+	if err := req.Unmarshal(buf); err != nil {
+		promMetricsDecodeErr.Inc()
+		response.Write(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("failed to decode write request: %s", err)))
+		return
+	}
+
+	var count int
+	for _, ts := range req.Timeseries {
+		md, err := promMetricData(ctx.OrgId, ts.Labels)
+		if err != nil {
+			log.Debug("HTTP prometheusWrite: %s", err)
+			promMetricsDecodeErr.Inc()
+			continue
+		}
+		for _, sample := range ts.Samples {
+			// graphite has no notion of stale series, so we skip prometheus' staleness markers (and any other NaN)
+			if math.IsNaN(sample.Value) {
+				continue
+			}
+			point := *md
+			point.Value = sample.Value
+			point.Time = sample.Timestamp / 1000
+			s.PrometheusHandler.Process(&point, ingestPartition(&point))
+			count++
+		}
+	}
+	promMetricsPerMessage.ValueUint32(uint32(count))
+	ctx.PlainText(200, []byte("OK"))
+}
+
+// promMetricData returns the MetricData, without value and time, for the series with the given labels.
+// the same labels always result in the same id, irrespective of their order
+func promMetricData(orgId int, labels []prompb.Label) (*schema.MetricData, error) {
+	var name string
+	tags := make([]string, 0, len(labels))
+	for _, l := range labels {
+		if l.Name == promNameLabel {
+			name = l.Value
+			continue
+		}
+		// in prometheus, an empty label value is the same as not having the label
+		if l.Value == "" {
+			continue
+		}
+		tags = append(tags, l.Name+"="+l.Value)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("series without %s label: %v", promNameLabel, labels)
+	}
+	sort.Strings(tags)
+	_, s := mdata.MatchSchema(name, 0)
+	md := &schema.MetricData{
+		OrgId:    orgId,
+		Name:     name,
+		Metric:   name,
+		Interval: s.Retentions[0].SecondsPerPoint,
+		Unit:     "unknown",
+		Mtype:    "gauge",
+		Tags:     tags,
+	}
+	md.SetId()
+	return md, nil
+}
+
+// ingestPartitioner spreads the series we ingest over http across the partitions of this node
+var ingestPartitioner, _ = partitioner.NewKafka("bySeries")
+
+// ingestPartition returns the partition to index the given series under, which we ingested over http.
+// like the kafka input does with bySeries partitioning, we hash the series key, but only over
+// the partitions this node handles, so that we load the series again when we restart.
+// the input plugin we require to be enabled sets these partitions.
+func ingestPartition(md *schema.MetricData) int32 {
+	partitions := cluster.Manager.GetPartitions()
+	if len(partitions) == 0 {
+		return 0
+	}
+	i, err := ingestPartitioner.Partition(md, int32(len(partitions)))
+	if err != nil {
+		return partitions[0]
+	}
+	return partitions[i]
+}
+
+// prometheusRead answers a prometheus remote read request with the series matching each of its queries
+func (s *Server) prometheusRead(ctx *middleware.Context) {
+	compressed, err := ioutil.ReadAll(ctx.Req.Request.Body)
+	if err != nil {
+		response.Write(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("failed to read request body: %s", err)))
+		return
+	}
+	buf, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		response.Write(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("failed to snappy-decode request body: %s", err)))
+		return
+	}
+	var req prompb.ReadRequest
+	if err := req.Unmarshal(buf); err != nil {
+		response.Write(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("failed to decode read request: %s", err)))
+		return
+	}
+
+	resp := prompb.ReadResponse{
+		Results: make([]prompb.QueryResult, 0, len(req.Queries)),
+	}
+	for _, q := range req.Queries {
+		series, err := s.promQuery(ctx.Req.Context(), ctx.OrgId, q)
+		if err != nil {
+			response.Write(ctx, response.WrapError(err))
+			return
+		}
+		resp.Results = append(resp.Results, prompb.QueryResult{Timeseries: series})
+	}
+	response.Write(ctx, response.NewSnappyProtobuf(200, &resp))
+}
+
+// promSerie is a series matching a prometheus query, along with what we need to fetch its data
+type promSerie struct {
+	labels  []prompb.Label
+	target  string // identifies the series by its name and tags, like graphite does
+	def     idx.Archive
+	pattern string
+	node    cluster.Node
+}
+
+// promFind returns the series that match all matchers and have been seen since from.
+// we look the series up via their tags if possible, or via their name otherwise,
+// and then apply all matchers to them, so that we match exactly like prometheus would.
+// the series we look up count towards the max-series-per-target limit, whether all matchers match them or not.
+func (s *Server) promFind(ctx context.Context, orgId int, matchers []promMatcher, from uint32) ([]promSerie, error) {
+	var series []Series
+	var err error
+	limits := getLimits(orgId)
+	findLimits := limits.findLimits(limits.maxSeriesPerTarget)
+	if expressions, ok := promTagExpressions(matchers); ok {
+		series, err = s.findByTag(ctx, orgId, expressions, int64(from), findLimits)
+	} else if name, ok := promName(matchers); ok {
+		series, err = s.findSeries(ctx, orgId, []string{name}, int64(from), findLimits)
+	} else {
+		return nil, response.NewError(http.StatusBadRequest, "query needs an equality matcher on __name__, or a non-empty equality or regex matcher on another label")
+	}
+	if isMaxSeries(err) {
+		selector := make([]prompb.LabelMatcher, 0, len(matchers))
+		for _, m := range matchers {
+			selector = append(selector, m.LabelMatcher)
+		}
+		return nil, errMaxSeriesPerTarget(promSelector(selector), limits.maxSeriesPerTarget)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var out []promSerie
+	for _, serie := range series {
+		for _, node := range serie.Series {
+			for _, def := range node.Defs {
+				labels := promLabels(def.Name, def.Tags)
+				if !promMatch(matchers, labels) {
+					continue
+				}
+				out = append(out, promSerie{
+					labels:  labels,
+					target:  taggedName(def.Name, def.Tags),
+					def:     def,
+					pattern: serie.Pattern,
+					node:    serie.Node,
+				})
+			}
+		}
+	}
+	return out, nil
+}
+
+// promQuery returns the raw data of the series matching the query
+func (s *Server) promQuery(ctx context.Context, orgId int, q prompb.Query) ([]prompb.TimeSeries, error) {
+	matchers, err := newPromMatchers(q.Matchers)
+	if err != nil {
+		return nil, response.NewError(http.StatusBadRequest, err.Error())
+	}
+	from := uint32(q.StartTimestampMs / 1000)
+	to := uint32(q.EndTimestampMs/1000) + 1
+	if from >= to {
+		return nil, response.NewError(http.StatusBadRequest, "query start must be before its end")
+	}
+
+	series, err := s.promFind(ctx, orgId, matchers, from)
+	if err != nil {
+		return nil, err
+	}
+	if len(series) == 0 {
+		return nil, nil
+	}
+
+	now := uint32(time.Now().Unix())
+	reqs := make([]models.Req, 0, len(series))
+	labelsByTarget := make(map[string][]prompb.Label, len(series))
+	for _, serie := range series {
+		labelsByTarget[serie.target] = serie.labels
+		def := serie.def
+		fn := mdata.Aggregations.Get(def.AggId).AggregationMethod[0]
+		req := models.NewReq(def.Id, serie.target, serie.pattern, from, to, 0, uint32(def.Interval), consolidation.Consolidator(fn), 0, serie.node, def.SchemaId, def.AggId)
+		// prometheus wants the raw data of each series, so unlike render requests, we don't align the series with each other
+		aligned, _, _, err := alignRequests(now, []models.Req{req})
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, aligned[0])
+	}
+
+	out, err := s.getTargets(ctx, reqs)
+	if err != nil {
+		return nil, err
+	}
+	out = mergeSeries(out)
+	sort.Sort(models.SeriesByTarget(out))
+
+	result := make([]prompb.TimeSeries, 0, len(out))
+	for _, serie := range out {
+		ts := prompb.TimeSeries{
+			Labels:  labelsByTarget[serie.Target],
+			Samples: make([]prompb.Sample, 0, len(serie.Datapoints)),
+		}
+		for _, p := range serie.Datapoints {
+			if math.IsNaN(p.Val) {
+				continue
+			}
+			ts.Samples = append(ts.Samples, prompb.Sample{Value: p.Val, Timestamp: int64(p.Ts) * 1000})
+		}
+		result = append(result, ts)
+	}
+	return result, nil
+}
+
+// promMatcher is a prometheus label matcher, with its regex compiled if it has one
+type promMatcher struct {
+	prompb.LabelMatcher
+	re *regexp.Regexp
+}
+
+func newPromMatchers(in []prompb.LabelMatcher) ([]promMatcher, error) {
+	out := make([]promMatcher, 0, len(in))
+	for _, m := range in {
+		pm := promMatcher{LabelMatcher: m}
+		switch m.Type {
+		case prompb.LabelMatcherEQ, prompb.LabelMatcherNEQ:
+		case prompb.LabelMatcherRE, prompb.LabelMatcherNRE:
+			// prometheus regexes are fully anchored
+			re, err := regexp.Compile("^(?:" + m.Value + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex for label %s: %s", m.Name, err)
+			}
+			pm.re = re
+		default:
+			return nil, fmt.Errorf("unknown matcher type %d for label %s", m.Type, m.Name)
+		}
+		out = append(out, pm)
+	}
+	return out, nil
+}
+
+// matches returns whether the matcher matches the label value. a missing label has the empty value
+func (m promMatcher) matches(value string) bool {
+	switch m.Type {
+	case prompb.LabelMatcherEQ:
+		return value == m.Value
+	case prompb.LabelMatcherNEQ:
+		return value != m.Value
+	case prompb.LabelMatcherRE:
+		return m.re.MatchString(value)
+	default:
+		return !m.re.MatchString(value)
+	}
+}
+
+// promMatch returns whether the series with the given labels matches all matchers
+func promMatch(matchers []promMatcher, labels []prompb.Label) bool {
+	for _, m := range matchers {
+		var value string
+		for _, l := range labels {
+			if l.Name == m.Name {
+				value = l.Value
+				break
+			}
+		}
+		if !m.matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// promTagExpressions translates the matchers into tag expressions, with the matchers on __name__ on the name tag.
+// matchers that involve the empty value can't be expressed in terms of tags, so we leave them out.
+// the expressions can only be used if at least one of the matchers on other labels selects series, rather than
+// excluding them, as the tag index only has series with tags.
+func promTagExpressions(matchers []promMatcher) ([]string, bool) {
+	var expressions []string
+	var selecting bool
+	for _, m := range matchers {
+		if strings.Contains(m.Value, ";") {
+			continue
+		}
+		name := m.Name
+		if name == promNameLabel {
+			name = "name"
+		}
+		switch m.Type {
+		case prompb.LabelMatcherEQ:
+			if m.Value == "" {
+				continue
+			}
+			expressions = append(expressions, name+"="+m.Value)
+			selecting = selecting || m.Name != promNameLabel
+		case prompb.LabelMatcherNEQ:
+			if m.Value == "" {
+				continue
+			}
+			expressions = append(expressions, name+"!="+m.Value)
+		case prompb.LabelMatcherRE:
+			if m.re.MatchString("") {
+				continue
+			}
+			expressions = append(expressions, name+"=~"+m.re.String())
+			selecting = selecting || m.Name != promNameLabel
+		case prompb.LabelMatcherNRE:
+			expressions = append(expressions, name+"!=~"+m.re.String())
+		}
+	}
+	return expressions, selecting
+}
+
+// promName returns the name from the equality matcher on __name__, if there is one
+func promName(matchers []promMatcher) (string, bool) {
+	for _, m := range matchers {
+		if m.Name == promNameLabel && m.Type == prompb.LabelMatcherEQ && m.Value != "" {
+			return m.Value, true
+		}
+	}
+	return "", false
+}
+
+// promLabels returns the labels of the series with the given name and tags, sorted by label name
+func promLabels(name string, tags []string) []prompb.Label {
+	labels := make([]prompb.Label, 0, len(tags)+1)
+	labels = append(labels, prompb.Label{Name: promNameLabel, Value: name})
+	for _, tag := range tags {
+		if i := strings.Index(tag, "="); i != -1 {
+			labels = append(labels, prompb.Label{Name: tag[:i], Value: tag[i+1:]})
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return labels
+}
+
+// promSelector returns the matchers in prometheus' selector syntax, e.g. {__name__="up",job=~"api.*"}
+func promSelector(matchers []prompb.LabelMatcher) string {
+	ops := map[prompb.LabelMatcherType]string{
+		prompb.LabelMatcherEQ:  "=",
+		prompb.LabelMatcherNEQ: "!=",
+		prompb.LabelMatcherRE:  "=~",
+		prompb.LabelMatcherNRE: "!~",
+	}
+	parts := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		parts = append(parts, m.Name+ops[m.Type]+strconv.Quote(m.Value))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/grafana/metrictank/api/middleware"
+	"github.com/grafana/metrictank/api/prompb"
+	"github.com/grafana/metrictank/cluster"
+	"github.com/grafana/metrictank/conf"
+	"github.com/grafana/metrictank/mdata"
+	"gopkg.in/macaron.v1"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type mockHandler struct {
+	metrics []schema.MetricData
+}
+
+func (h *mockHandler) Process(metric *schema.MetricData, partition int32) {
+	h.metrics = append(h.metrics, *metric)
+}
+
+func TestPromMetricData(t *testing.T) {
+	mdata.SetSingleSchema(conf.NewRetentionMT(15, 3600, 600, 2, true))
+	a, err := promMetricData(5, []prompb.Label{
+		{Name: "__name__", Value: "http_requests_total"},
+		{Name: "job", Value: "api"},
+		{Name: "instance", Value: "host1:9090"},
+		{Name: "empty", Value: ""},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if a.Name != "http_requests_total" || a.OrgId != 5 || a.Interval != 15 {
+		t.Fatalf("unexpected metric data %v", a)
+	}
+	expTags := []string{"instance=host1:9090", "job=api"}
+	if len(a.Tags) != 2 || a.Tags[0] != expTags[0] || a.Tags[1] != expTags[1] {
+		t.Fatalf("expected tags %v, got %v", expTags, a.Tags)
+	}
+
+	b, err := promMetricData(5, []prompb.Label{
+		{Name: "job", Value: "api"},
+		{Name: "instance", Value: "host1:9090"},
+		{Name: "__name__", Value: "http_requests_total"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if a.Id != b.Id {
+		t.Fatalf("expected the same id irrespective of label order, got %s and %s", a.Id, b.Id)
+	}
+
+	if _, err := promMetricData(5, []prompb.Label{{Name: "job", Value: "api"}}); err == nil {
+		t.Fatalf("expected error for series without name")
+	}
+}
+
+func TestPrometheusWrite(t *testing.T) {
+	cluster.Init("default", "test", time.Now(), "http", 6060)
+	mdata.SetSingleSchema(conf.NewRetentionMT(15, 3600, 600, 2, true))
+	handler := &mockHandler{}
+	s := &Server{PrometheusHandler: handler}
+	m := macaron.New()
+	m.Use(macaron.Renderer())
+	m.Post("/prometheus/write", func(c *macaron.Context) {
+		s.prometheusWrite(&middleware.Context{Context: c, OrgId: 5})
+	})
+
+	wr := prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "api"}},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 15000}, {Value: 0, Timestamp: 30500}},
+			},
+		},
+	}
+	buf, _ := wr.Marshal()
+	req, _ := http.NewRequest("POST", "/prometheus/write", bytes.NewReader(snappy.Encode(nil, buf)))
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(handler.metrics) != 2 {
+		t.Fatalf("expected 2 points, got %d", len(handler.metrics))
+	}
+	for i, exp := range []struct {
+		value float64
+		time  int64
+	}{{1, 15}, {0, 30}} {
+		md := handler.metrics[i]
+		if md.Name != "up" || md.OrgId != 5 || md.Value != exp.value || md.Time != exp.time || md.Validate() != nil {
+			t.Fatalf("point %d: unexpected metric data %v", i, md)
+		}
+	}
+
+	req, _ = http.NewRequest("POST", "/prometheus/write", bytes.NewReader(buf))
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for uncompressed body, got %d", rec.Code)
+	}
+}
+
+func TestIngestPartition(t *testing.T) {
+	cluster.Init("default", "test", time.Now(), "http", 6060)
+	cluster.Manager.SetPartitions([]int32{3, 5, 7})
+	defer cluster.Manager.SetPartitions(nil)
+	seen := make(map[int32]bool)
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		md := &schema.MetricData{OrgId: 1, Name: name, Metric: name, Interval: 10, Mtype: "gauge"}
+		md.SetId()
+		partition := ingestPartition(md)
+		if partition != 3 && partition != 5 && partition != 7 {
+			t.Fatalf("series %s: expected one of the partitions of this node, got %d", name, partition)
+		}
+		if again := ingestPartition(md); again != partition {
+			t.Fatalf("series %s: expected the same partition every time, got %d and %d", name, partition, again)
+		}
+		seen[partition] = true
+	}
+	if len(seen) < 2 {
+		t.Fatalf("expected the series to be spread over the partitions, got %v", seen)
+	}
+}
+
+func TestPromTagExpressions(t *testing.T) {
+	cases := []struct {
+		matchers  []prompb.LabelMatcher
+		exp       []string
+		selecting bool
+	}{
+		{
+			[]prompb.LabelMatcher{
+				{Type: prompb.LabelMatcherEQ, Name: "__name__", Value: "up"},
+				{Type: prompb.LabelMatcherEQ, Name: "job", Value: "api"},
+				{Type: prompb.LabelMatcherNEQ, Name: "dc", Value: "east"},
+			},
+			[]string{"name=up", "job=api", "dc!=east"},
+			true,
+		},
+		{
+			[]prompb.LabelMatcher{
+				{Type: prompb.LabelMatcherRE, Name: "job", Value: "api|web"},
+				{Type: prompb.LabelMatcherNRE, Name: "instance", Value: "host1.*"},
+			},
+			[]string{"job=~^(?:api|web)$", "instance!=~^(?:host1.*)$"},
+			true,
+		},
+		{
+			[]prompb.LabelMatcher{
+				{Type: prompb.LabelMatcherRE, Name: "__name__", Value: "up|down"},
+				{Type: prompb.LabelMatcherNEQ, Name: "__name__", Value: "down"},
+				{Type: prompb.LabelMatcherEQ, Name: "job", Value: "api"},
+			},
+			[]string{"name=~^(?:up|down)$", "name!=down", "job=api"},
+			true,
+		},
+		{
+			// a matcher on __name__ alone can't select series in the tag index, which only has series with tags
+			[]prompb.LabelMatcher{
+				{Type: prompb.LabelMatcherEQ, Name: "__name__", Value: "up"},
+			},
+			[]string{"name=up"},
+			false,
+		},
+		{
+			// only exclusions, or matchers that also match a missing label, can't select series on their own
+			[]prompb.LabelMatcher{
+				{Type: prompb.LabelMatcherEQ, Name: "__name__", Value: "up"},
+				{Type: prompb.LabelMatcherNEQ, Name: "job", Value: "api"},
+				{Type: prompb.LabelMatcherRE, Name: "dc", Value: ".*"},
+				{Type: prompb.LabelMatcherEQ, Name: "env", Value: ""},
+			},
+			[]string{"name=up", "job!=api"},
+			false,
+		},
+	}
+	for i, c := range cases {
+		matchers, err := newPromMatchers(c.matchers)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error %s", i, err)
+		}
+		exp, selecting := promTagExpressions(matchers)
+		if selecting != c.selecting || len(exp) != len(c.exp) {
+			t.Fatalf("case %d: expected %v (selecting %t), got %v (selecting %t)", i, c.exp, c.selecting, exp, selecting)
+		}
+		for j := range exp {
+			if exp[j] != c.exp[j] {
+				t.Fatalf("case %d: expected %v, got %v", i, c.exp, exp)
+			}
+		}
+	}
+
+	if _, err := newPromMatchers([]prompb.LabelMatcher{{Type: prompb.LabelMatcherRE, Name: "job", Value: "("}}); err == nil {
+		t.Fatalf("expected error for invalid regex")
+	}
+}
+
+func TestPromMatch(t *testing.T) {
+	labels := promLabels("up", []string{"job=api", "instance=host1:9090"})
+	if labels[0].Name != "__name__" || labels[1].Name != "instance" || labels[2].Name != "job" {
+		t.Fatalf("expected labels sorted by name, got %v", labels)
+	}
+	cases := []struct {
+		matcher prompb.LabelMatcher
+		exp     bool
+	}{
+		{prompb.LabelMatcher{Type: prompb.LabelMatcherEQ, Name: "__name__", Value: "up"}, true},
+		{prompb.LabelMatcher{Type: prompb.LabelMatcherEQ, Name: "job", Value: "ap"}, false},
+		{prompb.LabelMatcher{Type: prompb.LabelMatcherNEQ, Name: "job", Value: "api"}, false},
+		{prompb.LabelMatcher{Type: prompb.LabelMatcherRE, Name: "job", Value: "ap"}, false},
+		{prompb.LabelMatcher{Type: prompb.LabelMatcherRE, Name: "job", Value: "a.i"}, true},
+		{prompb.LabelMatcher{Type: prompb.LabelMatcherNRE, Name: "instance", Value: "host2.*"}, true},
+		{prompb.LabelMatcher{Type: prompb.LabelMatcherEQ, Name: "env", Value: ""}, true},
+		{prompb.LabelMatcher{Type: prompb.LabelMatcherRE, Name: "env", Value: ".+"}, false},
+	}
+	for i, c := range cases {
+		matchers, err := newPromMatchers([]prompb.LabelMatcher{c.matcher})
+		if err != nil {
+			t.Fatalf("case %d: unexpected error %s", i, err)
+		}
+		if promMatch(matchers, labels) != c.exp {
+			t.Fatalf("case %d: expected match of %v to be %t", i, c.matcher, c.exp)
+		}
+	}
+}
+
+func TestPrometheusReadBadRequest(t *testing.T) {
+	s := &Server{}
+	m := macaron.New()
+	m.Use(macaron.Renderer())
+	m.Post("/prometheus/read", func(c *macaron.Context) {
+		s.prometheusRead(&middleware.Context{Context: c, OrgId: 5})
+	})
+
+	rr := prompb.ReadRequest{
+		Queries: []prompb.Query{
+			{
+				StartTimestampMs: 15000,
+				EndTimestampMs:   60000,
+				Matchers:         []prompb.LabelMatcher{{Type: prompb.LabelMatcherNEQ, Name: "job", Value: "api"}},
+			},
+		},
+	}
+	buf, _ := rr.Marshal()
+	req, _ := http.NewRequest("POST", "/prometheus/read", bytes.NewReader(snappy.Encode(nil, buf)))
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for query without selecting matcher, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
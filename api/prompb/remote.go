@@ -0,0 +1,298 @@
+package prompb
+
+// LabelMatcherType is the kind of comparison a LabelMatcher makes
+type LabelMatcherType int32
+
+const (
+	LabelMatcherEQ  LabelMatcherType = 0 // equal
+	LabelMatcherNEQ LabelMatcherType = 1 // not equal
+	LabelMatcherRE  LabelMatcherType = 2 // regex match
+	LabelMatcherNRE LabelMatcherType = 3 // regex non-match
+)
+
+// LabelMatcher selects series based on the value of one of their labels
+type LabelMatcher struct {
+	Type  LabelMatcherType
+	Name  string
+	Value string
+}
+
+// Query selects the series matching all matchers, over a time range in milliseconds
+type Query struct {
+	StartTimestampMs int64
+	EndTimestampMs   int64
+	Matchers         []LabelMatcher
+}
+
+// ReadRequest is the body of a remote read request
+type ReadRequest struct {
+	Queries []Query
+}
+
+// QueryResult holds the series matching a Query
+type QueryResult struct {
+	Timeseries []TimeSeries
+}
+
+// ReadResponse is the body of a remote read response, holding a result for each query of the request
+type ReadResponse struct {
+	Results []QueryResult
+}
+
+func (m *LabelMatcher) unmarshal(buf []byte) error {
+	d := decoder{buf: buf}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			if err := expect(wireType, wireVarint); err != nil {
+				return err
+			}
+			v, err := d.varint()
+			if err != nil {
+				return err
+			}
+			m.Type = LabelMatcherType(v)
+		case 2, 3:
+			if err := expect(wireType, wireBytes); err != nil {
+				return err
+			}
+			v, err := d.bytes()
+			if err != nil {
+				return err
+			}
+			if field == 2 {
+				m.Name = string(v)
+			} else {
+				m.Value = string(v)
+			}
+		default:
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m LabelMatcher) size() int {
+	var n int
+	if m.Type != 0 {
+		n += sizeKey(1) + sizeVarint(uint64(m.Type))
+	}
+	if m.Name != "" {
+		n += sizeBytes(2, len(m.Name))
+	}
+	if m.Value != "" {
+		n += sizeBytes(3, len(m.Value))
+	}
+	return n
+}
+
+func (m LabelMatcher) appendTo(b []byte) []byte {
+	if m.Type != 0 {
+		b = appendInt64(b, 1, int64(m.Type))
+	}
+	if m.Name != "" {
+		b = appendString(b, 2, m.Name)
+	}
+	if m.Value != "" {
+		b = appendString(b, 3, m.Value)
+	}
+	return b
+}
+
+func (q *Query) unmarshal(buf []byte) error {
+	d := decoder{buf: buf}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1, 2:
+			if err := expect(wireType, wireVarint); err != nil {
+				return err
+			}
+			v, err := d.varint()
+			if err != nil {
+				return err
+			}
+			if field == 1 {
+				q.StartTimestampMs = int64(v)
+			} else {
+				q.EndTimestampMs = int64(v)
+			}
+		case 3:
+			if err := expect(wireType, wireBytes); err != nil {
+				return err
+			}
+			v, err := d.bytes()
+			if err != nil {
+				return err
+			}
+			var m LabelMatcher
+			if err := m.unmarshal(v); err != nil {
+				return err
+			}
+			q.Matchers = append(q.Matchers, m)
+		default:
+			// notably the read hints (field 4), which we don't use
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (q Query) size() int {
+	var n int
+	if q.StartTimestampMs != 0 {
+		n += sizeKey(1) + sizeVarint(uint64(q.StartTimestampMs))
+	}
+	if q.EndTimestampMs != 0 {
+		n += sizeKey(2) + sizeVarint(uint64(q.EndTimestampMs))
+	}
+	for _, m := range q.Matchers {
+		n += sizeBytes(3, m.size())
+	}
+	return n
+}
+
+func (q Query) appendTo(b []byte) []byte {
+	if q.StartTimestampMs != 0 {
+		b = appendInt64(b, 1, q.StartTimestampMs)
+	}
+	if q.EndTimestampMs != 0 {
+		b = appendInt64(b, 2, q.EndTimestampMs)
+	}
+	for _, m := range q.Matchers {
+		b = appendKey(b, 3, wireBytes)
+		b = appendVarint(b, uint64(m.size()))
+		b = m.appendTo(b)
+	}
+	return b
+}
+
+// Unmarshal decodes a protobuf encoded ReadRequest
+func (rr *ReadRequest) Unmarshal(buf []byte) error {
+	d := decoder{buf: buf}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			if err := expect(wireType, wireBytes); err != nil {
+				return err
+			}
+			v, err := d.bytes()
+			if err != nil {
+				return err
+			}
+			var q Query
+			if err := q.unmarshal(v); err != nil {
+				return err
+			}
+			rr.Queries = append(rr.Queries, q)
+		default:
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Marshal returns the protobuf encoding of the ReadRequest
+func (rr *ReadRequest) Marshal() ([]byte, error) {
+	var n int
+	for _, q := range rr.Queries {
+		n += sizeBytes(1, q.size())
+	}
+	b := make([]byte, 0, n)
+	for _, q := range rr.Queries {
+		b = appendKey(b, 1, wireBytes)
+		b = appendVarint(b, uint64(q.size()))
+		b = q.appendTo(b)
+	}
+	return b, nil
+}
+
+func (qr *QueryResult) unmarshal(buf []byte) error {
+	// a QueryResult has the same layout as a WriteRequest
+	var wr WriteRequest
+	if err := wr.Unmarshal(buf); err != nil {
+		return err
+	}
+	qr.Timeseries = wr.Timeseries
+	return nil
+}
+
+func (qr QueryResult) size() int {
+	var n int
+	for _, ts := range qr.Timeseries {
+		n += sizeBytes(1, ts.size())
+	}
+	return n
+}
+
+func (qr QueryResult) appendTo(b []byte) []byte {
+	for _, ts := range qr.Timeseries {
+		b = appendKey(b, 1, wireBytes)
+		b = appendVarint(b, uint64(ts.size()))
+		b = ts.appendTo(b)
+	}
+	return b
+}
+
+// Unmarshal decodes a protobuf encoded ReadResponse
+func (rr *ReadResponse) Unmarshal(buf []byte) error {
+	d := decoder{buf: buf}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			if err := expect(wireType, wireBytes); err != nil {
+				return err
+			}
+			v, err := d.bytes()
+			if err != nil {
+				return err
+			}
+			var qr QueryResult
+			if err := qr.unmarshal(v); err != nil {
+				return err
+			}
+			rr.Results = append(rr.Results, qr)
+		default:
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Marshal returns the protobuf encoding of the ReadResponse
+func (rr *ReadResponse) Marshal() ([]byte, error) {
+	var n int
+	for _, qr := range rr.Results {
+		n += sizeBytes(1, qr.size())
+	}
+	b := make([]byte, 0, n)
+	for _, qr := range rr.Results {
+		b = appendKey(b, 1, wireBytes)
+		b = appendVarint(b, uint64(qr.size()))
+		b = qr.appendTo(b)
+	}
+	return b, nil
+}
@@ -0,0 +1,73 @@
+package prompb
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// a ReadRequest for job=~"a.*" from 1000 to 2000ms,
+// as encoded by the reference protobuf implementation
+var readRequestJob = []byte{
+	0x0a, 0x14, // queries, 20 bytes
+	0x08, 0xe8, 0x07, // start 1000
+	0x10, 0xd0, 0x0f, // end 2000
+	0x1a, 0x0c, // matchers, 12 bytes
+	0x08, 0x02, // type RE
+	0x12, 0x03, 'j', 'o', 'b',
+	0x1a, 0x03, 'a', '.', '*',
+}
+
+func TestReadRequestMarshal(t *testing.T) {
+	rr := ReadRequest{
+		Queries: []Query{
+			{
+				StartTimestampMs: 1000,
+				EndTimestampMs:   2000,
+				Matchers:         []LabelMatcher{{Type: LabelMatcherRE, Name: "job", Value: "a.*"}},
+			},
+		},
+	}
+	got, err := rr.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal: %s", err)
+	}
+	if !bytes.Equal(got, readRequestJob) {
+		t.Fatalf("expected %x, got %x", readRequestJob, got)
+	}
+
+	var back ReadRequest
+	if err := back.Unmarshal(got); err != nil {
+		t.Fatalf("failed to unmarshal: %s", err)
+	}
+	if !reflect.DeepEqual(back, rr) {
+		t.Fatalf("expected %v, got %v", rr, back)
+	}
+}
+
+func TestReadResponseMarshal(t *testing.T) {
+	rr := ReadResponse{
+		Results: []QueryResult{
+			{
+				Timeseries: []TimeSeries{
+					{
+						Labels:  []Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "api"}},
+						Samples: []Sample{{Value: 1, Timestamp: 1000}, {Value: -2.5, Timestamp: 2000}},
+					},
+				},
+			},
+			{},
+		},
+	}
+	buf, err := rr.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal: %s", err)
+	}
+	var back ReadResponse
+	if err := back.Unmarshal(buf); err != nil {
+		t.Fatalf("failed to unmarshal: %s", err)
+	}
+	if !reflect.DeepEqual(back, rr) {
+		t.Fatalf("expected %v, got %v", rr, back)
+	}
+}
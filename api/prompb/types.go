@@ -0,0 +1,247 @@
+// Package prompb implements the protobuf messages of the prometheus remote storage protocol.
+// the types and field numbers mirror prometheus' prompb package, so that we can
+// encode and decode them without pulling in a protobuf library and the prometheus code base.
+package prompb
+
+import (
+	"math"
+)
+
+// Sample is a single value of a series, at a timestamp in milliseconds
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// Label is a name/value pair identifying a series
+type Label struct {
+	Name  string
+	Value string
+}
+
+// TimeSeries is a series, identified by its labels, along with its samples
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// WriteRequest is the body of a remote write request
+type WriteRequest struct {
+	Timeseries []TimeSeries
+}
+
+func (s *Sample) unmarshal(buf []byte) error {
+	d := decoder{buf: buf}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			if err := expect(wireType, wireFixed64); err != nil {
+				return err
+			}
+			v, err := d.fixed64()
+			if err != nil {
+				return err
+			}
+			s.Value = math.Float64frombits(v)
+		case 2:
+			if err := expect(wireType, wireVarint); err != nil {
+				return err
+			}
+			v, err := d.varint()
+			if err != nil {
+				return err
+			}
+			s.Timestamp = int64(v)
+		default:
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s Sample) size() int {
+	n := sizeKey(1) + 8
+	if s.Timestamp != 0 {
+		n += sizeKey(2) + sizeVarint(uint64(s.Timestamp))
+	}
+	return n
+}
+
+func (s Sample) appendTo(b []byte) []byte {
+	b = appendDouble(b, 1, s.Value)
+	if s.Timestamp != 0 {
+		b = appendInt64(b, 2, s.Timestamp)
+	}
+	return b
+}
+
+func (l *Label) unmarshal(buf []byte) error {
+	d := decoder{buf: buf}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1, 2:
+			if err := expect(wireType, wireBytes); err != nil {
+				return err
+			}
+			v, err := d.bytes()
+			if err != nil {
+				return err
+			}
+			if field == 1 {
+				l.Name = string(v)
+			} else {
+				l.Value = string(v)
+			}
+		default:
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (l Label) size() int {
+	var n int
+	if l.Name != "" {
+		n += sizeBytes(1, len(l.Name))
+	}
+	if l.Value != "" {
+		n += sizeBytes(2, len(l.Value))
+	}
+	return n
+}
+
+func (l Label) appendTo(b []byte) []byte {
+	if l.Name != "" {
+		b = appendString(b, 1, l.Name)
+	}
+	if l.Value != "" {
+		b = appendString(b, 2, l.Value)
+	}
+	return b
+}
+
+func (ts *TimeSeries) unmarshal(buf []byte) error {
+	d := decoder{buf: buf}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			if err := expect(wireType, wireBytes); err != nil {
+				return err
+			}
+			v, err := d.bytes()
+			if err != nil {
+				return err
+			}
+			var l Label
+			if err := l.unmarshal(v); err != nil {
+				return err
+			}
+			ts.Labels = append(ts.Labels, l)
+		case 2:
+			if err := expect(wireType, wireBytes); err != nil {
+				return err
+			}
+			v, err := d.bytes()
+			if err != nil {
+				return err
+			}
+			var s Sample
+			if err := s.unmarshal(v); err != nil {
+				return err
+			}
+			ts.Samples = append(ts.Samples, s)
+		default:
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (ts TimeSeries) size() int {
+	var n int
+	for _, l := range ts.Labels {
+		n += sizeBytes(1, l.size())
+	}
+	for _, s := range ts.Samples {
+		n += sizeBytes(2, s.size())
+	}
+	return n
+}
+
+func (ts TimeSeries) appendTo(b []byte) []byte {
+	for _, l := range ts.Labels {
+		b = appendKey(b, 1, wireBytes)
+		b = appendVarint(b, uint64(l.size()))
+		b = l.appendTo(b)
+	}
+	for _, s := range ts.Samples {
+		b = appendKey(b, 2, wireBytes)
+		b = appendVarint(b, uint64(s.size()))
+		b = s.appendTo(b)
+	}
+	return b
+}
+
+// Unmarshal decodes a protobuf encoded WriteRequest
+func (wr *WriteRequest) Unmarshal(buf []byte) error {
+	d := decoder{buf: buf}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			if err := expect(wireType, wireBytes); err != nil {
+				return err
+			}
+			v, err := d.bytes()
+			if err != nil {
+				return err
+			}
+			var ts TimeSeries
+			if err := ts.unmarshal(v); err != nil {
+				return err
+			}
+			wr.Timeseries = append(wr.Timeseries, ts)
+		default:
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Marshal returns the protobuf encoding of the WriteRequest
+func (wr *WriteRequest) Marshal() ([]byte, error) {
+	var n int
+	for _, ts := range wr.Timeseries {
+		n += sizeBytes(1, ts.size())
+	}
+	b := make([]byte, 0, n)
+	for _, ts := range wr.Timeseries {
+		b = appendKey(b, 1, wireBytes)
+		b = appendVarint(b, uint64(ts.size()))
+		b = ts.appendTo(b)
+	}
+	return b, nil
+}
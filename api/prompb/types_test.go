@@ -0,0 +1,74 @@
+package prompb
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// a WriteRequest with one series up{} with a single sample of value 1 at 1000ms,
+// as encoded by the reference protobuf implementation
+var writeRequestUp = []byte{
+	0x0a, 0x1e, // timeseries, 30 bytes
+	0x0a, 0x0e, // labels, 14 bytes
+	0x0a, 0x08, '_', '_', 'n', 'a', 'm', 'e', '_', '_',
+	0x12, 0x02, 'u', 'p',
+	0x12, 0x0c, // samples, 12 bytes
+	0x09, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf0, 0x3f, // value 1
+	0x10, 0xe8, 0x07, // timestamp 1000
+}
+
+func TestWriteRequestMarshal(t *testing.T) {
+	wr := WriteRequest{
+		Timeseries: []TimeSeries{
+			{
+				Labels:  []Label{{Name: "__name__", Value: "up"}},
+				Samples: []Sample{{Value: 1, Timestamp: 1000}},
+			},
+		},
+	}
+	got, err := wr.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal: %s", err)
+	}
+	if !bytes.Equal(got, writeRequestUp) {
+		t.Fatalf("expected %x, got %x", writeRequestUp, got)
+	}
+
+	var back WriteRequest
+	if err := back.Unmarshal(got); err != nil {
+		t.Fatalf("failed to unmarshal: %s", err)
+	}
+	if !reflect.DeepEqual(back, wr) {
+		t.Fatalf("expected %v, got %v", wr, back)
+	}
+}
+
+func TestWriteRequestUnmarshalUnknownFields(t *testing.T) {
+	// the same request, with an unknown varint field 5 and an unknown length delimited field 6 in the series
+	buf := []byte{
+		0x0a, 0x23,
+		0x28, 0x01,
+		0x32, 0x01, 'x',
+		0x0a, 0x0e,
+		0x0a, 0x08, '_', '_', 'n', 'a', 'm', 'e', '_', '_',
+		0x12, 0x02, 'u', 'p',
+		0x12, 0x0c,
+		0x09, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf0, 0x3f,
+		0x10, 0xe8, 0x07,
+	}
+	var wr WriteRequest
+	if err := wr.Unmarshal(buf); err != nil {
+		t.Fatalf("failed to unmarshal: %s", err)
+	}
+	if len(wr.Timeseries) != 1 || wr.Timeseries[0].Labels[0].Value != "up" || wr.Timeseries[0].Samples[0].Timestamp != 1000 {
+		t.Fatalf("unexpected request %v", wr)
+	}
+}
+
+func TestWriteRequestUnmarshalTruncated(t *testing.T) {
+	var wr WriteRequest
+	if err := wr.Unmarshal(writeRequestUp[:len(writeRequestUp)-2]); err == nil {
+		t.Fatalf("expected error for truncated message, got %v", wr)
+	}
+}
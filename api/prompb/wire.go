@@ -0,0 +1,157 @@
+package prompb
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var (
+	errTruncated   = errors.New("prompb: unexpected end of message")
+	errOverflow    = errors.New("prompb: varint overflows 64 bits")
+	errWireType    = errors.New("prompb: unexpected wire type")
+	errBadWireType = errors.New("prompb: unsupported wire type")
+)
+
+// decoder reads protobuf encoded fields from a buffer
+type decoder struct {
+	buf []byte
+	pos int
+}
+
+func (d *decoder) done() bool {
+	return d.pos >= len(d.buf)
+}
+
+func (d *decoder) varint() (uint64, error) {
+	var v uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if d.pos >= len(d.buf) {
+			return 0, errTruncated
+		}
+		b := d.buf[d.pos]
+		d.pos++
+		v |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return v, nil
+		}
+	}
+	return 0, errOverflow
+}
+
+// key reads the key of the next field, returning its field number and wire type
+func (d *decoder) key() (int, int, error) {
+	k, err := d.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(k >> 3), int(k & 7), nil
+}
+
+func (d *decoder) fixed64() (uint64, error) {
+	if d.pos+8 > len(d.buf) {
+		return 0, errTruncated
+	}
+	v := binary.LittleEndian.Uint64(d.buf[d.pos:])
+	d.pos += 8
+	return v, nil
+}
+
+// bytes reads a length delimited field. the returned slice refers to the decoder's buffer
+func (d *decoder) bytes() ([]byte, error) {
+	l, err := d.varint()
+	if err != nil {
+		return nil, err
+	}
+	if l > uint64(len(d.buf)-d.pos) {
+		return nil, errTruncated
+	}
+	b := d.buf[d.pos : d.pos+int(l)]
+	d.pos += int(l)
+	return b, nil
+}
+
+// skip skips over the value of a field we don't know about
+func (d *decoder) skip(wireType int) error {
+	var err error
+	switch wireType {
+	case wireVarint:
+		_, err = d.varint()
+	case wireFixed64:
+		_, err = d.fixed64()
+	case wireBytes:
+		_, err = d.bytes()
+	case wireFixed32:
+		if d.pos+4 > len(d.buf) {
+			return errTruncated
+		}
+		d.pos += 4
+	default:
+		err = errBadWireType
+	}
+	return err
+}
+
+// expect verifies the wire type of a known field
+func expect(got, want int) error {
+	if got != want {
+		return errWireType
+	}
+	return nil
+}
+
+func sizeVarint(v uint64) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+	return n
+}
+
+func sizeKey(field int) int {
+	return sizeVarint(uint64(field) << 3)
+}
+
+// sizeBytes returns the size of a length delimited field holding l bytes
+func sizeBytes(field, l int) int {
+	return sizeKey(field) + sizeVarint(uint64(l)) + l
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendKey(b []byte, field, wireType int) []byte {
+	return appendVarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+func appendString(b []byte, field int, s string) []byte {
+	b = appendKey(b, field, wireBytes)
+	b = appendVarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendInt64(b []byte, field int, v int64) []byte {
+	b = appendKey(b, field, wireVarint)
+	return appendVarint(b, uint64(v))
+}
+
+func appendDouble(b []byte, field int, v float64) []byte {
+	b = appendKey(b, field, wireFixed64)
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+	return append(b, buf[:]...)
+}
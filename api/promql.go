@@ -0,0 +1,290 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/metrictank/api/middleware"
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/api/prompb"
+	"github.com/grafana/metrictank/api/response"
+	"github.com/grafana/metrictank/promql"
+)
+
+// maxPromPoints is the maximum number of steps of a range query, like prometheus enforces
+const maxPromPoints = 11000
+
+// promQuerier selects the data for PromQL queries, via the same index and store lookups as remote read
+type promQuerier struct {
+	s     *Server
+	orgId int
+}
+
+func (q promQuerier) Select(ctx context.Context, matchers []*promql.LabelMatcher, start, end int64) ([]promql.Series, error) {
+	if start < 0 {
+		start = 0
+	}
+	series, err := q.s.promQuery(ctx, q.orgId, prompb.Query{
+		StartTimestampMs: start,
+		EndTimestampMs:   end,
+		Matchers:         toPrompbMatchers(matchers),
+	})
+	if err != nil {
+		// so we can tell errors of the lookups apart from the ones of the evaluation
+		return nil, response.WrapError(err)
+	}
+	out := make([]promql.Series, 0, len(series))
+	for _, ts := range series {
+		serie := promql.Series{
+			Metric: make(promql.Labels, 0, len(ts.Labels)),
+			Points: make([]promql.Point, 0, len(ts.Samples)),
+		}
+		for _, l := range ts.Labels {
+			serie.Metric = append(serie.Metric, promql.Label{Name: l.Name, Value: l.Value})
+		}
+		for _, s := range ts.Samples {
+			serie.Points = append(serie.Points, promql.Point{T: s.Timestamp, V: s.Value})
+		}
+		out = append(out, serie)
+	}
+	return out, nil
+}
+
+func toPrompbMatchers(matchers []*promql.LabelMatcher) []prompb.LabelMatcher {
+	out := make([]prompb.LabelMatcher, 0, len(matchers))
+	for _, m := range matchers {
+		// the match types are declared in the same order
+		out = append(out, prompb.LabelMatcher{Type: prompb.LabelMatcherType(m.Type), Name: m.Name, Value: m.Value})
+	}
+	return out
+}
+
+func (s *Server) prometheusQueryRange(ctx *middleware.Context, request models.PrometheusRangeQuery) {
+	start, err := parsePromTime(request.Start, time.Now())
+	if err != nil {
+		promError(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("invalid start: %s", err)))
+		return
+	}
+	end, err := parsePromTime(request.End, time.Now())
+	if err != nil {
+		promError(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("invalid end: %s", err)))
+		return
+	}
+	if end < start {
+		promError(ctx, response.NewError(http.StatusBadRequest, "end timestamp must not be before start time"))
+		return
+	}
+	step, err := parsePromDuration(request.Step)
+	if err != nil {
+		promError(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("invalid step: %s", err)))
+		return
+	}
+	if step <= 0 {
+		promError(ctx, response.NewError(http.StatusBadRequest, "zero or negative query resolution step widths are not accepted. Try a positive integer"))
+		return
+	}
+	// timestamps have millisecond precision, so finer steps make no sense
+	if step < time.Millisecond {
+		promError(ctx, response.NewError(http.StatusBadRequest, "query resolution step widths below 1ms are not accepted"))
+		return
+	}
+	if (end-start)/int64(step/time.Millisecond) > maxPromPoints {
+		promError(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("exceeded maximum resolution of %d points per timeseries. Try decreasing the query resolution (?step=XX)", maxPromPoints)))
+		return
+	}
+	expr, err := promql.Parse(request.Query)
+	if err != nil {
+		promError(ctx, response.NewError(http.StatusBadRequest, err.Error()))
+		return
+	}
+	if t := expr.Type(); t != promql.ValueTypeScalar && t != promql.ValueTypeVector {
+		promError(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("invalid expression type %q for range query, must be scalar or instant vector", t)))
+		return
+	}
+
+	newctx, done := s.queries.add(ctx.Req.Context(), "promql", ctx.OrgId, []string{request.Query})
+	defer done()
+	matrix, err := promql.EvalRange(newctx, promQuerier{s, ctx.OrgId}, expr, start, end, step)
+	if err != nil {
+		if newctx.Err() == context.Canceled {
+			err = errQueryCanceled
+		}
+		promError(ctx, err)
+		return
+	}
+	promSuccess(ctx, models.PrometheusQueryData{ResultType: string(promql.ValueTypeMatrix), Result: matrix})
+}
+
+func (s *Server) prometheusQuery(ctx *middleware.Context, request models.PrometheusQuery) {
+	ts, err := parsePromTime(request.Time, time.Now())
+	if err != nil {
+		promError(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("invalid time: %s", err)))
+		return
+	}
+	expr, err := promql.Parse(request.Query)
+	if err != nil {
+		promError(ctx, response.NewError(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	newctx, done := s.queries.add(ctx.Req.Context(), "promql", ctx.OrgId, []string{request.Query})
+	defer done()
+	v, err := promql.EvalInstant(newctx, promQuerier{s, ctx.OrgId}, expr, ts)
+	if err != nil {
+		if newctx.Err() == context.Canceled {
+			err = errQueryCanceled
+		}
+		promError(ctx, err)
+		return
+	}
+	promSuccess(ctx, models.PrometheusQueryData{ResultType: string(v.Type()), Result: v})
+}
+
+func (s *Server) prometheusSeries(ctx *middleware.Context, request models.PrometheusSeriesQuery) {
+	from, err := parsePromTime(request.Start, time.Unix(0, 0))
+	if err != nil {
+		promError(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("invalid start: %s", err)))
+		return
+	}
+	result := make([]map[string]string, 0)
+	seen := make(map[string]struct{})
+	for _, selector := range request.Match {
+		matchers, err := promql.ParseMetricSelector(selector)
+		if err != nil {
+			promError(ctx, response.NewError(http.StatusBadRequest, err.Error()))
+			return
+		}
+		pms, err := newPromMatchers(toPrompbMatchers(matchers))
+		if err != nil {
+			promError(ctx, response.NewError(http.StatusBadRequest, err.Error()))
+			return
+		}
+		series, err := s.promFind(ctx.Req.Context(), ctx.OrgId, pms, uint32(from/1000))
+		if err != nil {
+			promError(ctx, err)
+			return
+		}
+		for _, serie := range series {
+			if _, ok := seen[serie.target]; ok {
+				continue
+			}
+			seen[serie.target] = struct{}{}
+			labels := make(map[string]string, len(serie.labels))
+			for _, l := range serie.labels {
+				labels[l.Name] = l.Value
+			}
+			result = append(result, labels)
+		}
+	}
+	promSuccess(ctx, result)
+}
+
+func (s *Server) prometheusLabels(ctx *middleware.Context, request models.PrometheusLabelsQuery) {
+	tags, err := s.tagList(ctx.Req.Context(), ctx.OrgId)
+	if err != nil {
+		promError(ctx, err)
+		return
+	}
+	// the name is indexed as the name tag, but it is the __name__ label
+	labels := []string{promNameLabel}
+	for _, tag := range tags {
+		if tag != "name" {
+			labels = append(labels, tag)
+		}
+	}
+	sort.Strings(labels)
+	promSuccess(ctx, labels)
+}
+
+func (s *Server) prometheusLabelValues(ctx *middleware.Context, request models.PrometheusLabelsQuery) {
+	name := ctx.Params(":name")
+	from, err := parsePromTime(request.Start, time.Unix(0, 0))
+	if err != nil {
+		promError(ctx, response.NewError(http.StatusBadRequest, fmt.Sprintf("invalid start: %s", err)))
+		return
+	}
+	values := make([]string, 0)
+	if name == promNameLabel {
+		series, err := s.listSeries(ctx.Req.Context(), ctx.OrgId)
+		if err != nil {
+			promError(ctx, err)
+			return
+		}
+		seen := make(map[string]struct{})
+		for _, def := range series {
+			if def.LastUpdate < from/1000 {
+				continue
+			}
+			if _, ok := seen[def.Name]; !ok {
+				values = append(values, def.Name)
+				seen[def.Name] = struct{}{}
+			}
+		}
+	} else {
+		result, err := s.tagDetails(ctx.Req.Context(), ctx.OrgId, name, from/1000)
+		if err != nil {
+			promError(ctx, err)
+			return
+		}
+		for value := range result {
+			values = append(values, value)
+		}
+	}
+	sort.Strings(values)
+	promSuccess(ctx, values)
+}
+
+func promSuccess(ctx *middleware.Context, data interface{}) {
+	response.Write(ctx, response.NewJson(200, models.PrometheusResponse{Status: "success", Data: data}, ""))
+}
+
+// promError responds with the error, in the format of the prometheus api.
+// errors without a status code come from evaluating the query
+func promError(ctx *middleware.Context, err error) {
+	code := http.StatusUnprocessableEntity
+	errorType := "execution"
+	if e, ok := err.(response.Error); ok {
+		code = e.Code()
+		switch {
+		case code == http.StatusBadRequest:
+			errorType = "bad_data"
+		case code == errQueryCanceled.Code():
+			errorType = "canceled"
+		case code >= 500:
+			errorType = "internal"
+		}
+	}
+	response.Write(ctx, response.NewJson(code, models.PrometheusResponse{Status: "error", ErrorType: errorType, Error: err.Error()}, ""))
+}
+
+// parsePromTime parses a timestamp in the format of the prometheus api into milliseconds. if it's empty, def is returned
+func parsePromTime(s string, def time.Time) (int64, error) {
+	if s == "" {
+		return def.UnixNano() / int64(time.Millisecond), nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return int64(math.Floor(f*1000 + 0.5)), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse %q to a valid timestamp", s)
+	}
+	return t.UnixNano() / int64(time.Millisecond), nil
+}
+
+// parsePromDuration parses a duration in the format of the prometheus api: a number of seconds, or a PromQL duration
+func parsePromDuration(s string) (time.Duration, error) {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(f * float64(time.Second)), nil
+	}
+	d, err := promql.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse %q to a valid duration", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/metrictank/api/middleware"
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/api/prompb"
+	"github.com/grafana/metrictank/promql"
+	"gopkg.in/macaron.v1"
+)
+
+func TestParsePromTime(t *testing.T) {
+	def := time.Unix(100, 0)
+	cases := map[string]int64{
+		"":                         100000,
+		"1435781430.781":           1435781430781,
+		"1435781430":               1435781430000,
+		"2015-07-01T20:10:30.781Z": 1435781430781,
+	}
+	for in, exp := range cases {
+		ms, err := parsePromTime(in, def)
+		if err != nil || ms != exp {
+			t.Fatalf("%q: expected %d, got %d (err %v)", in, exp, ms, err)
+		}
+	}
+	if _, err := parsePromTime("yesterday", def); err == nil {
+		t.Fatalf("expected error for invalid time")
+	}
+}
+
+func TestParsePromDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"15":  15 * time.Second,
+		"0.5": 500 * time.Millisecond,
+		"1m":  time.Minute,
+	}
+	for in, exp := range cases {
+		d, err := parsePromDuration(in)
+		if err != nil || d != exp {
+			t.Fatalf("%q: expected %s, got %s (err %v)", in, exp, d, err)
+		}
+	}
+	if _, err := parsePromDuration("1 minute"); err == nil {
+		t.Fatalf("expected error for invalid duration")
+	}
+}
+
+func TestToPrompbMatchers(t *testing.T) {
+	matchers, err := promql.ParseMetricSelector(`up{a="1",b!="2",c=~"3",d!~"4"}`)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	exp := []prompb.LabelMatcherType{prompb.LabelMatcherEQ, prompb.LabelMatcherEQ, prompb.LabelMatcherNEQ, prompb.LabelMatcherRE, prompb.LabelMatcherNRE}
+	for i, m := range toPrompbMatchers(matchers) {
+		if m.Type != exp[i] || m.Name != matchers[i].Name || m.Value != matchers[i].Value {
+			t.Fatalf("matcher %d: expected %v to be of type %d", i, m, exp[i])
+		}
+	}
+}
+
+func TestPrometheusQueryRangeErrors(t *testing.T) {
+	s := &Server{queries: newQueryList()}
+	m := macaron.New()
+	m.Use(macaron.Renderer())
+	m.Get("/prometheus/api/v1/query_range", func(c *macaron.Context) {
+		request := models.PrometheusRangeQuery{
+			Query: c.Query("query"),
+			Start: c.Query("start"),
+			End:   c.Query("end"),
+			Step:  c.Query("step"),
+		}
+		s.prometheusQueryRange(&middleware.Context{Context: c, OrgId: 5}, request)
+	})
+
+	cases := []struct {
+		query string
+		err   string
+	}{
+		{"query=up&start=100&end=50&step=15", "end timestamp must not be before start time"},
+		{"query=up&start=0&end=1000000&step=1", "exceeded maximum resolution of 11000 points per timeseries. Try decreasing the query resolution (?step=XX)"},
+		{"query=up&start=0&end=100&step=0", "zero or negative query resolution step widths are not accepted. Try a positive integer"},
+		{"query=up&start=0&end=100&step=0.0001", "query resolution step widths below 1ms are not accepted"},
+		{"query=up&start=foo&end=100&step=15", `invalid start: cannot parse "foo" to a valid timestamp`},
+		{"query=up[5m]&start=0&end=100&step=15", `invalid expression type "matrix" for range query, must be scalar or instant vector`},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "/prometheus/api/v1/query_range?"+c.query, nil)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%q: expected status 400, got %d: %s", c.query, rec.Code, rec.Body.String())
+		}
+		var resp models.PrometheusResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%q: failed to decode response %q: %s", c.query, rec.Body.String(), err)
+		}
+		if resp.Status != "error" || resp.ErrorType != "bad_data" || resp.Error != c.err {
+			t.Fatalf("%q: unexpected response %+v", c.query, resp)
+		}
+	}
+}
+
+func TestPrometheusQueryScalar(t *testing.T) {
+	s := &Server{queries: newQueryList()}
+	m := macaron.New()
+	m.Use(macaron.Renderer())
+	m.Get("/prometheus/api/v1/query", func(c *macaron.Context) {
+		s.prometheusQuery(&middleware.Context{Context: c, OrgId: 5}, models.PrometheusQuery{Query: c.Query("query"), Time: c.Query("time")})
+	})
+
+	// expressions without selectors don't need any data
+	req, _ := http.NewRequest("GET", "/prometheus/api/v1/query?query=time()*2&time=1500.5", nil)
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+	exp := `{"status":"success","data":{"resultType":"scalar","result":[1500.5,"3001"]}}`
+	if rec.Code != 200 || rec.Body.String() != exp {
+		t.Fatalf("expected status 200 and body %s, got %d and %s", exp, rec.Code, rec.Body.String())
+	}
+	if len(s.queries.list()) != 0 {
+		t.Fatalf("expected the query to be removed from the active queries")
+	}
+}
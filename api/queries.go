@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/grafana/metrictank/api/middleware"
+	"github.com/grafana/metrictank/api/response"
+)
+
+// activeQuery is a render, find, promql or opentsdb request that is being executed
+type activeQuery struct {
+	Id      uint64    `json:"id"`
+	Type    string    `json:"type"` // render, find, promql or opentsdb
+	OrgId   int       `json:"orgId"`
+	Targets []string  `json:"targets"`
+	Start   time.Time `json:"start"`
+	Phase   string    `json:"phase"` // what the query is currently doing, e.g. index, fetch or expr
+
+	phase  atomic.Value
+	cancel context.CancelFunc
+}
+
+func (q *activeQuery) setPhase(phase string) {
+	q.phase.Store(phase)
+}
+
+// queryList tracks the active queries, so they can be listed and canceled
+type queryList struct {
+	sync.Mutex
+	lastId  uint64
+	queries map[uint64]*activeQuery
+}
+
+func newQueryList() *queryList {
+	return &queryList{
+		queries: make(map[uint64]*activeQuery),
+	}
+}
+
+// errQueryCanceled is returned to the client of a query that was canceled, using nginx's "client closed request" code
+var errQueryCanceled = response.NewError(499, "query was canceled")
+
+type activeQueryKey struct{}
+
+// add tracks a new query. it returns the context to execute the query with, which gets canceled when
+// the query is canceled, and a function that must be called once the query is done
+func (ql *queryList) add(ctx context.Context, typ string, orgId int, targets []string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	q := &activeQuery{
+		Type:    typ,
+		OrgId:   orgId,
+		Targets: targets,
+		Start:   time.Now(),
+		cancel:  cancel,
+	}
+	q.setPhase("init")
+
+	ql.Lock()
+	ql.lastId++
+	q.Id = ql.lastId
+	ql.queries[q.Id] = q
+	ql.Unlock()
+
+	done := func() {
+		ql.Lock()
+		delete(ql.queries, q.Id)
+		ql.Unlock()
+		cancel()
+	}
+	return context.WithValue(ctx, activeQueryKey{}, q), done
+}
+
+// list returns the active queries, oldest first
+func (ql *queryList) list() []activeQuery {
+	ql.Lock()
+	out := make([]activeQuery, 0, len(ql.queries))
+	for _, q := range ql.queries {
+		out = append(out, activeQuery{
+			Id:      q.Id,
+			Type:    q.Type,
+			OrgId:   q.OrgId,
+			Targets: q.Targets,
+			Start:   q.Start,
+			Phase:   q.phase.Load().(string),
+		})
+	}
+	ql.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Id < out[j].Id })
+	return out
+}
+
+// cancel cancels the query with the given id. it returns false if there is no such query
+func (ql *queryList) cancel(id uint64) bool {
+	ql.Lock()
+	q, ok := ql.queries[id]
+	ql.Unlock()
+	if ok {
+		q.cancel()
+	}
+	return ok
+}
+
+// setQueryPhase records the phase of the query executed with the given context, if any
+func setQueryPhase(ctx context.Context, phase string) {
+	if q, ok := ctx.Value(activeQueryKey{}).(*activeQuery); ok {
+		q.setPhase(phase)
+	}
+}
+
+func (s *Server) listQueries(ctx *middleware.Context) {
+	response.Write(ctx, response.NewJson(200, s.queries.list(), ""))
+}
+
+func (s *Server) cancelQuery(ctx *middleware.Context) {
+	id, err := strconv.ParseUint(ctx.Params(":id"), 10, 64)
+	if err != nil {
+		response.Write(ctx, response.NewError(http.StatusBadRequest, "invalid query id"))
+		return
+	}
+	if !s.queries.cancel(id) {
+		response.Write(ctx, response.NewError(http.StatusNotFound, "query not found"))
+		return
+	}
+	response.Write(ctx, response.NewJson(200, map[string]uint64{"canceled": id}, ""))
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQueryList(t *testing.T) {
+	ql := newQueryList()
+	ctx1, done1 := ql.add(context.Background(), "render", 1, []string{"foo.*"})
+	ctx2, done2 := ql.add(context.Background(), "find", 2, []string{"bar.*"})
+	setQueryPhase(ctx1, "fetch")
+
+	queries := ql.list()
+	if len(queries) != 2 {
+		t.Fatalf("expected 2 active queries, got %d", len(queries))
+	}
+	q := queries[0]
+	if q.Id != 1 || q.Type != "render" || q.OrgId != 1 || q.Targets[0] != "foo.*" || q.Phase != "fetch" {
+		t.Fatalf("unexpected first query %+v", q)
+	}
+	if queries[1].Id != 2 || queries[1].Phase != "init" {
+		t.Fatalf("unexpected second query %+v", queries[1])
+	}
+
+	if !ql.cancel(2) {
+		t.Fatalf("expected query 2 to be canceled")
+	}
+	if ctx2.Err() != context.Canceled {
+		t.Fatalf("expected context of query 2 to be canceled, got %v", ctx2.Err())
+	}
+	if ctx1.Err() != nil {
+		t.Fatalf("expected context of query 1 not to be canceled, got %v", ctx1.Err())
+	}
+	if ql.cancel(3) {
+		t.Fatalf("expected canceling unknown query 3 to fail")
+	}
+
+	done1()
+	done2()
+	if queries := ql.list(); len(queries) != 0 {
+		t.Fatalf("expected no active queries, got %v", queries)
+	}
+}
@@ -24,10 +24,11 @@ var (
 )
 
 // alignRequests updates the requests with all details for fetching, making sure all metrics are in the same, optimal interval
-// note: it is assumed that all requests have the same from & to.
+// note: requests may have different from & to (e.g. due to timeShift), but they are assumed to span
+// similar time ranges. the longest of them determines the interval.
 // also takes a "now" value which we compare the TTL against
-func alignRequests(now, from, to uint32, reqs []models.Req) ([]models.Req, uint32, uint32, error) {
-	tsRange := to - from
+func alignRequests(now uint32, reqs []models.Req) ([]models.Req, uint32, uint32, error) {
+	var tsRange uint32
 
 	var listIntervals []uint32
 	var seenIntervals = make(map[uint32]struct{})
@@ -37,9 +38,9 @@ func alignRequests(now, from, to uint32, reqs []models.Req) ([]models.Req, uint3
 		req := &reqs[i]
 		req.Archive = -1
 		targets[req.Target] = struct{}{}
+		tsRange = util.Max(tsRange, req.To-req.From)
 	}
 	numTargets := uint32(len(targets))
-	minTTL := now - reqs[0].From
 
 	minIntervalSoft := uint32(0)
 	minIntervalHard := uint32(0)
@@ -59,6 +60,7 @@ func alignRequests(now, from, to uint32, reqs []models.Req) ([]models.Req, uint3
 	// fallback to lowest res option (which *should* have the longest TTL)
 	for i := range reqs {
 		req := &reqs[i]
+		minTTL := now - req.From
 		retentions := mdata.Schemas.Get(req.SchemaId).Retentions
 		for i, ret := range retentions {
 			// skip non-ready option.
@@ -139,7 +141,7 @@ func alignRequests(now, from, to uint32, reqs []models.Req) ([]models.Req, uint3
 				req.AggNum = interval / req.ArchInterval
 			}
 		}
-		pointsFetch += tsRange / req.ArchInterval
+		pointsFetch += (req.To - req.From) / req.ArchInterval
 		reqRenderChosenArchive.Value(req.Archive)
 	}
 
@@ -28,7 +28,7 @@ func testAlign(reqs []models.Req, retentions [][]conf.Retention, outReqs []model
 	}
 
 	mdata.Schemas = conf.NewSchemas(schemas)
-	out, _, _, err := alignRequests(now, reqs[0].From, reqs[0].To, reqs)
+	out, _, _, err := alignRequests(now, reqs)
 	if err != outErr {
 		t.Errorf("different err value expected: %v, got: %v", outErr, err)
 	}
@@ -113,6 +113,29 @@ func TestAlignRequestsAlerting(t *testing.T) {
 	)
 }
 
+// 1 series requested over 2 time ranges (e.g. via timeShift). req 1100-1130 and 0-30. now 1200.
+// the raw archive only retains the recent range, so the rollup must be used for the shifted one, and as a consequence for both.
+func TestAlignRequestsShiftedRange(t *testing.T) {
+	testAlign([]models.Req{
+		reqRaw("a", 1100, 1130, 800, 60, consolidation.Avg, 0, 0),
+		reqRaw("a", 0, 30, 800, 60, consolidation.Avg, 0, 0),
+	},
+		[][]conf.Retention{
+			{
+				conf.NewRetentionMT(60, 1150, 0, 0, true),
+				conf.NewRetentionMT(120, 1200, 0, 0, true),
+			},
+		},
+		[]models.Req{
+			reqOut("a", 1100, 1130, 800, 60, consolidation.Avg, 0, 0, 1, 120, 1200, 120, 1),
+			reqOut("a", 0, 30, 800, 60, consolidation.Avg, 0, 0, 1, 120, 1200, 120, 1),
+		},
+		nil,
+		1200,
+		t,
+	)
+}
+
 // 2 series requested with different raw intervals from different schemas. req 0-30. now 1200. neither has long enough archive. no rollups, so best effort from raw
 func TestAlignRequestsBasicBestEffort(t *testing.T) {
 	testAlign([]models.Req{
@@ -430,7 +453,7 @@ func testMaxPointsPerReq(maxPointsSoft, maxPointsHard int, reqs []models.Req, t
 		}),
 	}})
 
-	out, _, _, err := alignRequests(30*day, reqs[0].From, reqs[0].To, reqs)
+	out, _, _, err := alignRequests(30*day, reqs)
 	maxPointsPerReqSoft = origMaxPointsPerReqSoft
 	maxPointsPerReqHard = origMaxPointsPerReqHard
 	return out, err
@@ -523,7 +546,7 @@ func BenchmarkAlignRequests(b *testing.B) {
 	})
 
 	for n := 0; n < b.N; n++ {
-		res, _, _, _ = alignRequests(14*24*3600, 0, 3600*24*7, reqs)
+		res, _, _, _ = alignRequests(14*24*3600, reqs)
 	}
 	result = res
 }
@@ -0,0 +1,47 @@
+package response
+
+import (
+	"github.com/golang/snappy"
+)
+
+type ProtobufMarshaler interface {
+	Marshal() ([]byte, error)
+}
+
+// SnappyProtobuf is a snappy compressed protobuf response, as used by the prometheus remote storage protocol
+type SnappyProtobuf struct {
+	code int
+	body ProtobufMarshaler
+}
+
+func NewSnappyProtobuf(code int, body ProtobufMarshaler) *SnappyProtobuf {
+	return &SnappyProtobuf{
+		code: code,
+		body: body,
+	}
+}
+
+func (r *SnappyProtobuf) Code() int {
+	return r.code
+}
+
+func (r *SnappyProtobuf) Close() {
+	//NOOP
+	return
+}
+
+func (r *SnappyProtobuf) Body() ([]byte, error) {
+	buf, err := r.body.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, buf), nil
+}
+
+func (r *SnappyProtobuf) Headers() (headers map[string]string) {
+	headers = map[string]string{
+		"content-type":     "application/x-protobuf",
+		"content-encoding": "snappy",
+	}
+	return headers
+}
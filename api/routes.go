@@ -29,6 +29,8 @@ func (s *Server) RegisterRoutes() {
 	r.Post("/node", bind(models.NodeStatus{}), s.setNodeStatus)
 	r.Get("/debug/pprof/block", blockHandler)
 	r.Get("/debug/pprof/mutex", mutexHandler)
+	r.Get("/debug/queries", s.listQueries)
+	r.Delete("/debug/queries/:id", s.cancelQuery)
 
 	r.Get("/cluster", s.getClusterStatus)
 	r.Post("/cluster", bind(models.ClusterMembers{}), s.postClusterMembers)
@@ -39,6 +41,9 @@ func (s *Server) RegisterRoutes() {
 	r.Combo("/index/list", ready, bind(models.IndexList{})).Get(s.indexList).Post(s.indexList)
 	r.Combo("/index/delete", ready, bind(models.IndexDelete{})).Get(s.indexDelete).Post(s.indexDelete)
 	r.Combo("/index/get", ready, bind(models.IndexGet{})).Get(s.indexGet).Post(s.indexGet)
+	r.Combo("/index/tags", ready, bind(models.IndexTags{})).Get(s.indexTags).Post(s.indexTags)
+	r.Combo("/index/tag_details", ready, bind(models.IndexTagDetails{})).Get(s.indexTagDetails).Post(s.indexTagDetails)
+	r.Combo("/index/find_by_tag", ready, bind(models.IndexFindByTag{})).Get(s.indexFindByTag).Post(s.indexFindByTag)
 
 	r.Options("/*", func(ctx *macaron.Context) {
 		ctx.Write(nil)
@@ -50,4 +55,28 @@ func (s *Server) RegisterRoutes() {
 	r.Get("/metrics/index.json", withOrg, ready, s.metricsIndex)
 	r.Post("/metrics/delete", withOrg, ready, bind(models.MetricsDelete{}), s.metricsDelete)
 
+	// Graphite tag endpoints
+	r.Combo("/tags", withOrg, ready, bind(models.GraphiteTags{})).Get(s.graphiteTags).Post(s.graphiteTags)
+	r.Combo("/tags/findSeries", withOrg, ready, bind(models.GraphiteTagFindSeries{})).Get(s.graphiteTagFindSeries).Post(s.graphiteTagFindSeries)
+	r.Combo("/tags/autoComplete/tags", withOrg, ready, bind(models.GraphiteAutoCompleteTags{})).Get(s.graphiteAutoCompleteTags).Post(s.graphiteAutoCompleteTags)
+	r.Combo("/tags/autoComplete/values", withOrg, ready, bind(models.GraphiteAutoCompleteTagValues{})).Get(s.graphiteAutoCompleteTagValues).Post(s.graphiteAutoCompleteTagValues)
+	r.Combo("/tags/:tag", withOrg, ready, bind(models.GraphiteTagDetails{})).Get(s.graphiteTagDetails).Post(s.graphiteTagDetails)
+
+	// Prometheus endpoints
+	if PrometheusWriteEnabled {
+		r.Post("/prometheus/write", withOrg, ready, s.prometheusWrite)
+	}
+	r.Post("/prometheus/read", withOrg, ready, s.prometheusRead)
+	r.Combo("/prometheus/api/v1/query_range", withOrg, ready, bind(models.PrometheusRangeQuery{})).Get(s.prometheusQueryRange).Post(s.prometheusQueryRange)
+	r.Combo("/prometheus/api/v1/query", withOrg, ready, bind(models.PrometheusQuery{})).Get(s.prometheusQuery).Post(s.prometheusQuery)
+	r.Combo("/prometheus/api/v1/series", withOrg, ready, bind(models.PrometheusSeriesQuery{})).Get(s.prometheusSeries).Post(s.prometheusSeries)
+	r.Combo("/prometheus/api/v1/labels", withOrg, ready, bind(models.PrometheusLabelsQuery{})).Get(s.prometheusLabels).Post(s.prometheusLabels)
+	r.Get("/prometheus/api/v1/label/:name/values", withOrg, ready, bind(models.PrometheusLabelsQuery{}), s.prometheusLabelValues)
+
+	// OpenTSDB endpoints
+	if OpenTSDBPutEnabled {
+		r.Post("/api/put", withOrg, ready, s.openTSDBPut)
+	}
+	r.Get("/api/query", withOrg, ready, bind(models.OpenTSDBQueryForm{}), s.openTSDBQueryGet)
+	r.Post("/api/query", withOrg, ready, s.openTSDBQueryPost)
 }
@@ -4,8 +4,10 @@ package batch
 
 // aggregation functions for batches of data
 import (
-	"gopkg.in/raintank/schema.v1"
 	"math"
+	"sort"
+
+	"gopkg.in/raintank/schema.v1"
 )
 
 type AggFunc func(in []schema.Point) float64
@@ -108,3 +110,87 @@ func Sum(in []schema.Point) float64 {
 	}
 	return sum
 }
+
+// AvgZero is like Avg, but treats null values as 0
+func AvgZero(in []schema.Point) float64 {
+	if len(in) == 0 {
+		panic("avgZero() called in aggregator with 0 terms")
+	}
+	sum := float64(0)
+	for _, term := range in {
+		if !math.IsNaN(term.Val) {
+			sum += term.Val
+		}
+	}
+	return sum / float64(len(in))
+}
+
+// Med returns the median of the non-null values.
+// for an even number of values, it returns the average of the middle two
+func Med(in []schema.Point) float64 {
+	vals := make([]float64, 0, len(in))
+	for _, v := range in {
+		if !math.IsNaN(v.Val) {
+			vals = append(vals, v.Val)
+		}
+	}
+	if len(vals) == 0 {
+		return math.NaN()
+	}
+	sort.Float64s(vals)
+	mid := len(vals) / 2
+	if len(vals)%2 == 0 {
+		return (vals[mid-1] + vals[mid]) / 2
+	}
+	return vals[mid]
+}
+
+// Diff subtracts the other non-null values from the first non-null value
+func Diff(in []schema.Point) float64 {
+	diff := math.NaN()
+	for _, v := range in {
+		if math.IsNaN(v.Val) {
+			continue
+		}
+		if math.IsNaN(diff) {
+			diff = v.Val
+		} else {
+			diff -= v.Val
+		}
+	}
+	return diff
+}
+
+// Stddev returns the population standard deviation of the non-null values
+func Stddev(in []schema.Point) float64 {
+	avg := Avg(in)
+	if math.IsNaN(avg) {
+		return avg
+	}
+	valid := float64(0)
+	deviations := float64(0)
+	for _, v := range in {
+		if !math.IsNaN(v.Val) {
+			valid += 1
+			deviations += (v.Val - avg) * (v.Val - avg)
+		}
+	}
+	return math.Sqrt(deviations / valid)
+}
+
+// Range returns the difference between the highest and the lowest non-null value
+func Range(in []schema.Point) float64 {
+	return Max(in) - Min(in)
+}
+
+// Mult returns the product of the values. it is null as soon as one of the values is
+func Mult(in []schema.Point) float64 {
+	if len(in) == 0 {
+		panic("mult() called in aggregator with 0 terms")
+	}
+	mult := float64(1)
+	for _, v := range in {
+		mult *= v.Val
+	}
+	return mult
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -152,8 +153,12 @@ func (n Node) Post(ctx context.Context, name, path string, body Traceable) (ret
 		log.Error(3, "CLU failed to inject span into headers: %s", err)
 	}
 	req.Header.Add("Content-Type", "application/json")
-	rsp, err := client.Do(req)
+	rsp, err := client.Do(req.WithContext(ctx))
 	if err != nil {
+		if ctx.Err() != nil {
+			// the request was canceled, the node is fine
+			return nil, ctx.Err()
+		}
 		log.Error(3, "CLU Node: %s unreachable. %s", n.Name, err.Error())
 		return nil, NewError(http.StatusServiceUnavailable, fmt.Errorf("cluster node unavailable"))
 	}
@@ -163,7 +168,12 @@ func (n Node) Post(ctx context.Context, name, path string, body Traceable) (ret
 func handleResp(rsp *http.Response) ([]byte, error) {
 	defer rsp.Body.Close()
 	if rsp.StatusCode != 200 {
-		return nil, NewError(rsp.StatusCode, fmt.Errorf(rsp.Status))
+		// our peers describe their errors in the body
+		body, err := ioutil.ReadAll(rsp.Body)
+		if err != nil || len(body) == 0 {
+			return nil, NewError(rsp.StatusCode, errors.New(rsp.Status))
+		}
+		return nil, NewError(rsp.StatusCode, errors.New(string(body)))
 	}
 	return ioutil.ReadAll(rsp.Body)
 }
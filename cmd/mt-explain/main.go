@@ -68,7 +68,7 @@ func main() {
 		return
 	}
 
-	plan, err := expr.NewPlan(exps, fromUnix, toUnix, uint32(*mdp), *stable, nil)
+	plan, err := expr.NewPlan(exps, fromUnix, toUnix, uint32(*mdp), *stable, loc, nil)
 	if err != nil {
 		if fun, ok := err.(expr.ErrUnknownFunction); ok {
 			fmt.Printf("Unsupported function %q: must defer query to graphite\n", string(fun))
@@ -29,7 +29,7 @@ func NewAggregations() Aggregations {
 		DefaultAggregation: Aggregation{
 			Name:              "default",
 			Pattern:           regexp.MustCompile(".*"),
-			XFilesFactor:      0.5,
+			XFilesFactor:      0, // unlike graphite's 0.5, to keep the behavior from before we honored xFilesFactor
 			AggregationMethod: []Method{Avg},
 		},
 	}
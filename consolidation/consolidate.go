@@ -1,14 +1,21 @@
 package consolidation
 
 import (
+	"math"
+
+	"github.com/grafana/metrictank/batch"
 	"gopkg.in/raintank/schema.v1"
 )
 
 // Consolidate consolidates `in`, aggNum points at a time via the given function
+// groups in which the ratio of non-null points is below xFilesFactor result in a null point.
 // note: the returned slice repurposes in's backing array.
-func Consolidate(in []schema.Point, aggNum uint32, consolidator Consolidator) []schema.Point {
+func Consolidate(in []schema.Point, aggNum uint32, consolidator Consolidator, xFilesFactor float64) []schema.Point {
 	num := int(aggNum)
 	aggFunc := GetAggFunc(consolidator)
+	if xFilesFactor > 0 {
+		aggFunc = withXFilesFactor(aggFunc, xFilesFactor)
+	}
 
 	// let's see if the input data is a perfect fit for the requested aggNum
 	// (e.g. no remainder). This case is the easiest to handle
@@ -51,6 +58,23 @@ func Consolidate(in []schema.Point, aggNum uint32, consolidator Consolidator) []
 	return out
 }
 
+// withXFilesFactor wraps the aggregation function so that it returns null
+// if the ratio of non-null input points is below xFilesFactor, like graphite does.
+func withXFilesFactor(aggFunc batch.AggFunc, xFilesFactor float64) batch.AggFunc {
+	return func(in []schema.Point) float64 {
+		var known int
+		for _, p := range in {
+			if !math.IsNaN(p.Val) {
+				known++
+			}
+		}
+		if float64(known)/float64(len(in)) < xFilesFactor {
+			return math.NaN()
+		}
+		return aggFunc(in)
+	}
+}
+
 // returns how many points should be aggregated together so that you end up with as many points as possible,
 // but never more than maxPoints
 func AggEvery(numPoints, maxPoints uint32) uint32 {
@@ -63,7 +87,8 @@ func AggEvery(numPoints, maxPoints uint32) uint32 {
 // ConsolidateStable consolidates points in a "stable" way, meaning if you run the same function again so that the input
 // receives new points at the end and old points get removed at the beginning, we keep picking the same points to consolidate together
 // interval is the interval between the input points
-func ConsolidateStable(points []schema.Point, interval, maxDataPoints uint32, consolidator Consolidator) ([]schema.Point, uint32) {
+// xFilesFactor is honored as in Consolidate
+func ConsolidateStable(points []schema.Point, interval, maxDataPoints uint32, consolidator Consolidator, xFilesFactor float64) ([]schema.Point, uint32) {
 	aggNum := AggEvery(uint32(len(points)), maxDataPoints)
 	// note that the amount of points to strip is always < 1 postAggInterval's worth.
 	// there's 2 important considerations here:
@@ -89,7 +114,7 @@ func ConsolidateStable(points []schema.Point, interval, maxDataPoints uint32, co
 		_, num := nudge(points[0].Ts, interval, aggNum)
 		points = points[num:]
 	}
-	points = Consolidate(points, aggNum, consolidator)
+	points = Consolidate(points, aggNum, consolidator, xFilesFactor)
 	interval *= aggNum
 	return points, interval
 }
@@ -1,6 +1,7 @@
 package consolidation
 
 import (
+	"math"
 	"testing"
 
 	"github.com/grafana/metrictank/test"
@@ -16,7 +17,7 @@ type testCase struct {
 
 func validate(cases []testCase, t *testing.T) {
 	for i, c := range cases {
-		out := Consolidate(c.in, c.num, c.consol)
+		out := Consolidate(c.in, c.num, c.consol, 0)
 		if len(out) != len(c.out) {
 			t.Fatalf("output for testcase %d mismatch: expected: %v, got: %v", i, c.out, out)
 
@@ -30,6 +31,57 @@ func validate(cases []testCase, t *testing.T) {
 	}
 }
 
+func TestConsolidateXFilesFactor(t *testing.T) {
+	nan := math.NaN()
+	in := []schema.Point{
+		{Val: 1, Ts: 10},
+		{Val: nan, Ts: 20},
+		{Val: nan, Ts: 30},
+		{Val: 4, Ts: 40},
+		{Val: 5, Ts: 50},
+		{Val: nan, Ts: 60},
+		{Val: 7, Ts: 70},
+		{Val: nan, Ts: 80},
+		{Val: nan, Ts: 90},
+		{Val: nan, Ts: 100},
+	}
+	cases := []struct {
+		xff float64
+		out []schema.Point
+	}{
+		{
+			0,
+			[]schema.Point{{Val: 1, Ts: 30}, {Val: 9, Ts: 60}, {Val: 7, Ts: 90}, {Val: nan, Ts: 120}},
+		},
+		{
+			0.5,
+			[]schema.Point{{Val: nan, Ts: 30}, {Val: 9, Ts: 60}, {Val: nan, Ts: 90}, {Val: nan, Ts: 120}},
+		},
+		{
+			0.3,
+			[]schema.Point{{Val: 1, Ts: 30}, {Val: 9, Ts: 60}, {Val: 7, Ts: 90}, {Val: nan, Ts: 120}},
+		},
+		{
+			1,
+			[]schema.Point{{Val: nan, Ts: 30}, {Val: nan, Ts: 60}, {Val: nan, Ts: 90}, {Val: nan, Ts: 120}},
+		},
+	}
+	for i, c := range cases {
+		points := make([]schema.Point, len(in))
+		copy(points, in)
+		out := Consolidate(points, 3, Sum, c.xff)
+		if len(out) != len(c.out) {
+			t.Fatalf("case %d: output mismatch: expected: %v, got: %v", i, c.out, out)
+		}
+		for j, p := range out {
+			exp := c.out[j]
+			if p.Ts != exp.Ts || (p.Val != exp.Val && !(math.IsNaN(p.Val) && math.IsNaN(exp.Val))) {
+				t.Fatalf("case %d: output mismatch at point %d: expected: %v, got: %v", i, j, exp, p)
+			}
+		}
+	}
+}
+
 func TestOddConsolidationAlignments(t *testing.T) {
 	cases := []testCase{
 		{
@@ -338,7 +390,7 @@ func TestConsolidateStableABitMoreDataEven(t *testing.T) {
 		t)
 }
 func testConsolidateStable(in []schema.Point, inInt uint32, mdp uint32, expOut []schema.Point, expOutInt uint32, t *testing.T) {
-	out, outInt := ConsolidateStable(in, inInt, mdp, Sum)
+	out, outInt := ConsolidateStable(in, inInt, mdp, Sum, 0)
 	if outInt != expOutInt {
 		t.Fatalf("output interval mismatch: expected: %v, got: %v", expOutInt, outInt)
 	}
@@ -501,7 +553,7 @@ func benchmarkConsolidate(fn func() []schema.Point, aggNum uint32, consolidator
 		in := fn()
 		l = len(in)
 		b.StartTimer()
-		ret := Consolidate(in, aggNum, consolidator)
+		ret := Consolidate(in, aggNum, consolidator, 0)
 		dummy = ret
 	}
 	b.SetBytes(int64(l * 12))
@@ -55,6 +55,48 @@ func (e expr) Print(indent int) string {
 	return "HUH-SHOULD-NEVER-HAPPEN"
 }
 
+// ExprTree is the parsed form of an expression, as presented to users to explain how their query is executed
+type ExprTree struct {
+	Type      string              `json:"type"`  // name, bool, func, int, float or string
+	Value     string              `json:"value"` // the metric pattern, function name or the literal value
+	Args      []ExprTree          `json:"args,omitempty"`
+	NamedArgs map[string]ExprTree `json:"namedArgs,omitempty"`
+}
+
+// Tree returns the parsed form of the expression
+func (e expr) Tree() ExprTree {
+	t := ExprTree{
+		Type:  strings.ToLower(strings.TrimPrefix(e.etype.String(), "et")),
+		Value: e.str,
+	}
+	for _, a := range e.args {
+		t.Args = append(t.Args, a.Tree())
+	}
+	if len(e.namedArgs) > 0 {
+		t.NamedArgs = make(map[string]ExprTree, len(e.namedArgs))
+		for k, v := range e.namedArgs {
+			t.NamedArgs[k] = v.Tree()
+		}
+	}
+	return t
+}
+
+// depth returns the number of levels of the expression tree, e.g. 1 for a series name, 2 for sum(foo)
+func (e expr) depth() int {
+	var max int
+	for _, a := range e.args {
+		if d := a.depth(); d > max {
+			max = d
+		}
+	}
+	for _, a := range e.namedArgs {
+		if d := a.depth(); d > max {
+			max = d
+		}
+	}
+	return max + 1
+}
+
 // consumeBasicArg verifies that the argument at given pos matches the expected arg
 // it's up to the caller to assure that given pos is valid before calling.
 // if arg allows for multiple arguments, pos is advanced to cover all accepted arguments.
@@ -127,6 +169,46 @@ func (e expr) consumeBasicArg(pos int, exp Arg) (int, error) {
 			}
 		}
 		*v.val = got.str
+	case ArgStrings:
+		if got.etype != etString {
+			return 0, ErrBadArgumentStr{"string", got.etype.String()}
+		}
+		for _, va := range v.validator {
+			if err := va(got); err != nil {
+				return 0, fmt.Errorf("%s: %s", v.key, err.Error())
+			}
+		}
+		*v.val = append(*v.val, got.str)
+		// special case! consume all subsequent args (if any) in args that will also yield a string
+		for len(e.args) > pos+1 && e.args[pos+1].etype == etString {
+			pos += 1
+			for _, va := range v.validator {
+				if err := va(e.args[pos]); err != nil {
+					return 0, fmt.Errorf("%s: %s", v.key, err.Error())
+				}
+			}
+			*v.val = append(*v.val, e.args[pos].str)
+		}
+	case ArgStringsOrInts:
+		if got.etype != etString && got.etype != etInt {
+			return 0, ErrBadArgumentStr{"string or int", got.etype.String()}
+		}
+		for _, va := range v.validator {
+			if err := va(got); err != nil {
+				return 0, fmt.Errorf("%s: %s", v.key, err.Error())
+			}
+		}
+		*v.val = append(*v.val, *got)
+		// special case! consume all subsequent args (if any) in args that will also yield a string or an integer
+		for len(e.args) > pos+1 && (e.args[pos+1].etype == etString || e.args[pos+1].etype == etInt) {
+			pos += 1
+			for _, va := range v.validator {
+				if err := va(e.args[pos]); err != nil {
+					return 0, fmt.Errorf("%s: %s", v.key, err.Error())
+				}
+			}
+			*v.val = append(*v.val, *e.args[pos])
+		}
 	case ArgRegex:
 		if got.etype != etString {
 			return 0, ErrBadArgumentStr{"string (regex)", string(got.etype)}
@@ -146,6 +228,19 @@ func (e expr) consumeBasicArg(pos int, exp Arg) (int, error) {
 			return 0, ErrBadArgumentStr{"string", string(got.etype)}
 		}
 		*v.val = got.bool
+	case ArgIn:
+		if got.isNone() {
+			break
+		}
+		var types []string
+		for _, a := range v.args {
+			// for series args, this only validates the type. they are set up by consumeSeriesArg
+			if next, err := e.consumeBasicArg(pos, a); err == nil {
+				return next, nil
+			}
+			types = append(types, fmt.Sprintf("%T", a))
+		}
+		return 0, ErrBadArgumentStr{strings.Join(types, " or "), got.etype.String()}
 	default:
 		return 0, fmt.Errorf("unsupported type %T for consumeBasicArg", exp)
 	}
@@ -201,6 +296,14 @@ func (e expr) consumeSeriesArg(pos int, exp Arg, context Context, stable bool, r
 			}
 			*v.val = append(*v.val, fn)
 		}
+	case ArgIn:
+		for _, a := range v.args {
+			switch a.(type) {
+			case ArgSeries, ArgSeriesList, ArgSeriesLists:
+				return e.consumeSeriesArg(pos, a, context, stable, reqs)
+			}
+		}
+		return 0, nil, fmt.Errorf("no series type in %T for consumeSeriesArg", exp)
 	default:
 		return 0, nil, fmt.Errorf("unsupported type %T for consumeSeriesArg", exp)
 	}
@@ -223,8 +326,18 @@ func (e expr) consumeKwarg(key string, optArgs []Arg) error {
 	if !found {
 		return ErrUnknownKwarg{key}
 	}
-	got := e.namedArgs[key]
+	return consumeKwargVal(key, exp, e.namedArgs[key])
+}
+
+// consumeKwargVal verifies the value given for the keyword argument with the given key,
+// and saves it in exp.val
+func consumeKwargVal(key string, exp Arg, got *expr) error {
 	switch v := exp.(type) {
+	case ArgSeries, ArgSeriesList:
+		// like with positional args, series are set up by consumeSeriesArg
+		if got.etype != etName && got.etype != etFunc {
+			return ErrBadKwarg{key, exp, got.etype}
+		}
 	case ArgInt:
 		if got.etype != etInt {
 			return ErrBadKwarg{key, exp, got.etype}
@@ -240,6 +353,11 @@ func (e expr) consumeKwarg(key string, optArgs []Arg) error {
 		default:
 			return ErrBadKwarg{key, exp, got.etype}
 		}
+		for _, va := range v.validator {
+			if err := va(got); err != nil {
+				return fmt.Errorf("%s: %s", v.key, err.Error())
+			}
+		}
 	case ArgString:
 		if got.etype != etString {
 			return ErrBadKwarg{key, exp, got.etype}
@@ -250,8 +368,36 @@ func (e expr) consumeKwarg(key string, optArgs []Arg) error {
 			return ErrBadKwarg{key, exp, got.etype}
 		}
 		*v.val = got.bool
+	case ArgIn:
+		if got.isNone() {
+			return nil
+		}
+		for _, a := range v.args {
+			if consumeKwargVal(key, a, got) == nil {
+				return nil
+			}
+		}
+		return ErrBadKwarg{key, exp, got.etype}
 	default:
 		return fmt.Errorf("unsupported type %T for consumeKwarg", exp)
 	}
 	return nil
 }
+
+// isNone returns whether the expression is the special value None,
+// which graphite uses for unset optional arguments
+func (e expr) isNone() bool {
+	return e.etype == etName && e.str == "None"
+}
+
+// isSeriesArg returns whether the argument at given pos, matching the expected arg, provides series
+func (e expr) isSeriesArg(pos int, exp Arg) bool {
+	switch exp.(type) {
+	case ArgSeries, ArgSeriesList, ArgSeriesLists:
+		return true
+	case ArgIn:
+		got := e.args[pos]
+		return got.etype == etFunc || (got.etype == etName && !got.isNone())
+	}
+	return false
+}
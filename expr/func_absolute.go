@@ -0,0 +1,38 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+type FuncAbsolute struct {
+	in GraphiteFunc
+}
+
+func NewAbsolute() GraphiteFunc {
+	return &FuncAbsolute{}
+}
+
+func (s *FuncAbsolute) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncAbsolute) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncAbsolute) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []models.Series
+	for _, serie := range series {
+		outputs = append(outputs, transformSerie(cache, serie, fmt.Sprintf("absolute(%s)", serie.Target), math.Abs))
+	}
+	return outputs, nil
+}
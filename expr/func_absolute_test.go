@@ -0,0 +1,28 @@
+package expr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAbsolute(t *testing.T) {
+	nan := math.NaN()
+	inf := math.Inf(1)
+	cases := []struct {
+		name string
+		in   []float64
+		out  []float64
+	}{
+		{"mixed", transformVals, []float64{2, 0, nan, 0.5, 4, 100}},
+		{"infinite", []float64{-inf, inf}, []float64{inf, inf}},
+		{"negative zero", []float64{math.Copysign(0, -1)}, []float64{0}},
+		{"all null", []float64{nan, nan}, []float64{nan, nan}},
+		{"empty", []float64{}, []float64{}},
+	}
+	for _, c := range cases {
+		in := getTransformInput(c.in)
+		f := NewAbsolute()
+		f.(*FuncAbsolute).in = NewMock(in)
+		testTransform("absolute "+c.name, f, in, "absolute(foo)", c.out, t)
+	}
+}
@@ -0,0 +1,59 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+// FuncAggregate combines all input series into one, using any of the batch aggregation functions
+type FuncAggregate struct {
+	in           GraphiteFunc
+	fn           string
+	xFilesFactor float64
+}
+
+func NewAggregate() GraphiteFunc {
+	return &FuncAggregate{}
+}
+
+func (s *FuncAggregate) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgString{key: "func", val: &s.fn, validator: []Validator{IsAggFunc}},
+		ArgFloat{key: "xFilesFactor", opt: true, val: &s.xFilesFactor, validator: []Validator{IsXFilesFactor}},
+	}, []Arg{ArgSeries{}}
+}
+
+func (s *FuncAggregate) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncAggregate) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, _, err := consumeFuncs(cache, []GraphiteFunc{s.in})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(series) == 0 {
+		return series, nil
+	}
+	series = normalize(cache, series)
+
+	out := pointSlicePool.Get().([]schema.Point)
+	crossSeriesBatch(getBatchAggFunc(s.fn), s.xFilesFactor)(series, &out)
+	name := fmt.Sprintf("%sSeries(%s)", s.fn, strings.Join(uniqueQueryPatts(series), ","))
+	cons, queryCons := summarizeCons(series)
+	output := models.Series{
+		Target:       name,
+		QueryPatt:    name,
+		Datapoints:   out,
+		Interval:     series[0].Interval,
+		Consolidator: cons,
+		QueryCons:    queryCons,
+	}
+	cache[Req{}] = append(cache[Req{}], output)
+	return []models.Series{output}, nil
+}
@@ -0,0 +1,101 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestAggregate(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		fn           string
+		xFilesFactor float64
+		out          []schema.Point
+	}{
+		{
+			"avg_zero",
+			0,
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 3.25, Ts: 30}, {Val: 1, Ts: 40}, {Val: 1.5, Ts: 50}, {Val: 617283947, Ts: 60}},
+		},
+		{
+			"average",
+			1,
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 3.25, Ts: 30}, {Val: nan, Ts: 40}, {Val: nan, Ts: 50}, {Val: 617283947, Ts: 60}},
+		},
+		{
+			"count",
+			0,
+			[]schema.Point{{Val: 2, Ts: 10}, {Val: 2, Ts: 20}, {Val: 2, Ts: 30}, {Val: 1, Ts: 40}, {Val: 1, Ts: 50}, {Val: 2, Ts: 60}},
+		},
+		{
+			"diff",
+			0,
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 4.5, Ts: 30}, {Val: 2, Ts: 40}, {Val: 3, Ts: 50}, {Val: 1234567886, Ts: 60}},
+		},
+		{
+			"last",
+			0,
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 1, Ts: 30}, {Val: 2, Ts: 40}, {Val: 3, Ts: 50}, {Val: 4, Ts: 60}},
+		},
+		{
+			"median",
+			0,
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 3.25, Ts: 30}, {Val: 2, Ts: 40}, {Val: 3, Ts: 50}, {Val: 617283947, Ts: 60}},
+		},
+		{
+			"multiply",
+			0,
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 5.5, Ts: 30}, {Val: nan, Ts: 40}, {Val: nan, Ts: 50}, {Val: 4938271560, Ts: 60}},
+		},
+		{
+			"range",
+			0,
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 4.5, Ts: 30}, {Val: 0, Ts: 40}, {Val: 0, Ts: 50}, {Val: 1234567886, Ts: 60}},
+		},
+		{
+			"stddev",
+			0,
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 2.25, Ts: 30}, {Val: 0, Ts: 40}, {Val: 0, Ts: 50}, {Val: 617283943, Ts: 60}},
+		},
+	}
+	for _, cas := range cases {
+		f := NewAggregate()
+		agg := f.(*FuncAggregate)
+		agg.in = NewMock([]models.Series{
+			{Target: "a", QueryPatt: "foo", Interval: 10, Datapoints: getCopy(a)},
+			{Target: "c", QueryPatt: "foo", Interval: 10, Datapoints: getCopy(c)},
+		})
+		agg.fn = cas.fn
+		agg.xFilesFactor = cas.xFilesFactor
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("case %q: err should be nil. got %q", cas.fn, err)
+		}
+		if len(got) != 1 || got[0].Target != cas.fn+"Series(foo)" {
+			t.Fatalf("case %q: expected 1 series named %sSeries(foo), got %v", cas.fn, cas.fn, got)
+		}
+		checkPoints(cas.fn, got[0].Datapoints, cas.out, t)
+	}
+}
+
+func TestAggregateNaming(t *testing.T) {
+	f := NewAggregate()
+	agg := f.(*FuncAggregate)
+	agg.in = NewMock([]models.Series{
+		{Target: "a", QueryPatt: "foo.*", Interval: 10, Datapoints: getCopy(a)},
+		{Target: "b", QueryPatt: "bar.*", Interval: 10, Datapoints: getCopy(b)},
+		{Target: "c", QueryPatt: "foo.*", Interval: 10, Datapoints: getCopy(c)},
+	})
+	agg.fn = "sum"
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	exp := "sumSeries(foo.*,bar.*)"
+	if len(got) != 1 || got[0].Target != exp || got[0].QueryPatt != exp {
+		t.Fatalf("expected 1 series named %s, got %v", exp, got)
+	}
+}
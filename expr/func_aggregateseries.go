@@ -0,0 +1,61 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+// FuncAggregateSeries combines all input series into one, using a cross series aggregation function
+type FuncAggregateSeries struct {
+	in         []GraphiteFunc
+	name       string
+	aggregator string
+}
+
+// NewAggregateSeriesConstructor returns a constructor for a function with the given name, that combines all
+// input series into one, using the given aggregation function (as accepted by getCrossSeriesAggFunc)
+func NewAggregateSeriesConstructor(name, aggregator string) func() GraphiteFunc {
+	return func() GraphiteFunc {
+		return &FuncAggregateSeries{name: name, aggregator: aggregator}
+	}
+}
+
+func (s *FuncAggregateSeries) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesLists{val: &s.in},
+	}, []Arg{ArgSeries{}}
+}
+
+func (s *FuncAggregateSeries) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncAggregateSeries) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, queryPatts, err := consumeFuncs(cache, s.in)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(series) == 0 {
+		return series, nil
+	}
+	series = normalize(cache, series)
+
+	out := pointSlicePool.Get().([]schema.Point)
+	getCrossSeriesAggFunc(s.aggregator)(series, &out)
+	name := fmt.Sprintf("%s(%s)", s.name, strings.Join(queryPatts, ","))
+	cons, queryCons := summarizeCons(series)
+	output := models.Series{
+		Target:       name,
+		QueryPatt:    name,
+		Datapoints:   out,
+		Interval:     series[0].Interval,
+		Consolidator: cons,
+		QueryCons:    queryCons,
+	}
+	cache[Req{}] = append(cache[Req{}], output)
+	return []models.Series{output}, nil
+}
@@ -0,0 +1,59 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestAggregateSeries(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		name       string
+		aggregator string
+		out        []schema.Point
+	}{
+		{
+			"diffSeries",
+			"diff",
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 4.5, Ts: 30}, {Val: 2, Ts: 40}, {Val: 3, Ts: 50}, {Val: 1234567886, Ts: 60}},
+		},
+		{
+			"multiplySeries",
+			"multiply",
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 5.5, Ts: 30}, {Val: nan, Ts: 40}, {Val: nan, Ts: 50}, {Val: 4938271560, Ts: 60}},
+		},
+		{
+			"minSeries",
+			"min",
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 1, Ts: 30}, {Val: 2, Ts: 40}, {Val: 3, Ts: 50}, {Val: 4, Ts: 60}},
+		},
+		{
+			"rangeSeries",
+			"range",
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 4.5, Ts: 30}, {Val: 0, Ts: 40}, {Val: 0, Ts: 50}, {Val: 1234567886, Ts: 60}},
+		},
+		{
+			"stddevSeries",
+			"stddev",
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 2.25, Ts: 30}, {Val: 0, Ts: 40}, {Val: 0, Ts: 50}, {Val: 617283943, Ts: 60}},
+		},
+	}
+	for _, cas := range cases {
+		f := NewAggregateSeriesConstructor(cas.name, cas.aggregator)()
+		f.(*FuncAggregateSeries).in = []GraphiteFunc{
+			NewMock([]models.Series{{Target: "a", QueryPatt: "a", Interval: 10, Datapoints: getCopy(a)}}),
+			NewMock([]models.Series{{Target: "c", QueryPatt: "c", Interval: 10, Datapoints: getCopy(c)}}),
+		}
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("case %q: err should be nil. got %q", cas.name, err)
+		}
+		if len(got) != 1 || got[0].Target != cas.name+"(a,c)" {
+			t.Fatalf("case %q: expected 1 series named %s(a,c), got %v", cas.name, cas.name, got)
+		}
+		checkPoints(cas.name, got[0].Datapoints, cas.out, t)
+	}
+}
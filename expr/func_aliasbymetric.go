@@ -0,0 +1,40 @@
+package expr
+
+import (
+	"strings"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+type FuncAliasByMetric struct {
+	in GraphiteFunc
+}
+
+func NewAliasByMetric() GraphiteFunc {
+	return &FuncAliasByMetric{}
+}
+
+func (s *FuncAliasByMetric) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncAliasByMetric) Context(context Context) Context {
+	return context
+}
+
+// Exec names each series after the last node of its metric name, without any tags
+func (s *FuncAliasByMetric) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	for i, serie := range series {
+		name := strings.SplitN(serie.Target, ";", 2)[0]
+		name = aggKey(models.Series{Target: name}, []int64{-1})
+		series[i].Target = name
+		series[i].QueryPatt = name
+	}
+	return series, nil
+}
@@ -0,0 +1,21 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+func TestAliasByMetric(t *testing.T) {
+	f := NewAliasByMetric()
+	f.(*FuncAliasByMetric).in = NewMock([]models.Series{
+		{Target: "servers.a.cpu.used"},
+		{Target: "scale(servers.b.mem.free,2.000000)"},
+		{Target: "disk.used;dc=west;host=a"},
+	})
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	checkTargets("aliasByMetric", got, []string{"used", "free", "used"}, t)
+}
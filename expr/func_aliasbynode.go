@@ -32,22 +32,28 @@ func (s *FuncAliasByNode) Exec(cache map[Req][]models.Series) ([]models.Series,
 		return nil, err
 	}
 	for i, serie := range series {
-		metric := extractMetric(serie.Target)
-		parts := strings.Split(metric, ".")
-		var name []string
-		for _, n64 := range s.nodes {
-			n := int(n64)
-			if n < 0 {
-				n += len(parts)
-			}
-			if n >= len(parts) || n < 0 {
-				continue
-			}
-			name = append(name, parts[n])
-		}
-		n := strings.Join(name, ".")
+		n := aggKey(serie, s.nodes)
 		series[i].Target = n
 		series[i].QueryPatt = n
 	}
 	return series, nil
 }
+
+// aggKey returns the given nodes of the metric name of the series, joined by dots.
+// negative nodes count from the end of the name, nodes out of range are skipped.
+func aggKey(serie models.Series, nodes []int64) string {
+	metric := extractMetric(serie.Target)
+	parts := strings.Split(metric, ".")
+	var name []string
+	for _, n64 := range nodes {
+		n := int(n64)
+		if n < 0 {
+			n += len(parts)
+		}
+		if n >= len(parts) || n < 0 {
+			continue
+		}
+		name = append(name, parts[n])
+	}
+	return strings.Join(name, ".")
+}
@@ -0,0 +1,72 @@
+package expr
+
+import (
+	"strings"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+type FuncAliasByTags struct {
+	in   GraphiteFunc
+	tags []expr
+}
+
+func NewAliasByTags() GraphiteFunc {
+	return &FuncAliasByTags{}
+}
+
+func (s *FuncAliasByTags) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgStringsOrInts{key: "tags", val: &s.tags},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncAliasByTags) Context(context Context) Context {
+	return context
+}
+
+// Exec names each series after the values of the given tags, joined by dots.
+// numbers select a node of the metric name, like aliasByNode. tags that the series doesn't have are skipped.
+func (s *FuncAliasByTags) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	for i, serie := range series {
+		tags := seriesTags(serie)
+		var name []string
+		for _, tag := range s.tags {
+			if tag.etype == etInt {
+				if node := aggKey(models.Series{Target: tags["name"]}, []int64{tag.int}); node != "" {
+					name = append(name, node)
+				}
+				continue
+			}
+			if val, ok := tags[tag.str]; ok {
+				name = append(name, val)
+			}
+		}
+		series[i].Target = strings.Join(name, ".")
+		series[i].QueryPatt = series[i].Target
+	}
+	return series, nil
+}
+
+// seriesTags returns the tags of the series, including its name.
+// for series that don't carry their tags (e.g. function output), they are parsed from the name, like graphite does:
+// name;tag1=value1;tag2=value2
+func seriesTags(serie models.Series) map[string]string {
+	if serie.Tags != nil {
+		return serie.Tags
+	}
+	parts := strings.Split(serie.Target, ";")
+	tags := make(map[string]string, len(parts))
+	tags["name"] = parts[0]
+	for _, part := range parts[1:] {
+		if i := strings.Index(part, "="); i != -1 {
+			tags[part[:i]] = part[i+1:]
+		}
+	}
+	return tags
+}
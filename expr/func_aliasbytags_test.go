@@ -0,0 +1,31 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+func TestAliasByTags(t *testing.T) {
+	e, _, err := Parse("aliasByTags(foo, 'dc', 1, 'missing', 'host')")
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	plan, err := NewPlan([]*expr{e}, 10, 61, 800, true, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to plan: %s", err)
+	}
+	input := map[Req][]models.Series{
+		NewReq("foo", 10, 61, 0): {
+			// tags of fetched series come from the index
+			{Target: "disk.used", QueryPatt: "foo", Tags: map[string]string{"name": "disk.used", "dc": "west", "host": "a"}},
+			// otherwise they are parsed from the name
+			{Target: "mem.free;dc=east;host=b", QueryPatt: "foo"},
+		},
+	}
+	got, err := plan.Run(input)
+	if err != nil {
+		t.Fatalf("failed to run: %s", err)
+	}
+	checkTargets("aliasByTags", got, []string{"west.used.a", "east.free.b"}, t)
+}
@@ -0,0 +1,120 @@
+package expr
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+var errApplyByNodeInput = errors.New("applyByNode requires a metric pattern with at least nodeNum+1 nodes as input")
+
+// FuncApplyByNode evaluates the template function for each distinct prefix of the input series, up to the given node.
+// in the template, % is replaced by the prefix.
+// we need to request the data for the template before we know the prefixes, so we plan it with the prefix of the input pattern instead,
+// and give each evaluation of the template only the series that start with its prefix.
+type FuncApplyByNode struct {
+	in            GraphiteFunc
+	node          int64
+	template      string
+	newName       string
+	prefixPattern string       // the prefix of the input pattern, which we replaced % with to plan the template
+	templateFn    GraphiteFunc // the template, as planned with the prefix pattern
+	templateReqs  []Req        // the requests of the template
+}
+
+func NewApplyByNode() GraphiteFunc {
+	return &FuncApplyByNode{}
+}
+
+func (s *FuncApplyByNode) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgInt{key: "nodeNum", val: &s.node},
+		ArgString{key: "templateFunction", val: &s.template},
+		ArgString{key: "newName", opt: true, val: &s.newName},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncApplyByNode) Context(context Context) Context {
+	return context
+}
+
+// planTemplate adds the requests needed by the template.
+// it must be called once our input has been set up, as we derive the prefix pattern from it
+func (s *FuncApplyByNode) planTemplate(context Context, stable bool, reqs []Req) ([]Req, error) {
+	get, ok := s.in.(FuncGet)
+	if !ok {
+		return nil, errApplyByNodeInput
+	}
+	nodes := strings.Split(get.req.Query, ".")
+	if s.node < 0 || int(s.node) >= len(nodes) {
+		return nil, errApplyByNodeInput
+	}
+	s.prefixPattern = strings.Join(nodes[:s.node+1], ".")
+	e, _, err := Parse(strings.Replace(s.template, "%", s.prefixPattern, -1))
+	if err != nil {
+		return nil, err
+	}
+	numReqs := len(reqs)
+	s.templateFn, reqs, err = newplan(e, context, stable, reqs)
+	if err != nil {
+		return nil, err
+	}
+	s.templateReqs = reqs[numReqs:]
+	return reqs, nil
+}
+
+func (s *FuncApplyByNode) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{})
+	var prefixes []string
+	for _, serie := range series {
+		nodes := strings.Split(serie.Target, ".")
+		if int(s.node) >= len(nodes) {
+			continue
+		}
+		prefix := strings.Join(nodes[:s.node+1], ".")
+		if _, ok := seen[prefix]; !ok {
+			seen[prefix] = struct{}{}
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Strings(prefixes)
+
+	var outputs []models.Series
+	for _, prefix := range prefixes {
+		prefixCache := make(map[Req][]models.Series)
+		for _, r := range s.templateReqs {
+			for _, serie := range cache[r] {
+				if serie.Target != prefix && !strings.HasPrefix(serie.Target, prefix+".") {
+					continue
+				}
+				// make the series look like they were requested with the prefix, rather than the prefix pattern
+				if strings.HasPrefix(r.Query, s.prefixPattern) {
+					serie.QueryPatt = prefix + strings.TrimPrefix(r.Query, s.prefixPattern)
+				}
+				prefixCache[r] = append(prefixCache[r], serie)
+			}
+		}
+		applied, err := s.templateFn.Exec(prefixCache)
+		// any series generated by the template must be reclaimed with ours
+		cache[Req{}] = append(cache[Req{}], prefixCache[Req{}]...)
+		if err != nil {
+			return nil, err
+		}
+		for _, serie := range applied {
+			if s.newName != "" {
+				serie.Target = strings.Replace(s.newName, "%", prefix, -1)
+				serie.QueryPatt = serie.Target
+			}
+			outputs = append(outputs, serie)
+		}
+	}
+	return outputs, nil
+}
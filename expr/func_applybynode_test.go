@@ -0,0 +1,119 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestApplyByNode(t *testing.T) {
+	from := uint32(10)
+	to := uint32(61)
+	cases := []struct {
+		target  string
+		expReqs []Req
+		exp     []string
+	}{
+		{
+			"applyByNode(servers.*.cpu.total, 1, 'divideSeries(%.cpu.used, %.cpu.total)')",
+			[]Req{NewReq("servers.*.cpu.total", from, to, 0), NewReq("servers.*.cpu.used", from, to, 0), NewReq("servers.*.cpu.total", from, to, 0)},
+			[]string{"divideSeries(servers.a.cpu.used,servers.a.cpu.total)", "divideSeries(servers.b.cpu.used,servers.b.cpu.total)"},
+		},
+		{
+			"applyByNode(servers.*.cpu.total, 1, 'sumSeries(%.cpu.*)', '%.cpu.sum')",
+			[]Req{NewReq("servers.*.cpu.total", from, to, 0), NewReq("servers.*.cpu.*", from, to, 0)},
+			[]string{"servers.a.cpu.sum", "servers.b.cpu.sum"},
+		},
+	}
+	series := map[string][]models.Series{
+		"total": {
+			{Target: "servers.b.cpu.total", Interval: 10, Datapoints: getCopy(c)},
+			{Target: "servers.a.cpu.total", Interval: 10, Datapoints: getCopy(c)},
+		},
+		"used": {
+			{Target: "servers.a.cpu.used", Interval: 10, Datapoints: getCopy(a)},
+			{Target: "servers.b.cpu.used", Interval: 10, Datapoints: getCopy(d)},
+		},
+	}
+	for i, cas := range cases {
+		e, _, err := Parse(cas.target)
+		if err != nil {
+			t.Fatalf("case %d: failed to parse: %s", i, err)
+		}
+		plan, err := NewPlan([]*expr{e}, from, to, 800, true, nil, nil)
+		if err != nil {
+			t.Fatalf("case %d: failed to plan: %s", i, err)
+		}
+		if len(plan.Reqs) != len(cas.expReqs) {
+			t.Fatalf("case %d: expected reqs %v, got %v", i, cas.expReqs, plan.Reqs)
+		}
+		input := make(map[Req][]models.Series)
+		for j, r := range plan.Reqs {
+			if r != cas.expReqs[j] {
+				t.Fatalf("case %d: expected reqs %v, got %v", i, cas.expReqs, plan.Reqs)
+			}
+			if _, ok := input[r]; ok {
+				continue
+			}
+			for _, kind := range []string{"total", "used"} {
+				if r.Query == "servers.*.cpu."+kind || r.Query == "servers.*.cpu.*" {
+					for _, serie := range series[kind] {
+						serie.QueryPatt = r.Query
+						input[r] = append(input[r], serie)
+					}
+				}
+			}
+		}
+		got, err := plan.Run(input)
+		if err != nil {
+			t.Fatalf("case %d: failed to run: %s", i, err)
+		}
+		checkTargets(cas.target, got, cas.exp, t)
+	}
+}
+
+func TestApplyByNodeInput(t *testing.T) {
+	for _, target := range []string{
+		"applyByNode(sumSeries(foo.*), 0, '%.bar')",
+		"applyByNode(foo.*, 2, '%.bar')",
+	} {
+		e, _, err := Parse(target)
+		if err != nil {
+			t.Fatalf("%q: failed to parse: %s", target, err)
+		}
+		_, err = NewPlan([]*expr{e}, 10, 61, 800, true, nil, nil)
+		if err != errApplyByNodeInput {
+			t.Fatalf("%q: expected error %q, got %v", target, errApplyByNodeInput, err)
+		}
+	}
+}
+
+// TestApplyByNodeValues checks that each evaluation of the template only gets the series of its prefix
+func TestApplyByNodeValues(t *testing.T) {
+	e, _, err := Parse("applyByNode(servers.*.cpu, 1, 'scale(%.cpu, 2)')")
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	plan, err := NewPlan([]*expr{e}, 10, 61, 800, true, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to plan: %s", err)
+	}
+	req := NewReq("servers.*.cpu", 10, 61, 0)
+	input := map[Req][]models.Series{
+		req: {
+			{Target: "servers.a.cpu", QueryPatt: "servers.*.cpu", Interval: 10, Datapoints: getCopy(c)},
+			{Target: "servers.b.cpu", QueryPatt: "servers.*.cpu", Interval: 10, Datapoints: getCopy(d)},
+		},
+	}
+	got, err := plan.Run(input)
+	if err != nil {
+		t.Fatalf("failed to run: %s", err)
+	}
+	checkTargets("applyByNode", got, []string{"scale(servers.a.cpu,2.000000)", "scale(servers.b.cpu,2.000000)"}, t)
+	exp := make([]schema.Point, len(c))
+	for i, p := range c {
+		exp[i] = schema.Point{Val: p.Val * 2, Ts: p.Ts}
+	}
+	checkPoints("applyByNode", got[0].Datapoints, exp, t)
+}
@@ -0,0 +1,178 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+var errAsPercentTotal = errors.New("asPercent total must be missing, a number, a single series or as many series as the seriesList")
+
+type FuncAsPercent struct {
+	in          GraphiteFunc
+	totalFloat  float64      // NaN if not set
+	totalSeries GraphiteFunc // nil if not set
+	nodes       []int64
+}
+
+func NewAsPercent() GraphiteFunc {
+	return &FuncAsPercent{totalFloat: math.NaN()}
+}
+
+func (s *FuncAsPercent) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgIn{key: "total", opt: true, args: []Arg{
+			ArgFloat{val: &s.totalFloat},
+			ArgSeriesList{val: &s.totalSeries},
+		}},
+		ArgInts{key: "nodes", opt: true, val: &s.nodes},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncAsPercent) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncAsPercent) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	if len(series) == 0 {
+		return series, nil
+	}
+
+	var totals []models.Series
+	if s.totalSeries != nil {
+		totals, err = s.totalSeries.Exec(cache)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !math.IsNaN(s.totalFloat) && len(s.nodes) > 0 {
+		return nil, errors.New("asPercent total cannot be a number when nodes are specified")
+	}
+
+	all := normalize(cache, append(append([]models.Series{}, series...), totals...))
+	series, totals = all[:len(series)], all[len(series):]
+
+	if len(s.nodes) > 0 {
+		return s.execNodes(cache, series, totals), nil
+	}
+
+	var outputs []models.Series
+	switch {
+	case !math.IsNaN(s.totalFloat):
+		for _, serie := range series {
+			outputs = append(outputs, asPercent(cache, serie, nil, s.totalFloat, fmt.Sprintf("%g", s.totalFloat)))
+		}
+	case s.totalSeries == nil:
+		total := sumTotal(cache, series)
+		for _, serie := range series {
+			outputs = append(outputs, asPercent(cache, serie, total.Datapoints, 0, total.Target))
+		}
+	case len(totals) == 1:
+		for _, serie := range series {
+			outputs = append(outputs, asPercent(cache, serie, totals[0].Datapoints, 0, totals[0].Target))
+		}
+	case len(totals) == len(series):
+		// like graphite, we match up the series and the totals by name
+		sort.Sort(models.SeriesByTarget(series))
+		sort.Sort(models.SeriesByTarget(totals))
+		for i, serie := range series {
+			outputs = append(outputs, asPercent(cache, serie, totals[i].Datapoints, 0, totals[i].Target))
+		}
+	default:
+		return nil, errAsPercentTotal
+	}
+	return outputs, nil
+}
+
+// execNodes groups the series (and the totals, if given) by the given nodes,
+// and computes each series as a percentage of the total of its group.
+// without total series, the total of a group is the sum of its series.
+func (s *FuncAsPercent) execNodes(cache map[Req][]models.Series, series, totals []models.Series) []models.Series {
+	totalsByKey := make(map[string][]models.Series)
+	for _, total := range totals {
+		key := aggKey(total, s.nodes)
+		totalsByKey[key] = append(totalsByKey[key], total)
+	}
+	if s.totalSeries == nil {
+		for _, serie := range series {
+			key := aggKey(serie, s.nodes)
+			totalsByKey[key] = append(totalsByKey[key], serie)
+		}
+	}
+
+	sums := make(map[string]models.Series)
+	var outputs []models.Series
+	for _, serie := range series {
+		key := aggKey(serie, s.nodes)
+		group, ok := totalsByKey[key]
+		if !ok {
+			outputs = append(outputs, asPercent(cache, serie, nil, math.NaN(), "MISSING"))
+			continue
+		}
+		total, ok := sums[key]
+		if !ok {
+			total = group[0]
+			if len(group) > 1 {
+				total = sumTotal(cache, group)
+			}
+			sums[key] = total
+		}
+		outputs = append(outputs, asPercent(cache, serie, total.Datapoints, 0, total.Target))
+	}
+	return outputs
+}
+
+// sumTotal returns the sum of the given series, to be used as total
+func sumTotal(cache map[Req][]models.Series, series []models.Series) models.Series {
+	name := fmt.Sprintf("sumSeries(%s)", strings.Join(uniqueQueryPatts(series), ","))
+	out := pointSlicePool.Get().([]schema.Point)
+	crossSeriesSum(series, &out)
+	total := models.Series{
+		Target:     name,
+		QueryPatt:  name,
+		Datapoints: out,
+		Interval:   series[0].Interval,
+	}
+	cache[Req{}] = append(cache[Req{}], total)
+	return total
+}
+
+// asPercent returns the series as a percentage of the total points or, if those are nil, the fixed total value.
+func asPercent(cache map[Req][]models.Series, serie models.Series, total []schema.Point, totalVal float64, totalName string) models.Series {
+	out := pointSlicePool.Get().([]schema.Point)
+	for i, p := range serie.Datapoints {
+		t := totalVal
+		if total != nil {
+			t = math.NaN()
+			if i < len(total) {
+				t = total[i].Val
+			}
+		}
+		if math.IsNaN(p.Val) || math.IsNaN(t) || t == 0 {
+			p.Val = math.NaN()
+		} else {
+			p.Val = p.Val / t * 100
+		}
+		out = append(out, p)
+	}
+	name := fmt.Sprintf("asPercent(%s,%s)", serie.Target, totalName)
+	output := models.Series{
+		Target:     name,
+		QueryPatt:  name,
+		Tags:       serie.Tags,
+		Datapoints: out,
+		Interval:   serie.Interval,
+	}
+	cache[Req{}] = append(cache[Req{}], output)
+	return output
+}
@@ -0,0 +1,116 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func pct(val, total float64) float64 {
+	return val / total * 100
+}
+
+func getAsPercentInput() []models.Series {
+	return []models.Series{
+		{Target: "foo.a.d", QueryPatt: "foo.*.*", Interval: 10, Datapoints: getCopy(d)},
+		{Target: "foo.b.c", QueryPatt: "foo.*.*", Interval: 10, Datapoints: getCopy(c)},
+	}
+}
+
+func TestAsPercentSumTotal(t *testing.T) {
+	nan := math.NaN()
+	f := NewAsPercent()
+	f.(*FuncAsPercent).in = NewMock(getAsPercentInput())
+	testAsPercent("sum-total", f, []string{"asPercent(foo.a.d,sumSeries(foo.*.*))", "asPercent(foo.b.c,sumSeries(foo.*.*))"}, [][]schema.Point{
+		{{Val: nan, Ts: 10}, {Val: 100, Ts: 20}, {Val: 99.5, Ts: 30}, {Val: pct(29, 31), Ts: 40}, {Val: pct(80, 83), Ts: 50}, {Val: pct(250, 254), Ts: 60}},
+		{{Val: nan, Ts: 10}, {Val: 0, Ts: 20}, {Val: 0.5, Ts: 30}, {Val: pct(2, 31), Ts: 40}, {Val: pct(3, 83), Ts: 50}, {Val: pct(4, 254), Ts: 60}},
+	}, t)
+}
+
+func TestAsPercentFloatTotal(t *testing.T) {
+	f := NewAsPercent()
+	p := f.(*FuncAsPercent)
+	p.in = NewMock(getAsPercentInput()[1:])
+	p.totalFloat = 8
+	testAsPercent("float-total", f, []string{"asPercent(foo.b.c,8)"}, [][]schema.Point{
+		{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 12.5, Ts: 30}, {Val: 25, Ts: 40}, {Val: 37.5, Ts: 50}, {Val: 50, Ts: 60}},
+	}, t)
+}
+
+func TestAsPercentSeriesTotal(t *testing.T) {
+	nan := math.NaN()
+	f := NewAsPercent()
+	p := f.(*FuncAsPercent)
+	p.in = NewMock(getAsPercentInput())
+	p.totalSeries = NewMock([]models.Series{{Target: "total", QueryPatt: "total", Interval: 10, Datapoints: getCopy(d)}})
+	testAsPercent("series-total", f, []string{"asPercent(foo.a.d,total)", "asPercent(foo.b.c,total)"}, [][]schema.Point{
+		{{Val: nan, Ts: 10}, {Val: 100, Ts: 20}, {Val: 100, Ts: 30}, {Val: 100, Ts: 40}, {Val: 100, Ts: 50}, {Val: 100, Ts: 60}},
+		{{Val: nan, Ts: 10}, {Val: 0, Ts: 20}, {Val: pct(1, 199), Ts: 30}, {Val: pct(2, 29), Ts: 40}, {Val: pct(3, 80), Ts: 50}, {Val: pct(4, 250), Ts: 60}},
+	}, t)
+}
+
+func TestAsPercentSeriesTotalPairs(t *testing.T) {
+	nan := math.NaN()
+	f := NewAsPercent()
+	p := f.(*FuncAsPercent)
+	p.in = NewMock(getAsPercentInput())
+	// totals are matched up with the series by name, not by their order
+	p.totalSeries = NewMock([]models.Series{
+		{Target: "total.b", QueryPatt: "total.*", Interval: 10, Datapoints: getCopy(d)},
+		{Target: "total.a", QueryPatt: "total.*", Interval: 10, Datapoints: getCopy(c)},
+	})
+	testAsPercent("series-total-pairs", f, []string{"asPercent(foo.a.d,total.a)", "asPercent(foo.b.c,total.b)"}, [][]schema.Point{
+		{{Val: nan, Ts: 10}, {Val: nan, Ts: 20}, {Val: 19900, Ts: 30}, {Val: 1450, Ts: 40}, {Val: pct(80, 3), Ts: 50}, {Val: 6250, Ts: 60}},
+		{{Val: nan, Ts: 10}, {Val: 0, Ts: 20}, {Val: pct(1, 199), Ts: 30}, {Val: pct(2, 29), Ts: 40}, {Val: pct(3, 80), Ts: 50}, {Val: pct(4, 250), Ts: 60}},
+	}, t)
+
+	p.totalSeries = NewMock(getAsPercentInput()[:1])
+	p.in = NewMock([]models.Series{{Target: "a", Interval: 10}, {Target: "b", Interval: 10}, {Target: "c", Interval: 10}})
+	if _, err := f.Exec(make(map[Req][]models.Series)); err != nil {
+		t.Fatalf("a single total series should be accepted for any amount of series. got %q", err)
+	}
+	p.totalSeries = NewMock(getAsPercentInput())
+	if _, err := f.Exec(make(map[Req][]models.Series)); err != errAsPercentTotal {
+		t.Fatalf("expected err %q, got %q", errAsPercentTotal, err)
+	}
+}
+
+func TestAsPercentNodes(t *testing.T) {
+	nan := math.NaN()
+	f := NewAsPercent()
+	p := f.(*FuncAsPercent)
+	p.in = NewMock([]models.Series{
+		{Target: "foo.a.x", QueryPatt: "foo.*.*", Interval: 10, Datapoints: getCopy(d)},
+		{Target: "foo.b.x", QueryPatt: "foo.*.*", Interval: 10, Datapoints: getCopy(c)},
+		{Target: "foo.a.y", QueryPatt: "foo.*.*", Interval: 10, Datapoints: getCopy(c)},
+	})
+	p.nodes = []int64{1}
+	testAsPercent("nodes", f, []string{"asPercent(foo.a.x,sumSeries(foo.*.*))", "asPercent(foo.b.x,foo.b.x)", "asPercent(foo.a.y,sumSeries(foo.*.*))"}, [][]schema.Point{
+		{{Val: nan, Ts: 10}, {Val: 100, Ts: 20}, {Val: 99.5, Ts: 30}, {Val: pct(29, 31), Ts: 40}, {Val: pct(80, 83), Ts: 50}, {Val: pct(250, 254), Ts: 60}},
+		{{Val: nan, Ts: 10}, {Val: nan, Ts: 20}, {Val: 100, Ts: 30}, {Val: 100, Ts: 40}, {Val: 100, Ts: 50}, {Val: 100, Ts: 60}},
+		{{Val: nan, Ts: 10}, {Val: 0, Ts: 20}, {Val: 0.5, Ts: 30}, {Val: pct(2, 31), Ts: 40}, {Val: pct(3, 83), Ts: 50}, {Val: pct(4, 254), Ts: 60}},
+	}, t)
+
+	// with total series, each group has its own total. groups without total have no values
+	p.totalSeries = NewMock([]models.Series{
+		{Target: "total.a", QueryPatt: "total.*", Interval: 10, Datapoints: getCopy(d)},
+	})
+	testAsPercent("nodes-total", f, []string{"asPercent(foo.a.x,total.a)", "asPercent(foo.b.x,MISSING)", "asPercent(foo.a.y,total.a)"}, [][]schema.Point{
+		{{Val: nan, Ts: 10}, {Val: 100, Ts: 20}, {Val: 100, Ts: 30}, {Val: 100, Ts: 40}, {Val: 100, Ts: 50}, {Val: 100, Ts: 60}},
+		{{Val: nan, Ts: 10}, {Val: nan, Ts: 20}, {Val: nan, Ts: 30}, {Val: nan, Ts: 40}, {Val: nan, Ts: 50}, {Val: nan, Ts: 60}},
+		{{Val: nan, Ts: 10}, {Val: 0, Ts: 20}, {Val: pct(1, 199), Ts: 30}, {Val: pct(2, 29), Ts: 40}, {Val: pct(3, 80), Ts: 50}, {Val: pct(4, 250), Ts: 60}},
+	}, t)
+}
+
+func testAsPercent(name string, f GraphiteFunc, targets []string, out [][]schema.Point, t *testing.T) {
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("case %q: err should be nil. got %q", name, err)
+	}
+	checkTargets(name, got, targets, t)
+	for i, serie := range got {
+		checkPoints(name, serie.Datapoints, out[i], t)
+	}
+}
@@ -2,7 +2,6 @@ package expr
 
 import (
 	"fmt"
-	"math"
 	"strings"
 
 	"github.com/grafana/metrictank/api/models"
@@ -43,27 +42,9 @@ func (s *FuncAvgSeries) Exec(cache map[Req][]models.Series) ([]models.Series, er
 		series[0].QueryPatt = name
 		return series, nil
 	}
+	series = normalize(cache, series)
 	out := pointSlicePool.Get().([]schema.Point)
-	for i := 0; i < len(series[0].Datapoints); i++ {
-		num := 0
-		sum := float64(0)
-		for j := 0; j < len(series); j++ {
-			p := series[j].Datapoints[i].Val
-			if !math.IsNaN(p) {
-				num++
-				sum += p
-			}
-		}
-		point := schema.Point{
-			Ts: series[0].Datapoints[i].Ts,
-		}
-		if num == 0 {
-			point.Val = math.NaN()
-		} else {
-			point.Val = sum / float64(num)
-		}
-		out = append(out, point)
-	}
+	crossSeriesAvg(series, &out)
 
 	cons, queryCons := summarizeCons(series)
 	name := fmt.Sprintf("averageSeries(%s)", strings.Join(queryPatts, ","))
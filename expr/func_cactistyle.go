@@ -0,0 +1,127 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/batch"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncCactiStyle struct {
+	in     GraphiteFunc
+	system string
+	units  string
+}
+
+func NewCactiStyle() GraphiteFunc {
+	return &FuncCactiStyle{}
+}
+
+func (s *FuncCactiStyle) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgString{key: "system", opt: true, val: &s.system, validator: []Validator{IsUnitSystem}},
+		ArgString{key: "units", opt: true, val: &s.units},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncCactiStyle) Context(context Context) Context {
+	return context
+}
+
+// Exec adds the current, max and min values of each series to its name, aligned in columns like graphite does.
+func (s *FuncCactiStyle) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+
+	// the columns are as wide as the integer part of the widest value, plus some room for the decimals
+	var nameLen, lastLen, maxLen, minLen int
+	width := func(val float64) int {
+		if math.IsNaN(val) || val == 0 {
+			val = 3
+		}
+		return len(s.format(math.Trunc(val))) + 3
+	}
+	for _, serie := range series {
+		nameLen = maxInt(nameLen, len(serie.Target))
+		lastLen = maxInt(lastLen, width(safeAgg(batch.Lst, serie.Datapoints)))
+		maxLen = maxInt(maxLen, width(safeAgg(batch.Max, serie.Datapoints)))
+		minLen = maxInt(minLen, width(safeAgg(batch.Min, serie.Datapoints)))
+	}
+
+	for i, serie := range series {
+		name := fmt.Sprintf("%-*s Current:%-*s Max:%-*s Min:%-*s ",
+			nameLen, serie.Target,
+			lastLen, s.format(safeAgg(batch.Lst, serie.Datapoints)),
+			maxLen, s.format(safeAgg(batch.Max, serie.Datapoints)),
+			minLen, s.format(safeAgg(batch.Min, serie.Datapoints)),
+		)
+		series[i].Target = name
+		series[i].QueryPatt = name
+	}
+	return series, nil
+}
+
+// format formats the value with 2 decimals, in the unit system and with the units, if any
+func (s *FuncCactiStyle) format(val float64) string {
+	if math.IsNaN(val) {
+		return "nan"
+	}
+	if s.system == "" {
+		if s.units != "" {
+			return fmt.Sprintf("%.2f %s", val, s.units)
+		}
+		return fmt.Sprintf("%.2f", val)
+	}
+	val, prefix := formatUnits(val, s.system)
+	if s.units != "" {
+		return fmt.Sprintf("%.2f %s%s", val, prefix, s.units)
+	}
+	return fmt.Sprintf("%.2f%s", val, prefix)
+}
+
+// unitSystems are the unit prefixes of each unit system, from large to small
+var unitSystems = map[string][]struct {
+	prefix string
+	size   float64
+}{
+	"binary": {{"Pi", math.Pow(1024, 5)}, {"Ti", math.Pow(1024, 4)}, {"Gi", math.Pow(1024, 3)}, {"Mi", math.Pow(1024, 2)}, {"Ki", 1024}},
+	"si":     {{"P", math.Pow(1000, 5)}, {"T", math.Pow(1000, 4)}, {"G", math.Pow(1000, 3)}, {"M", math.Pow(1000, 2)}, {"K", 1000}},
+}
+
+// formatUnits scales the value down to the largest unit of the given system that it has at least one of,
+// and returns it along with the prefix of that unit.
+func formatUnits(val float64, system string) (float64, string) {
+	prefix := ""
+	for _, unit := range unitSystems[system] {
+		if math.Abs(val) >= unit.size {
+			val /= unit.size
+			prefix = unit.prefix
+			break
+		}
+	}
+	// like graphite, round values that are very close to a whole number
+	if val-math.Floor(val) < 0.00000000001 && val > 1 {
+		val = math.Floor(val)
+	}
+	return val, prefix
+}
+
+// safeAgg applies the aggregation function to the points, or returns NaN if there are none
+func safeAgg(fn batch.AggFunc, points []schema.Point) float64 {
+	if len(points) == 0 {
+		return math.NaN()
+	}
+	return fn(points)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
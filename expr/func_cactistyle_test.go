@@ -0,0 +1,50 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestCactiStyle(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		system string
+		units  string
+		exp    []string
+	}{
+		{
+			"",
+			"",
+			[]string{
+				"foo.bar Current:2048.00    Max:2048.00    Min:1.50    ",
+				"baz     Current:nan        Max:nan        Min:nan     ",
+			},
+		},
+		{
+			"binary",
+			"B",
+			[]string{
+				"foo.bar Current:2.00 KiB    Max:2.00 KiB    Min:1.50 B    ",
+				"baz     Current:nan         Max:nan         Min:nan       ",
+			},
+		},
+	}
+	for _, cas := range cases {
+		f := NewCactiStyle()
+		c := f.(*FuncCactiStyle)
+		c.in = NewMock([]models.Series{
+			{Target: "foo.bar", Datapoints: []schema.Point{{Val: 1.5, Ts: 10}, {Val: 2048, Ts: 20}}},
+			{Target: "baz", Datapoints: []schema.Point{{Val: nan, Ts: 10}, {Val: nan, Ts: 20}}},
+		})
+		c.system = cas.system
+		c.units = cas.units
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("case %q: err should be nil. got %q", cas.system, err)
+		}
+		checkTargets("cactiStyle", got, cas.exp, t)
+	}
+}
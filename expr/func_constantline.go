@@ -0,0 +1,56 @@
+package expr
+
+import (
+	"strconv"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncConstantLine struct {
+	value float64
+	from  uint32
+	to    uint32
+}
+
+func NewConstantLine() GraphiteFunc {
+	return &FuncConstantLine{}
+}
+
+func (s *FuncConstantLine) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgFloat{key: "value", val: &s.value},
+	}, []Arg{ArgSeries{}}
+}
+
+func (s *FuncConstantLine) Context(context Context) Context {
+	s.from = context.from
+	s.to = context.to
+	return context
+}
+
+func (s *FuncConstantLine) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	output := constantLine(cache, strconv.FormatFloat(s.value, 'f', -1, 64), s.value, s.from, s.to)
+	return []models.Series{output}, nil
+}
+
+// constantLine generates a series with the given name and value, over the given time range.
+// like graphite, it has 3 points: at the start, in the middle and at the end of the range.
+func constantLine(cache map[Req][]models.Series, name string, value float64, from, to uint32) models.Series {
+	interval := (to - from) / 2
+	if interval == 0 {
+		interval = 1
+	}
+	out := pointSlicePool.Get().([]schema.Point)
+	for i := uint32(0); i < 3; i++ {
+		out = append(out, schema.Point{Val: value, Ts: from + i*interval})
+	}
+	output := models.Series{
+		Target:     name,
+		QueryPatt:  name,
+		Datapoints: out,
+		Interval:   interval,
+	}
+	cache[Req{}] = append(cache[Req{}], output)
+	return output
+}
@@ -0,0 +1,61 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestConstantLine(t *testing.T) {
+	f := NewConstantLine()
+	c := f.(*FuncConstantLine)
+	c.value = 1.5
+	c.from = 1000
+	c.to = 1100
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	if len(got) != 1 || got[0].Target != "1.5" || got[0].Interval != 50 {
+		t.Fatalf("expected 1 series named 1.5 with interval 50, got %v", got)
+	}
+	checkPoints("constantLine", got[0].Datapoints, []schema.Point{{Val: 1.5, Ts: 1000}, {Val: 1.5, Ts: 1050}, {Val: 1.5, Ts: 1100}}, t)
+}
+
+// TestGeneratorPlan tests that generator functions don't request any data, and work with and without other series
+func TestGeneratorPlan(t *testing.T) {
+	from := uint32(1000)
+	to := uint32(1100)
+	cases := []struct {
+		target  string
+		expReqs []Req
+		exp     []string
+	}{
+		{"constantLine(100)", nil, []string{"100"}},
+		{"sumSeries(foo, threshold(5, 'sla'))", []Req{NewReq("foo", from, to, 0)}, []string{"sumSeries(foo,sla)"}},
+		{"identity('time')", nil, []string{"time"}},
+	}
+	for i, c := range cases {
+		e, _, err := Parse(c.target)
+		if err != nil {
+			t.Fatalf("case %d: %q, failed to parse: %s", i, c.target, err)
+		}
+		plan, err := NewPlan([]*expr{e}, from, to, 800, true, nil, nil)
+		if err != nil {
+			t.Fatalf("case %d: %q, failed to plan: %s", i, c.target, err)
+		}
+		if len(plan.Reqs) != len(c.expReqs) {
+			t.Fatalf("case %d: %q, expected reqs %v, got %v", i, c.target, c.expReqs, plan.Reqs)
+		}
+		input := make(map[Req][]models.Series)
+		for _, r := range plan.Reqs {
+			input[r] = []models.Series{{Target: "foo", QueryPatt: "foo", Interval: 10, Datapoints: getCopy(a)}}
+		}
+		out, err := plan.Run(input)
+		if err != nil {
+			t.Fatalf("case %d: %q, failed to run: %s", i, c.target, err)
+		}
+		checkTargets(c.target, out, c.exp, t)
+	}
+}
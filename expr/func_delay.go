@@ -0,0 +1,65 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncDelay struct {
+	in    GraphiteFunc
+	steps int64
+}
+
+func NewDelay() GraphiteFunc {
+	return &FuncDelay{}
+}
+
+func (s *FuncDelay) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgInt{key: "steps", val: &s.steps},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncDelay) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncDelay) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	steps := int(s.steps)
+	var outputs []models.Series
+	for _, serie := range series {
+		out := pointSlicePool.Get().([]schema.Point)
+		for i, p := range serie.Datapoints {
+			// a negative amount of steps moves values to earlier timestamps
+			j := i - steps
+			if j >= 0 && j < len(serie.Datapoints) {
+				p.Val = serie.Datapoints[j].Val
+			} else {
+				p.Val = math.NaN()
+			}
+			out = append(out, p)
+		}
+		target := fmt.Sprintf("delay(%s,%d)", serie.Target, s.steps)
+		output := models.Series{
+			Target:       target,
+			QueryPatt:    target,
+			Tags:         serie.Tags,
+			Datapoints:   out,
+			Interval:     serie.Interval,
+			Consolidator: serie.Consolidator,
+			XFilesFactor: serie.XFilesFactor,
+			QueryCons:    serie.QueryCons,
+		}
+		outputs = append(outputs, output)
+		cache[Req{}] = append(cache[Req{}], output)
+	}
+	return outputs, nil
+}
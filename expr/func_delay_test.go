@@ -0,0 +1,36 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestDelay(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		steps int64
+		exp   []schema.Point
+	}{
+		{0, getCopy(c)},
+		{2, []schema.Point{{Val: nan, Ts: 10}, {Val: nan, Ts: 20}, {Val: 0, Ts: 30}, {Val: 0, Ts: 40}, {Val: 1, Ts: 50}, {Val: 2, Ts: 60}}},
+		{-1, []schema.Point{{Val: 0, Ts: 10}, {Val: 1, Ts: 20}, {Val: 2, Ts: 30}, {Val: 3, Ts: 40}, {Val: 4, Ts: 50}, {Val: nan, Ts: 60}}},
+		{10, []schema.Point{{Val: nan, Ts: 10}, {Val: nan, Ts: 20}, {Val: nan, Ts: 30}, {Val: nan, Ts: 40}, {Val: nan, Ts: 50}, {Val: nan, Ts: 60}}},
+	}
+	for _, cas := range cases {
+		f := NewDelay()
+		d := f.(*FuncDelay)
+		d.in = NewMock([]models.Series{{Target: "c", QueryPatt: "c", Interval: 10, Datapoints: getCopy(c)}})
+		d.steps = cas.steps
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("err should be nil. got %q", err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("expected 1 output series, got %d", len(got))
+		}
+		checkPoints(got[0].Target, got[0].Datapoints, cas.exp, t)
+	}
+}
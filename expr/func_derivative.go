@@ -0,0 +1,57 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncDerivative struct {
+	in GraphiteFunc
+}
+
+func NewDerivative() GraphiteFunc {
+	return &FuncDerivative{}
+}
+
+func (s *FuncDerivative) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncDerivative) Context(context Context) Context {
+	context.consol = 0
+	return context
+}
+
+func (s *FuncDerivative) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []models.Series
+	for _, serie := range series {
+		out := pointSlicePool.Get().([]schema.Point)
+		prev := math.NaN()
+		for _, p := range serie.Datapoints {
+			val := p.Val
+			p.Val = val - prev // NaN if either is NaN
+			prev = val
+			out = append(out, p)
+		}
+		target := fmt.Sprintf("derivative(%s)", serie.Target)
+		output := models.Series{
+			Target:     target,
+			QueryPatt:  target,
+			Tags:       serie.Tags,
+			Datapoints: out,
+			Interval:   serie.Interval,
+		}
+		outputs = append(outputs, output)
+		cache[Req{}] = append(cache[Req{}], output)
+	}
+	return outputs, nil
+}
@@ -0,0 +1,67 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestDerivative(t *testing.T) {
+	nan := math.NaN()
+	f := NewDerivative()
+	f.(*FuncDerivative).in = NewMock([]models.Series{{Target: "d", QueryPatt: "d", Interval: 10, Datapoints: getCopy(d)}})
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	if len(got) != 1 || got[0].Target != "derivative(d)" || got[0].QueryPatt != "derivative(d)" {
+		t.Fatalf("expected 1 series named derivative(d), got %v", got)
+	}
+	checkPoints("derivative", got[0].Datapoints, []schema.Point{
+		{Val: nan, Ts: 10},
+		{Val: 33, Ts: 20},
+		{Val: 166, Ts: 30},
+		{Val: -170, Ts: 40},
+		{Val: 51, Ts: 50},
+		{Val: 170, Ts: 60},
+	}, t)
+}
+
+func TestNonNegativeDerivative(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		maxValue float64
+		exp      []schema.Point
+	}{
+		{
+			nan,
+			[]schema.Point{{Val: nan, Ts: 10}, {Val: 33, Ts: 20}, {Val: 166, Ts: 30}, {Val: nan, Ts: 40}, {Val: 51, Ts: 50}, {Val: 170, Ts: 60}},
+		},
+		{
+			// d emulates an 8 bit counter
+			255,
+			[]schema.Point{{Val: nan, Ts: 10}, {Val: 33, Ts: 20}, {Val: 166, Ts: 30}, {Val: 86, Ts: 40}, {Val: 51, Ts: 50}, {Val: 170, Ts: 60}},
+		},
+		{
+			// values above maxValue are ignored, and so is the next value as it has no valid previous value
+			100,
+			[]schema.Point{{Val: nan, Ts: 10}, {Val: 33, Ts: 20}, {Val: nan, Ts: 30}, {Val: nan, Ts: 40}, {Val: 51, Ts: 50}, {Val: nan, Ts: 60}},
+		},
+	}
+	for i, c := range cases {
+		f := NewNonNegativeDerivative()
+		n := f.(*FuncNonNegativeDerivative)
+		n.in = NewMock([]models.Series{{Target: "d", QueryPatt: "d", Interval: 10, Datapoints: getCopy(d)}})
+		n.maxValue = c.maxValue
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("case %d: err should be nil. got %q", i, err)
+		}
+		if len(got) != 1 || got[0].Target != "nonNegativeDerivative(d)" {
+			t.Fatalf("case %d: expected 1 series named nonNegativeDerivative(d), got %v", i, got)
+		}
+		checkPoints(got[0].Target, got[0].Datapoints, c.exp, t)
+	}
+}
@@ -41,9 +41,12 @@ func (s *FuncDivideSeries) Exec(cache map[Req][]models.Series) ([]models.Series,
 	if len(divisors) != 1 {
 		return nil, errors.New(fmt.Sprintf("need 1 divisor series, not %d", len(divisors)))
 	}
-	divisor := divisors[0]
 
-	var series []models.Series
+	series := normalize(cache, append([]models.Series{divisors[0]}, dividends...))
+	divisor := series[0]
+	dividends = series[1:]
+
+	var outputs []models.Series
 	for _, dividend := range dividends {
 		out := pointSlicePool.Get().([]schema.Point)
 		for i := 0; i < len(dividend.Datapoints); i++ {
@@ -64,10 +67,11 @@ func (s *FuncDivideSeries) Exec(cache map[Req][]models.Series) ([]models.Series,
 			Datapoints:   out,
 			Interval:     divisor.Interval,
 			Consolidator: dividend.Consolidator,
+			XFilesFactor: dividend.XFilesFactor,
 			QueryCons:    dividend.QueryCons,
 		}
 		cache[Req{}] = append(cache[Req{}], output)
-		series = append(series, output)
+		outputs = append(outputs, output)
 	}
-	return series, nil
+	return outputs, nil
 }
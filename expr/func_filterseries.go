@@ -0,0 +1,70 @@
+package expr
+
+import (
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/batch"
+	"github.com/grafana/metrictank/consolidation"
+)
+
+// FuncFilterSeries implements the functions that filter series based on how an aggregate
+// of their values compares to a threshold, e.g. maximumAbove or currentBelow.
+// series for which the aggregate is null never match.
+type FuncFilterSeries struct {
+	in        GraphiteFunc
+	fn        string
+	operator  string
+	threshold float64
+}
+
+// NewFilterSeriesConstructor returns a constructor for a function that keeps the series for which
+// the given aggregation function (as accepted by consolidateBy) compares to the threshold via the operator.
+func NewFilterSeriesConstructor(fn, operator string) func() GraphiteFunc {
+	return func() GraphiteFunc {
+		return &FuncFilterSeries{fn: fn, operator: operator}
+	}
+}
+
+func (s *FuncFilterSeries) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgFloat{key: "n", val: &s.threshold},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncFilterSeries) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncFilterSeries) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	aggFunc := consolidation.GetAggFunc(consolidation.FromConsolidateBy(s.fn))
+	var out []models.Series
+	for _, serie := range series {
+		if s.match(aggFunc, serie) {
+			out = append(out, serie)
+		}
+	}
+	return out, nil
+}
+
+func (s *FuncFilterSeries) match(aggFunc batch.AggFunc, serie models.Series) bool {
+	if len(serie.Datapoints) == 0 {
+		return false
+	}
+	val := aggFunc(serie.Datapoints)
+	if math.IsNaN(val) {
+		return false
+	}
+	switch s.operator {
+	case ">":
+		return val > s.threshold
+	case "<=":
+		return val <= s.threshold
+	}
+	return false
+}
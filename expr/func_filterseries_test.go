@@ -0,0 +1,38 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+// see getSortInput for the aggregates of each input series
+func TestFilterSeries(t *testing.T) {
+	cases := []struct {
+		name      string
+		fn        string
+		operator  string
+		threshold float64
+		exp       []string
+	}{
+		{"maximumAbove", "max", ">", 4, []string{"a", "d"}},
+		{"maximumAbove", "max", ">", 250, []string{"a"}},
+		{"maximumBelow", "max", "<=", 250, []string{"c", "d"}},
+		{"minimumAbove", "min", ">", -1, []string{"a", "c", "d"}},
+		{"minimumAbove", "min", ">", 0, nil},
+		{"averageAbove", "average", ">", 50, []string{"a", "d"}},
+		{"currentAbove", "last", ">", 4, []string{"a", "d"}},
+		{"currentBelow", "last", "<=", 4, []string{"c"}},
+	}
+	for _, c := range cases {
+		f := NewFilterSeriesConstructor(c.fn, c.operator)()
+		fs := f.(*FuncFilterSeries)
+		fs.in = NewMock(getSortInput())
+		fs.threshold = c.threshold
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("case %s: err should be nil. got %q", c.name, err)
+		}
+		checkTargets(c.name, got, c.exp, t)
+	}
+}
@@ -1,6 +1,10 @@
 package expr
 
-import "github.com/grafana/metrictank/api/models"
+import (
+	"sort"
+
+	"github.com/grafana/metrictank/api/models"
+)
 
 // internal function just for getting data
 type FuncGet struct {
@@ -19,6 +23,12 @@ func (s FuncGet) Context(context Context) Context {
 	return context
 }
 
+// Exec returns the fetched series, sorted by name like graphite does.
+// this is the only place where we sort: further processing functions may
+// define their own order (e.g. sortByMaxima), which must be retained.
 func (s FuncGet) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
-	return cache[s.req], nil
+	series := make([]models.Series, len(cache[s.req]))
+	copy(series, cache[s.req])
+	sort.Sort(models.SeriesByTarget(series))
+	return series, nil
 }
@@ -0,0 +1,91 @@
+package expr
+
+import (
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+// FuncGroupByNode implements both groupByNode and groupByNodes
+type FuncGroupByNode struct {
+	in         GraphiteFunc
+	aggregator string
+	node       int64
+	nodes      []int64
+	multi      bool // true for groupByNodes, false for groupByNode
+}
+
+func NewGroupByNode() GraphiteFunc {
+	return &FuncGroupByNode{aggregator: "average"}
+}
+
+func NewGroupByNodes() GraphiteFunc {
+	return &FuncGroupByNode{aggregator: "average", multi: true}
+}
+
+func (s *FuncGroupByNode) Signature() ([]Arg, []Arg) {
+	if s.multi {
+		return []Arg{
+			ArgSeriesList{val: &s.in},
+			ArgString{key: "callback", val: &s.aggregator, validator: []Validator{IsAggFunc}},
+			ArgInts{key: "nodes", opt: true, val: &s.nodes},
+		}, []Arg{ArgSeriesList{}}
+	}
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgInt{key: "nodeNum", val: &s.node},
+		ArgString{key: "callback", opt: true, val: &s.aggregator, validator: []Validator{IsAggFunc}},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncGroupByNode) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncGroupByNode) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	nodes := s.nodes
+	if !s.multi {
+		nodes = []int64{s.node}
+	}
+	return groupSeries(cache, series, s.aggregator, func(serie models.Series) string {
+		return aggKey(serie, nodes)
+	}), nil
+}
+
+// groupSeries splits the series into groups, based on the key returned by the keyer for each series,
+// and aggregates each group into a new series named after the key, using the given aggregation function.
+// the output series are in order of first appearance of their key.
+func groupSeries(cache map[Req][]models.Series, series []models.Series, aggregator string, keyer func(models.Series) string) []models.Series {
+	var keys []string
+	groups := make(map[string][]models.Series)
+	for _, serie := range series {
+		key := keyer(serie)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], serie)
+	}
+
+	aggFunc := getCrossSeriesAggFunc(aggregator)
+	outputs := make([]models.Series, 0, len(keys))
+	for _, key := range keys {
+		group := normalize(cache, groups[key])
+		out := pointSlicePool.Get().([]schema.Point)
+		aggFunc(group, &out)
+		cons, queryCons := summarizeCons(group)
+		output := models.Series{
+			Target:       key,
+			QueryPatt:    key,
+			Datapoints:   out,
+			Interval:     group[0].Interval,
+			Consolidator: cons,
+			QueryCons:    queryCons,
+		}
+		cache[Req{}] = append(cache[Req{}], output)
+		outputs = append(outputs, output)
+	}
+	return outputs
+}
@@ -0,0 +1,104 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+func getGroupByNodeInput() []models.Series {
+	return []models.Series{
+		{
+			QueryPatt:  "foo.*.bar.*",
+			Target:     "foo.a.bar.x",
+			Datapoints: getCopy(a),
+		},
+		{
+			QueryPatt:  "foo.*.bar.*",
+			Target:     "foo.b.bar.x",
+			Datapoints: getCopy(c),
+		},
+		{
+			QueryPatt:  "foo.*.bar.*",
+			Target:     "foo.a.bar.y",
+			Datapoints: getCopy(b),
+		},
+	}
+}
+
+func TestGroupByNodeSum(t *testing.T) {
+	f := NewGroupByNode()
+	g := f.(*FuncGroupByNode)
+	g.in = NewMock(getGroupByNodeInput())
+	g.node = 1
+	g.aggregator = "sum"
+	testGroupBy("groupByNode-sum", f, []models.Series{
+		{QueryPatt: "a", Datapoints: getCopy(sumab)},
+		{QueryPatt: "b", Datapoints: getCopy(c)},
+	}, t)
+}
+
+func TestGroupByNodeDefaultAverage(t *testing.T) {
+	f := NewGroupByNode()
+	g := f.(*FuncGroupByNode)
+	g.in = NewMock(getGroupByNodeInput())
+	g.node = -3
+	testGroupBy("groupByNode-average", f, []models.Series{
+		{QueryPatt: "a", Datapoints: getCopy(avgab)},
+		{QueryPatt: "b", Datapoints: getCopy(c)},
+	}, t)
+}
+
+func TestGroupByNodesMax(t *testing.T) {
+	f := NewGroupByNodes()
+	g := f.(*FuncGroupByNode)
+	g.in = NewMock(getGroupByNodeInput())
+	g.aggregator = "max"
+	g.nodes = []int64{3, 1}
+	testGroupBy("groupByNodes-max", f, []models.Series{
+		{QueryPatt: "x.a", Datapoints: getCopy(a)},
+		{QueryPatt: "x.b", Datapoints: getCopy(c)},
+		{QueryPatt: "y.a", Datapoints: getCopy(b)},
+	}, t)
+}
+
+func TestGroupByNodesAllInOneGroup(t *testing.T) {
+	f := NewGroupByNodes()
+	g := f.(*FuncGroupByNode)
+	g.in = NewMock(getGroupByNodeInput())
+	g.aggregator = "sum"
+	g.nodes = []int64{0, 2}
+	testGroupBy("groupByNodes-one-group", f, []models.Series{
+		{QueryPatt: "foo.bar", Datapoints: getCopy(sumabc)},
+	}, t)
+}
+
+func testGroupBy(name string, f GraphiteFunc, out []models.Series, t *testing.T) {
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("case %q: err should be nil. got %q", name, err)
+	}
+	if len(got) != len(out) {
+		t.Fatalf("case %q: expected %d output series, got %d", name, len(out), len(got))
+	}
+	for i, g := range got {
+		o := out[i]
+		if g.QueryPatt != o.QueryPatt {
+			t.Fatalf("case %q: expected target %q, got %q", name, o.QueryPatt, g.QueryPatt)
+		}
+		if g.Target != g.QueryPatt {
+			t.Fatalf("case %q: expected target %q to match query pattern %q", name, g.Target, g.QueryPatt)
+		}
+		if len(g.Datapoints) != len(o.Datapoints) {
+			t.Fatalf("case %q: len output expected %d, got %d", name, len(o.Datapoints), len(g.Datapoints))
+		}
+		for j, p := range g.Datapoints {
+			bothNaN := math.IsNaN(p.Val) && math.IsNaN(o.Datapoints[j].Val)
+			if (bothNaN || p.Val == o.Datapoints[j].Val) && p.Ts == o.Datapoints[j].Ts {
+				continue
+			}
+			t.Fatalf("case %q: series %q output point %d - expected %v got %v", name, g.Target, j, o.Datapoints[j], p)
+		}
+	}
+}
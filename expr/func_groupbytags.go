@@ -0,0 +1,65 @@
+package expr
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+type FuncGroupByTags struct {
+	in         GraphiteFunc
+	aggregator string
+	tags       []string
+}
+
+func NewGroupByTags() GraphiteFunc {
+	return &FuncGroupByTags{}
+}
+
+func (s *FuncGroupByTags) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgString{key: "callback", val: &s.aggregator, validator: []Validator{IsAggFunc}},
+		ArgStrings{key: "tags", val: &s.tags},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncGroupByTags) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncGroupByTags) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, len(s.tags))
+	copy(tags, s.tags)
+	sort.Strings(tags)
+
+	return groupSeries(cache, series, s.aggregator, func(serie models.Series) string {
+		return tagsKey(serie, tags, s.aggregator)
+	}), nil
+}
+
+// tagsKey returns the graphite style name for the group of the series according to the given (sorted) tags:
+// the name of the series if the name tag is requested (or the aggregator otherwise),
+// followed by the requested tags, e.g. `sum;dc=east;env=prod`.
+// series without tags are considered to only have a name tag, which is their target.
+func tagsKey(serie models.Series, tags []string, aggregator string) string {
+	seriesTags := serie.Tags
+	if seriesTags == nil {
+		seriesTags = map[string]string{"name": serie.Target}
+	}
+	name := aggregator
+	var buf []string
+	for _, tag := range tags {
+		if tag == "name" {
+			name = seriesTags["name"]
+			continue
+		}
+		buf = append(buf, tag+"="+seriesTags[tag])
+	}
+	return strings.Join(append([]string{name}, buf...), ";")
+}
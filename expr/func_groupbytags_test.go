@@ -0,0 +1,65 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+func getGroupByTagsInput() []models.Series {
+	return []models.Series{
+		{
+			QueryPatt:  "seriesByTag('name=cpu')",
+			Target:     "cpu",
+			Tags:       map[string]string{"name": "cpu", "dc": "east", "host": "a"},
+			Datapoints: getCopy(a),
+		},
+		{
+			QueryPatt:  "seriesByTag('name=cpu')",
+			Target:     "cpu",
+			Tags:       map[string]string{"name": "cpu", "dc": "west", "host": "b"},
+			Datapoints: getCopy(c),
+		},
+		{
+			QueryPatt:  "seriesByTag('name=cpu')",
+			Target:     "cpu",
+			Tags:       map[string]string{"name": "cpu", "dc": "east", "host": "c"},
+			Datapoints: getCopy(b),
+		},
+	}
+}
+
+func TestGroupByTagsSum(t *testing.T) {
+	f := NewGroupByTags()
+	g := f.(*FuncGroupByTags)
+	g.in = NewMock(getGroupByTagsInput())
+	g.aggregator = "sum"
+	g.tags = []string{"dc"}
+	testGroupBy("groupByTags-sum", f, []models.Series{
+		{QueryPatt: "sum;dc=east", Datapoints: getCopy(sumab)},
+		{QueryPatt: "sum;dc=west", Datapoints: getCopy(c)},
+	}, t)
+}
+
+func TestGroupByTagsWithName(t *testing.T) {
+	f := NewGroupByTags()
+	g := f.(*FuncGroupByTags)
+	g.in = NewMock(getGroupByTagsInput())
+	g.aggregator = "max"
+	g.tags = []string{"name", "dc", "missing"}
+	testGroupBy("groupByTags-name", f, []models.Series{
+		{QueryPatt: "cpu;dc=east;missing=", Datapoints: getCopy(maxab)},
+		{QueryPatt: "cpu;dc=west;missing=", Datapoints: getCopy(c)},
+	}, t)
+}
+
+func TestGroupByTagsUntagged(t *testing.T) {
+	f := NewGroupByTags()
+	g := f.(*FuncGroupByTags)
+	g.in = NewMock(getGroupByNodeInput())
+	g.aggregator = "average"
+	g.tags = []string{"dc"}
+	testGroupBy("groupByTags-untagged", f, []models.Series{
+		{QueryPatt: "average;dc=", Datapoints: getCopy(avgabc)},
+	}, t)
+}
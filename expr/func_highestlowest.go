@@ -0,0 +1,82 @@
+package expr
+
+import (
+	"math"
+	"sort"
+
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/batch"
+	"github.com/grafana/metrictank/consolidation"
+)
+
+// FuncHighestLowest implements the highest* and lowest* functions:
+// it returns the n series with the highest (or lowest) value for the given aggregation
+type FuncHighestLowest struct {
+	in      GraphiteFunc
+	n       int64
+	fn      string
+	highest bool
+}
+
+// NewHighestLowestConstructor returns a constructor for a function that selects series based on the
+// given aggregation function (as accepted by consolidateBy), either the highest or the lowest ones.
+func NewHighestLowestConstructor(fn string, highest bool) func() GraphiteFunc {
+	return func() GraphiteFunc {
+		return &FuncHighestLowest{n: 1, fn: fn, highest: highest}
+	}
+}
+
+func (s *FuncHighestLowest) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgInt{key: "n", opt: true, validator: []Validator{IntPositive}, val: &s.n},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncHighestLowest) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncHighestLowest) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	series = sortByAgg(series, consolidation.GetAggFunc(consolidation.FromConsolidateBy(s.fn)), s.highest)
+	if int64(len(series)) > s.n {
+		series = series[:s.n]
+	}
+	return series, nil
+}
+
+// sortByAgg returns a copy of the series, sorted by the value of the aggregation function applied to each of them,
+// in descending order if desc is true, in ascending order otherwise.
+// series whose aggregation is null (e.g. because they have no points) are considered -Inf, like in graphite.
+// series with equal values retain their relative order.
+func sortByAgg(in []models.Series, aggFunc batch.AggFunc, desc bool) []models.Series {
+	type seriesWithVal struct {
+		serie models.Series
+		val   float64
+	}
+	sorted := make([]seriesWithVal, len(in))
+	for i, serie := range in {
+		val := math.Inf(-1)
+		if len(serie.Datapoints) > 0 {
+			if v := aggFunc(serie.Datapoints); !math.IsNaN(v) {
+				val = v
+			}
+		}
+		sorted[i] = seriesWithVal{serie, val}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if desc {
+			return sorted[i].val > sorted[j].val
+		}
+		return sorted[i].val < sorted[j].val
+	})
+	out := make([]models.Series, len(sorted))
+	for i, s := range sorted {
+		out[i] = s.serie
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+// getSortInput returns series with distinct current, max, average, min and total values
+// a: current 1234567890, max 1234567890, avg 308641973.875, min 0, total 1234567895.5
+// c: current 4, max 4, avg 1.6666, min 0, total 10
+// d: current 250, max 250, avg 98.5, min 0, total 591
+// e: all nulls
+func getSortInput() []models.Series {
+	return []models.Series{
+		{QueryPatt: "a", Target: "a", Datapoints: getCopy(a)},
+		{QueryPatt: "c", Target: "c", Datapoints: getCopy(c)},
+		{QueryPatt: "d", Target: "d", Datapoints: getCopy(d)},
+		{QueryPatt: "e", Target: "e", Datapoints: []schema.Point{{Val: math.NaN(), Ts: 10}, {Val: math.NaN(), Ts: 20}}},
+	}
+}
+
+func TestHighestLowest(t *testing.T) {
+	cases := []struct {
+		name    string
+		fn      string
+		highest bool
+		n       int64
+		exp     []string
+	}{
+		{"highestCurrent", "last", true, 1, []string{"a"}},
+		{"highestCurrent", "last", true, 2, []string{"a", "d"}},
+		{"highestMax", "max", true, 10, []string{"a", "d", "c", "e"}},
+		{"highestAverage", "average", true, 3, []string{"a", "d", "c"}},
+		{"lowestCurrent", "last", false, 2, []string{"e", "c"}},
+		{"lowestAverage", "average", false, 3, []string{"e", "c", "d"}},
+	}
+	for _, c := range cases {
+		f := NewHighestLowestConstructor(c.fn, c.highest)()
+		hl := f.(*FuncHighestLowest)
+		hl.in = NewMock(getSortInput())
+		hl.n = c.n
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("case %s(%d): err should be nil. got %q", c.name, c.n, err)
+		}
+		checkTargets(c.name, got, c.exp, t)
+	}
+}
+
+func checkTargets(name string, got []models.Series, exp []string, t *testing.T) {
+	if len(got) != len(exp) {
+		t.Fatalf("case %q: expected %d series, got %d", name, len(exp), len(got))
+	}
+	for i, g := range got {
+		if g.Target != exp[i] {
+			t.Fatalf("case %q: expected series %d to be %q, got %q", name, i, exp[i], g.Target)
+		}
+	}
+}
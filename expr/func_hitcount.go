@@ -0,0 +1,91 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/grafana/metrictank/api/models"
+	"github.com/raintank/dur"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncHitcount struct {
+	in             GraphiteFunc
+	intervalString string
+	alignToFrom    bool
+	loc            *time.Location
+}
+
+func NewHitcount() GraphiteFunc {
+	return &FuncHitcount{}
+}
+
+func (s *FuncHitcount) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgString{key: "intervalString", val: &s.intervalString, validator: []Validator{IsIntervalString}},
+		ArgBool{key: "alignToFrom", opt: true, val: &s.alignToFrom},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncHitcount) Context(context Context) Context {
+	s.loc = context.loc
+	context.consol = 0
+	return context
+}
+
+// Exec treats each point, like graphite does, as a rate per second over the interval that starts at its timestamp,
+// and sums the resulting hits into the buckets, splitting them if a point spans multiple buckets.
+func (s *FuncHitcount) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	interval, _ := dur.ParseNDuration(s.intervalString)
+
+	var outputs []models.Series
+	for _, serie := range series {
+		out := pointSlicePool.Get().([]schema.Point)
+		if len(serie.Datapoints) > 0 {
+			bucketStart := getBucketStart(serie.Datapoints[0].Ts, interval, s.alignToFrom, s.loc)
+			for _, p := range serie.Datapoints {
+				// the range of time covered by the point, which we may need to split over multiple buckets
+				from, to := p.Ts, p.Ts+serie.Interval
+				for from < to {
+					ts := bucketStart(from)
+					end := ts + interval
+					if end > to {
+						end = to
+					}
+					if len(out) == 0 || out[len(out)-1].Ts != ts {
+						out = append(out, schema.Point{Val: math.NaN(), Ts: ts})
+					}
+					if !math.IsNaN(p.Val) {
+						bucket := &out[len(out)-1]
+						if math.IsNaN(bucket.Val) {
+							bucket.Val = 0
+						}
+						bucket.Val += p.Val * float64(end-from)
+					}
+					from = end
+				}
+			}
+		}
+
+		target := fmt.Sprintf("hitcount(%s, \"%s\")", serie.Target, s.intervalString)
+		if s.alignToFrom {
+			target = fmt.Sprintf("hitcount(%s, \"%s\", true)", serie.Target, s.intervalString)
+		}
+		output := models.Series{
+			Target:     target,
+			QueryPatt:  target,
+			Tags:       serie.Tags,
+			Datapoints: out,
+			Interval:   interval,
+		}
+		outputs = append(outputs, output)
+		cache[Req{}] = append(cache[Req{}], output)
+	}
+	return outputs, nil
+}
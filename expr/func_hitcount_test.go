@@ -0,0 +1,69 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestHitcount(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		name           string
+		in             []schema.Point
+		intervalString string
+		alignToFrom    bool
+		target         string
+		out            []schema.Point
+	}{
+		{
+			"basic",
+			c,
+			"20s",
+			false,
+			`hitcount(foo, "20s")`,
+			[]schema.Point{{Val: 0, Ts: 0}, {Val: 10, Ts: 20}, {Val: 50, Ts: 40}, {Val: 40, Ts: 60}},
+		},
+		{
+			"alignToFrom",
+			c,
+			"20s",
+			true,
+			`hitcount(foo, "20s", true)`,
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 30, Ts: 30}, {Val: 70, Ts: 50}},
+		},
+		{
+			"points-spanning-buckets",
+			c,
+			"15s",
+			false,
+			`hitcount(foo, "15s")`,
+			[]schema.Point{{Val: 0, Ts: 0}, {Val: 0, Ts: 15}, {Val: 20, Ts: 30}, {Val: 40, Ts: 45}, {Val: 40, Ts: 60}},
+		},
+		{
+			"with-nulls",
+			a,
+			"20s",
+			false,
+			`hitcount(foo, "20s")`,
+			[]schema.Point{{Val: 0, Ts: 0}, {Val: 55, Ts: 20}, {Val: nan, Ts: 40}, {Val: 12345678900, Ts: 60}},
+		},
+	}
+	for _, cas := range cases {
+		f := NewHitcount()
+		h := f.(*FuncHitcount)
+		h.in = NewMock([]models.Series{{Target: "foo", QueryPatt: "foo", Interval: 10, Datapoints: getCopy(cas.in)}})
+		h.intervalString = cas.intervalString
+		h.alignToFrom = cas.alignToFrom
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("case %q: err should be nil. got %q", cas.name, err)
+		}
+		if len(got) != 1 || got[0].Target != cas.target {
+			t.Fatalf("case %q: expected 1 series named %s, got %v", cas.name, cas.target, got)
+		}
+		checkPoints(cas.name, got[0].Datapoints, cas.out, t)
+	}
+}
@@ -0,0 +1,80 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncHoltWintersAberration struct {
+	in                GraphiteFunc
+	delta             float64
+	bootstrapInterval string
+	seasonality       string
+	from              uint32 // the start of the requested range, before we extended it with the bootstrap interval
+}
+
+func NewHoltWintersAberration() GraphiteFunc {
+	return &FuncHoltWintersAberration{delta: 3, bootstrapInterval: "7d", seasonality: "1d"}
+}
+
+func (s *FuncHoltWintersAberration) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgFloat{key: "delta", opt: true, val: &s.delta},
+		ArgString{key: "bootstrapInterval", opt: true, val: &s.bootstrapInterval, validator: []Validator{IsIntervalString}},
+		ArgString{key: "seasonality", opt: true, val: &s.seasonality, validator: []Validator{IsIntervalString}},
+	}, []Arg{ArgSeriesList{}}
+}
+
+// Context extends the range of the requests for our input with the bootstrap interval,
+// which is used to train the model before the requested range.
+func (s *FuncHoltWintersAberration) Context(context Context) Context {
+	s.from = context.from
+	context.from = holtWintersBootstrap(context.from, s.bootstrapInterval)
+	return context
+}
+
+// Exec returns, for each input series, how far its values are outside of the confidence bands:
+// positive above the upper band, negative below the lower band, and 0 within the bands.
+func (s *FuncHoltWintersAberration) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []models.Series
+	for _, serie := range series {
+		lower, upper := holtWintersBands(serie.Datapoints, serie.Interval, s.seasonality, s.delta)
+		out := pointSlicePool.Get().([]schema.Point)
+		for i, p := range serie.Datapoints {
+			if p.Ts < s.from {
+				continue
+			}
+			val := 0.0
+			if !math.IsNaN(p.Val) {
+				if p.Val > upper[i] {
+					val = p.Val - upper[i]
+				} else if p.Val < lower[i] {
+					val = p.Val - lower[i]
+				}
+			}
+			out = append(out, schema.Point{Val: val, Ts: p.Ts})
+		}
+		target := fmt.Sprintf("holtWintersAberration(%s)", serie.Target)
+		output := models.Series{
+			Target:       target,
+			QueryPatt:    target,
+			Tags:         serie.Tags,
+			Datapoints:   out,
+			Interval:     serie.Interval,
+			Consolidator: serie.Consolidator,
+			XFilesFactor: serie.XFilesFactor,
+			QueryCons:    serie.QueryCons,
+		}
+		outputs = append(outputs, output)
+		cache[Req{}] = append(cache[Req{}], output)
+	}
+	return outputs, nil
+}
@@ -0,0 +1,32 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestHoltWintersAberration(t *testing.T) {
+	f := NewHoltWintersAberration()
+	hw := f.(*FuncHoltWintersAberration)
+	hw.in = NewMock(getHoltWintersInput())
+	hw.from = 20
+	hw.delta = 2
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	if len(got) != 1 || got[0].Target != "holtWintersAberration(foo)" {
+		t.Fatalf("expected 1 series named holtWintersAberration(foo), got %v", got)
+	}
+	// only the spike is outside of the bands. nulls and points without a prediction have no aberration
+	exp := []schema.Point{
+		{Val: 0, Ts: 20},
+		{Val: 0, Ts: 30},
+		{Val: 0, Ts: 40},
+		{Val: 0, Ts: 50},
+		{Val: 105 - (5 + 2*0.1*100), Ts: 60},
+	}
+	checkPoints("holtWintersAberration", got[0].Datapoints, exp, t)
+}
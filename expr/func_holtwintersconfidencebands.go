@@ -0,0 +1,77 @@
+package expr
+
+import (
+	"fmt"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncHoltWintersConfidenceBands struct {
+	in                GraphiteFunc
+	delta             float64
+	bootstrapInterval string
+	seasonality       string
+	from              uint32 // the start of the requested range, before we extended it with the bootstrap interval
+}
+
+func NewHoltWintersConfidenceBands() GraphiteFunc {
+	return &FuncHoltWintersConfidenceBands{delta: 3, bootstrapInterval: "7d", seasonality: "1d"}
+}
+
+func (s *FuncHoltWintersConfidenceBands) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgFloat{key: "delta", opt: true, val: &s.delta},
+		ArgString{key: "bootstrapInterval", opt: true, val: &s.bootstrapInterval, validator: []Validator{IsIntervalString}},
+		ArgString{key: "seasonality", opt: true, val: &s.seasonality, validator: []Validator{IsIntervalString}},
+	}, []Arg{ArgSeriesList{}}
+}
+
+// Context extends the range of the requests for our input with the bootstrap interval,
+// which is used to train the model before the requested range.
+func (s *FuncHoltWintersConfidenceBands) Context(context Context) Context {
+	s.from = context.from
+	context.from = holtWintersBootstrap(context.from, s.bootstrapInterval)
+	return context
+}
+
+// Exec returns, for each input series, the lower and the upper confidence band
+func (s *FuncHoltWintersConfidenceBands) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []models.Series
+	for _, serie := range series {
+		lower, upper := holtWintersBands(serie.Datapoints, serie.Interval, s.seasonality, s.delta)
+		for _, band := range []struct {
+			name   string
+			values []float64
+		}{
+			{"holtWintersConfidenceLower", lower},
+			{"holtWintersConfidenceUpper", upper},
+		} {
+			out := pointSlicePool.Get().([]schema.Point)
+			for i, p := range serie.Datapoints {
+				if p.Ts >= s.from {
+					out = append(out, schema.Point{Val: band.values[i], Ts: p.Ts})
+				}
+			}
+			target := fmt.Sprintf("%s(%s)", band.name, serie.Target)
+			output := models.Series{
+				Target:       target,
+				QueryPatt:    target,
+				Tags:         serie.Tags,
+				Datapoints:   out,
+				Interval:     serie.Interval,
+				Consolidator: serie.Consolidator,
+				XFilesFactor: serie.XFilesFactor,
+				QueryCons:    serie.QueryCons,
+			}
+			outputs = append(outputs, output)
+			cache[Req{}] = append(cache[Req{}], output)
+		}
+	}
+	return outputs, nil
+}
@@ -0,0 +1,37 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestHoltWintersConfidenceBands(t *testing.T) {
+	nan := math.NaN()
+	f := NewHoltWintersConfidenceBands()
+	hw := f.(*FuncHoltWintersConfidenceBands)
+	hw.in = NewMock(getHoltWintersInput())
+	hw.from = 20
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	checkTargets("holtWintersConfidenceBands", got, []string{"holtWintersConfidenceLower(foo)", "holtWintersConfidenceUpper(foo)"}, t)
+	// the deviation of the spike is gamma * 100, the band is delta deviations away from the prediction
+	checkPoints("holtWintersConfidenceLower", got[0].Datapoints, []schema.Point{
+		{Val: 5, Ts: 20},
+		{Val: 5, Ts: 30},
+		{Val: nan, Ts: 40},
+		{Val: 5, Ts: 50},
+		{Val: 5 - 3*0.1*100, Ts: 60},
+	}, t)
+	checkPoints("holtWintersConfidenceUpper", got[1].Datapoints, []schema.Point{
+		{Val: 5, Ts: 20},
+		{Val: 5, Ts: 30},
+		{Val: nan, Ts: 40},
+		{Val: 5, Ts: 50},
+		{Val: 5 + 3*0.1*100, Ts: 60},
+	}, t)
+}
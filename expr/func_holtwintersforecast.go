@@ -0,0 +1,66 @@
+package expr
+
+import (
+	"fmt"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncHoltWintersForecast struct {
+	in                GraphiteFunc
+	bootstrapInterval string
+	seasonality       string
+	from              uint32 // the start of the requested range, before we extended it with the bootstrap interval
+}
+
+func NewHoltWintersForecast() GraphiteFunc {
+	return &FuncHoltWintersForecast{bootstrapInterval: "7d", seasonality: "1d"}
+}
+
+func (s *FuncHoltWintersForecast) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgString{key: "bootstrapInterval", opt: true, val: &s.bootstrapInterval, validator: []Validator{IsIntervalString}},
+		ArgString{key: "seasonality", opt: true, val: &s.seasonality, validator: []Validator{IsIntervalString}},
+	}, []Arg{ArgSeriesList{}}
+}
+
+// Context extends the range of the requests for our input with the bootstrap interval,
+// which is used to train the model before the requested range.
+func (s *FuncHoltWintersForecast) Context(context Context) Context {
+	s.from = context.from
+	context.from = holtWintersBootstrap(context.from, s.bootstrapInterval)
+	return context
+}
+
+func (s *FuncHoltWintersForecast) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []models.Series
+	for _, serie := range series {
+		predictions, _ := holtWintersAnalysis(serie.Datapoints, serie.Interval, s.seasonality)
+		out := pointSlicePool.Get().([]schema.Point)
+		for i, p := range serie.Datapoints {
+			if p.Ts >= s.from {
+				out = append(out, schema.Point{Val: predictions[i], Ts: p.Ts})
+			}
+		}
+		target := fmt.Sprintf("holtWintersForecast(%s)", serie.Target)
+		output := models.Series{
+			Target:       target,
+			QueryPatt:    target,
+			Tags:         serie.Tags,
+			Datapoints:   out,
+			Interval:     serie.Interval,
+			Consolidator: serie.Consolidator,
+			XFilesFactor: serie.XFilesFactor,
+			QueryCons:    serie.QueryCons,
+		}
+		outputs = append(outputs, output)
+		cache[Req{}] = append(cache[Req{}], output)
+	}
+	return outputs, nil
+}
@@ -0,0 +1,117 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+// getHoltWintersInput returns a steady series with a gap, followed by a spike
+func getHoltWintersInput() []models.Series {
+	return []models.Series{
+		{
+			Target:    "foo",
+			QueryPatt: "foo",
+			Interval:  10,
+			Datapoints: []schema.Point{
+				{Val: 5, Ts: 10},
+				{Val: 5, Ts: 20},
+				{Val: math.NaN(), Ts: 30},
+				{Val: 5, Ts: 40},
+				{Val: 5, Ts: 50},
+				{Val: 105, Ts: 60},
+			},
+		},
+	}
+}
+
+func TestHoltWintersForecast(t *testing.T) {
+	nan := math.NaN()
+	f := NewHoltWintersForecast()
+	hw := f.(*FuncHoltWintersForecast)
+	hw.in = NewMock(getHoltWintersInput())
+	hw.from = 20
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	if len(got) != 1 || got[0].Target != "holtWintersForecast(foo)" {
+		t.Fatalf("expected 1 series named holtWintersForecast(foo), got %v", got)
+	}
+	// the point after the gap has no prediction, and the bootstrap point is not returned
+	exp := []schema.Point{
+		{Val: 5, Ts: 20},
+		{Val: 5, Ts: 30},
+		{Val: nan, Ts: 40},
+		{Val: 5, Ts: 50},
+		{Val: 5, Ts: 60},
+	}
+	checkPoints("holtWintersForecast", got[0].Datapoints, exp, t)
+}
+
+// TestHoltWintersForecastNoSeason tests that series of which we don't know the interval,
+// or with an interval longer than the season, get no predictions rather than a panic
+func TestHoltWintersForecastNoSeason(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		interval    uint32
+		seasonality string
+	}{
+		{0, "1d"},
+		{10, "5s"},
+		{10, "0s"},
+	}
+	for _, c := range cases {
+		input := getHoltWintersInput()
+		input[0].Interval = c.interval
+		f := NewHoltWintersForecast()
+		hw := f.(*FuncHoltWintersForecast)
+		hw.in = NewMock(input)
+		hw.seasonality = c.seasonality
+		hw.from = 20
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("interval %d, seasonality %q: err should be nil. got %q", c.interval, c.seasonality, err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("interval %d, seasonality %q: expected 1 series, got %v", c.interval, c.seasonality, got)
+		}
+		exp := []schema.Point{
+			{Val: nan, Ts: 20},
+			{Val: nan, Ts: 30},
+			{Val: nan, Ts: 40},
+			{Val: nan, Ts: 50},
+			{Val: nan, Ts: 60},
+		}
+		checkPoints("holtWintersForecast", got[0].Datapoints, exp, t)
+	}
+}
+
+func TestHoltWintersContext(t *testing.T) {
+	from := uint32(1000000)
+	to := uint32(2000000)
+	cases := []struct {
+		target string
+		expReq Req
+	}{
+		{"holtWintersForecast(foo)", NewReq("foo", from-7*86400, to, 0)},
+		{"holtWintersConfidenceBands(foo, 2, '1d')", NewReq("foo", from-86400, to, 0)},
+		{"holtWintersAberration(foo, bootstrapInterval='2h')", NewReq("foo", from-7200, to, 0)},
+		{"holtWintersForecast(foo, '30d')", NewReq("foo", 0, to, 0)},
+	}
+	for i, c := range cases {
+		e, _, err := Parse(c.target)
+		if err != nil {
+			t.Fatalf("case %d: %q, failed to parse: %s", i, c.target, err)
+		}
+		plan, err := NewPlan([]*expr{e}, from, to, 800, true, nil, nil)
+		if err != nil {
+			t.Fatalf("case %d: %q, failed to plan: %s", i, c.target, err)
+		}
+		if len(plan.Reqs) != 1 || plan.Reqs[0] != c.expReq {
+			t.Fatalf("case %d: %q, expected req %v, got %v", i, c.target, c.expReq, plan.Reqs)
+		}
+	}
+}
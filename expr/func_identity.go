@@ -0,0 +1,44 @@
+package expr
+
+import (
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncIdentity struct {
+	name string
+	from uint32
+	to   uint32
+}
+
+func NewIdentity() GraphiteFunc {
+	return &FuncIdentity{}
+}
+
+func (s *FuncIdentity) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgString{key: "name", val: &s.name},
+	}, []Arg{ArgSeries{}}
+}
+
+func (s *FuncIdentity) Context(context Context) Context {
+	s.from = context.from
+	s.to = context.to
+	return context
+}
+
+// Exec generates a series of which each value is its timestamp, with a step of a minute like graphite
+func (s *FuncIdentity) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	out := pointSlicePool.Get().([]schema.Point)
+	for ts := s.from; ts < s.to; ts += 60 {
+		out = append(out, schema.Point{Val: float64(ts), Ts: ts})
+	}
+	output := models.Series{
+		Target:     s.name,
+		QueryPatt:  s.name,
+		Datapoints: out,
+		Interval:   60,
+	}
+	cache[Req{}] = append(cache[Req{}], output)
+	return []models.Series{output}, nil
+}
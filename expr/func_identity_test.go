@@ -0,0 +1,22 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestIdentity(t *testing.T) {
+	f := NewIdentity()
+	i := f.(*FuncIdentity)
+	i.name = "time"
+	i.from = 1000
+	i.to = 1180
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	checkTargets("identity", got, []string{"time"}, t)
+	checkPoints("identity", got[0].Datapoints, []schema.Point{{Val: 1000, Ts: 1000}, {Val: 1060, Ts: 1060}, {Val: 1120, Ts: 1120}}, t)
+}
@@ -0,0 +1,54 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestIntegral(t *testing.T) {
+	nan := math.NaN()
+	f := NewIntegral()
+	f.(*FuncIntegral).in = NewMock([]models.Series{{Target: "a", QueryPatt: "a", Interval: 10, Datapoints: getCopy(a)}})
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	if len(got) != 1 || got[0].Target != "integral(a)" {
+		t.Fatalf("expected 1 series named integral(a), got %v", got)
+	}
+	checkPoints("integral", got[0].Datapoints, []schema.Point{
+		{Val: 0, Ts: 10},
+		{Val: 0, Ts: 20},
+		{Val: 5.5, Ts: 30},
+		{Val: nan, Ts: 40},
+		{Val: nan, Ts: 50},
+		{Val: 1234567895.5, Ts: 60},
+	}, t)
+}
+
+func TestIntegralByInterval(t *testing.T) {
+	f := NewIntegralByInterval()
+	i := f.(*FuncIntegralByInterval)
+	i.in = NewMock([]models.Series{{Target: "c", QueryPatt: "c", Interval: 10, Datapoints: getCopy(c)}})
+	i.intervalUnit = "20s"
+	i.Context(Context{from: 10, to: 70})
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	if len(got) != 1 || got[0].Target != "integralByInterval(c,'20s')" {
+		t.Fatalf("expected 1 series named integralByInterval(c,'20s'), got %v", got)
+	}
+	// buckets start at 10, 30 and 50
+	checkPoints("integralByInterval", got[0].Datapoints, []schema.Point{
+		{Val: 0, Ts: 10},
+		{Val: 0, Ts: 20},
+		{Val: 1, Ts: 30},
+		{Val: 3, Ts: 40},
+		{Val: 3, Ts: 50},
+		{Val: 7, Ts: 60},
+	}, t)
+}
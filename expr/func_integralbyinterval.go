@@ -0,0 +1,77 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+	"github.com/raintank/dur"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncIntegralByInterval struct {
+	in           GraphiteFunc
+	intervalUnit string
+	from         uint32 // intervals are aligned to the start of the request
+}
+
+func NewIntegralByInterval() GraphiteFunc {
+	return &FuncIntegralByInterval{}
+}
+
+func (s *FuncIntegralByInterval) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgString{key: "intervalUnit", val: &s.intervalUnit, validator: []Validator{IsIntervalString}},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncIntegralByInterval) Context(context Context) Context {
+	s.from = context.from
+	context.consol = 0
+	return context
+}
+
+func (s *FuncIntegralByInterval) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	interval, _ := dur.ParseNDuration(s.intervalUnit)
+	// the interval bucket a timestamp falls into. note: ts may be before from
+	bucket := func(ts int64) int64 {
+		d := ts - int64(s.from)
+		b := d / int64(interval)
+		if d < 0 && d%int64(interval) != 0 {
+			b--
+		}
+		return b
+	}
+
+	var outputs []models.Series
+	for _, serie := range series {
+		out := pointSlicePool.Get().([]schema.Point)
+		current := 0.0
+		for _, p := range serie.Datapoints {
+			if bucket(int64(p.Ts)) != bucket(int64(p.Ts)-int64(serie.Interval)) {
+				current = 0
+			}
+			if !math.IsNaN(p.Val) {
+				current += p.Val
+				p.Val = current
+			}
+			out = append(out, p)
+		}
+		target := fmt.Sprintf("integralByInterval(%s,'%s')", serie.Target, s.intervalUnit)
+		output := models.Series{
+			Target:     target,
+			QueryPatt:  target,
+			Tags:       serie.Tags,
+			Datapoints: out,
+			Interval:   serie.Interval,
+		}
+		outputs = append(outputs, output)
+		cache[Req{}] = append(cache[Req{}], output)
+	}
+	return outputs, nil
+}
@@ -0,0 +1,63 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncInterpolate struct {
+	in    GraphiteFunc
+	limit int64
+}
+
+func NewInterpolate() GraphiteFunc {
+	return &FuncInterpolate{limit: math.MaxInt64}
+}
+
+func (s *FuncInterpolate) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgInt{key: "limit", opt: true, val: &s.limit, validator: []Validator{IntPositive}},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncInterpolate) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncInterpolate) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []models.Series
+	for _, serie := range series {
+		out := pointSlicePool.Get().([]schema.Point)
+		out = append(out, serie.Datapoints...)
+		// we can only interpolate between two known points, so gaps at the end remain
+		fillGaps(out, s.limit, false, func(gap []schema.Point, prev, next *schema.Point) {
+			slope := (next.Val - prev.Val) / float64(next.Ts-prev.Ts)
+			for i := range gap {
+				gap[i].Val = prev.Val + slope*float64(gap[i].Ts-prev.Ts)
+			}
+		})
+
+		target := fmt.Sprintf("interpolate(%s)", serie.Target)
+		output := models.Series{
+			Target:       target,
+			QueryPatt:    target,
+			Tags:         serie.Tags,
+			Datapoints:   out,
+			Interval:     serie.Interval,
+			Consolidator: serie.Consolidator,
+			XFilesFactor: serie.XFilesFactor,
+			QueryCons:    serie.QueryCons,
+		}
+		outputs = append(outputs, output)
+		cache[Req{}] = append(cache[Req{}], output)
+	}
+	return outputs, nil
+}
@@ -0,0 +1,26 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+func TestInterpolate(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		limit int64
+		out   []float64
+	}{
+		{math.MaxInt64, []float64{nan, 1, 2, 3, 4, 5, 6, nan, nan, nan}},
+		{1, []float64{nan, 1, nan, nan, 4, 5, 6, nan, nan, nan}},
+	}
+	for _, c := range cases {
+		f := NewInterpolate()
+		i := f.(*FuncInterpolate)
+		i.in = NewMock([]models.Series{{Target: "foo", QueryPatt: "foo", Interval: 10, Datapoints: getGaps()}})
+		i.limit = c.limit
+		testGapFunc("interpolate", f, "interpolate(foo)", c.out, t)
+	}
+}
@@ -0,0 +1,44 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+type FuncInvert struct {
+	in GraphiteFunc
+}
+
+func NewInvert() GraphiteFunc {
+	return &FuncInvert{}
+}
+
+func (s *FuncInvert) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncInvert) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncInvert) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []models.Series
+	for _, serie := range series {
+		outputs = append(outputs, transformSerie(cache, serie, fmt.Sprintf("invert(%s)", serie.Target), func(val float64) float64 {
+			// like graphite, the inverse of 0 is null
+			if val == 0 {
+				return math.NaN()
+			}
+			return 1 / val
+		}))
+	}
+	return outputs, nil
+}
@@ -0,0 +1,28 @@
+package expr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInvert(t *testing.T) {
+	nan := math.NaN()
+	inf := math.Inf(1)
+	cases := []struct {
+		name string
+		in   []float64
+		out  []float64
+	}{
+		{"mixed", transformVals, []float64{-0.5, nan, nan, 2, 0.25, 0.01}},
+		{"negative zero", []float64{math.Copysign(0, -1)}, []float64{nan}},
+		{"infinite", []float64{-inf, inf}, []float64{0, 0}},
+		{"all null", []float64{nan, nan}, []float64{nan, nan}},
+		{"empty", []float64{}, []float64{}},
+	}
+	for _, c := range cases {
+		in := getTransformInput(c.in)
+		f := NewInvert()
+		f.(*FuncInvert).in = NewMock(in)
+		testTransform("invert "+c.name, f, in, "invert(foo)", c.out, t)
+	}
+}
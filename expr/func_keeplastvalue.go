@@ -0,0 +1,80 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncKeepLastValue struct {
+	in    GraphiteFunc
+	limit int64
+}
+
+func NewKeepLastValue() GraphiteFunc {
+	return &FuncKeepLastValue{limit: math.MaxInt64}
+}
+
+func (s *FuncKeepLastValue) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgInt{key: "limit", opt: true, val: &s.limit, validator: []Validator{IntPositive}},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncKeepLastValue) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncKeepLastValue) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []models.Series
+	for _, serie := range series {
+		out := pointSlicePool.Get().([]schema.Point)
+		out = append(out, serie.Datapoints...)
+		fillGaps(out, s.limit, true, func(gap []schema.Point, prev, next *schema.Point) {
+			for i := range gap {
+				gap[i].Val = prev.Val
+			}
+		})
+
+		target := fmt.Sprintf("keepLastValue(%s)", serie.Target)
+		output := models.Series{
+			Target:       target,
+			QueryPatt:    target,
+			Tags:         serie.Tags,
+			Datapoints:   out,
+			Interval:     serie.Interval,
+			Consolidator: serie.Consolidator,
+			XFilesFactor: serie.XFilesFactor,
+			QueryCons:    serie.QueryCons,
+		}
+		outputs = append(outputs, output)
+		cache[Req{}] = append(cache[Req{}], output)
+	}
+	return outputs, nil
+}
+
+// fillGaps calls fill for each gap of at most limit consecutive null points that follows a non-null point,
+// with the points of the gap, and the non-null points before and after it.
+// gaps at the end of the points are only filled if fillEnd is true, in which case next is nil.
+func fillGaps(points []schema.Point, limit int64, fillEnd bool, fill func(gap []schema.Point, prev, next *schema.Point)) {
+	prev := -1 // index of the last non-null point
+	for i := range points {
+		if math.IsNaN(points[i].Val) {
+			continue
+		}
+		if prev >= 0 && i-prev > 1 && int64(i-prev-1) <= limit {
+			fill(points[prev+1:i], &points[prev], &points[i])
+		}
+		prev = i
+	}
+	if fillEnd && prev >= 0 && prev < len(points)-1 && int64(len(points)-prev-1) <= limit {
+		fill(points[prev+1:], &points[prev], nil)
+	}
+}
@@ -0,0 +1,60 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func getGaps() []schema.Point {
+	nan := math.NaN()
+	return []schema.Point{
+		{Val: nan, Ts: 10},
+		{Val: 1, Ts: 20},
+		{Val: nan, Ts: 30},
+		{Val: nan, Ts: 40},
+		{Val: 4, Ts: 50},
+		{Val: nan, Ts: 60},
+		{Val: 6, Ts: 70},
+		{Val: nan, Ts: 80},
+		{Val: nan, Ts: 90},
+		{Val: nan, Ts: 100},
+	}
+}
+
+func TestKeepLastValue(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		limit int64
+		out   []float64
+	}{
+		{math.MaxInt64, []float64{nan, 1, 1, 1, 4, 4, 6, 6, 6, 6}},
+		{2, []float64{nan, 1, 1, 1, 4, 4, 6, nan, nan, nan}},
+		{1, []float64{nan, 1, nan, nan, 4, 4, 6, nan, nan, nan}},
+	}
+	for _, c := range cases {
+		f := NewKeepLastValue()
+		k := f.(*FuncKeepLastValue)
+		k.in = NewMock([]models.Series{{Target: "foo", QueryPatt: "foo", Interval: 10, Datapoints: getGaps()}})
+		k.limit = c.limit
+		testGapFunc("keepLastValue", f, "keepLastValue(foo)", c.out, t)
+	}
+}
+
+func testGapFunc(name string, f GraphiteFunc, target string, out []float64, t *testing.T) {
+	in := getGaps()
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("case %q: err should be nil. got %q", name, err)
+	}
+	if len(got) != 1 || got[0].Target != target {
+		t.Fatalf("case %q: expected 1 series named %s, got %v", name, target, got)
+	}
+	exp := make([]schema.Point, len(in))
+	for i := range in {
+		exp[i] = schema.Point{Val: out[i], Ts: in[i].Ts}
+	}
+	checkPoints(name, got[0].Datapoints, exp, t)
+}
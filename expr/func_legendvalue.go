@@ -0,0 +1,72 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+type FuncLegendValue struct {
+	in         GraphiteFunc
+	valueTypes []string
+}
+
+func NewLegendValue() GraphiteFunc {
+	return &FuncLegendValue{}
+}
+
+// Signature accepts a unit system as the last value type, like graphite
+func (s *FuncLegendValue) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgStrings{key: "valueTypes", val: &s.valueTypes, validator: []Validator{IsLegendValueType}},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncLegendValue) Context(context Context) Context {
+	return context
+}
+
+// Exec adds the aggregate of each series for each of the value types to its name
+func (s *FuncLegendValue) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	valueTypes := s.valueTypes
+	var system string
+	if last := valueTypes[len(valueTypes)-1]; unitSystems[last] != nil {
+		system = last
+		valueTypes = valueTypes[:len(valueTypes)-1]
+	}
+	for _, valueType := range valueTypes {
+		aggFunc := getBatchAggFunc(valueType)
+		for i, serie := range series {
+			var val float64
+			if aggFunc == nil {
+				// a unit system in the wrong place
+				val = math.NaN()
+			} else {
+				val = safeAgg(aggFunc, serie.Datapoints)
+			}
+			if system == "" {
+				formatted := "None"
+				if !math.IsNaN(val) {
+					formatted = strconv.FormatFloat(val, 'f', -1, 64)
+				}
+				series[i].Target = fmt.Sprintf("%s (%s: %s)", serie.Target, valueType, formatted)
+			} else {
+				formatted := "None"
+				if !math.IsNaN(val) {
+					val, prefix := formatUnits(val, system)
+					formatted = fmt.Sprintf("%.2f%s", val, prefix)
+				}
+				series[i].Target = fmt.Sprintf("%-20s%-5s%-10s", serie.Target, valueType, formatted)
+			}
+			series[i].QueryPatt = series[i].Target
+		}
+	}
+	return series, nil
+}
@@ -0,0 +1,40 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestLegendValue(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		valueTypes []string
+		exp        []string
+	}{
+		{
+			[]string{"avg", "last"},
+			[]string{"foo (avg: 1500) (last: 2000)", "bar (avg: None) (last: None)"},
+		},
+		{
+			[]string{"max", "si"},
+			[]string{"foo                 max  2.00K     ", "bar                 max  None      "},
+		},
+	}
+	for _, cas := range cases {
+		f := NewLegendValue()
+		l := f.(*FuncLegendValue)
+		l.in = NewMock([]models.Series{
+			{Target: "foo", Datapoints: []schema.Point{{Val: 1000, Ts: 10}, {Val: 2000, Ts: 20}}},
+			{Target: "bar", Datapoints: []schema.Point{{Val: nan, Ts: 10}, {Val: nan, Ts: 20}}},
+		})
+		l.valueTypes = cas.valueTypes
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("case %v: err should be nil. got %q", cas.valueTypes, err)
+		}
+		checkTargets("legendValue", got, cas.exp, t)
+	}
+}
@@ -0,0 +1,47 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+type FuncLogarithm struct {
+	in   GraphiteFunc
+	base float64
+}
+
+func NewLogarithm() GraphiteFunc {
+	return &FuncLogarithm{base: 10}
+}
+
+func (s *FuncLogarithm) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgFloat{key: "base", opt: true, val: &s.base, validator: []Validator{IsLogBase}},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncLogarithm) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncLogarithm) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	logBase := math.Log(s.base)
+	var outputs []models.Series
+	for _, serie := range series {
+		outputs = append(outputs, transformSerie(cache, serie, fmt.Sprintf("log(%s,%f)", serie.Target, s.base), func(val float64) float64 {
+			// like graphite, the logarithm is only defined for positive values
+			if val <= 0 {
+				return math.NaN()
+			}
+			return math.Log(val) / logBase
+		}))
+	}
+	return outputs, nil
+}
@@ -0,0 +1,35 @@
+package expr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLogarithm(t *testing.T) {
+	nan := math.NaN()
+	inf := math.Inf(1)
+	cases := []struct {
+		name   string
+		base   float64
+		in     []float64
+		target string
+		out    []float64
+	}{
+		// the logarithm of 0 and negative values is null
+		{"base 10", 10, transformVals, "log(foo,10.000000)", []float64{nan, nan, nan, math.Log(0.5) / math.Log(10), math.Log(4) / math.Log(10), 2}},
+		{"base 2", 2, transformVals, "log(foo,2.000000)", []float64{nan, nan, nan, -1, 2, math.Log(100) / math.Log(2)}},
+		{"base below 1", 0.5, []float64{1, 4, 0.25}, "log(foo,0.500000)", []float64{0, math.Log(4) / math.Log(0.5), math.Log(0.25) / math.Log(0.5)}},
+		{"negative", 10, []float64{-1, -inf, math.Copysign(0, -1)}, "log(foo,10.000000)", []float64{nan, nan, nan}},
+		{"infinite", 10, []float64{inf}, "log(foo,10.000000)", []float64{inf}},
+		{"all null", 10, []float64{nan, nan}, "log(foo,10.000000)", []float64{nan, nan}},
+		{"empty", 10, []float64{}, "log(foo,10.000000)", []float64{}},
+	}
+	for _, c := range cases {
+		in := getTransformInput(c.in)
+		f := NewLogarithm()
+		l := f.(*FuncLogarithm)
+		l.in = NewMock(in)
+		l.base = c.base
+		testTransform("log "+c.name, f, in, c.target, c.out, t)
+	}
+}
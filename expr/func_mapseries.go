@@ -0,0 +1,49 @@
+package expr
+
+import (
+	"github.com/grafana/metrictank/api/models"
+)
+
+// FuncMapSeries groups the series by the given nodes, to be fed into reduceSeries.
+// unlike graphite we don't have lists of lists: the series are returned as one list, ordered by group.
+// this is all reduceSeries needs, as it matches the series by their name anyway.
+type FuncMapSeries struct {
+	in    GraphiteFunc
+	nodes []int64
+}
+
+func NewMapSeries() GraphiteFunc {
+	return &FuncMapSeries{}
+}
+
+func (s *FuncMapSeries) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgInts{key: "mapNodes", val: &s.nodes},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncMapSeries) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncMapSeries) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	groups := make(map[string][]models.Series)
+	for _, serie := range series {
+		key := aggKey(serie, s.nodes)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], serie)
+	}
+	outputs := make([]models.Series, 0, len(series))
+	for _, key := range keys {
+		outputs = append(outputs, groups[key]...)
+	}
+	return outputs, nil
+}
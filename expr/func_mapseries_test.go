@@ -0,0 +1,24 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+func TestMapSeries(t *testing.T) {
+	f := NewMapSeries()
+	m := f.(*FuncMapSeries)
+	m.in = NewMock([]models.Series{
+		{Target: "servers.a.cpu.used", Datapoints: getCopy(a)},
+		{Target: "servers.b.cpu.used", Datapoints: getCopy(b)},
+		{Target: "servers.a.cpu.total", Datapoints: getCopy(c)},
+		{Target: "servers.b.cpu.total", Datapoints: getCopy(d)},
+	})
+	m.nodes = []int64{1}
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	checkTargets("mapSeries", got, []string{"servers.a.cpu.used", "servers.a.cpu.total", "servers.b.cpu.used", "servers.b.cpu.total"}, t)
+}
@@ -0,0 +1,125 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/batch"
+	"github.com/grafana/metrictank/consolidation"
+	"github.com/raintank/dur"
+	"gopkg.in/raintank/schema.v1"
+)
+
+// FuncMovingWindow implements movingAverage, movingSum, movingMin, movingMax and movingMedian
+type FuncMovingWindow struct {
+	in             GraphiteFunc
+	name           string
+	fn             string
+	windowPoints   int64  // window as a number of points. 0 if the window is a time interval
+	windowInterval string // window as a time interval, e.g. 5min. empty if the window is a number of points
+	from           uint32 // the start of the requested range, before we extended it for the first window
+}
+
+// NewMovingWindowConstructor returns a constructor for a function with the given name, that aggregates
+// the points in a window preceding each point, using the given aggregation function
+// (as accepted by consolidateBy, or "median")
+func NewMovingWindowConstructor(name, fn string) func() GraphiteFunc {
+	return func() GraphiteFunc {
+		return &FuncMovingWindow{name: name, fn: fn}
+	}
+}
+
+func (s *FuncMovingWindow) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgIn{key: "windowSize", args: []Arg{
+			ArgInt{val: &s.windowPoints, validator: []Validator{IntPositive}},
+			ArgString{val: &s.windowInterval, validator: []Validator{IsIntervalString}},
+		}},
+	}, []Arg{ArgSeriesList{}}
+}
+
+// Context extends the range of the requests for our input with the first window,
+// so that we can also compute the first points of the requested range.
+func (s *FuncMovingWindow) Context(context Context) Context {
+	s.from = context.from
+	if s.windowInterval != "" {
+		window, _ := dur.ParseNDuration(s.windowInterval)
+		if window > context.from {
+			window = context.from
+		}
+		context.from -= window
+	} else {
+		// we don't know the interval yet, so we ask for the points instead.
+		context.bootstrap += uint32(s.windowPoints)
+	}
+	return context
+}
+
+func (s *FuncMovingWindow) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	aggFunc := s.aggFunc()
+
+	var outputs []models.Series
+	for _, serie := range series {
+		points := int(s.windowPoints)
+		var window uint32
+		if s.windowInterval != "" {
+			window, _ = dur.ParseNDuration(s.windowInterval)
+			if serie.Interval > 0 {
+				points = int(window / serie.Interval)
+			}
+		}
+		out := pointSlicePool.Get().([]schema.Point)
+		for i, p := range serie.Datapoints {
+			if p.Ts < s.from {
+				continue
+			}
+			// like graphite, the window consists of the points leading up to (but not including) the current one
+			start := i - points
+			if s.windowInterval != "" && serie.Interval == 0 {
+				// we don't know the interval (e.g. of the output of some functions), so we select the window by timestamp
+				start = sort.Search(i, func(j int) bool { return serie.Datapoints[j].Ts+window >= p.Ts })
+			}
+			if start < 0 {
+				start = 0
+			}
+			p.Val = math.NaN()
+			if start < i {
+				p.Val = aggFunc(serie.Datapoints[start:i])
+			}
+			out = append(out, p)
+		}
+
+		target := fmt.Sprintf("%s(%s,%d)", s.name, serie.Target, s.windowPoints)
+		if s.windowInterval != "" {
+			target = fmt.Sprintf("%s(%s,'%s')", s.name, serie.Target, s.windowInterval)
+		}
+		output := models.Series{
+			Target:       target,
+			QueryPatt:    target,
+			Tags:         serie.Tags,
+			Datapoints:   out,
+			Interval:     serie.Interval,
+			Consolidator: serie.Consolidator,
+			XFilesFactor: serie.XFilesFactor,
+			QueryCons:    serie.QueryCons,
+		}
+		outputs = append(outputs, output)
+		cache[Req{}] = append(cache[Req{}], output)
+	}
+	return outputs, nil
+}
+
+func (s *FuncMovingWindow) aggFunc() batch.AggFunc {
+	if s.fn == "median" {
+		// like medianSeries and aggregate, for an even number of values, we take the average of the middle two
+		return batch.Med
+	}
+	return consolidation.GetAggFunc(consolidation.FromConsolidateBy(s.fn))
+}
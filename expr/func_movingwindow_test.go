@@ -0,0 +1,141 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestMovingWindow(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		name           string
+		fn             string
+		windowPoints   int64
+		windowInterval string
+		from           uint32
+		in             []schema.Point
+		target         string
+		out            []schema.Point
+	}{
+		{
+			"movingSum",
+			"sum",
+			2,
+			"",
+			30,
+			c,
+			"movingSum(foo,2)",
+			[]schema.Point{{Val: 0, Ts: 30}, {Val: 1, Ts: 40}, {Val: 3, Ts: 50}, {Val: 5, Ts: 60}},
+		},
+		{
+			"movingSum",
+			"sum",
+			2,
+			"",
+			10,
+			c,
+			"movingSum(foo,2)",
+			[]schema.Point{{Val: nan, Ts: 10}, {Val: 0, Ts: 20}, {Val: 0, Ts: 30}, {Val: 1, Ts: 40}, {Val: 3, Ts: 50}, {Val: 5, Ts: 60}},
+		},
+		{
+			"movingAverage",
+			"average",
+			0,
+			"20s",
+			30,
+			a,
+			"movingAverage(foo,'20s')",
+			[]schema.Point{{Val: 0, Ts: 30}, {Val: 2.75, Ts: 40}, {Val: 5.5, Ts: 50}, {Val: nan, Ts: 60}},
+		},
+		{
+			"movingMedian",
+			"median",
+			3,
+			"",
+			40,
+			d,
+			"movingMedian(foo,3)",
+			[]schema.Point{{Val: 33, Ts: 40}, {Val: 33, Ts: 50}, {Val: 80, Ts: 60}},
+		},
+		{
+			"movingMedian",
+			"median",
+			0,
+			"20s",
+			40,
+			d,
+			"movingMedian(foo,'20s')",
+			[]schema.Point{{Val: 116, Ts: 40}, {Val: 114, Ts: 50}, {Val: 54.5, Ts: 60}},
+		},
+		{
+			"movingMin",
+			"min",
+			0,
+			"30s",
+			40,
+			d,
+			"movingMin(foo,'30s')",
+			[]schema.Point{{Val: 0, Ts: 40}, {Val: 29, Ts: 50}, {Val: 29, Ts: 60}},
+		},
+		{
+			"movingMax",
+			"max",
+			3,
+			"",
+			40,
+			d,
+			"movingMax(foo,3)",
+			[]schema.Point{{Val: 199, Ts: 40}, {Val: 199, Ts: 50}, {Val: 199, Ts: 60}},
+		},
+	}
+	for _, cas := range cases {
+		// series of which we don't know the interval must give the same result
+		for _, interval := range []uint32{10, 0} {
+			f := NewMovingWindowConstructor(cas.name, cas.fn)()
+			m := f.(*FuncMovingWindow)
+			m.in = NewMock([]models.Series{{Target: "foo", QueryPatt: "foo", Interval: interval, Datapoints: getCopy(cas.in)}})
+			m.windowPoints = cas.windowPoints
+			m.windowInterval = cas.windowInterval
+			m.from = cas.from
+			got, err := f.Exec(make(map[Req][]models.Series))
+			if err != nil {
+				t.Fatalf("case %q, interval %d: err should be nil. got %q", cas.target, interval, err)
+			}
+			if len(got) != 1 || got[0].Target != cas.target {
+				t.Fatalf("case %q, interval %d: expected 1 series named %s, got %v", cas.target, interval, cas.target, got)
+			}
+			checkPoints(cas.target, got[0].Datapoints, cas.out, t)
+		}
+	}
+}
+
+// TestMovingWindowContext tests that the requests are extended with the leading window
+func TestMovingWindowContext(t *testing.T) {
+	from := uint32(1000)
+	to := uint32(2000)
+	cases := []struct {
+		target string
+		expReq Req
+	}{
+		{"movingAverage(foo, '1min')", NewReq("foo", from-60, to, 0)},
+		{"movingSum(foo, 5)", Req{Query: "foo", From: from, To: to, Bootstrap: 5}},
+		{"movingMax(movingMin(foo, 3), '2min')", Req{Query: "foo", From: from - 120, To: to, Bootstrap: 3}},
+		{"movingMedian(movingAverage(foo, 2), 3)", Req{Query: "foo", From: from, To: to, Bootstrap: 5}},
+	}
+	for i, c := range cases {
+		e, _, err := Parse(c.target)
+		if err != nil {
+			t.Fatalf("case %d: %q, failed to parse: %s", i, c.target, err)
+		}
+		plan, err := NewPlan([]*expr{e}, from, to, 800, true, nil, nil)
+		if err != nil {
+			t.Fatalf("case %d: %q, failed to plan: %s", i, c.target, err)
+		}
+		if len(plan.Reqs) != 1 || plan.Reqs[0] != c.expReq {
+			t.Fatalf("case %d: %q, expected req %v, got %v", i, c.target, c.expReq, plan.Reqs)
+		}
+	}
+}
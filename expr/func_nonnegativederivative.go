@@ -0,0 +1,77 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncNonNegativeDerivative struct {
+	in       GraphiteFunc
+	maxValue float64
+}
+
+func NewNonNegativeDerivative() GraphiteFunc {
+	return &FuncNonNegativeDerivative{maxValue: math.NaN()}
+}
+
+func (s *FuncNonNegativeDerivative) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgFloat{key: "maxValue", opt: true, val: &s.maxValue},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncNonNegativeDerivative) Context(context Context) Context {
+	context.consol = 0
+	return context
+}
+
+func (s *FuncNonNegativeDerivative) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []models.Series
+	for _, serie := range series {
+		out := pointSlicePool.Get().([]schema.Point)
+		prev := math.NaN()
+		for _, p := range serie.Datapoints {
+			var delta float64
+			delta, prev = nonNegativeDelta(p.Val, prev, s.maxValue)
+			out = append(out, schema.Point{Val: delta, Ts: p.Ts})
+		}
+		target := fmt.Sprintf("nonNegativeDerivative(%s)", serie.Target)
+		output := models.Series{
+			Target:     target,
+			QueryPatt:  target,
+			Tags:       serie.Tags,
+			Datapoints: out,
+			Interval:   serie.Interval,
+		}
+		outputs = append(outputs, output)
+		cache[Req{}] = append(cache[Req{}], output)
+	}
+	return outputs, nil
+}
+
+// nonNegativeDelta returns the delta between val and prev, and the value to use as prev for the next point.
+// values above maxValue (if not NaN) are ignored. when the counter wrapped, the delta is computed based
+// on maxValue, or NaN if there is none.
+func nonNegativeDelta(val, prev, maxValue float64) (float64, float64) {
+	if !math.IsNaN(maxValue) && val > maxValue {
+		return math.NaN(), math.NaN()
+	}
+	if math.IsNaN(prev) || math.IsNaN(val) {
+		return math.NaN(), val
+	}
+	if val >= prev {
+		return val - prev, val
+	}
+	if !math.IsNaN(maxValue) {
+		return maxValue + 1 + val - prev, val
+	}
+	return math.NaN(), val
+}
@@ -0,0 +1,41 @@
+package expr
+
+import (
+	"fmt"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+type FuncOffset struct {
+	in     GraphiteFunc
+	factor float64
+}
+
+func NewOffset() GraphiteFunc {
+	return &FuncOffset{}
+}
+
+func (s *FuncOffset) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgFloat{key: "factor", val: &s.factor},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncOffset) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncOffset) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []models.Series
+	for _, serie := range series {
+		outputs = append(outputs, transformSerie(cache, serie, fmt.Sprintf("offset(%s,%f)", serie.Target, s.factor), func(val float64) float64 {
+			return val + s.factor
+		}))
+	}
+	return outputs, nil
+}
@@ -0,0 +1,31 @@
+package expr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOffset(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		name   string
+		factor float64
+		in     []float64
+		target string
+		out    []float64
+	}{
+		{"negative", -1.5, transformVals, "offset(foo,-1.500000)", []float64{-3.5, -1.5, nan, -1, 2.5, 98.5}},
+		{"positive", 2, transformVals, "offset(foo,2.000000)", []float64{0, 2, nan, 2.5, 6, 102}},
+		{"zero", 0, transformVals, "offset(foo,0.000000)", transformVals},
+		{"all null", 10, []float64{nan, nan}, "offset(foo,10.000000)", []float64{nan, nan}},
+		{"empty", 10, []float64{}, "offset(foo,10.000000)", []float64{}},
+	}
+	for _, c := range cases {
+		in := getTransformInput(c.in)
+		f := NewOffset()
+		o := f.(*FuncOffset)
+		o.in = NewMock(in)
+		o.factor = c.factor
+		testTransform("offset "+c.name, f, in, c.target, c.out, t)
+	}
+}
@@ -0,0 +1,45 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/batch"
+)
+
+type FuncOffsetToZero struct {
+	in GraphiteFunc
+}
+
+func NewOffsetToZero() GraphiteFunc {
+	return &FuncOffsetToZero{}
+}
+
+func (s *FuncOffsetToZero) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncOffsetToZero) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncOffsetToZero) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []models.Series
+	for _, serie := range series {
+		min := math.NaN()
+		if len(serie.Datapoints) > 0 {
+			min = batch.Min(serie.Datapoints)
+		}
+		outputs = append(outputs, transformSerie(cache, serie, fmt.Sprintf("offsetToZero(%s)", serie.Target), func(val float64) float64 {
+			return val - min
+		}))
+	}
+	return outputs, nil
+}
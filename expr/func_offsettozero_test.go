@@ -0,0 +1,27 @@
+package expr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOffsetToZero(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		name string
+		in   []float64
+		out  []float64
+	}{
+		{"mixed", transformVals, []float64{0, 2, nan, 2.5, 6, 102}},
+		{"positive", []float64{5, nan, 7, 5.5}, []float64{0, nan, 2, 0.5}},
+		{"zero minimum", []float64{0, 3}, []float64{0, 3}},
+		{"all null", []float64{nan, nan}, []float64{nan, nan}},
+		{"empty", []float64{}, []float64{}},
+	}
+	for _, c := range cases {
+		in := getTransformInput(c.in)
+		f := NewOffsetToZero()
+		f.(*FuncOffsetToZero).in = NewMock(in)
+		testTransform("offsetToZero "+c.name, f, in, "offsetToZero(foo)", c.out, t)
+	}
+}
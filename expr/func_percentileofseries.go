@@ -0,0 +1,67 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncPercentileOfSeries struct {
+	in          GraphiteFunc
+	n           float64
+	interpolate bool
+}
+
+func NewPercentileOfSeries() GraphiteFunc {
+	return &FuncPercentileOfSeries{}
+}
+
+func (s *FuncPercentileOfSeries) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgFloat{key: "n", val: &s.n, validator: []Validator{IsPercentile}},
+		ArgBool{key: "interpolate", opt: true, val: &s.interpolate},
+	}, []Arg{ArgSeries{}}
+}
+
+func (s *FuncPercentileOfSeries) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncPercentileOfSeries) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	if len(series) == 0 {
+		return series, nil
+	}
+	series = normalize(cache, series)
+
+	out := pointSlicePool.Get().([]schema.Point)
+	values := make([]float64, 0, len(series))
+	for i := 0; i < len(series[0].Datapoints); i++ {
+		values = values[:0]
+		for j := 0; j < len(series); j++ {
+			if p := series[j].Datapoints[i].Val; !math.IsNaN(p) {
+				values = append(values, p)
+			}
+		}
+		out = append(out, schema.Point{Val: getPercentile(values, s.n, s.interpolate), Ts: series[0].Datapoints[i].Ts})
+	}
+
+	name := fmt.Sprintf("percentileOfSeries(%s,%g)", series[0].QueryPatt, s.n)
+	cons, queryCons := summarizeCons(series)
+	output := models.Series{
+		Target:       name,
+		QueryPatt:    name,
+		Datapoints:   out,
+		Interval:     series[0].Interval,
+		Consolidator: cons,
+		QueryCons:    queryCons,
+	}
+	cache[Req{}] = append(cache[Req{}], output)
+	return []models.Series{output}, nil
+}
@@ -0,0 +1,34 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestPercentileOfSeries(t *testing.T) {
+	f := NewPercentileOfSeries()
+	p := f.(*FuncPercentileOfSeries)
+	p.in = NewMock([]models.Series{
+		{Target: "a", QueryPatt: "*", Interval: 10, Datapoints: getCopy(a)},
+		{Target: "c", QueryPatt: "*", Interval: 10, Datapoints: getCopy(c)},
+		{Target: "d", QueryPatt: "*", Interval: 10, Datapoints: getCopy(d)},
+	})
+	p.n = 50
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	if len(got) != 1 || got[0].Target != "percentileOfSeries(*,50)" {
+		t.Fatalf("expected 1 series named percentileOfSeries(*,50), got %v", got)
+	}
+	checkPoints("percentileOfSeries", got[0].Datapoints, []schema.Point{
+		{Val: 0, Ts: 10},
+		{Val: 0, Ts: 20},
+		{Val: 5.5, Ts: 30},
+		{Val: 29, Ts: 40},
+		{Val: 80, Ts: 50},
+		{Val: 250, Ts: 60},
+	}, t)
+}
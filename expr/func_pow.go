@@ -0,0 +1,55 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+type FuncPow struct {
+	in     GraphiteFunc
+	factor float64
+}
+
+func NewPow() GraphiteFunc {
+	return &FuncPow{}
+}
+
+func (s *FuncPow) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgFloat{key: "factor", val: &s.factor},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncPow) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncPow) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []models.Series
+	for _, serie := range series {
+		outputs = append(outputs, transformSerie(cache, serie, fmt.Sprintf("pow(%s,%f)", serie.Target, s.factor), func(val float64) float64 {
+			// like graphite, nulls stay null, even though NaN to the power 0 is 1
+			if math.IsNaN(val) {
+				return val
+			}
+			return nanIfInf(math.Pow(val, s.factor))
+		}))
+	}
+	return outputs, nil
+}
+
+// nanIfInf returns the value, or NaN if it is infinite.
+// graphite returns null for math errors like 0 to a negative power, or the root of a negative number.
+func nanIfInf(val float64) float64 {
+	if math.IsInf(val, 0) {
+		return math.NaN()
+	}
+	return val
+}
@@ -0,0 +1,34 @@
+package expr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPow(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		name   string
+		factor float64
+		in     []float64
+		target string
+		out    []float64
+	}{
+		{"square", 2, transformVals, "pow(foo,2.000000)", []float64{4, 0, nan, 0.25, 16, 10000}},
+		{"cube", 3, transformVals, "pow(foo,3.000000)", []float64{-8, 0, nan, 0.125, 64, 1000000}},
+		// the root of a negative number and 0 to a negative power are math errors, which are null
+		{"negative root", -0.5, transformVals, "pow(foo,-0.500000)", []float64{nan, nan, nan, math.Pow(0.5, -0.5), 0.5, 0.1}},
+		{"root", 0.5, transformVals, "pow(foo,0.500000)", []float64{nan, 0, nan, math.Sqrt(0.5), 2, 10}},
+		{"zero", 0, transformVals, "pow(foo,0.000000)", []float64{1, 1, nan, 1, 1, 1}},
+		{"all null", 2, []float64{nan, nan}, "pow(foo,2.000000)", []float64{nan, nan}},
+		{"empty", 2, []float64{}, "pow(foo,2.000000)", []float64{}},
+	}
+	for _, c := range cases {
+		in := getTransformInput(c.in)
+		f := NewPow()
+		p := f.(*FuncPow)
+		p.in = NewMock(in)
+		p.factor = c.factor
+		testTransform("pow "+c.name, f, in, c.target, c.out, t)
+	}
+}
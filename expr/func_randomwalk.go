@@ -0,0 +1,60 @@
+package expr
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncRandomWalk struct {
+	name string
+	step int64
+	from uint32
+	to   uint32
+	mdp  uint32
+}
+
+func NewRandomWalk() GraphiteFunc {
+	return &FuncRandomWalk{step: 60}
+}
+
+func (s *FuncRandomWalk) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgString{key: "name", val: &s.name},
+		ArgInt{key: "step", opt: true, val: &s.step, validator: []Validator{IntPositive, IntUint32}},
+	}, []Arg{ArgSeries{}}
+}
+
+func (s *FuncRandomWalk) Context(context Context) Context {
+	s.from = context.from
+	s.to = context.to
+	s.mdp = context.mdp
+	return context
+}
+
+// Exec generates a random walk starting at 0, for each step in the requested range.
+// each step changes the value by a random amount between -0.5 and 0.5
+// the step is increased if it would generate more points than requested, see generatorStep
+func (s *FuncRandomWalk) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	step := generatorStep(s.from, s.to, uint32(s.step), s.mdp)
+	out := pointSlicePool.Get().([]schema.Point)
+	val := 0.0
+	for ts := s.from; ts < s.to; ts += step {
+		out = append(out, schema.Point{Val: val, Ts: ts})
+		val += rand.Float64() - 0.5
+		if ts > math.MaxUint32-step {
+			// the next ts would overflow
+			break
+		}
+	}
+	output := models.Series{
+		Target:     s.name,
+		QueryPatt:  s.name,
+		Datapoints: out,
+		Interval:   step,
+	}
+	cache[Req{}] = append(cache[Req{}], output)
+	return []models.Series{output}, nil
+}
@@ -0,0 +1,36 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+func TestRandomWalk(t *testing.T) {
+	f := NewRandomWalk()
+	r := f.(*FuncRandomWalk)
+	r.name = "walk"
+	r.from = 1000
+	r.to = 2000
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	checkTargets("randomWalk", got, []string{"walk"}, t)
+	points := got[0].Datapoints
+	if len(points) != 17 || got[0].Interval != 60 {
+		t.Fatalf("expected 17 points with interval 60, got %d points with interval %d", len(points), got[0].Interval)
+	}
+	if points[0].Val != 0 || points[0].Ts != 1000 {
+		t.Fatalf("expected the walk to start at 0 at 1000, got %v", points[0])
+	}
+	for i := 1; i < len(points); i++ {
+		if points[i].Ts != points[i-1].Ts+60 {
+			t.Fatalf("point %d: expected ts %d, got %d", i, points[i-1].Ts+60, points[i].Ts)
+		}
+		if math.Abs(points[i].Val-points[i-1].Val) > 0.5 {
+			t.Fatalf("point %d: expected a step of at most 0.5, got %f -> %f", i, points[i-1].Val, points[i].Val)
+		}
+	}
+}
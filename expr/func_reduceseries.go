@@ -0,0 +1,131 @@
+package expr
+
+import (
+	"strings"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+// FuncReduceSeries groups the series by all their nodes but the reduce node,
+// and calls the reduce function for each group, with the series matching each of the reduce matchers as arguments.
+type FuncReduceSeries struct {
+	in       GraphiteFunc
+	fn       string
+	node     int64
+	matchers []string
+	context  Context // the context to plan the reduce function with
+	stable   bool
+}
+
+func NewReduceSeries() GraphiteFunc {
+	return &FuncReduceSeries{}
+}
+
+func (s *FuncReduceSeries) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgString{key: "reduceFunction", val: &s.fn},
+		ArgInt{key: "reduceNode", val: &s.node},
+		ArgStrings{key: "reduceMatchers", val: &s.matchers},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncReduceSeries) Context(context Context) Context {
+	return context
+}
+
+// planReduce validates that the reduce function exists and accepts one series per matcher.
+// our input series are only known at execution time, which is when we'll plan the actual calls.
+func (s *FuncReduceSeries) planReduce(context Context, stable bool) error {
+	s.context = context
+	s.stable = stable
+	_, _, err := s.newReduce(func(i int) string { return s.matchers[i] })
+	return err
+}
+
+// newReduce plans a call of the reduce function, with as arguments the series named by the given function for each matcher.
+func (s *FuncReduceSeries) newReduce(name func(i int) string) (GraphiteFunc, []Req, error) {
+	fdef, ok := funcs[s.fn]
+	if !ok || (s.stable && !fdef.stable) {
+		return nil, nil, ErrUnknownFunction(s.fn)
+	}
+	e := &expr{etype: etFunc, str: s.fn}
+	for i := range s.matchers {
+		e.args = append(e.args, &expr{etype: etName, str: name(i)})
+	}
+	fn := fdef.constr()
+	reqs, err := newplanFunc(e, fn, s.context, s.stable, nil)
+	return fn, reqs, err
+}
+
+func (s *FuncReduceSeries) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	groups := make(map[string][]*models.Series)
+	for i, serie := range series {
+		nodes := strings.Split(serie.Target, ".")
+		if int(s.node) >= len(nodes) {
+			continue
+		}
+		matcher := -1
+		for j, m := range s.matchers {
+			if nodes[s.node] == m {
+				matcher = j
+				break
+			}
+		}
+		if matcher == -1 {
+			continue
+		}
+		key := strings.Join(nodes[:s.node], ".") + ".reduce." + s.fn
+		if len(nodes) > int(s.node)+1 {
+			key += "." + strings.Join(nodes[s.node+1:], ".")
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+			groups[key] = make([]*models.Series, len(s.matchers))
+		}
+		groups[key][matcher] = &series[i]
+	}
+
+	var outputs []models.Series
+	for _, key := range keys {
+		group := groups[key]
+		complete := true
+		for _, serie := range group {
+			complete = complete && serie != nil
+		}
+		// unlike graphite, which fails, we skip groups that don't have a series for every matcher
+		if !complete {
+			continue
+		}
+		fn, reqs, err := s.newReduce(func(i int) string { return group[i].Target })
+		if err != nil {
+			return nil, err
+		}
+		reduceCache := make(map[Req][]models.Series)
+		for _, r := range reqs {
+			for _, serie := range group {
+				if serie.Target == r.Query {
+					reduceCache[r] = append(reduceCache[r], *serie)
+				}
+			}
+		}
+		reduced, err := fn.Exec(reduceCache)
+		// any series generated by the reduce function must be reclaimed with ours
+		cache[Req{}] = append(cache[Req{}], reduceCache[Req{}]...)
+		if err != nil {
+			return nil, err
+		}
+		for _, serie := range reduced {
+			serie.Target = key
+			serie.QueryPatt = key
+			outputs = append(outputs, serie)
+		}
+	}
+	return outputs, nil
+}
@@ -0,0 +1,48 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestReduceSeries(t *testing.T) {
+	from := uint32(10)
+	to := uint32(61)
+	e, _, err := Parse("reduceSeries(mapSeries(servers.*.cpu.*, 1), 'divideSeries', 3, 'used', 'total')")
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	plan, err := NewPlan([]*expr{e}, from, to, 800, true, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to plan: %s", err)
+	}
+	used := []schema.Point{{Val: 1, Ts: 10}, {Val: 2, Ts: 20}}
+	total := []schema.Point{{Val: 4, Ts: 10}, {Val: 4, Ts: 20}}
+	input := map[Req][]models.Series{
+		NewReq("servers.*.cpu.*", from, to, 0): {
+			{Target: "servers.a.cpu.used", QueryPatt: "servers.*.cpu.*", Interval: 10, Datapoints: getCopy(used)},
+			{Target: "servers.a.cpu.total", QueryPatt: "servers.*.cpu.*", Interval: 10, Datapoints: getCopy(total)},
+			{Target: "servers.b.cpu.total", QueryPatt: "servers.*.cpu.*", Interval: 10, Datapoints: getCopy(total)},
+		},
+	}
+	got, err := plan.Run(input)
+	if err != nil {
+		t.Fatalf("failed to run: %s", err)
+	}
+	// servers.b has no used series, so it can't be reduced
+	checkTargets("reduceSeries", got, []string{"servers.a.cpu.reduce.divideSeries"}, t)
+	checkPoints("reduceSeries", got[0].Datapoints, []schema.Point{{Val: 0.25, Ts: 10}, {Val: 0.5, Ts: 20}}, t)
+}
+
+func TestReduceSeriesUnknownFunction(t *testing.T) {
+	e, _, err := Parse("reduceSeries(foo.*, 'doesNotExist', 1, 'a', 'b')")
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	_, err = NewPlan([]*expr{e}, 10, 61, 800, true, nil, nil)
+	if err != ErrUnknownFunction("doesNotExist") {
+		t.Fatalf("expected unknown function error, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+var ErrPercentileRange = errors.New("percentile must be between 0 and 100")
+
+// FuncRemoveAboveBelowPercentile implements removeAbovePercentile and removeBelowPercentile:
+// it replaces all values above (or below) the n-th percentile of each series with null
+type FuncRemoveAboveBelowPercentile struct {
+	in    GraphiteFunc
+	n     float64
+	above bool
+}
+
+func NewRemoveAboveBelowPercentileConstructor(above bool) func() GraphiteFunc {
+	return func() GraphiteFunc {
+		return &FuncRemoveAboveBelowPercentile{above: above}
+	}
+}
+
+func (s *FuncRemoveAboveBelowPercentile) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgFloat{key: "n", val: &s.n, validator: []Validator{IsPercentile}},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncRemoveAboveBelowPercentile) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncRemoveAboveBelowPercentile) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+
+	name := "removeBelowPercentile"
+	if s.above {
+		name = "removeAbovePercentile"
+	}
+	var outputs []models.Series
+	var values []float64
+	for _, serie := range series {
+		values = values[:0]
+		for _, p := range serie.Datapoints {
+			if !math.IsNaN(p.Val) {
+				values = append(values, p.Val)
+			}
+		}
+		percentile := getPercentile(values, s.n, false)
+
+		out := pointSlicePool.Get().([]schema.Point)
+		for _, p := range serie.Datapoints {
+			if s.above && p.Val > percentile || !s.above && p.Val < percentile {
+				p.Val = math.NaN()
+			}
+			out = append(out, p)
+		}
+		target := fmt.Sprintf("%s(%s,%f)", name, serie.Target, s.n)
+		output := models.Series{
+			Target:       target,
+			QueryPatt:    target,
+			Tags:         serie.Tags,
+			Datapoints:   out,
+			Interval:     serie.Interval,
+			Consolidator: serie.Consolidator,
+			XFilesFactor: serie.XFilesFactor,
+			QueryCons:    serie.QueryCons,
+		}
+		outputs = append(outputs, output)
+		cache[Req{}] = append(cache[Req{}], output)
+	}
+	return outputs, nil
+}
+
+// getPercentile returns the n-th percentile of the given non-null values, like graphite does:
+// without interpolation, it returns the value at the nearest rank.
+// values gets sorted in place. returns NaN if there are no values.
+func getPercentile(values []float64, n float64, interpolate bool) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sort.Float64s(values)
+
+	fractionalRank := (n / 100.0) * float64(len(values)+1)
+	rank := int(fractionalRank)
+	rankFraction := fractionalRank - float64(rank)
+	if !interpolate {
+		rank += int(math.Ceil(rankFraction))
+	}
+
+	var percentile float64
+	if rank == 0 {
+		percentile = values[0]
+	} else if rank-1 == len(values) {
+		percentile = values[len(values)-1]
+	} else {
+		percentile = values[rank-1]
+	}
+
+	if interpolate && rank < len(values) {
+		percentile += rankFraction * (values[rank] - percentile)
+	}
+	return percentile
+}
@@ -0,0 +1,70 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestGetPercentile(t *testing.T) {
+	cases := []struct {
+		values      []float64
+		n           float64
+		interpolate bool
+		exp         float64
+	}{
+		{[]float64{}, 50, false, math.NaN()},
+		{[]float64{5}, 50, false, 5},
+		{[]float64{4, 1, 3, 2}, 0, false, 1},
+		{[]float64{4, 1, 3, 2}, 50, false, 3},
+		{[]float64{4, 1, 3, 2}, 50, true, 2.5},
+		{[]float64{4, 1, 3, 2}, 75, false, 4},
+		{[]float64{4, 1, 3, 2}, 100, false, 4},
+		{[]float64{4, 1, 3, 2}, 100, true, 4},
+	}
+	for i, c := range cases {
+		got := getPercentile(c.values, c.n, c.interpolate)
+		if got != c.exp && !(math.IsNaN(got) && math.IsNaN(c.exp)) {
+			t.Fatalf("case %d: expected %f, got %f", i, c.exp, got)
+		}
+	}
+}
+
+func TestRemoveAboveBelowPercentile(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		above bool
+		n     float64
+		exp   []schema.Point
+	}{
+		{
+			// of 0,0,1,2,3,4 the 50th percentile is 2
+			true,
+			50,
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 1, Ts: 30}, {Val: 2, Ts: 40}, {Val: nan, Ts: 50}, {Val: nan, Ts: 60}},
+		},
+		{
+			false,
+			50,
+			[]schema.Point{{Val: nan, Ts: 10}, {Val: nan, Ts: 20}, {Val: nan, Ts: 30}, {Val: 2, Ts: 40}, {Val: 3, Ts: 50}, {Val: 4, Ts: 60}},
+		},
+	}
+	for _, cas := range cases {
+		f := NewRemoveAboveBelowPercentileConstructor(cas.above)()
+		r := f.(*FuncRemoveAboveBelowPercentile)
+		input := []models.Series{{Target: "c", QueryPatt: "c", Datapoints: getCopy(c)}}
+		r.in = NewMock(input)
+		r.n = cas.n
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("err should be nil. got %q", err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("expected 1 output series, got %d", len(got))
+		}
+		checkPoints(got[0].Target, got[0].Datapoints, cas.exp, t)
+		checkPoints("input", input[0].Datapoints, c, t)
+	}
+}
@@ -0,0 +1,59 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestRemoveAboveBelowValue(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		above bool
+		n     float64
+		exp   []schema.Point
+	}{
+		{
+			true,
+			2,
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}, {Val: 1, Ts: 30}, {Val: 2, Ts: 40}, {Val: nan, Ts: 50}, {Val: nan, Ts: 60}},
+		},
+		{
+			false,
+			2,
+			[]schema.Point{{Val: nan, Ts: 10}, {Val: nan, Ts: 20}, {Val: nan, Ts: 30}, {Val: 2, Ts: 40}, {Val: 3, Ts: 50}, {Val: 4, Ts: 60}},
+		},
+	}
+	for _, cas := range cases {
+		f := NewRemoveAboveBelowValueConstructor(cas.above)()
+		r := f.(*FuncRemoveAboveBelowValue)
+		input := []models.Series{{Target: "c", QueryPatt: "c", Datapoints: getCopy(c)}}
+		r.in = NewMock(input)
+		r.n = cas.n
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("err should be nil. got %q", err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("expected 1 output series, got %d", len(got))
+		}
+		checkPoints(got[0].Target, got[0].Datapoints, cas.exp, t)
+		// the input must not be modified
+		checkPoints("input", input[0].Datapoints, c, t)
+	}
+}
+
+func checkPoints(name string, got, exp []schema.Point, t *testing.T) {
+	if len(got) != len(exp) {
+		t.Fatalf("case %q: len output expected %d, got %d", name, len(exp), len(got))
+	}
+	for j, p := range got {
+		bothNaN := math.IsNaN(p.Val) && math.IsNaN(exp[j].Val)
+		if (bothNaN || p.Val == exp[j].Val) && p.Ts == exp[j].Ts {
+			continue
+		}
+		t.Fatalf("case %q: output point %d - expected %v got %v", name, j, exp[j], p)
+	}
+}
@@ -46,6 +46,7 @@ func (s *FuncScale) Exec(cache map[Req][]models.Series) ([]models.Series, error)
 			Datapoints:   out,
 			Interval:     serie.Interval,
 			Consolidator: serie.Consolidator,
+			XFilesFactor: serie.XFilesFactor,
 			QueryCons:    serie.QueryCons,
 		}
 		outputs = append(outputs, s)
@@ -0,0 +1,44 @@
+package expr
+
+import (
+	"fmt"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+type FuncScaleToSeconds struct {
+	in      GraphiteFunc
+	seconds float64
+}
+
+func NewScaleToSeconds() GraphiteFunc {
+	return &FuncScaleToSeconds{}
+}
+
+func (s *FuncScaleToSeconds) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgFloat{key: "seconds", val: &s.seconds, validator: []Validator{FloatPositive}},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncScaleToSeconds) Context(context Context) Context {
+	return context
+}
+
+// Exec scales the values, which are per interval of the series, to be per the given amount of seconds.
+// note that the interval may still change after this, due to runtime consolidation.
+func (s *FuncScaleToSeconds) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []models.Series
+	for _, serie := range series {
+		factor := s.seconds / float64(serie.Interval)
+		outputs = append(outputs, transformSerie(cache, serie, fmt.Sprintf("scaleToSeconds(%s,%d)", serie.Target, int64(s.seconds)), func(val float64) float64 {
+			return val * factor
+		}))
+	}
+	return outputs, nil
+}
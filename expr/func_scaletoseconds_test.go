@@ -0,0 +1,33 @@
+package expr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScaleToSeconds(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		name     string
+		seconds  float64
+		interval uint32
+		in       []float64
+		target   string
+		out      []float64
+	}{
+		{"scale up", 60, 10, transformVals, "scaleToSeconds(foo,60)", []float64{-12, 0, nan, 3, 24, 600}},
+		{"scale down", 30, 60, transformVals, "scaleToSeconds(foo,30)", []float64{-1, 0, nan, 0.25, 2, 50}},
+		{"same interval", 10, 10, transformVals, "scaleToSeconds(foo,10)", transformVals},
+		{"all null", 60, 10, []float64{nan, nan}, "scaleToSeconds(foo,60)", []float64{nan, nan}},
+		{"empty", 60, 10, []float64{}, "scaleToSeconds(foo,60)", []float64{}},
+	}
+	for _, c := range cases {
+		in := getTransformInput(c.in)
+		in[0].Interval = c.interval
+		f := NewScaleToSeconds()
+		s := f.(*FuncScaleToSeconds)
+		s.in = NewMock(in)
+		s.seconds = c.seconds
+		testTransform("scaleToSeconds "+c.name, f, in, c.target, c.out, t)
+	}
+}
@@ -0,0 +1,45 @@
+package expr
+
+import (
+	"strings"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+// FuncSeriesByTag returns the series that match all given tag expressions.
+// like a metric pattern, it's turned into a Req by the planner, but the series get resolved via the tag index
+type FuncSeriesByTag struct {
+	expressions []string
+	req         Req
+}
+
+func NewSeriesByTag() GraphiteFunc {
+	return &FuncSeriesByTag{}
+}
+
+func (s *FuncSeriesByTag) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgStrings{key: "tagExpressions", val: &s.expressions},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncSeriesByTag) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncSeriesByTag) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	return NewGet(s.req).Exec(cache)
+}
+
+// query returns the normalized seriesByTag call, which is used as query pattern of the request
+func (s *FuncSeriesByTag) query() string {
+	quoted := make([]string, len(s.expressions))
+	for i, expr := range s.expressions {
+		if strings.Contains(expr, "'") {
+			quoted[i] = `"` + expr + `"`
+		} else {
+			quoted[i] = "'" + expr + "'"
+		}
+	}
+	return "seriesByTag(" + strings.Join(quoted, ",") + ")"
+}
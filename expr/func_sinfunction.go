@@ -0,0 +1,58 @@
+package expr
+
+import (
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncSinFunction struct {
+	name      string
+	amplitude float64
+	step      int64
+	from      uint32
+	to        uint32
+	mdp       uint32
+}
+
+func NewSinFunction() GraphiteFunc {
+	return &FuncSinFunction{amplitude: 1, step: 60}
+}
+
+func (s *FuncSinFunction) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgString{key: "name", val: &s.name},
+		ArgFloat{key: "amplitude", opt: true, val: &s.amplitude},
+		ArgInt{key: "step", opt: true, val: &s.step, validator: []Validator{IntPositive, IntUint32}},
+	}, []Arg{ArgSeries{}}
+}
+
+func (s *FuncSinFunction) Context(context Context) Context {
+	s.from = context.from
+	s.to = context.to
+	s.mdp = context.mdp
+	return context
+}
+
+// Exec generates the sine of the timestamp, for each step in the requested range
+// the step is increased if it would generate more points than requested, see generatorStep
+func (s *FuncSinFunction) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	step := generatorStep(s.from, s.to, uint32(s.step), s.mdp)
+	out := pointSlicePool.Get().([]schema.Point)
+	for ts := s.from; ts < s.to; ts += step {
+		out = append(out, schema.Point{Val: s.amplitude * math.Sin(float64(ts)), Ts: ts})
+		if ts > math.MaxUint32-step {
+			// the next ts would overflow
+			break
+		}
+	}
+	output := models.Series{
+		Target:     s.name,
+		QueryPatt:  s.name,
+		Datapoints: out,
+		Interval:   step,
+	}
+	cache[Req{}] = append(cache[Req{}], output)
+	return []models.Series{output}, nil
+}
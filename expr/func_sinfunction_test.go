@@ -0,0 +1,75 @@
+package expr
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestSinFunction(t *testing.T) {
+	f := NewSinFunction()
+	s := f.(*FuncSinFunction)
+	s.name = "wave"
+	s.amplitude = 2
+	s.step = 30
+	s.from = 1000
+	s.to = 1090
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	checkTargets("sinFunction", got, []string{"wave"}, t)
+	exp := []schema.Point{
+		{Val: 2 * math.Sin(1000), Ts: 1000},
+		{Val: 2 * math.Sin(1030), Ts: 1030},
+		{Val: 2 * math.Sin(1060), Ts: 1060},
+	}
+	checkPoints("sinFunction", got[0].Datapoints, exp, t)
+}
+
+func TestSinFunctionBounds(t *testing.T) {
+	cases := []struct {
+		from, to, mdp uint32
+		step          int64
+		expPoints     int
+		expInterval   uint32
+	}{
+		// within mdp, the step is kept
+		{1000, 1090, 800, 30, 3, 30},
+		// a year at a step of 1 is reduced to mdp points
+		{0, 365 * 86400, 800, 1, 800, 39420},
+		// without mdp, to maxGeneratedPoints
+		{0, 365 * 86400, 0, 1, 985500, 32},
+		// close to the end of time, ts must not overflow
+		{math.MaxUint32 - 100, math.MaxUint32, 0, 60, 2, 60},
+		{math.MaxUint32 - 100, math.MaxUint32, 0, math.MaxUint32, 1, math.MaxUint32},
+	}
+	for i, c := range cases {
+		f := NewSinFunction()
+		s := f.(*FuncSinFunction)
+		s.name = "wave"
+		s.step = c.step
+		s.Context(Context{from: c.from, to: c.to, mdp: c.mdp})
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("case %d: err should be nil. got %q", i, err)
+		}
+		if len(got[0].Datapoints) != c.expPoints || got[0].Interval != c.expInterval {
+			t.Fatalf("case %d: expected %d points with interval %d, got %d points with interval %d", i, c.expPoints, c.expInterval, len(got[0].Datapoints), got[0].Interval)
+		}
+	}
+}
+
+func TestSinFunctionStepTooLarge(t *testing.T) {
+	exprs, err := ParseMany([]string{"sinFunction('wave', 1, 4294967296)"})
+	if err != nil {
+		t.Fatalf("parse error %s", err)
+	}
+	_, err = NewPlan(exprs, 1000, 2000, 800, true, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), ErrIntUint32.Error()) {
+		t.Fatalf("expected error %q, got %v", ErrIntUint32, err)
+	}
+}
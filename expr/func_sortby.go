@@ -0,0 +1,52 @@
+package expr
+
+import (
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/consolidation"
+)
+
+// FuncSortBy implements the sortByMaxima, sortByMinima and sortByTotal functions
+type FuncSortBy struct {
+	in      GraphiteFunc
+	fn      string
+	desc    bool // the default order of the function
+	reverse bool
+}
+
+// NewSortByConstructor returns a constructor for a function that sorts series based on the
+// given aggregation function (as accepted by consolidateBy), in descending order if desc is true.
+func NewSortByConstructor(fn string, desc bool) func() GraphiteFunc {
+	return func() GraphiteFunc {
+		return &FuncSortBy{fn: fn, desc: desc}
+	}
+}
+
+func (s *FuncSortBy) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgBool{key: "reverse", opt: true, val: &s.reverse},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncSortBy) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncSortBy) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	if s.fn == "min" {
+		// like graphite, sortByMinima only considers series with a maximum above 0
+		maxFunc := consolidation.GetAggFunc(consolidation.Max)
+		var positive []models.Series
+		for _, serie := range series {
+			if maxFunc(serie.Datapoints) > 0 {
+				positive = append(positive, serie)
+			}
+		}
+		series = positive
+	}
+	return sortByAgg(series, consolidation.GetAggFunc(consolidation.FromConsolidateBy(s.fn)), s.desc != s.reverse), nil
+}
@@ -0,0 +1,103 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestSortBy(t *testing.T) {
+	cases := []struct {
+		name    string
+		fn      string
+		desc    bool
+		reverse bool
+		exp     []string
+	}{
+		{"sortByMaxima", "max", true, false, []string{"a", "d", "c", "e"}},
+		{"sortByMaxima", "max", true, true, []string{"e", "c", "d", "a"}},
+		{"sortByTotal", "sum", true, false, []string{"a", "d", "c", "e"}},
+		{"sortByMinima", "min", false, false, []string{"a", "c", "d"}},
+		{"sortByMinima", "min", false, true, []string{"a", "c", "d"}},
+	}
+	for _, c := range cases {
+		f := NewSortByConstructor(c.fn, c.desc)()
+		sb := f.(*FuncSortBy)
+		sb.in = NewMock(getSortInput())
+		sb.reverse = c.reverse
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("case %s: err should be nil. got %q", c.name, err)
+		}
+		checkTargets(c.name, got, c.exp, t)
+	}
+}
+
+func TestSortByMinima(t *testing.T) {
+	f := NewSortByConstructor("min", false)()
+	f.(*FuncSortBy).in = NewMock([]models.Series{
+		{Target: "zero", Datapoints: []schema.Point{{Val: 0, Ts: 10}, {Val: 0, Ts: 20}}},
+		{Target: "positive", Datapoints: []schema.Point{{Val: 1, Ts: 10}, {Val: 2, Ts: 20}}},
+		{Target: "negative", Datapoints: []schema.Point{{Val: -1, Ts: 10}, {Val: -2, Ts: 20}}},
+		{Target: "mixed", Datapoints: []schema.Point{{Val: -5, Ts: 10}, {Val: 3, Ts: 20}}},
+	})
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	// only series with a maximum above 0 are returned
+	checkTargets("sortByMinima", got, []string{"mixed", "positive"}, t)
+}
+
+func TestSortByName(t *testing.T) {
+	input := []models.Series{
+		{Target: "foo.10"},
+		{Target: "foo.9"},
+		{Target: "bar"},
+		{Target: "foo.1"},
+	}
+	cases := []struct {
+		natural bool
+		reverse bool
+		exp     []string
+	}{
+		{false, false, []string{"bar", "foo.1", "foo.10", "foo.9"}},
+		{false, true, []string{"foo.9", "foo.10", "foo.1", "bar"}},
+		{true, false, []string{"bar", "foo.1", "foo.9", "foo.10"}},
+		{true, true, []string{"foo.10", "foo.9", "foo.1", "bar"}},
+	}
+	for _, c := range cases {
+		f := NewSortByName()
+		sb := f.(*FuncSortByName)
+		sb.in = NewMock(input)
+		sb.natural = c.natural
+		sb.reverse = c.reverse
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("err should be nil. got %q", err)
+		}
+		checkTargets("sortByName", got, c.exp, t)
+	}
+	if input[0].Target != "foo.10" {
+		t.Fatalf("sortByName should not modify its input")
+	}
+}
+
+func TestLimit(t *testing.T) {
+	for n, exp := range map[int64][]string{
+		1: {"a"},
+		3: {"a", "c", "d"},
+		5: {"a", "c", "d", "e"},
+	} {
+		f := NewLimit()
+		l := f.(*FuncLimit)
+		l.in = NewMock(getSortInput())
+		l.n = n
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("err should be nil. got %q", err)
+		}
+		checkTargets("limit", got, exp, t)
+	}
+}
@@ -0,0 +1,78 @@
+package expr
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+type FuncSortByName struct {
+	in      GraphiteFunc
+	natural bool
+	reverse bool
+}
+
+func NewSortByName() GraphiteFunc {
+	return &FuncSortByName{}
+}
+
+func (s *FuncSortByName) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgBool{key: "natural", opt: true, val: &s.natural},
+		ArgBool{key: "reverse", opt: true, val: &s.reverse},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncSortByName) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncSortByName) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.Series, len(series))
+	copy(out, series)
+	less := func(i, j int) bool { return out[i].Target < out[j].Target }
+	if s.natural {
+		less = func(i, j int) bool { return naturalLess(out[i].Target, out[j].Target) }
+	}
+	if s.reverse {
+		sort.SliceStable(out, func(i, j int) bool { return less(j, i) })
+	} else {
+		sort.SliceStable(out, less)
+	}
+	return out, nil
+}
+
+// naturalLess compares strings such that embedded numbers are compared numerically, e.g. "a2" < "a10"
+func naturalLess(a, b string) bool {
+	for len(a) > 0 && len(b) > 0 {
+		chunkA, restA := naturalChunk(a)
+		chunkB, restB := naturalChunk(b)
+		if chunkA != chunkB {
+			numA, errA := strconv.ParseUint(chunkA, 10, 64)
+			numB, errB := strconv.ParseUint(chunkB, 10, 64)
+			if errA == nil && errB == nil && numA != numB {
+				return numA < numB
+			}
+			return chunkA < chunkB
+		}
+		a, b = restA, restB
+	}
+	return len(a) < len(b)
+}
+
+// naturalChunk splits off the leading run of either digits or non-digits
+func naturalChunk(s string) (string, string) {
+	isDigit := func(c byte) bool { return c >= '0' && c <= '9' }
+	digit := isDigit(s[0])
+	i := 1
+	for i < len(s) && isDigit(s[i]) == digit {
+		i++
+	}
+	return s[:i], s[i:]
+}
@@ -0,0 +1,39 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+type FuncSquareRoot struct {
+	in GraphiteFunc
+}
+
+func NewSquareRoot() GraphiteFunc {
+	return &FuncSquareRoot{}
+}
+
+func (s *FuncSquareRoot) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncSquareRoot) Context(context Context) Context {
+	return context
+}
+
+func (s *FuncSquareRoot) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []models.Series
+	for _, serie := range series {
+		// note that the root of a negative number is NaN, which is null
+		outputs = append(outputs, transformSerie(cache, serie, fmt.Sprintf("squareRoot(%s)", serie.Target), math.Sqrt))
+	}
+	return outputs, nil
+}
@@ -0,0 +1,28 @@
+package expr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSquareRoot(t *testing.T) {
+	nan := math.NaN()
+	inf := math.Inf(1)
+	cases := []struct {
+		name string
+		in   []float64
+		out  []float64
+	}{
+		{"mixed", transformVals, []float64{nan, 0, nan, math.Sqrt(0.5), 2, 10}},
+		{"negative", []float64{-1, -100, -inf}, []float64{nan, nan, nan}},
+		{"infinite", []float64{inf}, []float64{inf}},
+		{"all null", []float64{nan, nan}, []float64{nan, nan}},
+		{"empty", []float64{}, []float64{}},
+	}
+	for _, c := range cases {
+		in := getTransformInput(c.in)
+		f := NewSquareRoot()
+		f.(*FuncSquareRoot).in = NewMock(in)
+		testTransform("squareRoot "+c.name, f, in, "squareRoot(foo)", c.out, t)
+	}
+}
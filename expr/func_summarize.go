@@ -0,0 +1,101 @@
+package expr
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/consolidation"
+	"github.com/raintank/dur"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncSummarize struct {
+	in             GraphiteFunc
+	intervalString string
+	fn             string
+	alignToFrom    bool
+	loc            *time.Location
+}
+
+func NewSummarize() GraphiteFunc {
+	return &FuncSummarize{fn: "sum"}
+}
+
+func (s *FuncSummarize) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgString{key: "intervalString", val: &s.intervalString, validator: []Validator{IsIntervalString}},
+		ArgString{key: "func", opt: true, val: &s.fn, validator: []Validator{IsConsolFunc}},
+		ArgBool{key: "alignToFrom", opt: true, val: &s.alignToFrom},
+	}, []Arg{ArgSeriesList{}}
+}
+
+func (s *FuncSummarize) Context(context Context) Context {
+	s.loc = context.loc
+	context.consol = 0
+	return context
+}
+
+func (s *FuncSummarize) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	interval, _ := dur.ParseNDuration(s.intervalString)
+	aggFunc := consolidation.GetAggFunc(consolidation.FromConsolidateBy(s.fn))
+
+	var outputs []models.Series
+	for _, serie := range series {
+		out := pointSlicePool.Get().([]schema.Point)
+		if len(serie.Datapoints) > 0 {
+			points := serie.Datapoints
+			bucketStart := getBucketStart(points[0].Ts, interval, s.alignToFrom, s.loc)
+			// the input has no gaps, so the points of a bucket are always adjacent
+			for i := 0; i < len(points); {
+				ts := bucketStart(points[i].Ts)
+				j := i + 1
+				for j < len(points) && bucketStart(points[j].Ts) == ts {
+					j++
+				}
+				out = append(out, schema.Point{Val: aggFunc(points[i:j]), Ts: ts})
+				i = j
+			}
+		}
+
+		target := fmt.Sprintf("summarize(%s, \"%s\", \"%s\")", serie.Target, s.intervalString, s.fn)
+		if s.alignToFrom {
+			target = fmt.Sprintf("summarize(%s, \"%s\", \"%s\", true)", serie.Target, s.intervalString, s.fn)
+		}
+		output := models.Series{
+			Target:     target,
+			QueryPatt:  target,
+			Tags:       serie.Tags,
+			Datapoints: out,
+			Interval:   interval,
+		}
+		outputs = append(outputs, output)
+		cache[Req{}] = append(cache[Req{}], output)
+	}
+	return outputs, nil
+}
+
+// getBucketStart returns a function that returns the start of the bucket of the given interval that a timestamp falls into.
+// with alignToFrom, buckets start at the given start of the series.
+// otherwise, like in graphite, buckets are aligned to multiples of the interval since the epoch,
+// but in the given timezone (or UTC if nil), so that e.g. 1d buckets start at midnight in that timezone.
+func getBucketStart(start, interval uint32, alignToFrom bool, loc *time.Location) func(ts uint32) uint32 {
+	if alignToFrom {
+		return func(ts uint32) uint32 {
+			return ts - (ts-start)%interval
+		}
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return func(ts uint32) uint32 {
+		_, offset := time.Unix(int64(ts), 0).In(loc).Zone()
+		local := int64(ts) + int64(offset)
+		return uint32(local - local%int64(interval) - int64(offset))
+	}
+}
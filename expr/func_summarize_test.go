@@ -0,0 +1,98 @@
+package expr
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestSummarize(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		name        string
+		in          []schema.Point
+		fn          string
+		alignToFrom bool
+		target      string
+		out         []schema.Point
+	}{
+		{
+			"sum",
+			c,
+			"sum",
+			false,
+			`summarize(foo, "20s", "sum")`,
+			[]schema.Point{{Val: 0, Ts: 0}, {Val: 1, Ts: 20}, {Val: 5, Ts: 40}, {Val: 4, Ts: 60}},
+		},
+		{
+			"sum-alignToFrom",
+			c,
+			"sum",
+			true,
+			`summarize(foo, "20s", "sum", true)`,
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 3, Ts: 30}, {Val: 7, Ts: 50}},
+		},
+		{
+			"avg-with-nulls",
+			a,
+			"avg",
+			false,
+			`summarize(foo, "20s", "avg")`,
+			[]schema.Point{{Val: 0, Ts: 0}, {Val: 2.75, Ts: 20}, {Val: nan, Ts: 40}, {Val: 1234567890, Ts: 60}},
+		},
+		{
+			"max-alignToFrom",
+			a,
+			"max",
+			true,
+			`summarize(foo, "20s", "max", true)`,
+			[]schema.Point{{Val: 0, Ts: 10}, {Val: 5.5, Ts: 30}, {Val: 1234567890, Ts: 50}},
+		},
+	}
+	for _, cas := range cases {
+		f := NewSummarize()
+		s := f.(*FuncSummarize)
+		s.in = NewMock([]models.Series{{Target: "foo", QueryPatt: "foo", Interval: 10, Datapoints: getCopy(cas.in)}})
+		s.intervalString = "20s"
+		s.fn = cas.fn
+		s.alignToFrom = cas.alignToFrom
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("case %q: err should be nil. got %q", cas.name, err)
+		}
+		if len(got) != 1 || got[0].Target != cas.target {
+			t.Fatalf("case %q: expected 1 series named %s, got %v", cas.name, cas.target, got)
+		}
+		if got[0].Interval != 20 {
+			t.Fatalf("case %q: expected interval 20, got %d", cas.name, got[0].Interval)
+		}
+		checkPoints(cas.name, got[0].Datapoints, cas.out, t)
+	}
+}
+
+func TestSummarizeTimezone(t *testing.T) {
+	// 2 days of hourly points, starting at midnight UTC
+	start := uint32(10 * 24 * 3600)
+	var in []schema.Point
+	for i := uint32(0); i < 48; i++ {
+		in = append(in, schema.Point{Val: 1, Ts: start + i*3600})
+	}
+	f := NewSummarize()
+	s := f.(*FuncSummarize)
+	s.in = NewMock([]models.Series{{Target: "a", QueryPatt: "a", Interval: 3600, Datapoints: in}})
+	s.intervalString = "1d"
+	s.Context(Context{from: start, to: start + 48*3600, loc: time.FixedZone("EST", -5*3600)})
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	// days start at midnight EST, which is 5AM UTC
+	checkPoints("summarize-timezone", got[0].Datapoints, []schema.Point{
+		{Val: 5, Ts: start - 19*3600},
+		{Val: 24, Ts: start + 5*3600},
+		{Val: 19, Ts: start + 29*3600},
+	}, t)
+}
@@ -2,7 +2,6 @@ package expr
 
 import (
 	"fmt"
-	"math"
 	"strings"
 
 	"github.com/grafana/metrictank/api/models"
@@ -43,24 +42,9 @@ func (s *FuncSumSeries) Exec(cache map[Req][]models.Series) ([]models.Series, er
 		series[0].QueryPatt = name
 		return series, nil
 	}
+	series = normalize(cache, series)
 	out := pointSlicePool.Get().([]schema.Point)
-	for i := 0; i < len(series[0].Datapoints); i++ {
-		nan := true
-		point := schema.Point{
-			Ts:  series[0].Datapoints[i].Ts,
-			Val: 0,
-		}
-		for j := 0; j < len(series); j++ {
-			if !math.IsNaN(series[j].Datapoints[i].Val) {
-				point.Val += series[j].Datapoints[i].Val
-				nan = false
-			}
-		}
-		if nan {
-			point.Val = math.NaN()
-		}
-		out = append(out, point)
-	}
+	crossSeriesSum(series, &out)
 	name := fmt.Sprintf("sumSeries(%s)", strings.Join(queryPatts, ","))
 	cons, queryCons := summarizeCons(series)
 	output := models.Series{
@@ -0,0 +1,44 @@
+package expr
+
+import (
+	"strconv"
+
+	"github.com/grafana/metrictank/api/models"
+)
+
+type FuncThreshold struct {
+	value float64
+	label string
+	color string
+	from  uint32
+	to    uint32
+}
+
+func NewThreshold() GraphiteFunc {
+	return &FuncThreshold{}
+}
+
+// Signature accepts the color for compatibility with graphite,
+// but we don't render graphs, so it is ignored
+func (s *FuncThreshold) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgFloat{key: "value", val: &s.value},
+		ArgString{key: "label", opt: true, val: &s.label},
+		ArgString{key: "color", opt: true, val: &s.color},
+	}, []Arg{ArgSeries{}}
+}
+
+func (s *FuncThreshold) Context(context Context) Context {
+	s.from = context.from
+	s.to = context.to
+	return context
+}
+
+func (s *FuncThreshold) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	name := s.label
+	if name == "" {
+		name = strconv.FormatFloat(s.value, 'f', -1, 64)
+	}
+	output := constantLine(cache, name, s.value, s.from, s.to)
+	return []models.Series{output}, nil
+}
@@ -0,0 +1,32 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestThreshold(t *testing.T) {
+	cases := []struct {
+		label  string
+		target string
+	}{
+		{"", "90"},
+		{"SLA", "SLA"},
+	}
+	for _, c := range cases {
+		f := NewThreshold()
+		th := f.(*FuncThreshold)
+		th.value = 90
+		th.label = c.label
+		th.from = 1000
+		th.to = 1060
+		got, err := f.Exec(make(map[Req][]models.Series))
+		if err != nil {
+			t.Fatalf("case %q: err should be nil. got %q", c.label, err)
+		}
+		checkTargets("threshold", got, []string{c.target}, t)
+		checkPoints("threshold", got[0].Datapoints, []schema.Point{{Val: 90, Ts: 1000}, {Val: 90, Ts: 1030}, {Val: 90, Ts: 1060}}, t)
+	}
+}
@@ -0,0 +1,102 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/grafana/metrictank/api/models"
+	"github.com/raintank/dur"
+	"gopkg.in/raintank/schema.v1"
+)
+
+type FuncTimeShift struct {
+	in       GraphiteFunc
+	shift    string
+	resetEnd bool
+	offset   int64  // in seconds. negative means we fetch data from the past
+	to       uint32 // the end of the requested range, before shifting
+}
+
+func NewTimeShift() GraphiteFunc {
+	return &FuncTimeShift{resetEnd: true}
+}
+
+func (s *FuncTimeShift) Signature() ([]Arg, []Arg) {
+	return []Arg{
+		ArgSeriesList{val: &s.in},
+		ArgString{key: "timeShift", val: &s.shift, validator: []Validator{IsSignedIntervalString}},
+		ArgBool{key: "resetEnd", opt: true, val: &s.resetEnd},
+	}, []Arg{ArgSeriesList{}}
+}
+
+// Context shifts the time range of the requests for our input,
+// so we fetch the same series over the shifted time range.
+func (s *FuncTimeShift) Context(context Context) Context {
+	s.offset, _ = parseSignedInterval(s.shift)
+	s.to = context.to
+	context.from = shiftTs(context.from, s.offset)
+	context.to = shiftTs(context.to, s.offset)
+	return context
+}
+
+func (s *FuncTimeShift) Exec(cache map[Req][]models.Series) ([]models.Series, error) {
+	series, err := s.in.Exec(cache)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []models.Series
+	for _, serie := range series {
+		out := pointSlicePool.Get().([]schema.Point)
+		for _, p := range serie.Datapoints {
+			p.Ts = shiftTs(p.Ts, -s.offset)
+			// with resetEnd, we don't return data beyond the end of the requested range.
+			// this can happen when shifting data from the future.
+			if s.resetEnd && p.Ts >= s.to {
+				break
+			}
+			out = append(out, p)
+		}
+		target := fmt.Sprintf("timeShift(%s, \"%s\")", serie.Target, s.shift)
+		output := models.Series{
+			Target:       target,
+			QueryPatt:    target,
+			Tags:         serie.Tags,
+			Datapoints:   out,
+			Interval:     serie.Interval,
+			Consolidator: serie.Consolidator,
+			XFilesFactor: serie.XFilesFactor,
+			QueryCons:    serie.QueryCons,
+		}
+		outputs = append(outputs, output)
+		cache[Req{}] = append(cache[Req{}], output)
+	}
+	return outputs, nil
+}
+
+// shiftTs shifts the timestamp by offset seconds, without going past the epoch
+// or beyond the largest timestamp we can represent
+func shiftTs(ts uint32, offset int64) uint32 {
+	shifted := int64(ts) + offset
+	if shifted < 0 {
+		return 0
+	}
+	if shifted > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint32(shifted)
+}
+
+// parseSignedInterval parses a duration like 1d, -1h or +5min to a number of seconds.
+// like in graphite, durations without sign are negative.
+func parseSignedInterval(s string) (int64, error) {
+	sign := int64(-1)
+	if strings.HasPrefix(s, "+") {
+		sign = 1
+		s = s[1:]
+	} else if strings.HasPrefix(s, "-") {
+		s = s[1:]
+	}
+	interval, err := dur.ParseDuration(s)
+	return sign * int64(interval), err
+}
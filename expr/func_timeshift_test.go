@@ -0,0 +1,117 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestParseSignedInterval(t *testing.T) {
+	cases := []struct {
+		in     string
+		exp    int64
+		expErr bool
+	}{
+		{"1h", -3600, false},
+		{"-1h", -3600, false},
+		{"+1h", 3600, false},
+		{"1d2h", -93600, false},
+		{"+5min", 300, false},
+		{"1foo", 0, true},
+		{"", 0, true},
+	}
+	for _, c := range cases {
+		got, err := parseSignedInterval(c.in)
+		if (err != nil) != c.expErr {
+			t.Fatalf("case %q: expected error %t, got %v", c.in, c.expErr, err)
+		}
+		if err == nil && got != c.exp {
+			t.Fatalf("case %q: expected %d, got %d", c.in, c.exp, got)
+		}
+	}
+}
+
+func TestTimeShift(t *testing.T) {
+	from := uint32(7210)
+	to := uint32(7270)
+	// c, but with timestamps starting at from
+	var exp []schema.Point
+	for i, p := range c {
+		exp = append(exp, schema.Point{Val: p.Val, Ts: from + uint32(i)*10})
+	}
+	cases := []struct {
+		shift    string
+		resetEnd bool
+		expReq   Req
+		exp      []schema.Point
+	}{
+		{
+			"1h",
+			true,
+			NewReq("foo", from-3600, to-3600, 0),
+			exp,
+		},
+		{
+			"+10s",
+			true,
+			NewReq("foo", from+10, to+10, 0),
+			exp,
+		},
+		{
+			// the shifted series has an extra point beyond the requested range
+			"+10s",
+			false,
+			NewReq("foo", from+10, to+10, 0),
+			append(getCopy(exp), schema.Point{Val: 5, Ts: to}),
+		},
+	}
+	for _, cas := range cases {
+		e, _, err := Parse("timeShift(foo, '" + cas.shift + "')")
+		if err != nil {
+			t.Fatalf("case %q: %s", cas.shift, err)
+		}
+		plan, err := NewPlan([]*expr{e}, from, to, 800, true, nil, nil)
+		if err != nil {
+			t.Fatalf("case %q: %s", cas.shift, err)
+		}
+		if len(plan.Reqs) != 1 || plan.Reqs[0] != cas.expReq {
+			t.Fatalf("case %q: expected req %v, got %v", cas.shift, cas.expReq, plan.Reqs)
+		}
+		plan.funcs[0].(*FuncTimeShift).resetEnd = cas.resetEnd
+
+		// the data as it would be fetched for the shifted range
+		var fetched []schema.Point
+		for i, p := range c {
+			fetched = append(fetched, schema.Point{Val: p.Val, Ts: cas.expReq.From + uint32(i)*10})
+		}
+		fetched = append(fetched, schema.Point{Val: 5, Ts: cas.expReq.From + 60})
+
+		out, err := plan.Run(map[Req][]models.Series{
+			cas.expReq: {{Target: "foo", QueryPatt: "foo", Interval: 10, Datapoints: fetched}},
+		})
+		if err != nil {
+			t.Fatalf("case %q: %s", cas.shift, err)
+		}
+		if len(out) != 1 || out[0].Target != `timeShift(foo, "`+cas.shift+`")` {
+			t.Fatalf("case %q: expected 1 series named timeShift(foo, %q), got %v", cas.shift, cas.shift, out)
+		}
+		checkPoints(out[0].Target, out[0].Datapoints, cas.exp, t)
+	}
+}
+
+func TestTimeShiftBeyondEpoch(t *testing.T) {
+	e, _, err := Parse("timeShift(foo, '100y')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := NewPlan([]*expr{e}, 1000, 2000, 800, true, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the shifted range must be clamped at the epoch, rather than wrapping around
+	expReq := NewReq("foo", 0, 0, 0)
+	if len(plan.Reqs) != 1 || plan.Reqs[0] != expReq {
+		t.Fatalf("expected req %v, got %v", expReq, plan.Reqs)
+	}
+}
@@ -11,16 +11,18 @@ import (
 type FuncTransformNull struct {
 	in  GraphiteFunc
 	def float64
+	ref GraphiteFunc // optional. nil if not set
 }
 
 func NewTransformNull() GraphiteFunc {
-	return &FuncTransformNull{nil, math.NaN()}
+	return &FuncTransformNull{nil, math.NaN(), nil}
 }
 
 func (s *FuncTransformNull) Signature() ([]Arg, []Arg) {
 	return []Arg{
 		ArgSeriesList{val: &s.in},
 		ArgFloat{key: "default", opt: true, val: &s.def},
+		ArgSeriesList{key: "referenceSeries", opt: true, val: &s.ref},
 	}, []Arg{ArgSeriesList{}}
 }
 
@@ -39,10 +41,34 @@ func (s *FuncTransformNull) Exec(cache map[Req][]models.Series) ([]models.Series
 		custom = false
 	}
 
+	// with reference series, we only replace nulls where any of the reference series has a value
+	var ref []models.Series
+	var refName string
+	if s.ref != nil {
+		ref, err = s.ref.Exec(cache)
+		if err != nil {
+			return nil, err
+		}
+		ref = normalize(cache, ref)
+		if len(ref) > 0 {
+			refName = ref[0].QueryPatt
+		}
+	}
+	hasRefValue := func(i int) bool {
+		for _, r := range ref {
+			if i < len(r.Datapoints) && !math.IsNaN(r.Datapoints[i].Val) {
+				return true
+			}
+		}
+		return false
+	}
+
 	var out []models.Series
 	for _, serie := range series {
 		var target string
-		if custom {
+		if s.ref != nil {
+			target = fmt.Sprintf("transFormNull(%s,%f,%s)", serie.Target, s.def, refName)
+		} else if custom {
 			target = fmt.Sprintf("transFormNull(%s,%f)", serie.Target, s.def)
 		} else {
 			target = fmt.Sprintf("transFormNull(%s)", serie.Target)
@@ -53,10 +79,11 @@ func (s *FuncTransformNull) Exec(cache map[Req][]models.Series) ([]models.Series
 			Datapoints:   pointSlicePool.Get().([]schema.Point),
 			Interval:     serie.Interval,
 			Consolidator: serie.Consolidator,
+			XFilesFactor: serie.XFilesFactor,
 			QueryCons:    serie.QueryCons,
 		}
-		for _, p := range serie.Datapoints {
-			if math.IsNaN(p.Val) {
+		for i, p := range serie.Datapoints {
+			if math.IsNaN(p.Val) && (s.ref == nil || hasRefValue(i)) {
 				p.Val = s.def
 			}
 			transformed.Datapoints = append(transformed.Datapoints, p)
@@ -0,0 +1,71 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestTransformNull(t *testing.T) {
+	nan := math.NaN()
+	f := NewTransformNull()
+	f.(*FuncTransformNull).in = NewMock([]models.Series{{Target: "a", QueryPatt: "a", Interval: 10, Datapoints: getCopy(a)}})
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	if len(got) != 1 || got[0].Target != "transFormNull(a)" {
+		t.Fatalf("expected 1 series named transFormNull(a), got %v", got)
+	}
+	checkPoints("transformNull", got[0].Datapoints, []schema.Point{
+		{Val: 0, Ts: 10},
+		{Val: 0, Ts: 20},
+		{Val: 5.5, Ts: 30},
+		{Val: 0, Ts: 40},
+		{Val: 0, Ts: 50},
+		{Val: 1234567890, Ts: 60},
+	}, t)
+
+	// with a reference series, only the nulls where the reference series has a value are replaced
+	f = NewTransformNull()
+	tn := f.(*FuncTransformNull)
+	tn.in = NewMock([]models.Series{{Target: "a", QueryPatt: "a", Interval: 10, Datapoints: getCopy(a)}})
+	tn.def = -1
+	tn.ref = NewMock([]models.Series{{Target: "b", QueryPatt: "b", Interval: 10, Datapoints: getCopy(b)}})
+	got, err = f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("err should be nil. got %q", err)
+	}
+	if len(got) != 1 || got[0].Target != "transFormNull(a,-1.000000,b)" {
+		t.Fatalf("expected 1 series named transFormNull(a,-1.000000,b), got %v", got)
+	}
+	checkPoints("transformNull-reference", got[0].Datapoints, []schema.Point{
+		{Val: 0, Ts: 10},
+		{Val: 0, Ts: 20},
+		{Val: 5.5, Ts: 30},
+		{Val: nan, Ts: 40},
+		{Val: -1, Ts: 50},
+		{Val: 1234567890, Ts: 60},
+	}, t)
+}
+
+// TestTransformNullReferenceSeriesArg tests that the reference series can be given by position and by key
+func TestTransformNullReferenceSeriesArg(t *testing.T) {
+	from := uint32(1000)
+	to := uint32(2000)
+	for _, target := range []string{"transformNull(foo, -1, bar)", "transformNull(foo, -1, referenceSeries=bar)"} {
+		e, _, err := Parse(target)
+		if err != nil {
+			t.Fatalf("%q: failed to parse: %s", target, err)
+		}
+		plan, err := NewPlan([]*expr{e}, from, to, 800, true, nil, nil)
+		if err != nil {
+			t.Fatalf("%q: failed to plan: %s", target, err)
+		}
+		if len(plan.Reqs) != 2 || plan.Reqs[0] != NewReq("foo", from, to, 0) || plan.Reqs[1] != NewReq("bar", from, to, 0) {
+			t.Fatalf("%q: expected requests for foo and bar, got %v", target, plan.Reqs)
+		}
+	}
+}
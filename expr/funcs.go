@@ -1,14 +1,20 @@
 package expr
 
 import (
+	"math"
+	"time"
+
 	"github.com/grafana/metrictank/api/models"
 	"github.com/grafana/metrictank/consolidation"
 )
 
 type Context struct {
-	from   uint32
-	to     uint32
-	consol consolidation.Consolidator // can be 0 to mean undefined
+	from      uint32
+	to        uint32
+	consol    consolidation.Consolidator // can be 0 to mean undefined
+	loc       *time.Location             // timezone of the request, used for calendar alignment. nil means UTC
+	bootstrap uint32                     // number of points needed before from. see Req.Bootstrap
+	mdp       uint32                     // max number of points per series the request wants. 0 means no limit. see Plan.MaxDataPoints
 }
 
 type GraphiteFunc interface {
@@ -46,22 +52,94 @@ var funcs map[string]funcDef
 func init() {
 	// keys must be sorted alphabetically. but functions with aliases can go together, in which case they are sorted by the first of their aliases
 	funcs = map[string]funcDef{
-		"alias":          {NewAlias, true},
-		"aliasByNode":    {NewAliasByNode, true},
-		"aliasSub":       {NewAliasSub, true},
-		"avg":            {NewAvgSeries, true},
-		"averageSeries":  {NewAvgSeries, true},
-		"consolidateBy":  {NewConsolidateBy, true},
-		"divideSeries":   {NewDivideSeries, true},
-		"max":            {NewMaxSeries, true},
-		"maxSeries":      {NewMaxSeries, true},
-		"movingAverage":  {NewMovingAverage, false},
-		"perSecond":      {NewPerSecond, true},
-		"scale":          {NewScale, true},
-		"smartSummarize": {NewSmartSummarize, false},
-		"sum":            {NewSumSeries, true},
-		"sumSeries":      {NewSumSeries, true},
-		"transformNull":  {NewTransformNull, true},
+		"absolute":                   {NewAbsolute, true},
+		"aggregate":                  {NewAggregate, true},
+		"alias":                      {NewAlias, true},
+		"aliasByMetric":              {NewAliasByMetric, true},
+		"aliasByNode":                {NewAliasByNode, true},
+		"aliasByTags":                {NewAliasByTags, true},
+		"aliasSub":                   {NewAliasSub, true},
+		"applyByNode":                {NewApplyByNode, true},
+		"asPercent":                  {NewAsPercent, true},
+		"avg":                        {NewAvgSeries, true},
+		"averageAbove":               {NewFilterSeriesConstructor("average", ">"), true},
+		"averageSeries":              {NewAvgSeries, true},
+		"cactiStyle":                 {NewCactiStyle, true},
+		"consolidateBy":              {NewConsolidateBy, true},
+		"constantLine":               {NewConstantLine, true},
+		"currentAbove":               {NewFilterSeriesConstructor("last", ">"), true},
+		"currentBelow":               {NewFilterSeriesConstructor("last", "<="), true},
+		"delay":                      {NewDelay, true},
+		"derivative":                 {NewDerivative, true},
+		"diffSeries":                 {NewAggregateSeriesConstructor("diffSeries", "diff"), true},
+		"divideSeries":               {NewDivideSeries, true},
+		"groupByNode":                {NewGroupByNode, true},
+		"groupByNodes":               {NewGroupByNodes, true},
+		"groupByTags":                {NewGroupByTags, true},
+		"highestAverage":             {NewHighestLowestConstructor("average", true), true},
+		"highestCurrent":             {NewHighestLowestConstructor("last", true), true},
+		"highestMax":                 {NewHighestLowestConstructor("max", true), true},
+		"hitcount":                   {NewHitcount, true},
+		"holtWintersAberration":      {NewHoltWintersAberration, true},
+		"holtWintersConfidenceBands": {NewHoltWintersConfidenceBands, true},
+		"holtWintersForecast":        {NewHoltWintersForecast, true},
+		"identity":                   {NewIdentity, true},
+		"integral":                   {NewIntegral, true},
+		"integralByInterval":         {NewIntegralByInterval, true},
+		"interpolate":                {NewInterpolate, true},
+		"invert":                     {NewInvert, true},
+		"keepLastValue":              {NewKeepLastValue, true},
+		"legendValue":                {NewLegendValue, true},
+		"limit":                      {NewLimit, true},
+		"log":                        {NewLogarithm, true},
+		"logarithm":                  {NewLogarithm, true},
+		"lowestAverage":              {NewHighestLowestConstructor("average", false), true},
+		"lowestCurrent":              {NewHighestLowestConstructor("last", false), true},
+		"mapSeries":                  {NewMapSeries, true},
+		"max":                        {NewMaxSeries, true},
+		"maxSeries":                  {NewMaxSeries, true},
+		"maximumAbove":               {NewFilterSeriesConstructor("max", ">"), true},
+		"maximumBelow":               {NewFilterSeriesConstructor("max", "<="), true},
+		"minSeries":                  {NewAggregateSeriesConstructor("minSeries", "min"), true},
+		"minimumAbove":               {NewFilterSeriesConstructor("min", ">"), true},
+		"movingAverage":              {NewMovingWindowConstructor("movingAverage", "average"), true},
+		"movingMax":                  {NewMovingWindowConstructor("movingMax", "max"), true},
+		"movingMedian":               {NewMovingWindowConstructor("movingMedian", "median"), true},
+		"movingMin":                  {NewMovingWindowConstructor("movingMin", "min"), true},
+		"movingSum":                  {NewMovingWindowConstructor("movingSum", "sum"), true},
+		"multiplySeries":             {NewAggregateSeriesConstructor("multiplySeries", "multiply"), true},
+		"nonNegativeDerivative":      {NewNonNegativeDerivative, true},
+		"offset":                     {NewOffset, true},
+		"offsetToZero":               {NewOffsetToZero, true},
+		"perSecond":                  {NewPerSecond, true},
+		"percentileOfSeries":         {NewPercentileOfSeries, true},
+		"pow":                        {NewPow, true},
+		"randomWalk":                 {NewRandomWalk, true},
+		"randomWalkFunction":         {NewRandomWalk, true},
+		"rangeOfSeries":              {NewAggregateSeriesConstructor("rangeSeries", "range"), true},
+		"rangeSeries":                {NewAggregateSeriesConstructor("rangeSeries", "range"), true},
+		"reduceSeries":               {NewReduceSeries, true},
+		"removeAbovePercentile":      {NewRemoveAboveBelowPercentileConstructor(true), true},
+		"removeAboveValue":           {NewRemoveAboveBelowValueConstructor(true), true},
+		"removeBelowPercentile":      {NewRemoveAboveBelowPercentileConstructor(false), true},
+		"removeBelowValue":           {NewRemoveAboveBelowValueConstructor(false), true},
+		"scale":                      {NewScale, true},
+		"scaleToSeconds":             {NewScaleToSeconds, true},
+		"seriesByTag":                {NewSeriesByTag, true},
+		"sinFunction":                {NewSinFunction, true},
+		"smartSummarize":             {NewSmartSummarize, false},
+		"sortByMaxima":               {NewSortByConstructor("max", true), true},
+		"sortByMinima":               {NewSortByConstructor("min", false), true},
+		"sortByName":                 {NewSortByName, true},
+		"sortByTotal":                {NewSortByConstructor("sum", true), true},
+		"squareRoot":                 {NewSquareRoot, true},
+		"stddevSeries":               {NewAggregateSeriesConstructor("stddevSeries", "stddev"), true},
+		"sum":                        {NewSumSeries, true},
+		"sumSeries":                  {NewSumSeries, true},
+		"summarize":                  {NewSummarize, true},
+		"threshold":                  {NewThreshold, true},
+		"timeShift":                  {NewTimeShift, true},
+		"transformNull":              {NewTransformNull, true},
 	}
 }
 
@@ -91,3 +169,43 @@ func consumeFuncs(cache map[Req][]models.Series, fns []GraphiteFunc) ([]models.S
 	}
 	return series, queryPatts, nil
 }
+
+// uniqueQueryPatts returns the distinct query patterns of the given series, in order of appearance,
+// so that functions combining a single series list can name their output like graphite does.
+func uniqueQueryPatts(series []models.Series) []string {
+	var queryPatts []string
+	seen := make(map[string]struct{})
+	for _, serie := range series {
+		if _, ok := seen[serie.QueryPatt]; !ok {
+			queryPatts = append(queryPatts, serie.QueryPatt)
+			seen[serie.QueryPatt] = struct{}{}
+		}
+	}
+	return queryPatts
+}
+
+// maxGeneratedPoints is the max number of points a generator function like randomWalk generates,
+// for requests that don't limit the number of points per series
+const maxGeneratedPoints = 1000000
+
+// generatorStep returns the interval at which a generator function generates points between from and to:
+// the requested step, or a multiple of it if that would generate more than mdp points
+// (or maxGeneratedPoints if mdp is 0), like runtime consolidation would reduce them to.
+func generatorStep(from, to, step, mdp uint32) uint32 {
+	max := uint64(mdp)
+	if max == 0 {
+		max = maxGeneratedPoints
+	}
+	if to <= from {
+		return step
+	}
+	points := (uint64(to-from) + uint64(step) - 1) / uint64(step)
+	if points <= max {
+		return step
+	}
+	factor := (points + max - 1) / max
+	if uint64(step)*factor > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return step * uint32(factor)
+}
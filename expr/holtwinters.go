@@ -0,0 +1,121 @@
+package expr
+
+import (
+	"math"
+
+	"github.com/raintank/dur"
+	"gopkg.in/raintank/schema.v1"
+)
+
+// the smoothing parameters used by graphite
+const (
+	hwAlpha = 0.1
+	hwBeta  = 0.0035
+	hwGamma = 0.1
+)
+
+// holtWintersBootstrap returns the start of the range we need to fetch in order to have
+// the given bootstrap interval of data before from.
+func holtWintersBootstrap(from uint32, bootstrapInterval string) uint32 {
+	bootstrap, _ := dur.ParseNDuration(bootstrapInterval)
+	if bootstrap > from {
+		return 0
+	}
+	return from - bootstrap
+}
+
+// holtWintersAnalysis runs a triple exponential smoothing over the points, like graphite does,
+// with a season of the given length, and returns the prediction and the deviation for each point.
+// predictions are NaN where they can't be made due to missing input.
+// if the interval is unknown, or the season is shorter than the interval, all predictions and deviations are NaN.
+func holtWintersAnalysis(points []schema.Point, interval uint32, seasonality string) ([]float64, []float64) {
+	predictions := make([]float64, len(points))
+	deviations := make([]float64, len(points))
+
+	season, _ := dur.ParseNDuration(seasonality)
+	if interval == 0 || season < interval {
+		// we can't tell which point was one season ago (e.g. the output of some functions has no interval,
+		// or a rollup archive was chosen that is coarser than the season), so we can't predict anything
+		for i := range points {
+			predictions[i] = math.NaN()
+			deviations[i] = math.NaN()
+		}
+		return predictions, deviations
+	}
+	seasonLength := int(season / interval)
+
+	intercepts := make([]float64, len(points))
+	slopes := make([]float64, len(points))
+	seasonals := make([]float64, len(points))
+
+	// lastSeason returns the value from one season before i, or 0 if we don't have a full season yet
+	lastSeason := func(values []float64, i int) float64 {
+		j := i - seasonLength
+		if j >= 0 && j < len(values) {
+			return values[j]
+		}
+		return 0
+	}
+
+	nextPred := math.NaN()
+	for i, p := range points {
+		actual := p.Val
+		if math.IsNaN(actual) {
+			// missing input values break all the math, do the best we can and move on
+			intercepts[i] = math.NaN()
+			predictions[i] = nextPred
+			nextPred = math.NaN()
+			continue
+		}
+
+		var lastIntercept, lastSlope, prediction float64
+		if i == 0 {
+			// seed the first prediction as the first actual
+			lastIntercept = actual
+			prediction = actual
+		} else {
+			lastIntercept = intercepts[i-1]
+			lastSlope = slopes[i-1]
+			if math.IsNaN(lastIntercept) {
+				lastIntercept = actual
+			}
+			prediction = nextPred
+		}
+
+		lastSeasonal := lastSeason(seasonals, i)
+		nextLastSeasonal := lastSeason(seasonals, i+1)
+		lastSeasonalDev := lastSeason(deviations, i)
+
+		intercept := hwAlpha*(actual-lastSeasonal) + (1-hwAlpha)*(lastIntercept+lastSlope)
+		slope := hwBeta*(intercept-lastIntercept) + (1-hwBeta)*lastSlope
+		seasonal := hwGamma*(actual-intercept) + (1-hwGamma)*lastSeasonal
+		nextPred = intercept + slope + nextLastSeasonal
+
+		predictionOrZero := prediction
+		if math.IsNaN(predictionOrZero) {
+			predictionOrZero = 0
+		}
+		deviation := hwGamma*math.Abs(actual-predictionOrZero) + (1-hwGamma)*lastSeasonalDev
+
+		intercepts[i] = intercept
+		slopes[i] = slope
+		seasonals[i] = seasonal
+		predictions[i] = prediction
+		deviations[i] = deviation
+	}
+	return predictions, deviations
+}
+
+// holtWintersBands returns the lower and upper confidence bands for the points,
+// which are delta deviations below and above the predictions.
+func holtWintersBands(points []schema.Point, interval uint32, seasonality string, delta float64) ([]float64, []float64) {
+	predictions, deviations := holtWintersAnalysis(points, interval, seasonality)
+	lower := make([]float64, len(points))
+	upper := make([]float64, len(points))
+	for i := range points {
+		// any NaN prediction makes the bands NaN as well
+		lower[i] = predictions[i] - delta*deviations[i]
+		upper[i] = predictions[i] + delta*deviations[i]
+	}
+	return lower, upper
+}
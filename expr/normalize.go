@@ -0,0 +1,60 @@
+package expr
+
+import (
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/consolidation"
+	"github.com/grafana/metrictank/util"
+	"gopkg.in/raintank/schema.v1"
+)
+
+// normalize brings the given series to the same interval, the least common multiple of all their intervals,
+// so they can be combined point by point.
+// series coming straight from the store are already normalized by the query engine (see alignRequests in the api package),
+// but the output of some functions (e.g. summarize) may have a different interval.
+func normalize(cache map[Req][]models.Series, in []models.Series) []models.Series {
+	var intervals []uint32
+	seen := make(map[uint32]struct{})
+	for _, serie := range in {
+		if _, ok := seen[serie.Interval]; !ok {
+			intervals = append(intervals, serie.Interval)
+			seen[serie.Interval] = struct{}{}
+		}
+	}
+	if len(intervals) < 2 {
+		return in
+	}
+	interval := util.Lcm(intervals)
+	out := make([]models.Series, 0, len(in))
+	for _, serie := range in {
+		if serie.Interval != interval {
+			serie = normalizeTo(cache, serie, interval)
+		}
+		out = append(out, serie)
+	}
+	return out
+}
+
+// normalizeTo consolidates the series to the given interval, which must be a multiple of its interval.
+// like runtime consolidation, each output point is the aggregate of the input points leading up to its timestamp,
+// which is a multiple of the interval.
+func normalizeTo(cache map[Req][]models.Series, in models.Series, interval uint32) models.Series {
+	aggNum := interval / in.Interval
+	datapoints := in.Datapoints
+	// strip the leading points that belong to a bucket that started before the series did
+	for i := uint32(1); i < aggNum && len(datapoints) > 0 && datapoints[0].Ts%interval != in.Interval; i++ {
+		datapoints = datapoints[1:]
+	}
+	out := pointSlicePool.Get().([]schema.Point)
+	out = append(out, datapoints...)
+	consolidator := in.Consolidator
+	if consolidator == 0 {
+		consolidator = consolidation.Avg
+	}
+	if len(out) > 0 {
+		out = consolidation.Consolidate(out, aggNum, consolidator, in.XFilesFactor)
+	}
+	in.Datapoints = out
+	in.Interval = interval
+	cache[Req{}] = append(cache[Req{}], in)
+	return in
+}
@@ -0,0 +1,43 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/consolidation"
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestNormalize(t *testing.T) {
+	in := []models.Series{
+		{Target: "c", Interval: 10, Datapoints: getCopy(c)},
+		{Target: "coarse", Interval: 20, Datapoints: []schema.Point{{Val: 1, Ts: 20}, {Val: 2, Ts: 40}, {Val: 3, Ts: 60}}},
+		// the first point belongs to a bucket that started before the series did, so it is stripped.
+		{Target: "d", Interval: 10, Consolidator: consolidation.Max, Datapoints: getCopy(d)[1:]},
+	}
+	got := normalize(make(map[Req][]models.Series), in)
+	exp := [][]schema.Point{
+		{{Val: 0, Ts: 20}, {Val: 1.5, Ts: 40}, {Val: 3.5, Ts: 60}},
+		{{Val: 1, Ts: 20}, {Val: 2, Ts: 40}, {Val: 3, Ts: 60}},
+		{{Val: 199, Ts: 40}, {Val: 250, Ts: 60}},
+	}
+	for i, serie := range got {
+		if serie.Interval != 20 {
+			t.Fatalf("series %q: expected interval 20, got %d", serie.Target, serie.Interval)
+		}
+		checkPoints(serie.Target, serie.Datapoints, exp[i], t)
+	}
+	// the input must not be modified
+	checkPoints("c-input", in[0].Datapoints, c, t)
+}
+
+func TestNormalizeSameInterval(t *testing.T) {
+	in := []models.Series{
+		{Target: "a", Interval: 10, Datapoints: getCopy(a)},
+		{Target: "c", Interval: 10, Datapoints: getCopy(c)},
+	}
+	got := normalize(make(map[Req][]models.Series), in)
+	for i, serie := range got {
+		checkPoints(serie.Target, serie.Datapoints, in[i].Datapoints, t)
+	}
+}
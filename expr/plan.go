@@ -4,7 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"sort"
+	"time"
 
 	"github.com/grafana/metrictank/api/models"
 	"github.com/grafana/metrictank/consolidation"
@@ -12,10 +12,16 @@ import (
 
 // Req represents a request for one/more series
 type Req struct {
-	Query string // whatever was parsed as the query out of a graphite target. e.g. target=sum(foo.{b,a}r.*) -> foo.{b,a}r.* -> this will go straight to index lookup
-	From  uint32
-	To    uint32
-	Cons  consolidation.Consolidator // can be 0 to mean undefined
+	Query string                     `json:"query"` // whatever was parsed as the query out of a graphite target. e.g. target=sum(foo.{b,a}r.*) -> foo.{b,a}r.* -> this will go straight to index lookup
+	From  uint32                     `json:"from"`
+	To    uint32                     `json:"to"`
+	Cons  consolidation.Consolidator `json:"cons"` // can be 0 to mean undefined
+	// number of points to fetch before From, on top of the time range.
+	// used by functions that need a window of a number of points, which we can't translate to a time range before we know the interval.
+	Bootstrap uint32 `json:"bootstrap"`
+	// for the data of a seriesByTag() call, the tag expressions to look up the series by. nil otherwise.
+	// it's a pointer, so that Req remains comparable.
+	Tags *[]string `json:"tags,omitempty"`
 }
 
 // NewReq creates a new Req. pass cons=0 to leave consolidator undefined,
@@ -29,8 +35,18 @@ func NewReq(query string, from, to uint32, cons consolidation.Consolidator) Req
 	}
 }
 
+// TagExpressions returns the tag expressions to look up the series by,
+// if the request is for the data of a seriesByTag() call
+func (r Req) TagExpressions() ([]string, bool) {
+	if r.Tags == nil {
+		return nil, false
+	}
+	return *r.Tags, true
+}
+
 type Plan struct {
 	Reqs          []Req          // data that needs to be fetched before functions can be executed
+	reqTargets    []int          // for each of the Reqs, the index of the target it is for
 	funcs         []GraphiteFunc // top-level funcs to execute, the head of each tree for each target
 	exprs         []*expr
 	MaxDataPoints uint32
@@ -57,6 +73,31 @@ func (p Plan) Dump(w io.Writer) {
 	fmt.Fprintf(w, "To: %d\n", p.To)
 }
 
+// ExprTrees returns the parsed form of each of the expressions of the plan
+func (p Plan) ExprTrees() []ExprTree {
+	trees := make([]ExprTree, 0, len(p.exprs))
+	for _, e := range p.exprs {
+		trees = append(trees, e.Tree())
+	}
+	return trees
+}
+
+// ReqTarget returns the index of the target that the i'th of the Reqs is for
+func (p Plan) ReqTarget(i int) int {
+	return p.reqTargets[i]
+}
+
+// Depth returns the depth of the deepest expression of the plan
+func (p Plan) Depth() int {
+	var max int
+	for _, e := range p.exprs {
+		if d := e.depth(); d > max {
+			max = d
+		}
+	}
+	return max
+}
+
 // Plan validates the expressions and comes up with the initial (potentially non-optimal) execution plan
 // which is just a list of requests and the expressions.
 // traverse tree and as we go down:
@@ -64,23 +105,31 @@ func (p Plan) Dump(w io.Writer) {
 // * validation of arguments
 // * allow functions to modify the Context (change data range or consolidation)
 // * future version: allow functions to mark safe to pre-aggregate using consolidateBy or not
-func NewPlan(exprs []*expr, from, to, mdp uint32, stable bool, reqs []Req) (Plan, error) {
+// loc is the timezone of the request. it may be nil, which means UTC.
+func NewPlan(exprs []*expr, from, to, mdp uint32, stable bool, loc *time.Location, reqs []Req) (Plan, error) {
 	var err error
 	var funcs []GraphiteFunc
-	for _, e := range exprs {
+	reqTargets := make([]int, len(reqs))
+	for i, e := range exprs {
 		var fn GraphiteFunc
 		context := Context{
 			from: from,
 			to:   to,
+			loc:  loc,
+			mdp:  mdp,
 		}
 		fn, reqs, err = newplan(e, context, stable, reqs)
 		if err != nil {
 			return Plan{}, err
 		}
+		for len(reqTargets) < len(reqs) {
+			reqTargets = append(reqTargets, i)
+		}
 		funcs = append(funcs, fn)
 	}
 	return Plan{
 		Reqs:          reqs,
+		reqTargets:    reqTargets,
 		exprs:         exprs,
 		funcs:         funcs,
 		MaxDataPoints: mdp,
@@ -96,6 +145,7 @@ func newplan(e *expr, context Context, stable bool, reqs []Req) (GraphiteFunc, [
 	}
 	if e.etype == etName {
 		req := NewReq(e.str, context.from, context.to, context.consol)
+		req.Bootstrap = context.bootstrap
 		reqs = append(reqs, req)
 		return NewGet(req), reqs, nil
 	}
@@ -111,7 +161,27 @@ func newplan(e *expr, context Context, stable bool, reqs []Req) (GraphiteFunc, [
 
 	fn := fdef.constr()
 	reqs, err := newplanFunc(e, fn, context, stable, reqs)
-	return fn, reqs, err
+	if err != nil {
+		return nil, nil, err
+	}
+	switch f := fn.(type) {
+	case *FuncSeriesByTag:
+		// like a metric pattern, seriesByTag requests data, rather than processing it
+		f.req = NewReq(f.query(), context.from, context.to, context.consol)
+		f.req.Bootstrap = context.bootstrap
+		f.req.Tags = &f.expressions
+		reqs = append(reqs, f.req)
+	case *FuncApplyByNode:
+		// applyByNode also requests the data for its template function, which depends on its input
+		reqs, err = f.planTemplate(context, stable, reqs)
+	case *FuncReduceSeries:
+		// reduceSeries calls another function, which we can only validate now that we know its name
+		err = f.planReduce(context, stable)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return fn, reqs, nil
 }
 
 // newplanFunc adds requests as needed for the given expr, and validates the function input
@@ -133,6 +203,7 @@ func newplanFunc(e *expr, fn GraphiteFunc, context Context, stable bool, reqs []
 	pos := 0    // pos in args of next given arg to process
 	cutoff := 0 // marks the index of the first optional point (if any)
 	var argExp Arg
+	var seriesArgs []seriesArg // the series args we found, which we can only set up once we know the context
 	for cutoff, argExp = range argsExp {
 		if argExp.Optional() {
 			break
@@ -140,6 +211,9 @@ func newplanFunc(e *expr, fn GraphiteFunc, context Context, stable bool, reqs []
 		if len(e.args) <= pos {
 			return nil, ErrMissingArg
 		}
+		if e.isSeriesArg(pos, argExp) {
+			seriesArgs = append(seriesArgs, seriesArg{e, pos, argExp})
+		}
 		pos, err = e.consumeBasicArg(pos, argExp)
 		if err != nil {
 			return nil, err
@@ -159,6 +233,9 @@ func newplanFunc(e *expr, fn GraphiteFunc, context Context, stable bool, reqs []
 		if len(e.args) <= pos {
 			break // no more args specified. we're done.
 		}
+		if e.isSeriesArg(pos, argOpt) {
+			seriesArgs = append(seriesArgs, seriesArg{e, pos, argOpt})
+		}
 		pos, err = e.consumeBasicArg(pos, argOpt)
 		if err != nil {
 			return nil, err
@@ -181,6 +258,13 @@ func newplanFunc(e *expr, fn GraphiteFunc, context Context, stable bool, reqs []
 			return nil, err
 		}
 		seenKwargs[key] = struct{}{}
+		// series given by key are set up like positional ones, from an expression that only has the given argument
+		kwarg := &expr{args: []*expr{e.namedArgs[key]}}
+		for _, argOpt := range argsExp[cutoff:] {
+			if argOpt.Key() == key && kwarg.isSeriesArg(0, argOpt) {
+				seriesArgs = append(seriesArgs, seriesArg{kwarg, 0, argOpt})
+			}
+		}
 	}
 
 	// functions now have their non-series input args set,
@@ -189,19 +273,20 @@ func newplanFunc(e *expr, fn GraphiteFunc, context Context, stable bool, reqs []
 	// now that we know the needed context for the data coming into
 	// this function, we can set up the input arguments for the function
 	// that are series
-	pos = 0
-	for _, argExp = range argsExp[:cutoff] {
-		switch argExp.(type) {
-		case ArgSeries, ArgSeriesList, ArgSeriesLists:
-			pos, reqs, err = e.consumeSeriesArg(pos, argExp, context, stable, reqs)
-			if err != nil {
-				return nil, err
-			}
-		default:
-			return reqs, err
+	for _, s := range seriesArgs {
+		_, reqs, err = s.e.consumeSeriesArg(s.pos, s.arg, context, stable, reqs)
+		if err != nil {
+			return nil, err
 		}
 	}
-	return reqs, err
+	return reqs, nil
+}
+
+// seriesArg is an argument providing series, at the given position in the args of an expression
+type seriesArg struct {
+	e   *expr
+	pos int
+	arg Arg
 }
 
 // Run invokes all processing as specified in the plan (expressions, from/to) with the input as input
@@ -213,7 +298,6 @@ func (p Plan) Run(input map[Req][]models.Series) ([]models.Series, error) {
 		if err != nil {
 			return nil, err
 		}
-		sort.Sort(models.SeriesByTarget(series))
 		out = append(out, series...)
 	}
 	for i, o := range out {
@@ -223,7 +307,7 @@ func (p Plan) Run(input map[Req][]models.Series) ([]models.Series, error) {
 			if o.Consolidator == 0 {
 				o.Consolidator = consolidation.Avg
 			}
-			out[i].Datapoints, out[i].Interval = consolidation.ConsolidateStable(o.Datapoints, o.Interval, p.MaxDataPoints, o.Consolidator)
+			out[i].Datapoints, out[i].Interval = consolidation.ConsolidateStable(o.Datapoints, o.Interval, p.MaxDataPoints, o.Consolidator, o.XFilesFactor)
 		}
 	}
 	return out, nil
@@ -1,6 +1,7 @@
 package expr
 
 import (
+	"math"
 	"reflect"
 	"testing"
 
@@ -163,6 +164,54 @@ func TestArgs(t *testing.T) {
 	}
 }
 
+// TestArgIn tests that arguments that can be of multiple types, including series, are correctly set up
+func TestArgIn(t *testing.T) {
+	from := uint32(1000)
+	to := uint32(2000)
+	cases := []struct {
+		target    string
+		expReq    []Req
+		expFloat  float64
+		expSeries bool
+		expNodes  []int64
+		expErr    error
+	}{
+		{"asPercent(foo.*)", []Req{NewReq("foo.*", from, to, 0)}, math.NaN(), false, nil, nil},
+		{"asPercent(foo.*, 10)", []Req{NewReq("foo.*", from, to, 0)}, 10, false, nil, nil},
+		{"asPercent(foo.*, total=2.5)", []Req{NewReq("foo.*", from, to, 0)}, 2.5, false, nil, nil},
+		{"asPercent(foo.*, sumSeries(bar.*))", []Req{NewReq("foo.*", from, to, 0), NewReq("bar.*", from, to, 0)}, math.NaN(), true, nil, nil},
+		{"asPercent(foo.*, None, 1, 2)", []Req{NewReq("foo.*", from, to, 0)}, math.NaN(), false, []int64{1, 2}, nil},
+		{"asPercent(foo.*, bar.*, 1)", []Req{NewReq("foo.*", from, to, 0), NewReq("bar.*", from, to, 0)}, math.NaN(), true, []int64{1}, nil},
+		{"asPercent(foo.*, 'bar')", nil, math.NaN(), false, nil, ErrBadArgumentStr{"expr.ArgFloat or expr.ArgSeriesList", "etString"}},
+	}
+	for i, c := range cases {
+		e, _, err := Parse(c.target)
+		if err != nil {
+			t.Fatalf("case %d: %q, failed to parse: %s", i, c.target, err)
+		}
+		fn, reqs, err := newplan(e, Context{from: from, to: to}, true, nil)
+		if !reflect.DeepEqual(err, c.expErr) {
+			t.Fatalf("case %d: %q, expected error %v - got %v", i, c.target, c.expErr, err)
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(reqs, c.expReq) {
+			t.Errorf("case %d: %q, expected req %v - got %v", i, c.target, c.expReq, reqs)
+		}
+		f := fn.(*FuncAsPercent)
+		if f.totalFloat != c.expFloat && !(math.IsNaN(f.totalFloat) && math.IsNaN(c.expFloat)) {
+			t.Errorf("case %d: %q, expected total %f - got %f", i, c.target, c.expFloat, f.totalFloat)
+		}
+		if (f.totalSeries != nil) != c.expSeries {
+			t.Errorf("case %d: %q, expected total series %t - got %v", i, c.target, c.expSeries, f.totalSeries)
+		}
+		if !reflect.DeepEqual(f.nodes, c.expNodes) {
+			t.Errorf("case %d: %q, expected nodes %v - got %v", i, c.target, c.expNodes, f.nodes)
+		}
+	}
+}
+
 // TestConsolidateBy tests for a variety of input targets, wether consolidateBy settings are correctly
 // propagated down the tree (to fetch requests) and up the tree (to runtime consolidation of the output)
 func TestConsolidateBy(t *testing.T) {
@@ -270,7 +319,7 @@ func TestConsolidateBy(t *testing.T) {
 	for i, c := range cases {
 		// for the purpose of this test, we assume ParseMany works fine.
 		exprs, _ := ParseMany([]string{c.in})
-		plan, err := NewPlan(exprs, from, to, 800, stable, nil)
+		plan, err := NewPlan(exprs, from, to, 800, stable, nil, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -333,10 +382,11 @@ func TestNamingChains(t *testing.T) {
 				"metrictank.stats.env.instance2.input.carbon.metric_invalid.counter32",
 				"metrictank.stats.env.instance1.input.kafka.metric_invalid.counter32",
 			},
+			// like graphite, output follows the order of the (sorted) input series
 			[]string{
-				"carbon metric invalid",
 				"carbon metric invalid",
 				"kafka metric invalid",
+				"carbon metric invalid",
 			},
 		},
 		{
@@ -420,6 +470,20 @@ func TestNamingChains(t *testing.T) {
 				"a",
 			},
 		},
+		{
+			// functions that define the order of their output must not get re-sorted
+			`aliasByNode(sortByName(*.bar, false, true), 0)`,
+			[]string{
+				"a.bar",
+				"c.bar",
+				"b.bar",
+			},
+			[]string{
+				"c",
+				"b",
+				"a",
+			},
+		},
 	}
 
 	for i, c := range cases {
@@ -427,7 +491,7 @@ func TestNamingChains(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		plan, err := NewPlan(exprs, from, to, 800, stable, nil)
+		plan, err := NewPlan(exprs, from, to, 800, stable, nil, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -458,3 +522,196 @@ func TestNamingChains(t *testing.T) {
 		}
 	}
 }
+
+// TestSeriesByTag tests that seriesByTag calls result in tag based requests, which get fed into the rest of the tree
+func TestSeriesByTag(t *testing.T) {
+	from := uint32(1000)
+	to := uint32(2000)
+	stable := true
+	cases := []struct {
+		in        string
+		expReq    []Req
+		expTags   [][]string
+		expTarget []string
+	}{
+		{
+			`seriesByTag('name=cpu', "dc=~us-.*")`,
+			[]Req{
+				NewReq("seriesByTag('name=cpu','dc=~us-.*')", from, to, 0),
+			},
+			[][]string{
+				{"name=cpu", "dc=~us-.*"},
+			},
+			[]string{"cpu.a", "cpu.b"},
+		},
+		{
+			`sumSeries(consolidateBy(seriesByTag('name=cpu','dc=~us-.*'), "max"))`,
+			[]Req{
+				NewReq("seriesByTag('name=cpu','dc=~us-.*')", from, to, consolidation.Max),
+			},
+			[][]string{
+				{"name=cpu", "dc=~us-.*"},
+			},
+			[]string{`sumSeries(consolidateBy(seriesByTag('name=cpu','dc=~us-.*'),"max"))`},
+		},
+		{
+			`sumSeries(seriesByTag("owner=o'brien"), foo)`,
+			[]Req{
+				NewReq(`seriesByTag("owner=o'brien")`, from, to, 0),
+				NewReq("foo", from, to, 0),
+			},
+			[][]string{
+				{"owner=o'brien"},
+				nil,
+			},
+			[]string{`sumSeries(seriesByTag("owner=o'brien"),foo)`},
+		},
+		{
+			`seriesByTag("owner=o'brien", 'quote=say "hi"')`,
+			[]Req{
+				NewReq(`seriesByTag("owner=o'brien",'quote=say "hi"')`, from, to, 0),
+			},
+			[][]string{
+				{"owner=o'brien", `quote=say "hi"`},
+			},
+			[]string{"cpu.a", "cpu.b"},
+		},
+	}
+
+	for i, c := range cases {
+		exprs, err := ParseMany([]string{c.in})
+		if err != nil {
+			t.Fatalf("case %d: %q, parse error %s", i, c.in, err)
+		}
+		plan, err := NewPlan(exprs, from, to, 800, stable, nil, nil)
+		if err != nil {
+			t.Fatalf("case %d: %q, plan error %s", i, c.in, err)
+		}
+		for j := range c.expReq {
+			if c.expTags[j] != nil {
+				c.expReq[j].Tags = &c.expTags[j]
+			}
+		}
+		if !reflect.DeepEqual(plan.Reqs, c.expReq) {
+			t.Fatalf("case %d: %q, expected req %v - got %v", i, c.in, c.expReq, plan.Reqs)
+		}
+		input := make(map[Req][]models.Series)
+		for j, r := range plan.Reqs {
+			tags, ok := r.TagExpressions()
+			if ok != (c.expTags[j] != nil) || !reflect.DeepEqual(tags, c.expTags[j]) {
+				t.Fatalf("case %d: %q, req %d: expected tag expressions %v - got %v (%t)", i, c.in, j, c.expTags[j], tags, ok)
+			}
+			for _, name := range []string{"cpu.a", "cpu.b"} {
+				input[r] = append(input[r], models.Series{
+					Target:     name,
+					QueryPatt:  r.Query,
+					QueryCons:  r.Cons,
+					Datapoints: getCopy(a),
+				})
+			}
+		}
+		out, err := plan.Run(input)
+		if err != nil {
+			t.Fatalf("case %d: %q, run error %s", i, c.in, err)
+		}
+		var targets []string
+		for _, o := range out {
+			targets = append(targets, o.Target)
+		}
+		if !reflect.DeepEqual(targets, c.expTarget) {
+			t.Fatalf("case %d: %q, expected targets %v - got %v", i, c.in, c.expTarget, targets)
+		}
+	}
+}
+
+// TestSeriesByTagBadArgument tests that seriesByTag rejects expressions that aren't strings, naming their type
+func TestSeriesByTagBadArgument(t *testing.T) {
+	exprs, err := ParseMany([]string{"seriesByTag(1)"})
+	if err != nil {
+		t.Fatalf("parse error %s", err)
+	}
+	_, err = NewPlan(exprs, 1000, 2000, 800, true, nil, nil)
+	exp := ErrBadArgumentStr{"string", "etInt"}
+	if err != exp {
+		t.Fatalf("expected error %q - got %v", exp, err)
+	}
+}
+
+func TestExprTrees(t *testing.T) {
+	e, _, err := Parse("summarize(sumSeries(foo.*, bar), '1h', func='max')")
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	plan, err := NewPlan([]*expr{e}, 1000, 2000, 800, true, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to plan: %s", err)
+	}
+	exp := []ExprTree{
+		{
+			Type:  "func",
+			Value: "summarize",
+			Args: []ExprTree{
+				{
+					Type:  "func",
+					Value: "sumSeries",
+					Args: []ExprTree{
+						{Type: "name", Value: "foo.*"},
+						{Type: "name", Value: "bar"},
+					},
+				},
+				{Type: "string", Value: "1h"},
+			},
+			NamedArgs: map[string]ExprTree{
+				"func": {Type: "string", Value: "max"},
+			},
+		},
+	}
+	if got := plan.ExprTrees(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+}
+
+func TestPlanDepth(t *testing.T) {
+	cases := []struct {
+		in  []string
+		exp int
+	}{
+		{[]string{"foo.bar"}, 1},
+		{[]string{"sumSeries(foo.*)"}, 2},
+		{[]string{"foo.bar", "scale(sumSeries(foo.*, bar), 2)"}, 3},
+		{[]string{"summarize(sumSeries(foo.*), '1h', func='max')"}, 3},
+	}
+	for i, c := range cases {
+		exprs, err := ParseMany(c.in)
+		if err != nil {
+			t.Fatalf("case %d: failed to parse: %s", i, err)
+		}
+		plan, err := NewPlan(exprs, 1000, 2000, 800, true, nil, nil)
+		if err != nil {
+			t.Fatalf("case %d: failed to plan: %s", i, err)
+		}
+		if got := plan.Depth(); got != c.exp {
+			t.Fatalf("case %d: %q, expected depth %d, got %d", i, c.in, c.exp, got)
+		}
+	}
+}
+
+func TestPlanReqTarget(t *testing.T) {
+	exprs, err := ParseMany([]string{"foo.bar", "sumSeries(foo.*, bar.*)", "constantLine(1)", "seriesByTag('a=b')"})
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	plan, err := NewPlan(exprs, 1000, 2000, 800, true, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to plan: %s", err)
+	}
+	exp := []int{0, 1, 1, 3}
+	if len(plan.Reqs) != len(exp) {
+		t.Fatalf("expected %d reqs, got %v", len(exp), plan.Reqs)
+	}
+	for i := range plan.Reqs {
+		if got := plan.ReqTarget(i); got != exp[i] {
+			t.Fatalf("req %d (%v): expected target %d, got %d", i, plan.Reqs[i], exp[i], got)
+		}
+	}
+}
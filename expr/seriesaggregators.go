@@ -0,0 +1,279 @@
+package expr
+
+import (
+	"math"
+
+	"github.com/grafana/metrictank/api/models"
+	"github.com/grafana/metrictank/batch"
+	"gopkg.in/raintank/schema.v1"
+)
+
+// crossSeriesAggFunc aggregates the points of the given series, timestamp by timestamp, and appends the result to out.
+// the input series must all have the same amount of points, with the same timestamps.
+type crossSeriesAggFunc func(in []models.Series, out *[]schema.Point)
+
+// getCrossSeriesAggFunc returns the aggregation function for the given name, as used by
+// functions that take an aggregation function as a parameter (e.g. groupByNode), or nil if there is none
+func getCrossSeriesAggFunc(c string) crossSeriesAggFunc {
+	switch c {
+	case "avg", "average":
+		return crossSeriesAvg
+	case "diff":
+		return crossSeriesDiff
+	case "max":
+		return crossSeriesMax
+	case "min":
+		return crossSeriesMin
+	case "multiply":
+		return crossSeriesMultiply
+	case "range", "rangeOf":
+		return crossSeriesRange
+	case "stddev":
+		return crossSeriesStddev
+	case "sum", "total":
+		return crossSeriesSum
+	}
+	if fn := getBatchAggFunc(c); fn != nil {
+		return crossSeriesBatch(fn, 0)
+	}
+	return nil
+}
+
+// getBatchAggFunc returns the batch aggregation function for the given name, as accepted by graphite's aggregate(),
+// or nil if there is none
+func getBatchAggFunc(c string) batch.AggFunc {
+	switch c {
+	case "avg", "average":
+		return batch.Avg
+	case "avg_zero":
+		return batch.AvgZero
+	case "count":
+		return batch.Cnt
+	case "diff":
+		return batch.Diff
+	case "last", "current":
+		return batch.Lst
+	case "max":
+		return batch.Max
+	case "median":
+		return batch.Med
+	case "min":
+		return batch.Min
+	case "multiply":
+		return batch.Mult
+	case "range", "rangeOf":
+		return batch.Range
+	case "stddev":
+		return batch.Stddev
+	case "sum", "total":
+		return batch.Sum
+	}
+	return nil
+}
+
+// crossSeriesBatch returns a cross series aggregation function that applies the given batch aggregation function
+// to the points of all series at each timestamp.
+// points for which the ratio of non-null values is lower than xFilesFactor are null.
+func crossSeriesBatch(fn batch.AggFunc, xFilesFactor float64) crossSeriesAggFunc {
+	return func(in []models.Series, out *[]schema.Point) {
+		points := make([]schema.Point, len(in))
+		for i := 0; i < len(in[0].Datapoints); i++ {
+			valid := 0
+			for j := 0; j < len(in); j++ {
+				points[j] = in[j].Datapoints[i]
+				if !math.IsNaN(points[j].Val) {
+					valid++
+				}
+			}
+			point := schema.Point{
+				Ts:  in[0].Datapoints[i].Ts,
+				Val: math.NaN(),
+			}
+			if float64(valid)/float64(len(in)) >= xFilesFactor {
+				point.Val = fn(points)
+			}
+			*out = append(*out, point)
+		}
+	}
+}
+
+func crossSeriesAvg(in []models.Series, out *[]schema.Point) {
+	for i := 0; i < len(in[0].Datapoints); i++ {
+		num := 0
+		sum := float64(0)
+		for j := 0; j < len(in); j++ {
+			p := in[j].Datapoints[i].Val
+			if !math.IsNaN(p) {
+				num++
+				sum += p
+			}
+		}
+		point := schema.Point{
+			Ts: in[0].Datapoints[i].Ts,
+		}
+		if num == 0 {
+			point.Val = math.NaN()
+		} else {
+			point.Val = sum / float64(num)
+		}
+		*out = append(*out, point)
+	}
+}
+
+func crossSeriesMax(in []models.Series, out *[]schema.Point) {
+	for i := 0; i < len(in[0].Datapoints); i++ {
+		nan := true
+		point := schema.Point{
+			Ts:  in[0].Datapoints[i].Ts,
+			Val: -math.MaxFloat64,
+		}
+		for j := 0; j < len(in); j++ {
+			if !math.IsNaN(in[j].Datapoints[i].Val) {
+				point.Val = math.Max(point.Val, in[j].Datapoints[i].Val)
+				nan = false
+			}
+		}
+		if nan {
+			point.Val = math.NaN()
+		}
+		*out = append(*out, point)
+	}
+}
+
+func crossSeriesSum(in []models.Series, out *[]schema.Point) {
+	for i := 0; i < len(in[0].Datapoints); i++ {
+		nan := true
+		point := schema.Point{
+			Ts:  in[0].Datapoints[i].Ts,
+			Val: 0,
+		}
+		for j := 0; j < len(in); j++ {
+			if !math.IsNaN(in[j].Datapoints[i].Val) {
+				point.Val += in[j].Datapoints[i].Val
+				nan = false
+			}
+		}
+		if nan {
+			point.Val = math.NaN()
+		}
+		*out = append(*out, point)
+	}
+}
+
+func crossSeriesMin(in []models.Series, out *[]schema.Point) {
+	for i := 0; i < len(in[0].Datapoints); i++ {
+		nan := true
+		point := schema.Point{
+			Ts:  in[0].Datapoints[i].Ts,
+			Val: math.MaxFloat64,
+		}
+		for j := 0; j < len(in); j++ {
+			if !math.IsNaN(in[j].Datapoints[i].Val) {
+				point.Val = math.Min(point.Val, in[j].Datapoints[i].Val)
+				nan = false
+			}
+		}
+		if nan {
+			point.Val = math.NaN()
+		}
+		*out = append(*out, point)
+	}
+}
+
+// crossSeriesDiff subtracts the values of all other series from the first one.
+// like in graphite, if the first series has no value, the first series that has one is used instead.
+func crossSeriesDiff(in []models.Series, out *[]schema.Point) {
+	for i := 0; i < len(in[0].Datapoints); i++ {
+		nan := true
+		point := schema.Point{
+			Ts:  in[0].Datapoints[i].Ts,
+			Val: 0,
+		}
+		for j := 0; j < len(in); j++ {
+			p := in[j].Datapoints[i].Val
+			if math.IsNaN(p) {
+				continue
+			}
+			if nan {
+				point.Val = p
+				nan = false
+			} else {
+				point.Val -= p
+			}
+		}
+		if nan {
+			point.Val = math.NaN()
+		}
+		*out = append(*out, point)
+	}
+}
+
+// crossSeriesMultiply multiplies the values of all series.
+// like in graphite, the result is null as soon as one of the values is.
+func crossSeriesMultiply(in []models.Series, out *[]schema.Point) {
+	for i := 0; i < len(in[0].Datapoints); i++ {
+		point := schema.Point{
+			Ts:  in[0].Datapoints[i].Ts,
+			Val: 1,
+		}
+		for j := 0; j < len(in); j++ {
+			point.Val *= in[j].Datapoints[i].Val
+		}
+		*out = append(*out, point)
+	}
+}
+
+func crossSeriesRange(in []models.Series, out *[]schema.Point) {
+	for i := 0; i < len(in[0].Datapoints); i++ {
+		nan := true
+		min := math.MaxFloat64
+		max := -math.MaxFloat64
+		for j := 0; j < len(in); j++ {
+			p := in[j].Datapoints[i].Val
+			if !math.IsNaN(p) {
+				min = math.Min(min, p)
+				max = math.Max(max, p)
+				nan = false
+			}
+		}
+		point := schema.Point{
+			Ts:  in[0].Datapoints[i].Ts,
+			Val: max - min,
+		}
+		if nan {
+			point.Val = math.NaN()
+		}
+		*out = append(*out, point)
+	}
+}
+
+// crossSeriesStddev computes the population standard deviation of the values of all series
+func crossSeriesStddev(in []models.Series, out *[]schema.Point) {
+	for i := 0; i < len(in[0].Datapoints); i++ {
+		num := 0
+		sum := float64(0)
+		for j := 0; j < len(in); j++ {
+			p := in[j].Datapoints[i].Val
+			if !math.IsNaN(p) {
+				num++
+				sum += p
+			}
+		}
+		point := schema.Point{
+			Ts:  in[0].Datapoints[i].Ts,
+			Val: math.NaN(),
+		}
+		if num > 0 {
+			avg := sum / float64(num)
+			deviations := float64(0)
+			for j := 0; j < len(in); j++ {
+				p := in[j].Datapoints[i].Val
+				if !math.IsNaN(p) {
+					deviations += (p - avg) * (p - avg)
+				}
+			}
+			point.Val = math.Sqrt(deviations / float64(num))
+		}
+		*out = append(*out, point)
+	}
+}
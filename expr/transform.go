@@ -0,0 +1,27 @@
+package expr
+
+import (
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+// transformSerie returns a copy of the series with the given name, with each value transformed by the given function.
+// it's up to the function to handle null values, though most math functions leave them null.
+func transformSerie(cache map[Req][]models.Series, serie models.Series, target string, transform func(val float64) float64) models.Series {
+	out := pointSlicePool.Get().([]schema.Point)
+	for _, p := range serie.Datapoints {
+		out = append(out, schema.Point{Val: transform(p.Val), Ts: p.Ts})
+	}
+	output := models.Series{
+		Target:       target,
+		QueryPatt:    target,
+		Tags:         serie.Tags,
+		Datapoints:   out,
+		Interval:     serie.Interval,
+		Consolidator: serie.Consolidator,
+		XFilesFactor: serie.XFilesFactor,
+		QueryCons:    serie.QueryCons,
+	}
+	cache[Req{}] = append(cache[Req{}], output)
+	return output
+}
@@ -0,0 +1,62 @@
+package expr
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grafana/metrictank/api/models"
+	"gopkg.in/raintank/schema.v1"
+)
+
+// transformVals are the values most transform tests start from: negative, zero, null, fractional and positive values
+var transformVals = []float64{-2, 0, math.NaN(), 0.5, 4, 100}
+
+// getTransformInput returns a series named foo, with an interval of 10 and the given values
+func getTransformInput(vals []float64) []models.Series {
+	points := make([]schema.Point, len(vals))
+	for i, val := range vals {
+		points[i] = schema.Point{Val: val, Ts: uint32(i+1) * 10}
+	}
+	return []models.Series{
+		{
+			Target:     "foo",
+			QueryPatt:  "foo",
+			Interval:   10,
+			Datapoints: points,
+		},
+	}
+}
+
+// testTransform executes the function, which must read from the given input,
+// and checks that it returns a single series with the given name and values,
+// leaving its input untouched.
+func testTransform(name string, f GraphiteFunc, in []models.Series, target string, out []float64, t *testing.T) {
+	orig := make([]models.Series, len(in))
+	copy(orig, in)
+	for i := range orig {
+		orig[i].Datapoints = getCopy(in[i].Datapoints)
+	}
+
+	got, err := f.Exec(make(map[Req][]models.Series))
+	if err != nil {
+		t.Fatalf("case %q: err should be nil. got %q", name, err)
+	}
+	if len(got) != 1 || got[0].Target != target {
+		t.Fatalf("case %q: expected 1 series named %s, got %v", name, target, got)
+	}
+	exp := make([]schema.Point, len(out))
+	for i := range out {
+		exp[i] = schema.Point{Val: out[i], Ts: uint32(i+1) * 10}
+	}
+	checkPoints(name, got[0].Datapoints, exp, t)
+
+	if len(in) != len(orig) {
+		t.Fatalf("case %q: expected the input to still have %d series, got %d", name, len(orig), len(in))
+	}
+	for i := range in {
+		if in[i].Target != orig[i].Target || in[i].Interval != orig[i].Interval {
+			t.Fatalf("case %q: input series %d changed: expected %s with interval %d, got %s with interval %d", name, i, orig[i].Target, orig[i].Interval, in[i].Target, in[i].Interval)
+		}
+		checkPoints(name+" (input)", in[i].Datapoints, orig[i].Datapoints, t)
+	}
+}
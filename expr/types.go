@@ -47,6 +47,17 @@ type ArgSeriesLists struct {
 func (a ArgSeriesLists) Key() string    { return a.key }
 func (a ArgSeriesLists) Optional() bool { return a.opt }
 
+// ArgIn is an argument that can be of any of the given types, e.g. a number or a seriesList.
+// the first type that matches the given argument is used. None is accepted to leave the argument unset.
+type ArgIn struct {
+	key  string
+	opt  bool
+	args []Arg
+}
+
+func (a ArgIn) Key() string    { return a.key }
+func (a ArgIn) Optional() bool { return a.opt }
+
 // ArgInt is a number without decimals
 type ArgInt struct {
 	key       string
@@ -91,6 +102,29 @@ type ArgString struct {
 func (a ArgString) Key() string    { return a.key }
 func (a ArgString) Optional() bool { return a.opt }
 
+// ArgStrings represents one or more strings
+type ArgStrings struct {
+	key       string
+	opt       bool
+	validator []Validator
+	val       *[]string
+}
+
+func (a ArgStrings) Key() string    { return a.key }
+func (a ArgStrings) Optional() bool { return a.opt }
+
+// ArgStringsOrInts represents one or more strings or numbers without decimals,
+// e.g. a mix of tag names and node numbers. each value is kept as an expression of type etString or etInt
+type ArgStringsOrInts struct {
+	key       string
+	opt       bool
+	validator []Validator
+	val       *[]expr
+}
+
+func (a ArgStringsOrInts) Key() string    { return a.key }
+func (a ArgStringsOrInts) Optional() bool { return a.opt }
+
 // like string, but should result in a regex
 type ArgRegex struct {
 	key       string
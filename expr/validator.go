@@ -1,6 +1,12 @@
 package expr
 
-import "errors"
+import (
+	"errors"
+	"math"
+
+	"github.com/grafana/metrictank/consolidation"
+	"github.com/raintank/dur"
+)
 
 var ErrIntPositive = errors.New("integer must be positive")
 
@@ -13,3 +19,112 @@ func IntPositive(e *expr) error {
 	}
 	return nil
 }
+
+var ErrIntUint32 = errors.New("integer must be at most 4294967295")
+
+// IntUint32 validates an integer that must fit in a uint32, like a number of seconds
+func IntUint32(e *expr) error {
+	if e.int > math.MaxUint32 {
+		return ErrIntUint32
+	}
+	return nil
+}
+
+var ErrInvalidAggFunc = errors.New("Invalid aggregation func")
+
+func IsAggFunc(e *expr) error {
+	if getCrossSeriesAggFunc(e.str) == nil {
+		return ErrInvalidAggFunc
+	}
+	return nil
+}
+
+func IsPercentile(e *expr) error {
+	val := e.float
+	if e.etype == etInt {
+		val = float64(e.int)
+	}
+	if val < 0 || val > 100 {
+		return ErrPercentileRange
+	}
+	return nil
+}
+
+// IsIntervalString validates a non-zero duration like 5min or 1d
+func IsIntervalString(e *expr) error {
+	_, err := dur.ParseNDuration(e.str)
+	return err
+}
+
+// IsSignedIntervalString validates a duration that may have a sign, like -1h or +5min
+func IsSignedIntervalString(e *expr) error {
+	_, err := parseSignedInterval(e.str)
+	return err
+}
+
+// IsConsolFunc validates the name of a consolidation function, like "sum" or "avg"
+func IsConsolFunc(e *expr) error {
+	return consolidation.Validate(e.str)
+}
+
+var ErrFloatPositive = errors.New("number must be positive")
+
+// FloatPositive validates a number that must be positive
+func FloatPositive(e *expr) error {
+	val := e.float
+	if e.etype == etInt {
+		val = float64(e.int)
+	}
+	if val <= 0 {
+		return ErrFloatPositive
+	}
+	return nil
+}
+
+var ErrInvalidLogBase = errors.New("logarithm base must be positive and not 1")
+
+// IsLogBase validates the base of a logarithm
+func IsLogBase(e *expr) error {
+	val := e.float
+	if e.etype == etInt {
+		val = float64(e.int)
+	}
+	if val <= 0 || val == 1 {
+		return ErrInvalidLogBase
+	}
+	return nil
+}
+
+var ErrXFilesFactorRange = errors.New("xFilesFactor must be between 0 and 1")
+
+// IsXFilesFactor validates the ratio of non-null values that is required for a non-null result
+func IsXFilesFactor(e *expr) error {
+	val := e.float
+	if e.etype == etInt {
+		val = float64(e.int)
+	}
+	if val < 0 || val > 1 {
+		return ErrXFilesFactorRange
+	}
+	return nil
+}
+
+var ErrInvalidUnitSystem = errors.New("unit system must be si or binary")
+
+// IsUnitSystem validates the name of a unit system, used to format values with unit prefixes like K or Mi
+func IsUnitSystem(e *expr) error {
+	if unitSystems[e.str] == nil {
+		return ErrInvalidUnitSystem
+	}
+	return nil
+}
+
+var ErrInvalidLegendValueType = errors.New("value type must be an aggregation func or a unit system")
+
+// IsLegendValueType validates a value type of legendValue: an aggregation function, or a unit system
+func IsLegendValueType(e *expr) error {
+	if getBatchAggFunc(e.str) == nil && unitSystems[e.str] == nil {
+		return ErrInvalidLegendValueType
+	}
+	return nil
+}
@@ -232,7 +232,7 @@ func TestFind(t *testing.T) {
 
 	Convey("When listing root nodes", t, func() {
 		Convey("root nodes for orgId 1", func() {
-			nodes, err := ix.Find(1, "*", 0)
+			nodes, err := ix.Find(1, "*", 0, idx.FindLimits{})
 			So(err, ShouldBeNil)
 			So(nodes, ShouldHaveLength, 2)
 			So(nodes[0].Path, ShouldBeIn, "metric", "foo")
@@ -240,7 +240,7 @@ func TestFind(t *testing.T) {
 			So(nodes[0].Leaf, ShouldBeFalse)
 		})
 		Convey("root nodes for orgId 2", func() {
-			nodes, err := ix.Find(2, "*", 0)
+			nodes, err := ix.Find(2, "*", 0, idx.FindLimits{})
 			So(err, ShouldBeNil)
 			So(nodes, ShouldHaveLength, 1)
 			So(nodes[0].Path, ShouldEqual, "metric")
@@ -249,7 +249,7 @@ func TestFind(t *testing.T) {
 	})
 
 	Convey("When searching with GLOB", t, func() {
-		nodes, err := ix.Find(2, "metric.{f*,demo}.*", 0)
+		nodes, err := ix.Find(2, "metric.{f*,demo}.*", 0, idx.FindLimits{})
 		So(err, ShouldBeNil)
 		So(nodes, ShouldHaveLength, 10)
 		for _, n := range nodes {
@@ -258,7 +258,7 @@ func TestFind(t *testing.T) {
 	})
 
 	Convey("When searching with multiple wildcards", t, func() {
-		nodes, err := ix.Find(1, "*.*", 0)
+		nodes, err := ix.Find(1, "*.*", 0, idx.FindLimits{})
 		So(err, ShouldBeNil)
 		So(nodes, ShouldHaveLength, 2)
 		for _, n := range nodes {
@@ -267,24 +267,24 @@ func TestFind(t *testing.T) {
 	})
 
 	Convey("When searching nodes not in public series", t, func() {
-		nodes, err := ix.Find(1, "foo.demo.*", 0)
+		nodes, err := ix.Find(1, "foo.demo.*", 0, idx.FindLimits{})
 		So(err, ShouldBeNil)
 		So(nodes, ShouldHaveLength, 5)
 		Convey("When searching for specific series", func() {
-			found, err := ix.Find(1, nodes[0].Path, 0)
+			found, err := ix.Find(1, nodes[0].Path, 0, idx.FindLimits{})
 			So(err, ShouldBeNil)
 			So(found, ShouldHaveLength, 1)
 			So(found[0].Path, ShouldEqual, nodes[0].Path)
 		})
 		Convey("When searching nodes that are children of a leaf", func() {
-			found, err := ix.Find(1, nodes[0].Path+".*", 0)
+			found, err := ix.Find(1, nodes[0].Path+".*", 0, idx.FindLimits{})
 			So(err, ShouldBeNil)
 			So(found, ShouldHaveLength, 0)
 		})
 	})
 
 	Convey("When searching with multiple wildcards mixed leaf/branch", t, func() {
-		nodes, err := ix.Find(1, "*.demo.*", 0)
+		nodes, err := ix.Find(1, "*.demo.*", 0, idx.FindLimits{})
 		So(err, ShouldBeNil)
 		So(nodes, ShouldHaveLength, 15)
 		for _, n := range nodes {
@@ -297,13 +297,13 @@ func TestFind(t *testing.T) {
 		}
 	})
 	Convey("When searching nodes for unknown orgId", t, func() {
-		nodes, err := ix.Find(4, "foo.demo.*", 0)
+		nodes, err := ix.Find(4, "foo.demo.*", 0, idx.FindLimits{})
 		So(err, ShouldBeNil)
 		So(nodes, ShouldHaveLength, 0)
 	})
 
 	Convey("When searching nodes that dont exist", t, func() {
-		nodes, err := ix.Find(1, "foo.demo.blah.*", 0)
+		nodes, err := ix.Find(1, "foo.demo.blah.*", 0, idx.FindLimits{})
 		So(err, ShouldBeNil)
 		So(nodes, ShouldHaveLength, 0)
 	})
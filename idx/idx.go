@@ -18,8 +18,17 @@ var (
 	BranchUnderLeaf    = errors.New("can't add branch under leaf")
 	errInvalidQuery    = errors.New("invalid query")
 	errInvalidIdString = errors.New("invalid ID string")
+
+	ErrMaxSeries     = errors.New("query resolves to more series than the limit")
+	ErrMaxExpansions = errors.New("pattern expands into more patterns than the limit")
 )
 
+// FindLimits bound the work a search may do. 0 disables a limit
+type FindLimits struct {
+	MaxSeries     int // max number of series a search may resolve to
+	MaxExpansions int // max number of patterns a pattern may expand into via {a,b} lists
+}
+
 //go:generate msgp
 type Node struct {
 	Path        string
@@ -122,13 +131,14 @@ Interface
   passed OrgId is "-1", then all metricDefinitions across all organisations
   should be returned.
 
-* Find(int, string, int64) ([]Node, error):
+* Find(int, string, int64, FindLimits) ([]Node, error):
   This method provides searches.  The method is passed an OrgId, a query
   pattern and a unix timestamp. Searches should return all nodes that match for
   the given OrgId and OrgId -1.  The pattern should be handled in the same way
   Graphite would. see https://graphite.readthedocs.io/en/latest/render_api.html#paths-and-wildcards
   And the unix stimestamp is used to ignore series that have been stale since
-  the timestamp.
+  the timestamp. Searches that exceed the limits are aborted with ErrMaxSeries
+  or ErrMaxExpansions.
 
 * Delete(int, string) ([]Archive, error):
   This method is used for deleting items from the index. The method is passed
@@ -155,12 +165,13 @@ Interface
   will be filtered and only those of which the LastUpdate time is >= the from
   timestamp will be considered while the others are being ignored.
 
-* FindByTag(int, []string, int64) ([]string, error):
+* FindByTag(int, []string, int64, FindLimits) ([]string, error):
   This method takes a list of expressions in the format key<operator>value.
   The allowed operators are: =, !=, =~, !=~.
   It returns a slice of IDs that match the given conditions, the conditions are
   logically AND-ed. If the third argument is > 0 then the results will be filtered
   and only those where the LastUpdate time is >= from will be returned as results.
+  If more IDs than the MaxSeries limit match, it returns ErrMaxSeries.
 */
 
 type MetricIndex interface {
@@ -170,10 +181,10 @@ type MetricIndex interface {
 	Get(string) (Archive, bool)
 	GetPath(int, string) []Archive
 	Delete(int, string) ([]Archive, error)
-	Find(int, string, int64) ([]Node, error)
+	Find(int, string, int64, FindLimits) ([]Node, error)
 	List(int) []Archive
 	Prune(int, time.Time) ([]Archive, error)
 	TagList(int) []string
 	Tag(int, string, int64) map[string]uint32
-	FindByTag(int, []string, int64) (map[MetricID]struct{}, error)
+	FindByTag(int, []string, int64, FindLimits) (map[MetricID]struct{}, error)
 }
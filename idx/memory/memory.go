@@ -147,6 +147,19 @@ func (m *MemoryIdx) Update(entry idx.Archive) {
 	m.Unlock()
 }
 
+// nameTag is the tag under which the tag index indexes the name of a series,
+// so that tag queries can select series by their name, like graphite's name=foo
+const nameTag = "name"
+
+// indexedTags returns the tags of a given metric definition as they are indexed,
+// which includes its name as the nameTag tag. like before, series without tags are not indexed.
+func indexedTags(def *schema.MetricDefinition) []string {
+	if len(def.Tags) == 0 || def.Name == "" {
+		return def.Tags
+	}
+	return append([]string{nameTag + "=" + def.Name}, def.Tags...)
+}
+
 // indexTags reads the tags of a given metric definition and creates the
 // corresponding tag index entries to refer to it. It assumes a lock is
 // already held.
@@ -156,7 +169,7 @@ func (m *MemoryIdx) indexTags(def *schema.MetricDefinition) {
 		tags = make(TagIndex)
 		m.Tags[def.OrgId] = tags
 	}
-	for _, tag := range def.Tags {
+	for _, tag := range indexedTags(def) {
 		tagSplits := strings.SplitN(tag, "=", 2)
 		if len(tagSplits) < 2 {
 			// should never happen because every tag in the index
@@ -199,7 +212,7 @@ func (m *MemoryIdx) deindexTags(def *schema.MetricDefinition) {
 		return
 	}
 
-	for _, tag := range def.Tags {
+	for _, tag := range indexedTags(def) {
 		tagSplits := strings.SplitN(tag, "=", 2)
 		if len(tagSplits) < 2 {
 			// should never happen because every tag in the index
@@ -445,7 +458,7 @@ func (m *MemoryIdx) TagList(orgId int) []string {
 	return results
 }
 
-func (m *MemoryIdx) FindByTag(orgId int, expressions []string, from int64) (map[idx.MetricID]struct{}, error) {
+func (m *MemoryIdx) FindByTag(orgId int, expressions []string, from int64, limits idx.FindLimits) (map[idx.MetricID]struct{}, error) {
 	if !tagSupport {
 		log.Warn("memory-idx: received tag query, but tag support is disabled")
 		return nil, nil
@@ -456,7 +469,11 @@ func (m *MemoryIdx) FindByTag(orgId int, expressions []string, from int64) (map[
 		return nil, err
 	}
 
-	return m.idsByTagQuery(orgId, query), nil
+	ids := m.idsByTagQuery(orgId, query)
+	if limits.MaxSeries > 0 && len(ids) > limits.MaxSeries {
+		return nil, idx.ErrMaxSeries
+	}
+	return ids, nil
 }
 
 func (m *MemoryIdx) idsByTagQuery(orgId int, query TagQuery) TagIDs {
@@ -471,15 +488,15 @@ func (m *MemoryIdx) idsByTagQuery(orgId int, query TagQuery) TagIDs {
 	return query.Run(tree, m.DefById)
 }
 
-func (m *MemoryIdx) Find(orgId int, pattern string, from int64) ([]idx.Node, error) {
+func (m *MemoryIdx) Find(orgId int, pattern string, from int64, limits idx.FindLimits) ([]idx.Node, error) {
 	pre := time.Now()
 	m.RLock()
 	defer m.RUnlock()
-	matchedNodes, err := m.find(orgId, pattern)
+	matchedNodes, err := m.find(orgId, pattern, limits.MaxExpansions)
 	if err != nil {
 		return nil, err
 	}
-	publicNodes, err := m.find(-1, pattern)
+	publicNodes, err := m.find(-1, pattern, limits.MaxExpansions)
 	if err != nil {
 		return nil, err
 	}
@@ -487,6 +504,7 @@ func (m *MemoryIdx) Find(orgId int, pattern string, from int64) ([]idx.Node, err
 	log.Debug("memory-idx: %d nodes matching pattern %s found", len(matchedNodes), pattern)
 	results := make([]idx.Node, 0)
 	seen := make(map[string]struct{})
+	var series int
 	// if there are public (orgId -1) and private leaf nodes with the same series
 	// path, then the public metricDefs will be excluded.
 	for _, n := range matchedNodes {
@@ -511,6 +529,10 @@ func (m *MemoryIdx) Find(orgId int, pattern string, from int64) ([]idx.Node, err
 				if len(idxNode.Defs) == 0 {
 					continue
 				}
+				series += len(idxNode.Defs)
+				if limits.MaxSeries > 0 && series > limits.MaxSeries {
+					return nil, idx.ErrMaxSeries
+				}
 			}
 			results = append(results, idxNode)
 			seen[n.Path] = struct{}{}
@@ -523,7 +545,9 @@ func (m *MemoryIdx) Find(orgId int, pattern string, from int64) ([]idx.Node, err
 	return results, nil
 }
 
-func (m *MemoryIdx) find(orgId int, pattern string) ([]*Node, error) {
+// find returns the nodes matching the pattern. maxExpansions is the max number of patterns
+// the pattern may expand into via {a,b} lists, across all of its nodes. 0 disables the limit
+func (m *MemoryIdx) find(orgId int, pattern string, maxExpansions int) ([]*Node, error) {
 	var results []*Node
 	tree, ok := m.Tree[orgId]
 	if !ok {
@@ -561,14 +585,21 @@ func (m *MemoryIdx) find(orgId int, pattern string) ([]*Node, error) {
 	}
 
 	children := []*Node{startNode}
+	expansions := 1
 	for i := pos; i < len(nodes); i++ {
 		p := nodes[i]
 
-		matcher, err := getMatcher(p)
+		// the patterns of this node multiply with those of the previous ones
+		var max int
+		if maxExpansions > 0 {
+			max = maxExpansions / expansions
+		}
+		matcher, n, err := getMatcher(p, max)
 
 		if err != nil {
 			return nil, err
 		}
+		expansions *= n
 
 		grandChildren := make([]*Node, 0)
 		for _, c := range children {
@@ -641,7 +672,7 @@ func (m *MemoryIdx) Delete(orgId int, pattern string) ([]idx.Archive, error) {
 	pre := time.Now()
 	m.Lock()
 	defer m.Unlock()
-	found, err := m.find(orgId, pattern)
+	found, err := m.find(orgId, pattern, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -801,18 +832,24 @@ func (m *MemoryIdx) Prune(orgId int, oldest time.Time) ([]idx.Archive, error) {
 	return pruned, nil
 }
 
-func getMatcher(path string) (func([]string) []string, error) {
+// getMatcher returns a function that returns the children matching the given node of a pattern,
+// and the number of patterns the node expands into, which may be at most maxExpansions (0 disables the limit)
+func getMatcher(path string, maxExpansions int) (func([]string) []string, int, error) {
 	// Matches everything
 	if path == "*" {
 		return func(children []string) []string {
 			log.Debug("memory-idx: Matching all children")
 			return children
-		}, nil
+		}, 1, nil
 	}
 
 	var patterns []string
 	if strings.ContainsAny(path, "{}") {
-		patterns = expandQueries(path)
+		var err error
+		patterns, err = expandQueries(path, maxExpansions)
+		if err != nil {
+			return nil, 0, err
+		}
 	} else {
 		patterns = []string{path}
 	}
@@ -824,7 +861,7 @@ func getMatcher(path string) (func([]string) []string, error) {
 			r, err := regexp.Compile(toRegexp(p))
 			if err != nil {
 				log.Debug("memory-idx: regexp failed to compile. %s - %s", p, err)
-				return nil, err
+				return nil, 0, err
 			}
 			regexes = append(regexes, r)
 		}
@@ -840,7 +877,7 @@ func getMatcher(path string) (func([]string) []string, error) {
 				}
 			}
 			return matches
-		}, nil
+		}, len(patterns), nil
 	}
 
 	// Exact match one or more values
@@ -856,14 +893,15 @@ func getMatcher(path string) (func([]string) []string, error) {
 			}
 		}
 		return results
-	}, nil
+	}, len(patterns), nil
 }
 
 // We don't use filepath.Match as it doesn't support {} because that's not posix, it's a bashism
 // the easiest way of implementing this extra feature is just expanding single queries
 // that contain these queries into multiple queries, which will be checked separately
 // and the results of which will be ORed.
-func expandQueries(query string) []string {
+// we stop expanding with ErrMaxExpansions once there are more than max queries. 0 disables the limit
+func expandQueries(query string, max int) ([]string, error) {
 	queries := []string{query}
 
 	// as long as we find a { followed by a }, split it up into subqueries, and process
@@ -890,13 +928,16 @@ func expandQueries(query string) []string {
 				for _, option := range options {
 					expanded = append(expanded, query[:lbrace]+option+query[rbrace+1:])
 				}
+				if max > 0 && len(expanded) > max {
+					return nil, idx.ErrMaxExpansions
+				}
 			} else {
 				expanded = append(expanded, query)
 			}
 		}
 		queries = expanded
 	}
-	return queries
+	return queries, nil
 }
 
 func toRegexp(pattern string) string {
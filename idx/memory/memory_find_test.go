@@ -211,7 +211,7 @@ func Init() {
 }
 
 func ixFind(b *testing.B, org, q int) {
-	nodes, err := ix.Find(org, queries[q].Pattern, 0)
+	nodes, err := ix.Find(org, queries[q].Pattern, 0, idx.FindLimits{})
 	if err != nil {
 		panic(err)
 	}
@@ -291,7 +291,7 @@ func BenchmarkConcurrent8Find(b *testing.B) {
 }
 
 func ixFindByTag(b *testing.B, org, q int) {
-	series, err := ix.FindByTag(org, tagQueries[q].Expressions, 0)
+	series, err := ix.FindByTag(org, tagQueries[q].Expressions, 0, idx.FindLimits{})
 	if err != nil {
 		panic(err)
 	}
@@ -402,7 +402,7 @@ func BenchmarkTagQueryFilterAndIntersect(b *testing.B) {
 
 	for n := 0; n < b.N; n++ {
 		q := queries[n%len(queries)]
-		series, err := ix.FindByTag(1, q.Expressions, 150000)
+		series, err := ix.FindByTag(1, q.Expressions, 150000, idx.FindLimits{})
 		if err != nil {
 			b.Fatalf(err.Error())
 		}
@@ -430,7 +430,7 @@ func BenchmarkTagQueryFilterAndIntersectOnlyRegex(b *testing.B) {
 
 	for n := 0; n < b.N; n++ {
 		q := queries[n%len(queries)]
-		series, err := ix.FindByTag(1, q.Expressions, 0)
+		series, err := ix.FindByTag(1, q.Expressions, 0, idx.FindLimits{})
 		if err != nil {
 			b.Fatalf(err.Error())
 		}
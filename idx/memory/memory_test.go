@@ -121,7 +121,7 @@ func TestFind(t *testing.T) {
 
 	Convey("When listing root nodes", t, func() {
 		Convey("root nodes for orgId 1", func() {
-			nodes, err := ix.Find(1, "*", 0)
+			nodes, err := ix.Find(1, "*", 0, idx.FindLimits{})
 			So(err, ShouldBeNil)
 			So(nodes, ShouldHaveLength, 2)
 			So(nodes[0].Path, ShouldBeIn, "metric", "foo")
@@ -129,7 +129,7 @@ func TestFind(t *testing.T) {
 			So(nodes[0].Leaf, ShouldBeFalse)
 		})
 		Convey("root nodes for orgId 2", func() {
-			nodes, err := ix.Find(2, "*", 0)
+			nodes, err := ix.Find(2, "*", 0, idx.FindLimits{})
 			So(err, ShouldBeNil)
 			So(nodes, ShouldHaveLength, 1)
 			So(nodes[0].Path, ShouldEqual, "metric")
@@ -138,7 +138,7 @@ func TestFind(t *testing.T) {
 	})
 
 	Convey("When searching with GLOB", t, func() {
-		nodes, err := ix.Find(2, "metric.{f*,demo}.*", 0)
+		nodes, err := ix.Find(2, "metric.{f*,demo}.*", 0, idx.FindLimits{})
 		So(err, ShouldBeNil)
 		So(nodes, ShouldHaveLength, 10)
 		for _, n := range nodes {
@@ -147,7 +147,7 @@ func TestFind(t *testing.T) {
 	})
 
 	Convey("When searching with multiple wildcards", t, func() {
-		nodes, err := ix.Find(1, "*.*", 0)
+		nodes, err := ix.Find(1, "*.*", 0, idx.FindLimits{})
 		So(err, ShouldBeNil)
 		So(nodes, ShouldHaveLength, 2)
 		for _, n := range nodes {
@@ -156,24 +156,24 @@ func TestFind(t *testing.T) {
 	})
 
 	Convey("When searching nodes not in public series", t, func() {
-		nodes, err := ix.Find(1, "foo.demo.*", 0)
+		nodes, err := ix.Find(1, "foo.demo.*", 0, idx.FindLimits{})
 		So(err, ShouldBeNil)
 		So(nodes, ShouldHaveLength, 5)
 		Convey("When searching for specific series", func() {
-			found, err := ix.Find(1, nodes[0].Path, 0)
+			found, err := ix.Find(1, nodes[0].Path, 0, idx.FindLimits{})
 			So(err, ShouldBeNil)
 			So(found, ShouldHaveLength, 1)
 			So(found[0].Path, ShouldEqual, nodes[0].Path)
 		})
 		Convey("When searching nodes that are children of a leaf", func() {
-			found, err := ix.Find(1, nodes[0].Path+".*", 0)
+			found, err := ix.Find(1, nodes[0].Path+".*", 0, idx.FindLimits{})
 			So(err, ShouldBeNil)
 			So(found, ShouldHaveLength, 0)
 		})
 	})
 
 	Convey("When searching with multiple wildcards mixed leaf/branch", t, func() {
-		nodes, err := ix.Find(1, "*.demo.*", 0)
+		nodes, err := ix.Find(1, "*.demo.*", 0, idx.FindLimits{})
 		So(err, ShouldBeNil)
 		So(nodes, ShouldHaveLength, 15)
 		for _, n := range nodes {
@@ -186,26 +186,26 @@ func TestFind(t *testing.T) {
 		}
 	})
 	Convey("When searching nodes for unknown orgId", t, func() {
-		nodes, err := ix.Find(4, "foo.demo.*", 0)
+		nodes, err := ix.Find(4, "foo.demo.*", 0, idx.FindLimits{})
 		So(err, ShouldBeNil)
 		So(nodes, ShouldHaveLength, 0)
 	})
 
 	Convey("When searching nodes that dont exist", t, func() {
-		nodes, err := ix.Find(1, "foo.demo.blah.*", 0)
+		nodes, err := ix.Find(1, "foo.demo.blah.*", 0, idx.FindLimits{})
 		So(err, ShouldBeNil)
 		So(nodes, ShouldHaveLength, 0)
 	})
 
 	Convey("When searching with from timestamp", t, func() {
-		nodes, err := ix.Find(1, "*.demo.*", 4*86400)
+		nodes, err := ix.Find(1, "*.demo.*", 4*86400, idx.FindLimits{})
 		So(err, ShouldBeNil)
 		So(nodes, ShouldHaveLength, 10)
 		for _, n := range nodes {
 			So(n.Path, ShouldNotContainSubstring, "foo.demo")
 		}
 		Convey("When searching with from timestamp on series with multiple defs.", func() {
-			nodes, err := ix.Find(1, "*.demo.*", 2*86400)
+			nodes, err := ix.Find(1, "*.demo.*", 2*86400, idx.FindLimits{})
 			So(err, ShouldBeNil)
 			So(nodes, ShouldHaveLength, 15)
 			for _, n := range nodes {
@@ -218,6 +218,51 @@ func TestFind(t *testing.T) {
 
 }
 
+func TestFindLimits(t *testing.T) {
+	defer func(t bool) { tagSupport = t }(tagSupport)
+	tagSupport = true
+	ix := New()
+	ix.Init()
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("limits.b%d.c", i)
+		data := &schema.MetricData{Name: name, Metric: name, OrgId: 1, Interval: 10, Tags: []string{"limits=yes"}}
+		data.SetId()
+		ix.AddOrUpdate(data, 1)
+	}
+
+	findCases := []struct {
+		pattern string
+		limits  idx.FindLimits
+		expLen  int
+		expErr  error
+	}{
+		{"limits.*.c", idx.FindLimits{MaxSeries: 5}, 5, nil},
+		{"limits.*.c", idx.FindLimits{MaxSeries: 4}, 0, idx.ErrMaxSeries},
+		{"limits.b{0,1}.{c,d}", idx.FindLimits{MaxExpansions: 4}, 2, nil},
+		// the patterns of the nodes multiply
+		{"limits.b{0,1}.{c,d}", idx.FindLimits{MaxExpansions: 3}, 0, idx.ErrMaxExpansions},
+		{"limits.b{0,1}{2,3}.c", idx.FindLimits{MaxExpansions: 3}, 0, idx.ErrMaxExpansions},
+	}
+	for i, c := range findCases {
+		nodes, err := ix.Find(1, c.pattern, 0, c.limits)
+		if err != c.expErr {
+			t.Fatalf("case %d: expected error %v, got %v", i, c.expErr, err)
+		}
+		if len(nodes) != c.expLen {
+			t.Fatalf("case %d: expected %d nodes, got %d", i, c.expLen, len(nodes))
+		}
+	}
+
+	ids, err := ix.FindByTag(1, []string{"limits=yes"}, 0, idx.FindLimits{MaxSeries: 5})
+	if err != nil || len(ids) != 5 {
+		t.Fatalf("expected 5 series within the limit, got %d and error %v", len(ids), err)
+	}
+	_, err = ix.FindByTag(1, []string{"limits=yes"}, 0, idx.FindLimits{MaxSeries: 4})
+	if err != idx.ErrMaxSeries {
+		t.Fatalf("expected %v, got %v", idx.ErrMaxSeries, err)
+	}
+}
+
 func TestDelete(t *testing.T) {
 	ix := New()
 	ix.Init()
@@ -242,7 +287,7 @@ func TestDelete(t *testing.T) {
 			Convey("series should not be present in searches", func() {
 				nodes := strings.Split(org1Series[0].Name, ".")
 				branch := strings.Join(nodes[0:len(nodes)-2], ".")
-				found, err := ix.Find(1, branch+".*.*", 0)
+				found, err := ix.Find(1, branch+".*.*", 0, idx.FindLimits{})
 				So(err, ShouldBeNil)
 				So(found, ShouldHaveLength, 4)
 				for _, n := range found {
@@ -266,11 +311,11 @@ func TestDelete(t *testing.T) {
 				for _, def := range org1Series {
 					nodes := strings.Split(def.Name, ".")
 					branch := strings.Join(nodes[0:len(nodes)-1], ".")
-					found, err := ix.Find(1, branch+".*", 0)
+					found, err := ix.Find(1, branch+".*", 0, idx.FindLimits{})
 					So(err, ShouldBeNil)
 					So(found, ShouldHaveLength, 0)
 				}
-				found, err := ix.Find(1, "metric.*", 0)
+				found, err := ix.Find(1, "metric.*", 0, idx.FindLimits{})
 				So(err, ShouldBeNil)
 				So(found, ShouldHaveLength, 1)
 				So(found[0].Path, ShouldEqual, "metric.public")
@@ -422,10 +467,10 @@ func TestMixedBranchLeafDelete(t *testing.T) {
 			_, ok := ix.Get(series[0].Id)
 			So(ok, ShouldEqual, false)
 			Convey("series should not be present in searches", func() {
-				found, err := ix.Find(1, "a.b.c", 0)
+				found, err := ix.Find(1, "a.b.c", 0, idx.FindLimits{})
 				So(err, ShouldBeNil)
 				So(found, ShouldHaveLength, 0)
-				found, err = ix.Find(1, "a.b.c.d", 0)
+				found, err = ix.Find(1, "a.b.c.d", 0, idx.FindLimits{})
 				So(err, ShouldBeNil)
 				So(found, ShouldHaveLength, 0)
 			})
@@ -441,10 +486,10 @@ func TestMixedBranchLeafDelete(t *testing.T) {
 			_, ok := ix.Get(series[3].Id)
 			So(ok, ShouldEqual, false)
 			Convey("deleted series should not be present in searches", func() {
-				found, err := ix.Find(1, "a.b.c2.*", 0)
+				found, err := ix.Find(1, "a.b.c2.*", 0, idx.FindLimits{})
 				So(err, ShouldBeNil)
 				So(found, ShouldHaveLength, 1)
-				found, err = ix.Find(1, "a.b.c2.d", 0)
+				found, err = ix.Find(1, "a.b.c2.d", 0, idx.FindLimits{})
 				So(err, ShouldBeNil)
 				So(found, ShouldHaveLength, 0)
 			})
@@ -488,10 +533,10 @@ func TestPrune(t *testing.T) {
 		purged, err := ix.Prune(1, time.Unix(2, 0))
 		So(err, ShouldBeNil)
 		So(purged, ShouldHaveLength, 5)
-		nodes, err := ix.Find(1, "metric.bah.*", 0)
+		nodes, err := ix.Find(1, "metric.bah.*", 0, idx.FindLimits{})
 		So(err, ShouldBeNil)
 		So(nodes, ShouldHaveLength, 0)
-		nodes, err = ix.Find(1, "metric.foo.*", 0)
+		nodes, err = ix.Find(1, "metric.foo.*", 0, idx.FindLimits{})
 		So(err, ShouldBeNil)
 		So(nodes, ShouldHaveLength, 5)
 
@@ -513,7 +558,7 @@ func TestPrune(t *testing.T) {
 			purged, err := ix.Prune(1, time.Unix(12, 0))
 			So(err, ShouldBeNil)
 			So(purged, ShouldHaveLength, 4)
-			nodes, err := ix.Find(1, "metric.foo.*", 0)
+			nodes, err := ix.Find(1, "metric.foo.*", 0, idx.FindLimits{})
 			So(err, ShouldBeNil)
 			So(nodes, ShouldHaveLength, 1)
 		})
@@ -288,6 +288,28 @@ func (q *TagQuery) filterByMatch(resultSet TagIDs, byId map[string]*idx.Archive,
 		// because once we know that a tag matches we can just compare strings
 		matchingTags := make(map[string]struct{})
 		notMatchingTags := make(map[string]struct{})
+		matches := func(value string) bool {
+			// reduce regex matching by looking up cached non-matches and matches
+			if _, ok := notMatchingTags[value]; ok {
+				return false
+			}
+			if _, ok := matchingTags[value]; ok {
+				return true
+			}
+
+			// value == nil means that this expression can be short cut
+			// by not evaluating it
+			if e.value == nil || e.value.MatchString(value) {
+				if len(matchingTags) < matchCacheSize {
+					matchingTags[value] = struct{}{}
+				}
+				return true
+			}
+			if len(notMatchingTags) < matchCacheSize {
+				notMatchingTags[value] = struct{}{}
+			}
+			return false
+		}
 	IDS:
 		for id := range resultSet {
 			var def *idx.Archive
@@ -301,7 +323,14 @@ func (q *TagQuery) filterByMatch(resultSet TagIDs, byId map[string]*idx.Archive,
 				continue IDS
 			}
 
-		TAGS:
+			// the name is indexed as a tag, see indexedTags
+			if e.key == nameTag && def.Name != "" {
+				if matches(def.Name) == not {
+					delete(resultSet, id)
+				}
+				continue IDS
+			}
+
 			for _, tag := range def.Tags {
 				// length of key doesn't match
 				if len(tag) <= len(e.key)+1 || tag[len(e.key)] != 61 {
@@ -312,38 +341,15 @@ func (q *TagQuery) filterByMatch(resultSet TagIDs, byId map[string]*idx.Archive,
 					continue
 				}
 
-				value := tag[len(e.key)+1:]
-
-				// reduce regex matching by looking up cached non-matches
-				if _, ok := notMatchingTags[value]; ok {
-					// each key should only be present once per `def`, so if
-					// the key matches but the value doesn't we can skip the def
-					break TAGS
-				}
-
-				// reduce regex matching by looking up cached matches
-				if _, ok := matchingTags[value]; ok {
+				// each key should only be present once per `def`, so if
+				// the key matches but the value doesn't we can skip the def
+				if matches(tag[len(e.key)+1:]) {
 					if not {
 						delete(resultSet, id)
 					}
 					continue IDS
 				}
-
-				// value == nil means that this expression can be short cut
-				// by not evaluating it
-				if e.value == nil || e.value.MatchString(value) {
-					if len(matchingTags) < matchCacheSize {
-						matchingTags[value] = struct{}{}
-					}
-					if not {
-						delete(resultSet, id)
-					}
-					continue IDS
-				} else {
-					if len(notMatchingTags) < matchCacheSize {
-						notMatchingTags[value] = struct{}{}
-					}
-				}
+				break
 			}
 			if !not {
 				delete(resultSet, id)
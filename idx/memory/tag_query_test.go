@@ -274,6 +274,23 @@ func TestGetByTag(t *testing.T) {
 		}, {
 			expressions: []string{"key2=", "key1=value1"},
 			expectation: []idx.MetricID{ids[11], ids[3]},
+		}, {
+			// the name of tagged series is indexed as a tag
+			expressions: []string{"name=metric.1"},
+			expectation: []idx.MetricID{ids[1]},
+		}, {
+			// but untagged series aren't indexed at all
+			expressions: []string{"name=metric.2"},
+			expectation: []idx.MetricID{},
+		}, {
+			expressions: []string{"key1=value1", "name=~metric.1.*"},
+			expectation: []idx.MetricID{ids[1], ids[11]},
+		}, {
+			expressions: []string{"key1=value1", "name!=metric.1"},
+			expectation: []idx.MetricID{ids[11], ids[3]},
+		}, {
+			expressions: []string{"key1=value1", "name!=~metric.1.*"},
+			expectation: []idx.MetricID{ids[3]},
 		},
 	}
 
@@ -320,8 +337,9 @@ func TestDeleteTaggedSeries(t *testing.T) {
 		t.Fatalf("Expected to get 1 result, but got %d", len(res))
 	}
 
-	if len(ix.Tags[orgId]) != 2 {
-		t.Fatalf("Expected tag index to contain 2 keys, but it does not: %+v", ix.Tags)
+	// the tags and the name
+	if len(ix.Tags[orgId]) != 3 {
+		t.Fatalf("Expected tag index to contain 3 keys, but it does not: %+v", ix.Tags)
 	}
 
 	deleted, err := ix.Delete(orgId, mds[10].Metric)
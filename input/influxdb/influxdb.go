@@ -0,0 +1,295 @@
+// package influxdb provides an input for the influxdb line protocol, over http like influxdb's /write endpoint, and over tcp
+package influxdb
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/metrictank/cluster"
+	"github.com/grafana/metrictank/input"
+	"github.com/grafana/metrictank/input/carbon"
+	"github.com/grafana/metrictank/stats"
+	"github.com/raintank/worldping-api/pkg/log"
+	"github.com/rakyll/globalconf"
+	"gopkg.in/raintank/schema.v1"
+)
+
+// metric input.influxdb.metrics_per_message is how many metrics per message were seen. a message is a line over tcp, or a write request over http
+var metricsPerMessage = stats.NewMeter32("input.influxdb.metrics_per_message", false)
+
+// metric input.influxdb.metrics_decode_err is a count of times a line failed to parse
+var metricsDecodeErr = stats.NewCounter32("input.influxdb.metrics_decode_err")
+
+// maxLineLength is the longest line we accept. lines with many fields can be long
+const maxLineLength = 1024 * 1024
+
+type Influxdb struct {
+	input.Handler
+	addr             *net.TCPAddr
+	listener         *net.TCPListener
+	httpServer       *http.Server
+	handlerWaitGroup sync.WaitGroup
+	quit             chan struct{}
+	connTrack        *carbon.ConnTrack
+	intervalGetter   carbon.IntervalGetter
+}
+
+func (i *Influxdb) Name() string {
+	return "influxdb"
+}
+
+var Enabled bool
+var addr string
+var httpAddr string
+var precisionStr string
+var precision time.Duration
+var partitionId int
+
+func ConfigSetup() {
+	inInfluxdb := flag.NewFlagSet("influxdb-in", flag.ExitOnError)
+	inInfluxdb.BoolVar(&Enabled, "enabled", false, "")
+	inInfluxdb.StringVar(&addr, "addr", ":8089", "tcp listen address for line protocol. empty to disable")
+	inInfluxdb.StringVar(&httpAddr, "http-addr", ":8086", "http listen address for the /write endpoint. empty to disable")
+	inInfluxdb.StringVar(&precisionStr, "precision", "ns", "precision of the timestamps received over tcp (ns, us, ms, s, m or h). over http, it is given by the precision parameter")
+	inInfluxdb.IntVar(&partitionId, "partition", 0, "partition Id.")
+	globalconf.Register("influxdb-in", inInfluxdb)
+}
+
+func ConfigProcess() {
+	if !Enabled {
+		return
+	}
+	var err error
+	precision, err = parsePrecision(precisionStr)
+	if err != nil {
+		log.Fatal(4, "influxdb-in: %s", err.Error())
+	}
+	if addr == "" && httpAddr == "" {
+		log.Fatal(4, "influxdb-in: addr and http-addr can't both be empty")
+	}
+	cluster.Manager.SetPartitions([]int32{int32(partitionId)})
+}
+
+func New() *Influxdb {
+	i := &Influxdb{
+		connTrack: carbon.NewConnTrack(),
+	}
+	if addr != "" {
+		addrT, err := net.ResolveTCPAddr("tcp", addr)
+		if err != nil {
+			log.Fatal(4, "influxdb-in: %s", err.Error())
+		}
+		i.addr = addrT
+	}
+	return i
+}
+
+func (i *Influxdb) IntervalGetter(getter carbon.IntervalGetter) {
+	i.intervalGetter = getter
+}
+
+func (i *Influxdb) Start(handler input.Handler) {
+	i.Handler = handler
+	i.quit = make(chan struct{})
+	if i.addr != nil {
+		l, err := net.ListenTCP("tcp", i.addr)
+		if err != nil {
+			log.Fatal(4, "influxdb-in: %s", err.Error())
+		}
+		i.listener = l
+		log.Info("influxdb-in: listening on %v/tcp", i.addr)
+		go i.accept()
+	}
+	if httpAddr != "" {
+		l, err := net.Listen("tcp", httpAddr)
+		if err != nil {
+			log.Fatal(4, "influxdb-in: %s", err.Error())
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/write", i.handleWrite)
+		mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		i.httpServer = &http.Server{Handler: mux}
+		log.Info("influxdb-in: listening on %v/http", httpAddr)
+		go func() {
+			err := i.httpServer.Serve(l)
+			if err != nil && err != http.ErrServerClosed {
+				log.Error(4, "influxdb-in: http server error: %s", err.Error())
+			}
+		}()
+	}
+}
+
+// MaintainPriority is very simplistic for influxdb. like for carbon, there is no backfill,
+// so mark as ready immediately.
+func (i *Influxdb) MaintainPriority() {
+	cluster.Manager.SetPriority(0)
+}
+
+func (i *Influxdb) accept() {
+	for {
+		conn, err := i.listener.AcceptTCP()
+		if nil != err {
+			select {
+			case <-i.quit:
+				// we are shutting down.
+				return
+			default:
+			}
+			log.Error(4, "influxdb-in: Accept Error: %s", err.Error())
+			return
+		}
+		i.handlerWaitGroup.Add(1)
+		i.connTrack.Add(conn)
+		go i.handle(conn)
+	}
+}
+
+func (i *Influxdb) Stop() {
+	log.Info("influxdb-in: shutting down.")
+	close(i.quit)
+	if i.listener != nil {
+		i.listener.Close()
+	}
+	if i.httpServer != nil {
+		// waits for the write requests in progress to finish
+		i.httpServer.Shutdown(context.Background())
+	}
+	i.connTrack.CloseAll()
+	i.handlerWaitGroup.Wait()
+}
+
+func (i *Influxdb) handle(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		i.connTrack.Remove(conn)
+		i.handlerWaitGroup.Done()
+	}()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), maxLineLength)
+	for scanner.Scan() {
+		count, err := i.processLine(scanner.Bytes(), precision, time.Now())
+		if err != nil {
+			metricsDecodeErr.Inc()
+			log.Error(4, "influxdb-in: invalid line: %s", err.Error())
+			continue
+		}
+		if count > 0 {
+			metricsPerMessage.ValueUint32(uint32(count))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		select {
+		case <-i.quit:
+			// we are shutting down.
+		default:
+			log.Error(4, "influxdb-in: Recv error: %s", err.Error())
+		}
+	}
+}
+
+// handleWrite ingests the lines of a request to the /write endpoint. like influxdb, we store the valid lines
+// even if some are invalid, in which case we respond with the error of the first one
+func (i *Influxdb) handleWrite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "only POST is supported")
+		return
+	}
+	prec, err := parsePrecision(r.URL.Query().Get("precision"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to gunzip request body: %s", err))
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	now := time.Now()
+	var count int
+	var firstErr error
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 4096), maxLineLength)
+	for scanner.Scan() {
+		n, err := i.processLine(scanner.Bytes(), prec, now)
+		if err != nil {
+			metricsDecodeErr.Inc()
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		count += n
+	}
+	metricsPerMessage.ValueUint32(uint32(count))
+	if err := scanner.Err(); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read request body: %s", err))
+		return
+	}
+	if firstErr != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("partial write: %s", firstErr))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// processLine processes each numeric field of the line as a series, named after the measurement and the field,
+// with the tags of the line. it returns the number of series processed
+func (i *Influxdb) processLine(b []byte, precision time.Duration, now time.Time) (int, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || b[0] == '#' {
+		return 0, nil
+	}
+	l, err := parseLine(b, precision, now)
+	if err != nil {
+		return 0, err
+	}
+	// validate all series before processing any of them, so a line is either processed entirely or not at all
+	metrics := make([]*schema.MetricData, 0, len(l.fields))
+	for _, f := range l.fields {
+		name := l.measurement + "." + f.key
+		md := &schema.MetricData{
+			Name:     name,
+			Metric:   name,
+			Interval: i.intervalGetter.GetInterval(name),
+			Value:    f.value,
+			Unit:     "unknown",
+			Time:     l.ts,
+			Mtype:    "gauge",
+			Tags:     l.tags,
+			OrgId:    1, // admin org
+		}
+		if err := md.Validate(); err != nil {
+			return 0, fmt.Errorf("%s: %s", name, err)
+		}
+		md.SetId()
+		metrics = append(metrics, md)
+	}
+	for _, md := range metrics {
+		i.Handler.Process(md, int32(partitionId))
+	}
+	return len(metrics), nil
+}
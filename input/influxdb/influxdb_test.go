@@ -0,0 +1,152 @@
+package influxdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/raintank/schema.v1"
+)
+
+func TestParseLine(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	cases := []struct {
+		in        string
+		precision time.Duration
+		exp       line
+		expErr    bool
+	}{
+		{
+			in:        "cpu,host=server01,region=us-west usage_idle=98.5,usage_user=1i 1434055562000000000",
+			precision: time.Nanosecond,
+			exp: line{
+				measurement: "cpu",
+				tags:        []string{"host=server01", "region=us-west"},
+				fields:      []field{{"usage_idle", 98.5}, {"usage_user", 1}},
+				ts:          1434055562,
+			},
+		},
+		{
+			in:        "mem free=5u,up=true,down=F,status=\"ok, \\\"fine\\\"\" 1434055562",
+			precision: time.Second,
+			exp: line{
+				measurement: "mem",
+				fields:      []field{{"free", 5}, {"up", 1}, {"down", 0}},
+				ts:          1434055562,
+			},
+		},
+		{
+			in:        `disk\ io,path=/var\,log,dev\=x=sd\ a read\ bytes=3`,
+			precision: time.Nanosecond,
+			exp: line{
+				measurement: "disk io",
+				tags:        []string{"dev=x=sd a", "path=/var,log"},
+				fields:      []field{{"read bytes", 3}},
+				ts:          1500000000,
+			},
+		},
+		{
+			in:        "load value=1.5 1434055562500",
+			precision: time.Millisecond,
+			exp: line{
+				measurement: "load",
+				fields:      []field{{"value", 1.5}},
+				ts:          1434055562,
+			},
+		},
+		{in: "cpu", expErr: true},
+		{in: "cpu,host value=1", expErr: true},
+		{in: "cpu,host= value=1", expErr: true},
+		{in: "cpu value=", expErr: true},
+		{in: "cpu value=abc", expErr: true},
+		{in: "cpu value=NaN", expErr: true},
+		{in: "cpu value=\"unterminated", expErr: true},
+		{in: "cpu value=1 yesterday", expErr: true},
+	}
+	for _, c := range cases {
+		got, err := parseLine([]byte(c.in), c.precision, now)
+		if c.expErr {
+			if err == nil {
+				t.Errorf("%q: expected error, got %+v", c.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %s", c.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.exp) {
+			t.Errorf("%q:\nexpected %+v\n     got %+v", c.in, c.exp, got)
+		}
+	}
+}
+
+type mockHandler struct {
+	metrics []schema.MetricData
+}
+
+func (h *mockHandler) Process(metric *schema.MetricData, partition int32) {
+	h.metrics = append(h.metrics, *metric)
+}
+
+type mockIntervalGetter struct{}
+
+func (mockIntervalGetter) GetInterval(name string) int {
+	return 10
+}
+
+func TestHandleWrite(t *testing.T) {
+	handler := &mockHandler{}
+	i := &Influxdb{Handler: handler, intervalGetter: mockIntervalGetter{}}
+
+	body := "cpu,host=server01 usage_idle=98.5,usage_user=1i 1434055562\n\n# comment\nmem,host=server01 status=\"ok\" 1434055562\n"
+	req := httptest.NewRequest("POST", "/write?db=telegraf&precision=s", bytes.NewBufferString(body))
+	resp := httptest.NewRecorder()
+	i.handleWrite(resp, req)
+	if resp.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d: %s", resp.Code, resp.Body.String())
+	}
+	if len(handler.metrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %d", len(handler.metrics))
+	}
+	md := handler.metrics[0]
+	if md.Name != "cpu.usage_idle" || md.Value != 98.5 || md.Time != 1434055562 || md.Interval != 10 || md.OrgId != 1 {
+		t.Fatalf("unexpected metric data %v", md)
+	}
+	if !reflect.DeepEqual(md.Tags, []string{"host=server01"}) {
+		t.Fatalf("unexpected tags %v", md.Tags)
+	}
+	if handler.metrics[1].Name != "cpu.usage_user" || handler.metrics[0].Id == handler.metrics[1].Id {
+		t.Fatalf("unexpected metric data %v", handler.metrics[1])
+	}
+
+	// gzipped, with the default precision of nanoseconds, and an invalid line
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("cpu,host=server01 usage_idle=97 1434055572000000000\ncpu,host=a;b usage_idle=1\n"))
+	gz.Close()
+	req = httptest.NewRequest("POST", "/write?db=telegraf", &buf)
+	req.Header.Set("Content-Encoding", "gzip")
+	resp = httptest.NewRecorder()
+	i.handleWrite(resp, req)
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d: %s", resp.Code, resp.Body.String())
+	}
+	if len(handler.metrics) != 3 {
+		t.Fatalf("expected the valid line to be stored, got %d metrics", len(handler.metrics))
+	}
+	if md := handler.metrics[2]; md.Time != 1434055572 || md.Id != handler.metrics[0].Id {
+		t.Fatalf("unexpected metric data %v", md)
+	}
+
+	req = httptest.NewRequest("POST", "/write?precision=d", bytes.NewBufferString(body))
+	resp = httptest.NewRecorder()
+	i.handleWrite(resp, req)
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an unknown precision, got %d", resp.Code)
+	}
+}
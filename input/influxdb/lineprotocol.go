@@ -0,0 +1,179 @@
+package influxdb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// line is a parsed line of the line protocol
+type line struct {
+	measurement string
+	tags        []string // as key=value, sorted
+	fields      []field  // only the numeric and boolean ones, as we can't store strings
+	ts          int64    // in seconds
+}
+
+type field struct {
+	key   string
+	value float64
+}
+
+// parsePrecision parses the precision of timestamps, as given to influxdb's /write endpoint
+func parsePrecision(s string) (time.Duration, error) {
+	switch s {
+	case "", "n", "ns":
+		return time.Nanosecond, nil
+	case "u", "us", "µ":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	case "m":
+		return time.Minute, nil
+	case "h":
+		return time.Hour, nil
+	}
+	return 0, fmt.Errorf("unknown precision %q", s)
+}
+
+// parseLine parses a line of the line protocol, like "measurement[,tag=value...] field=value[,field=value...] [timestamp]".
+// commas and spaces are escaped with a backslash, and so are equal signs in tag keys, tag values and field keys.
+// string field values are double quoted. the timestamp is in the given precision, and defaults to now
+func parseLine(b []byte, precision time.Duration, now time.Time) (line, error) {
+	var l line
+	var i int
+	l.measurement, i = scanUntil(b, 0, ", ")
+	if l.measurement == "" {
+		return l, errors.New("missing measurement")
+	}
+
+	for i < len(b) && b[i] == ',' {
+		var key, value string
+		key, i = scanUntil(b, i+1, "=, ")
+		if i == len(b) || b[i] != '=' || key == "" {
+			return l, fmt.Errorf("invalid tag in %q", b)
+		}
+		value, i = scanUntil(b, i+1, ", ")
+		if value == "" {
+			return l, fmt.Errorf("missing value of tag %s", key)
+		}
+		l.tags = append(l.tags, key+"="+value)
+	}
+	sort.Strings(l.tags)
+
+	i = skipSpaces(b, i)
+	if i == len(b) {
+		return l, errors.New("missing fields")
+	}
+	for {
+		var key string
+		key, i = scanUntil(b, i, "=, ")
+		if i == len(b) || b[i] != '=' || key == "" {
+			return l, fmt.Errorf("invalid field in %q", b)
+		}
+		i++
+		if i < len(b) && b[i] == '"' {
+			var err error
+			i, err = skipString(b, i)
+			if err != nil {
+				return l, err
+			}
+		} else {
+			start := i
+			for i < len(b) && b[i] != ',' && b[i] != ' ' {
+				i++
+			}
+			value, err := parseFieldValue(string(b[start:i]))
+			if err != nil {
+				return l, fmt.Errorf("invalid value of field %s: %s", key, err)
+			}
+			l.fields = append(l.fields, field{key, value})
+		}
+		if i == len(b) || b[i] != ',' {
+			break
+		}
+		i++
+	}
+
+	i = skipSpaces(b, i)
+	if i == len(b) {
+		l.ts = now.Unix()
+		return l, nil
+	}
+	ts, err := strconv.ParseInt(strings.TrimSpace(string(b[i:])), 10, 64)
+	if err != nil {
+		return l, fmt.Errorf("invalid timestamp %q", b[i:])
+	}
+	l.ts = time.Unix(0, ts*int64(precision)).Unix()
+	return l, nil
+}
+
+// scanUntil returns the unescaped token starting at b[i], up to the first unescaped byte of stops, and the position after it
+func scanUntil(b []byte, i int, stops string) (string, int) {
+	var buf []byte
+	for ; i < len(b); i++ {
+		c := b[i]
+		if c == '\\' && i+1 < len(b) && strings.IndexByte(`,= "\`, b[i+1]) != -1 {
+			i++
+			buf = append(buf, b[i])
+			continue
+		}
+		if strings.IndexByte(stops, c) != -1 {
+			break
+		}
+		buf = append(buf, c)
+	}
+	return string(buf), i
+}
+
+// skipString returns the position after the double quoted string starting at b[i]
+func skipString(b []byte, i int) (int, error) {
+	for i++; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return i, fmt.Errorf("unterminated string in %q", b)
+}
+
+func skipSpaces(b []byte, i int) int {
+	for i < len(b) && b[i] == ' ' {
+		i++
+	}
+	return i
+}
+
+// parseFieldValue parses a non-string field value: a float, an integer like 5i, an unsigned integer like 5u,
+// or a boolean, which we store as 1 or 0
+func parseFieldValue(s string) (float64, error) {
+	switch s {
+	case "t", "T", "true", "True", "TRUE":
+		return 1, nil
+	case "f", "F", "false", "False", "FALSE":
+		return 0, nil
+	case "":
+		return 0, errors.New("empty value")
+	}
+	switch s[len(s)-1] {
+	case 'i':
+		v, err := strconv.ParseInt(s[:len(s)-1], 10, 64)
+		return float64(v), err
+	case 'u':
+		v, err := strconv.ParseUint(s[:len(s)-1], 10, 64)
+		return float64(v), err
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err == nil && (math.IsNaN(v) || math.IsInf(v, 0)) {
+		return 0, fmt.Errorf("invalid value %s", s)
+	}
+	return v, err
+}
@@ -67,7 +67,7 @@ func NewAggMetric(store Store, cachePusher cache.CachePusher, key string, retent
 	}
 
 	for _, ret := range retentions[1:] {
-		m.aggregators = append(m.aggregators, NewAggregator(store, cachePusher, key, ret, *agg, dropFirstChunk))
+		m.aggregators = append(m.aggregators, NewAggregator(store, cachePusher, key, uint32(retentions[0].SecondsPerPoint), ret, *agg, dropFirstChunk))
 	}
 
 	return &m
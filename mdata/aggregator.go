@@ -24,6 +24,7 @@ type Aggregator struct {
 	span            uint32
 	currentBoundary uint32 // working on this chunk
 	agg             *Aggregation
+	minCnt          float64 // buckets with less raw points than this are not flushed (see xFilesFactor)
 	minMetric       *AggMetric
 	maxMetric       *AggMetric
 	sumMetric       *AggMetric
@@ -31,7 +32,10 @@ type Aggregator struct {
 	lstMetric       *AggMetric
 }
 
-func NewAggregator(store Store, cachePusher cache.CachePusher, key string, ret conf.Retention, agg conf.Aggregation, dropFirstChunk bool) *Aggregator {
+// NewAggregator creates an aggregator for the given retention.
+// rawInterval is the interval of the raw series. together with the xFilesFactor of agg, it determines
+// how many raw points a bucket needs before we consider its aggregates valid.
+func NewAggregator(store Store, cachePusher cache.CachePusher, key string, rawInterval uint32, ret conf.Retention, agg conf.Aggregation, dropFirstChunk bool) *Aggregator {
 	if len(agg.AggregationMethod) == 0 {
 		panic("NewAggregator called without aggregations. this should never happen")
 	}
@@ -41,6 +45,9 @@ func NewAggregator(store Store, cachePusher cache.CachePusher, key string, ret c
 		span: span,
 		agg:  NewAggregation(),
 	}
+	if rawInterval > 0 && rawInterval <= span {
+		aggregator.minCnt = agg.XFilesFactor * float64(span/rawInterval)
+	}
 	for _, agg := range agg.AggregationMethod {
 		switch agg {
 		case conf.Avg:
@@ -72,7 +79,12 @@ func NewAggregator(store Store, cachePusher cache.CachePusher, key string, ret c
 }
 
 // flush adds points to the aggregation-series and resets aggregation state
+// if the bucket did not receive enough raw points to satisfy the xFilesFactor, no points are added.
 func (agg *Aggregator) flush() {
+	if agg.agg.Cnt < agg.minCnt {
+		agg.agg.Reset()
+		return
+	}
 	if agg.minMetric != nil {
 		agg.minMetric.Add(agg.currentBoundary, agg.agg.Min)
 	}
@@ -70,13 +70,13 @@ func TestAggregator(t *testing.T) {
 		AggregationMethod: []conf.Method{conf.Avg, conf.Min, conf.Max, conf.Sum, conf.Lst},
 	}
 
-	agg := NewAggregator(dnstore, &cache.MockCache{}, "test", ret, aggs, false)
+	agg := NewAggregator(dnstore, &cache.MockCache{}, "test", 10, ret, aggs, false)
 	agg.Add(100, 123.4)
 	agg.Add(110, 5)
 	expected := []schema.Point{}
 	compare("simple-min-unfinished", agg.minMetric, expected)
 
-	agg = NewAggregator(dnstore, &cache.MockCache{}, "test", ret, aggs, false)
+	agg = NewAggregator(dnstore, &cache.MockCache{}, "test", 10, ret, aggs, false)
 	agg.Add(100, 123.4)
 	agg.Add(110, 5)
 	agg.Add(130, 130)
@@ -85,7 +85,7 @@ func TestAggregator(t *testing.T) {
 	}
 	compare("simple-min-one-block", agg.minMetric, expected)
 
-	agg = NewAggregator(dnstore, &cache.MockCache{}, "test", ret, aggs, false)
+	agg = NewAggregator(dnstore, &cache.MockCache{}, "test", 10, ret, aggs, false)
 	agg.Add(100, 123.4)
 	agg.Add(110, 5)
 	agg.Add(120, 4)
@@ -94,7 +94,7 @@ func TestAggregator(t *testing.T) {
 	}
 	compare("simple-min-one-block-done-cause-last-point-just-right", agg.minMetric, expected)
 
-	agg = NewAggregator(dnstore, &cache.MockCache{}, "test", ret, aggs, false)
+	agg = NewAggregator(dnstore, &cache.MockCache{}, "test", 10, ret, aggs, false)
 	agg.Add(100, 123.4)
 	agg.Add(110, 5)
 	agg.Add(150, 1.123)
@@ -105,7 +105,7 @@ func TestAggregator(t *testing.T) {
 	}
 	compare("simple-min-two-blocks-done-cause-last-point-just-right", agg.minMetric, expected)
 
-	agg = NewAggregator(dnstore, &cache.MockCache{}, "test", ret, aggs, false)
+	agg = NewAggregator(dnstore, &cache.MockCache{}, "test", 10, ret, aggs, false)
 	agg.Add(100, 123.4)
 	agg.Add(110, 5)
 	agg.Add(190, 2451.123)
@@ -133,4 +133,30 @@ func TestAggregator(t *testing.T) {
 		{Val: 2451.123 + 1451.123 + 978894.445, Ts: 240},
 	})
 
+	// span 60 with raw interval 10 means 6 points per bucket, of which we need at least 3.
+	aggs.XFilesFactor = 0.5
+	agg = NewAggregator(dnstore, &cache.MockCache{}, "test", 10, ret, aggs, false)
+	agg.Add(100, 123.4)
+	agg.Add(110, 5)
+	agg.Add(130, 130)
+	agg.Add(140, 4)
+	agg.Add(150, 1.123)
+	agg.Add(190, 2)
+	agg.Add(240, 1)
+	compare("xff-min-skip-sparse-blocks", agg.minMetric, []schema.Point{
+		{Val: 1.123, Ts: 180},
+	})
+	compare("xff-cnt-skip-sparse-blocks", agg.cntMetric, []schema.Point{
+		{Val: 3, Ts: 180},
+	})
+
+	// the default aggregation keeps sparse blocks, like we did before honoring xFilesFactor
+	agg = NewAggregator(dnstore, &cache.MockCache{}, "test", 10, ret, conf.NewAggregations().DefaultAggregation, false)
+	agg.Add(100, 123.4)
+	agg.Add(190, 2)
+	agg.Add(250, 1)
+	compare("xff-default-keep-sparse-blocks", agg.sumMetric, []schema.Point{
+		{Val: 123.4, Ts: 120},
+		{Val: 2, Ts: 240},
+	})
 }
@@ -1,12 +1,14 @@
 package mdata
 
 import (
+	"context"
 	"time"
 
 	"github.com/grafana/metrictank/mdata/chunk"
 )
 
 type ChunkReadRequest struct {
+	ctx       context.Context
 	month     uint32
 	sortKey   uint32
 	q         string
@@ -403,10 +403,11 @@ func (c *CassandraStore) insertChunk(key string, t0, ttl uint32, data []byte) er
 }
 
 type outcome struct {
-	month   uint32
-	sortKey uint32
-	i       *gocql.Iter
-	omitted bool
+	month    uint32
+	sortKey  uint32
+	i        *gocql.Iter
+	omitted  bool
+	canceled bool
 }
 type asc []outcome
 
@@ -423,8 +424,13 @@ func (c *CassandraStore) processReadQueue() {
 			crr.out <- outcome{omitted: true}
 			continue
 		}
+		// don't bother querying for requests that have been canceled while they were in the queue
+		if crr.ctx.Err() != nil {
+			crr.out <- outcome{canceled: true}
+			continue
+		}
 		pre := time.Now()
-		iter := outcome{crr.month, crr.sortKey, c.Session.Query(crr.q, crr.p...).Iter(), false}
+		iter := outcome{crr.month, crr.sortKey, c.Session.Query(crr.q, crr.p...).WithContext(crr.ctx).Iter(), false, false}
 		cassGetExecDuration.Value(time.Since(pre))
 		crr.out <- iter
 	}
@@ -460,7 +466,7 @@ func (c *CassandraStore) SearchTable(ctx context.Context, key, table string, sta
 	crrs := make([]*ChunkReadRequest, 0)
 
 	query := func(month, sortKey uint32, q string, p ...interface{}) {
-		crrs = append(crrs, &ChunkReadRequest{month, sortKey, q, p, pre, nil})
+		crrs = append(crrs, &ChunkReadRequest{ctx, month, sortKey, q, p, pre, nil})
 	}
 
 	start_month := start - (start % Month_sec)       // starting row has to be at, or before, requested start
@@ -520,6 +526,11 @@ func (c *CassandraStore) SearchTable(ctx context.Context, key, table string, sta
 			tracing.Error(span, errReadTooOld)
 			return nil, errReadTooOld
 		}
+		if o.canceled {
+			tracing.Failure(span)
+			tracing.Error(span, ctx.Err())
+			return nil, ctx.Err()
+		}
 		seen += 1
 		outcomes = append(outcomes, o)
 		if seen == numQueries {
@@ -25,6 +25,7 @@ import (
 	"github.com/grafana/metrictank/idx/memory"
 	"github.com/grafana/metrictank/input"
 	inCarbon "github.com/grafana/metrictank/input/carbon"
+	inInfluxdb "github.com/grafana/metrictank/input/influxdb"
 	inKafkaMdm "github.com/grafana/metrictank/input/kafkamdm"
 	"github.com/grafana/metrictank/mdata"
 	"github.com/grafana/metrictank/mdata/cache"
@@ -130,6 +131,7 @@ func main() {
 	}
 	// load config for metric ingestors
 	inCarbon.ConfigSetup()
+	inInfluxdb.ConfigSetup()
 	inKafkaMdm.ConfigSetup()
 
 	// load config for cluster handlers
@@ -207,13 +209,14 @@ func main() {
 		Validate remaining settings
 	***********************************/
 	inCarbon.ConfigProcess()
+	inInfluxdb.ConfigProcess()
 	inKafkaMdm.ConfigProcess(*instance)
 	notifierNsq.ConfigProcess()
 	notifierKafka.ConfigProcess(*instance)
 	statsConfig.ConfigProcess(*instance)
 	mdata.ConfigProcess()
 
-	if !inCarbon.Enabled && !inKafkaMdm.Enabled {
+	if !inCarbon.Enabled && !inInfluxdb.Enabled && !inKafkaMdm.Enabled {
 		log.Fatal(4, "you should enable at least 1 input plugin")
 	}
 
@@ -292,6 +295,10 @@ func main() {
 		inputs = append(inputs, inCarbon.New())
 	}
 
+	if inInfluxdb.Enabled {
+		inputs = append(inputs, inInfluxdb.New())
+	}
+
 	if inKafkaMdm.Enabled {
 		sarama.Logger = l.New(os.Stdout, "[Sarama] ", l.LstdFlags)
 		inputs = append(inputs, inKafkaMdm.New())
@@ -372,10 +379,20 @@ func main() {
 	/***********************************
 		Start our inputs
 	***********************************/
+	// the http inputs only get requests once we're ready, which is after this point
+	if api.PrometheusWriteEnabled {
+		apiServer.BindPrometheusHandler(input.NewDefaultHandler(metrics, metricIndex, "prometheus"))
+	}
+	if api.OpenTSDBPutEnabled {
+		apiServer.BindOpenTSDBHandler(input.NewDefaultHandler(metrics, metricIndex, "opentsdb"))
+	}
 	for _, plugin := range inputs {
 		if carbonPlugin, ok := plugin.(*inCarbon.Carbon); ok {
 			carbonPlugin.IntervalGetter(inCarbon.NewIndexIntervalGetter(metricIndex))
 		}
+		if influxdbPlugin, ok := plugin.(*inInfluxdb.Influxdb); ok {
+			influxdbPlugin.IntervalGetter(inCarbon.NewIndexIntervalGetter(metricIndex))
+		}
 		plugin.Start(input.NewDefaultHandler(metrics, metricIndex, plugin.Name()))
 		plugin.MaintainPriority()
 	}
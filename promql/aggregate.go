@@ -0,0 +1,111 @@
+package promql
+
+import (
+	"math"
+	"sort"
+)
+
+// group holds the state of the aggregation of the samples with the same grouping labels
+type group struct {
+	metric  Labels
+	value   float64
+	mean    float64
+	count   int
+	samples Vector // for topk and bottomk
+}
+
+func (ev *evaluator) aggregate(e *AggregateExpr, vec Vector, param Value, ts int64) Vector {
+	var k int
+	if e.Param != nil {
+		f := param.(Scalar).V
+		if math.IsNaN(f) || f < 0 {
+			ev.errorf("invalid value %v for k in %s, must be a positive number", f, e.Op)
+		}
+		k = int(f)
+		if k == 0 {
+			return Vector{}
+		}
+	}
+
+	without := append([]string{MetricNameLabel}, e.Grouping...)
+	groups := make(map[string]*group)
+	var order []string
+	for _, s := range vec {
+		var metric Labels
+		if e.Without {
+			metric = s.Metric.Without(without...)
+		} else {
+			metric = s.Metric.Only(e.Grouping...)
+		}
+		key := metric.String()
+		g, ok := groups[key]
+		if !ok {
+			g = &group{metric: metric, value: s.V}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.count++
+		switch e.Op {
+		case "sum":
+			if ok {
+				g.value += s.V
+			}
+		case "min":
+			if s.V < g.value || math.IsNaN(g.value) {
+				g.value = s.V
+			}
+		case "max":
+			if s.V > g.value || math.IsNaN(g.value) {
+				g.value = s.V
+			}
+		case "avg", "stddev", "stdvar":
+			// welford's online algorithm, with value tracking the sum of squared differences
+			if !ok {
+				g.value = 0
+			}
+			delta := s.V - g.mean
+			g.mean += delta / float64(g.count)
+			g.value += delta * (s.V - g.mean)
+		case "topk", "bottomk":
+			g.samples = append(g.samples, s)
+		}
+	}
+
+	out := make(Vector, 0, len(groups))
+	for _, key := range order {
+		g := groups[key]
+		switch e.Op {
+		case "avg":
+			g.value = g.mean
+		case "count":
+			g.value = float64(g.count)
+		case "stddev":
+			g.value = math.Sqrt(g.value / float64(g.count))
+		case "stdvar":
+			g.value = g.value / float64(g.count)
+		case "topk", "bottomk":
+			out = append(out, selectK(g.samples, k, e.Op == "topk")...)
+			continue
+		}
+		out = append(out, Sample{Metric: g.metric, Point: Point{T: ts, V: g.value}})
+	}
+	return out
+}
+
+// selectK returns the k samples with the highest or lowest values. NaN values come last
+func selectK(samples Vector, k int, top bool) Vector {
+	sort.SliceStable(samples, func(i, j int) bool {
+		a, b := samples[i].V, samples[j].V
+		if math.IsNaN(a) || math.IsNaN(b) {
+			return !math.IsNaN(a)
+		}
+		if top {
+			return a > b
+		}
+		return a < b
+	})
+	if k < len(samples) {
+		samples = samples[:k]
+	}
+	return samples
+}
@@ -0,0 +1,229 @@
+package promql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expr is a node of a parsed PromQL expression
+type Expr interface {
+	Type() ValueType
+	String() string
+}
+
+// NumberLiteral is a number, like 1 or 2.5e3
+type NumberLiteral struct {
+	Val float64
+}
+
+// ParenExpr is an expression wrapped in parentheses
+type ParenExpr struct {
+	Expr Expr
+}
+
+// UnaryExpr is a negated or explicitly positive expression, like -foo
+type UnaryExpr struct {
+	Op   string
+	Expr Expr
+}
+
+// BinaryExpr is an arithmetic operation, like foo / 2
+type BinaryExpr struct {
+	Op  string
+	LHS Expr
+	RHS Expr
+}
+
+// VectorSelector selects the series matching all matchers, like foo{job="api"}
+type VectorSelector struct {
+	Name     string
+	Matchers []*LabelMatcher
+	Offset   time.Duration
+}
+
+// MatrixSelector selects the points within a time range of the series matching a vector selector, like foo[5m]
+type MatrixSelector struct {
+	*VectorSelector
+	Range time.Duration
+}
+
+// Call is a function call, like rate(foo[5m])
+type Call struct {
+	Func *Function
+	Args []Expr
+}
+
+// AggregateExpr aggregates a vector, like sum by (job) (foo)
+type AggregateExpr struct {
+	Op       string
+	Expr     Expr
+	Param    Expr // the k of topk and bottomk
+	Grouping []string
+	Without  bool
+}
+
+func (e *NumberLiteral) Type() ValueType  { return ValueTypeScalar }
+func (e *ParenExpr) Type() ValueType      { return e.Expr.Type() }
+func (e *UnaryExpr) Type() ValueType      { return e.Expr.Type() }
+func (e *VectorSelector) Type() ValueType { return ValueTypeVector }
+func (e *MatrixSelector) Type() ValueType { return ValueTypeMatrix }
+func (e *Call) Type() ValueType           { return e.Func.ReturnType }
+func (e *AggregateExpr) Type() ValueType  { return ValueTypeVector }
+
+func (e *BinaryExpr) Type() ValueType {
+	if e.LHS.Type() == ValueTypeScalar && e.RHS.Type() == ValueTypeScalar {
+		return ValueTypeScalar
+	}
+	return ValueTypeVector
+}
+
+func (e *NumberLiteral) String() string {
+	return strconv.FormatFloat(e.Val, 'f', -1, 64)
+}
+
+func (e *ParenExpr) String() string {
+	return "(" + e.Expr.String() + ")"
+}
+
+func (e *UnaryExpr) String() string {
+	if _, ok := e.Expr.(*BinaryExpr); ok {
+		return e.Op + "(" + e.Expr.String() + ")"
+	}
+	return e.Op + e.Expr.String()
+}
+
+func (e *BinaryExpr) String() string {
+	return e.LHS.String() + " " + e.Op + " " + e.RHS.String()
+}
+
+func (e *VectorSelector) String() string {
+	var matchers []string
+	for _, m := range e.Matchers {
+		// the name is already shown in front of the braces
+		if m.Name == MetricNameLabel && m.Type == MatchEqual && e.Name != "" {
+			continue
+		}
+		matchers = append(matchers, m.String())
+	}
+	s := e.Name
+	if len(matchers) > 0 || s == "" {
+		s += "{" + strings.Join(matchers, ",") + "}"
+	}
+	if e.Offset != 0 {
+		s += " offset " + formatDuration(e.Offset)
+	}
+	return s
+}
+
+func (e *MatrixSelector) String() string {
+	// the offset goes after the range
+	vs := *e.VectorSelector
+	vs.Offset = 0
+	s := vs.String() + "[" + formatDuration(e.Range) + "]"
+	if e.Offset != 0 {
+		s += " offset " + formatDuration(e.Offset)
+	}
+	return s
+}
+
+func (e *Call) String() string {
+	args := make([]string, 0, len(e.Args))
+	for _, arg := range e.Args {
+		args = append(args, arg.String())
+	}
+	return e.Func.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (e *AggregateExpr) String() string {
+	s := e.Op
+	if e.Without {
+		s += " without (" + strings.Join(e.Grouping, ", ") + ")"
+	} else if len(e.Grouping) > 0 {
+		s += " by (" + strings.Join(e.Grouping, ", ") + ")"
+	}
+	if e.Param != nil {
+		return s + " (" + e.Param.String() + ", " + e.Expr.String() + ")"
+	}
+	return s + " (" + e.Expr.String() + ")"
+}
+
+// MatchType is the kind of comparison a LabelMatcher makes
+type MatchType int
+
+const (
+	MatchEqual MatchType = iota
+	MatchNotEqual
+	MatchRegexp
+	MatchNotRegexp
+)
+
+func (t MatchType) String() string {
+	switch t {
+	case MatchEqual:
+		return "="
+	case MatchNotEqual:
+		return "!="
+	case MatchRegexp:
+		return "=~"
+	case MatchNotRegexp:
+		return "!~"
+	}
+	panic(fmt.Sprintf("unknown match type %d", t))
+}
+
+// LabelMatcher selects series based on the value of one of their labels
+type LabelMatcher struct {
+	Type  MatchType
+	Name  string
+	Value string
+
+	re *regexp.Regexp
+}
+
+// NewLabelMatcher returns a matcher, compiling its regex if it has one. like in prometheus, regexes are fully anchored
+func NewLabelMatcher(t MatchType, name, value string) (*LabelMatcher, error) {
+	m := &LabelMatcher{Type: t, Name: name, Value: value}
+	if t == MatchRegexp || t == MatchNotRegexp {
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, err
+		}
+		m.re = re
+	}
+	return m, nil
+}
+
+// Matches returns whether the matcher matches the label value. a missing label has the empty value
+func (m *LabelMatcher) Matches(value string) bool {
+	switch m.Type {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	default:
+		return !m.re.MatchString(value)
+	}
+}
+
+func (m *LabelMatcher) String() string {
+	return m.Name + m.Type.String() + strconv.Quote(m.Value)
+}
+
+// formatDuration formats a duration the way PromQL accepts it, using the largest unit that fits
+func formatDuration(d time.Duration) string {
+	ms := int64(d / time.Millisecond)
+	if ms == 0 {
+		return "0s"
+	}
+	for _, u := range durationUnits {
+		if ms%u.ms == 0 {
+			return strconv.FormatInt(ms/u.ms, 10) + u.unit
+		}
+	}
+	return strconv.FormatInt(ms, 10) + "ms"
+}
@@ -0,0 +1,332 @@
+// Package promql implements a subset of the prometheus query language.
+// expressions are parsed into a tree, which is evaluated at each step of a query, against the data
+// that a Querier selected for its selectors.
+package promql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// LookbackDelta is how far back an instant vector selector looks for the latest point of a series
+var LookbackDelta = 5 * time.Minute
+
+// Querier provides the data of the series that queries select
+type Querier interface {
+	// Select returns the series matching all matchers, with their points between start and end, in milliseconds.
+	Select(ctx context.Context, matchers []*LabelMatcher, start, end int64) ([]Series, error)
+}
+
+// evalError is returned for expressions that can't be evaluated against the data
+type evalError struct {
+	err error
+}
+
+// evaluator evaluates an expression at a given time, against the data that was selected for it
+type evaluator struct {
+	data map[*VectorSelector][]Series
+}
+
+// EvalInstant evaluates the expression at the given time, in milliseconds.
+// the result is a Scalar, a Vector or, for range selectors, a Matrix.
+func EvalInstant(ctx context.Context, q Querier, e Expr, ts int64) (v Value, err error) {
+	ev, err := newEvaluator(ctx, q, e, ts, ts)
+	if err != nil {
+		return nil, err
+	}
+	defer ev.recover(&err)
+	switch v := ev.eval(e, ts).(type) {
+	case Vector:
+		ev.checkDistinct(v)
+		sort.Slice(v, func(i, j int) bool { return v[i].Metric.String() < v[j].Metric.String() })
+		if v == nil {
+			return Vector{}, nil
+		}
+		return v, nil
+	case Matrix:
+		if v == nil {
+			return Matrix{}, nil
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// EvalRange evaluates the expression at each step from start to end, in milliseconds.
+// each series in the result has a point for each step at which it had a value.
+func EvalRange(ctx context.Context, q Querier, e Expr, start, end int64, step time.Duration) (m Matrix, err error) {
+	if t := e.Type(); t != ValueTypeScalar && t != ValueTypeVector {
+		return nil, fmt.Errorf("invalid expression type %q for range query, must be scalar or instant vector", t)
+	}
+	if step < time.Millisecond {
+		return nil, fmt.Errorf("step must be at least 1ms")
+	}
+	ev, err := newEvaluator(ctx, q, e, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer ev.recover(&err)
+
+	series := make(map[string]*Series)
+	for ts := start; ts <= end; ts += int64(step / time.Millisecond) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		switch v := ev.eval(e, ts).(type) {
+		case Scalar:
+			vec := Vector{{Metric: Labels{}, Point: Point(v)}}
+			ev.addSamples(series, vec)
+		case Vector:
+			ev.checkDistinct(v)
+			ev.addSamples(series, v)
+		}
+	}
+	m = make(Matrix, 0, len(series))
+	for _, s := range series {
+		m = append(m, *s)
+	}
+	sort.Slice(m, func(i, j int) bool { return m[i].Metric.String() < m[j].Metric.String() })
+	return m, nil
+}
+
+// newEvaluator selects the data needed to evaluate the expression between start and end
+func newEvaluator(ctx context.Context, q Querier, e Expr, start, end int64) (*evaluator, error) {
+	ev := &evaluator{
+		data: make(map[*VectorSelector][]Series),
+	}
+	var err error
+	inspect(e, func(vs *VectorSelector, rng time.Duration) {
+		if err != nil {
+			return
+		}
+		offset := durationMs(vs.Offset)
+		from := start - offset - durationMs(rng)
+		if rng == 0 {
+			from = start - offset - durationMs(LookbackDelta)
+		}
+		ev.data[vs], err = q.Select(ctx, vs.Matchers, from, end-offset)
+	})
+	return ev, err
+}
+
+// inspect calls fn for each selector of the expression, along with its range, which is 0 for vector selectors
+func inspect(e Expr, fn func(vs *VectorSelector, rng time.Duration)) {
+	switch e := e.(type) {
+	case *ParenExpr:
+		inspect(e.Expr, fn)
+	case *UnaryExpr:
+		inspect(e.Expr, fn)
+	case *BinaryExpr:
+		inspect(e.LHS, fn)
+		inspect(e.RHS, fn)
+	case *VectorSelector:
+		fn(e, 0)
+	case *MatrixSelector:
+		fn(e.VectorSelector, e.Range)
+	case *Call:
+		for _, arg := range e.Args {
+			inspect(arg, fn)
+		}
+	case *AggregateExpr:
+		if e.Param != nil {
+			inspect(e.Param, fn)
+		}
+		inspect(e.Expr, fn)
+	}
+}
+
+func (ev *evaluator) errorf(format string, args ...interface{}) {
+	panic(evalError{fmt.Errorf(format, args...)})
+}
+
+// recover turns the panics of errorf into an error
+func (ev *evaluator) recover(err *error) {
+	if r := recover(); r != nil {
+		if e, ok := r.(evalError); ok {
+			*err = e.err
+			return
+		}
+		panic(r)
+	}
+}
+
+func (ev *evaluator) addSamples(series map[string]*Series, vec Vector) {
+	for _, s := range vec {
+		key := s.Metric.String()
+		ss, ok := series[key]
+		if !ok {
+			ss = &Series{Metric: s.Metric}
+			series[key] = ss
+		}
+		ss.Points = append(ss.Points, s.Point)
+	}
+}
+
+// checkDistinct verifies that no two samples of the vector have the same labels, which happens when
+// e.g. the result of a function on series with different names but otherwise the same labels
+func (ev *evaluator) checkDistinct(vec Vector) {
+	seen := make(map[string]struct{}, len(vec))
+	for _, s := range vec {
+		key := s.Metric.String()
+		if _, ok := seen[key]; ok {
+			ev.errorf("vector cannot contain metrics with the same labelset %s", key)
+		}
+		seen[key] = struct{}{}
+	}
+}
+
+func (ev *evaluator) eval(e Expr, ts int64) Value {
+	switch e := e.(type) {
+	case *NumberLiteral:
+		return Scalar{T: ts, V: e.Val}
+	case *ParenExpr:
+		return ev.eval(e.Expr, ts)
+	case *UnaryExpr:
+		switch v := ev.eval(e.Expr, ts).(type) {
+		case Scalar:
+			return Scalar{T: ts, V: -v.V}
+		case Vector:
+			out := make(Vector, 0, len(v))
+			for _, s := range v {
+				out = append(out, Sample{Metric: s.Metric.Without(MetricNameLabel), Point: Point{T: ts, V: -s.V}})
+			}
+			return out
+		}
+	case *BinaryExpr:
+		return ev.binary(e, ts)
+	case *VectorSelector:
+		return ev.vector(e, ts)
+	case *MatrixSelector:
+		return ev.matrix(e, ts)
+	case *Call:
+		args := make([]Value, 0, len(e.Args))
+		for _, arg := range e.Args {
+			args = append(args, ev.eval(arg, ts))
+		}
+		return e.Func.call(args, e, ts)
+	case *AggregateExpr:
+		var param Value
+		if e.Param != nil {
+			param = ev.eval(e.Param, ts)
+		}
+		return ev.aggregate(e, ev.eval(e.Expr, ts).(Vector), param, ts)
+	}
+	ev.errorf("unexpected expression %s", e)
+	return nil
+}
+
+// vector returns the latest point of each selected series, if it is no older than the lookback delta
+func (ev *evaluator) vector(vs *VectorSelector, ts int64) Vector {
+	ref := ts - durationMs(vs.Offset)
+	var out Vector
+	for _, s := range ev.data[vs] {
+		i := sort.Search(len(s.Points), func(i int) bool { return s.Points[i].T > ref }) - 1
+		if i < 0 || s.Points[i].T < ref-durationMs(LookbackDelta) {
+			continue
+		}
+		out = append(out, Sample{Metric: s.Metric, Point: Point{T: ts, V: s.Points[i].V}})
+	}
+	return out
+}
+
+// matrix returns the points of each selected series within the range
+func (ev *evaluator) matrix(ms *MatrixSelector, ts int64) Matrix {
+	ref := ts - durationMs(ms.Offset)
+	from := ref - durationMs(ms.Range)
+	var out Matrix
+	for _, s := range ev.data[ms.VectorSelector] {
+		i := sort.Search(len(s.Points), func(i int) bool { return s.Points[i].T >= from })
+		j := sort.Search(len(s.Points), func(i int) bool { return s.Points[i].T > ref })
+		if i == j {
+			continue
+		}
+		out = append(out, Series{Metric: s.Metric, Points: s.Points[i:j]})
+	}
+	return out
+}
+
+func (ev *evaluator) binary(e *BinaryExpr, ts int64) Value {
+	lhs := ev.eval(e.LHS, ts)
+	rhs := ev.eval(e.RHS, ts)
+	switch l := lhs.(type) {
+	case Scalar:
+		switch r := rhs.(type) {
+		case Scalar:
+			return Scalar{T: ts, V: arithmetic(e.Op, l.V, r.V)}
+		case Vector:
+			out := make(Vector, 0, len(r))
+			for _, s := range r {
+				out = append(out, Sample{Metric: s.Metric.Without(MetricNameLabel), Point: Point{T: ts, V: arithmetic(e.Op, l.V, s.V)}})
+			}
+			return out
+		}
+	case Vector:
+		switch r := rhs.(type) {
+		case Scalar:
+			out := make(Vector, 0, len(l))
+			for _, s := range l {
+				out = append(out, Sample{Metric: s.Metric.Without(MetricNameLabel), Point: Point{T: ts, V: arithmetic(e.Op, s.V, r.V)}})
+			}
+			return out
+		case Vector:
+			return ev.vectorBinary(e.Op, l, r, ts)
+		}
+	}
+	ev.errorf("invalid operands for binary expression %s", e)
+	return nil
+}
+
+// vectorBinary applies the operation to the samples of both vectors that have the same labels, apart from their name.
+// like prometheus' default, the matching must be one-to-one
+func (ev *evaluator) vectorBinary(op string, lhs, rhs Vector, ts int64) Vector {
+	right := make(map[string]Sample, len(rhs))
+	for _, s := range rhs {
+		key := s.Metric.Without(MetricNameLabel).String()
+		if _, ok := right[key]; ok {
+			ev.errorf("many-to-many matching not allowed: found duplicate series for the match group %s on the right hand-side of the operation", key)
+		}
+		right[key] = s
+	}
+	matched := make(map[string]struct{}, len(lhs))
+	var out Vector
+	for _, s := range lhs {
+		metric := s.Metric.Without(MetricNameLabel)
+		key := metric.String()
+		r, ok := right[key]
+		if !ok {
+			continue
+		}
+		if _, ok := matched[key]; ok {
+			ev.errorf("many-to-many matching not allowed: found duplicate series for the match group %s on the left hand-side of the operation", key)
+		}
+		matched[key] = struct{}{}
+		out = append(out, Sample{Metric: metric, Point: Point{T: ts, V: arithmetic(op, s.V, r.V)}})
+	}
+	return out
+}
+
+func arithmetic(op string, l, r float64) float64 {
+	switch op {
+	case "+":
+		return l + r
+	case "-":
+		return l - r
+	case "*":
+		return l * r
+	case "/":
+		return l / r
+	case "%":
+		return math.Mod(l, r)
+	case "^":
+		return math.Pow(l, r)
+	}
+	panic(fmt.Sprintf("unknown operator %q", op))
+}
+
+func durationMs(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
@@ -0,0 +1,216 @@
+package promql
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+// testQuerier selects from a fixed set of series
+type testQuerier []Series
+
+func (q testQuerier) Select(ctx context.Context, matchers []*LabelMatcher, start, end int64) ([]Series, error) {
+	var out []Series
+outer:
+	for _, s := range q {
+		for _, m := range matchers {
+			if !m.Matches(s.Metric.Get(m.Name)) {
+				continue outer
+			}
+		}
+		var points []Point
+		for _, p := range s.Points {
+			if p.T >= start && p.T <= end {
+				points = append(points, p)
+			}
+		}
+		out = append(out, Series{Metric: s.Metric, Points: points})
+	}
+	return out, nil
+}
+
+// counter returns a series with a point every 15s from 0 to 10 minutes, the value of which increases by inc per point
+func counter(inc float64, labels map[string]string) Series {
+	s := Series{Metric: NewLabels(labels)}
+	for t := int64(0); t <= 600000; t += 15000 {
+		s.Points = append(s.Points, Point{T: t, V: inc * float64(t/15000)})
+	}
+	return s
+}
+
+var testData = testQuerier{
+	counter(1, map[string]string{"__name__": "requests", "job": "api", "instance": "a"}),
+	counter(2, map[string]string{"__name__": "requests", "job": "api", "instance": "b"}),
+	counter(4, map[string]string{"__name__": "requests", "job": "web", "instance": "c"}),
+	counter(3, map[string]string{"__name__": "errors", "job": "api", "instance": "a"}),
+}
+
+func evalInstant(t *testing.T, q string, ts int64) Value {
+	e, err := Parse(q)
+	if err != nil {
+		t.Fatalf("%q: unexpected parse error %s", q, err)
+	}
+	v, err := EvalInstant(context.Background(), testData, e, ts)
+	if err != nil {
+		t.Fatalf("%q: unexpected error %s", q, err)
+	}
+	return v
+}
+
+func TestEvalInstant(t *testing.T) {
+	cases := []struct {
+		query string
+		ts    int64
+		exp   map[string]float64 // by the labels of the samples
+	}{
+		{`requests{instance="a"}`, 300000, map[string]float64{`{__name__="requests",instance="a",job="api"}`: 20}},
+		{`requests{instance="a"}`, 307000, map[string]float64{`{__name__="requests",instance="a",job="api"}`: 20}},
+		// the last point is older than the lookback delta
+		{`requests{instance="a"}`, 1000000, map[string]float64{}},
+		{`requests{instance="a"} offset 1m`, 300000, map[string]float64{`{__name__="requests",instance="a",job="api"}`: 16}},
+		{`rate(requests{instance="b"}[1m])`, 300000, map[string]float64{`{instance="b",job="api"}`: 8.0 / 60}},
+		{`increase(requests{instance="b"}[1m])`, 300000, map[string]float64{`{instance="b",job="api"}`: 8}},
+		{`irate(requests{instance="c"}[1m])`, 300000, map[string]float64{`{instance="c",job="web"}`: 4.0 / 15}},
+		{`delta(requests{instance="c"}[1m])`, 300000, map[string]float64{`{instance="c",job="web"}`: 16}},
+		{`sum by (job) (requests)`, 300000, map[string]float64{`{job="api"}`: 60, `{job="web"}`: 80}},
+		{`sum without (instance) (requests)`, 300000, map[string]float64{`{job="api"}`: 60, `{job="web"}`: 80}},
+		{`sum(requests)`, 300000, map[string]float64{`{}`: 140}},
+		{`avg(requests)`, 300000, map[string]float64{`{}`: 140.0 / 3}},
+		{`max by (job) (requests)`, 300000, map[string]float64{`{job="api"}`: 40, `{job="web"}`: 80}},
+		{`min(requests)`, 300000, map[string]float64{`{}`: 20}},
+		{`count by (job) (requests)`, 300000, map[string]float64{`{job="api"}`: 2, `{job="web"}`: 1}},
+		{`stddev(requests{job="api"})`, 300000, map[string]float64{`{}`: 10}},
+		{`topk(1, requests)`, 300000, map[string]float64{`{__name__="requests",instance="c",job="web"}`: 80}},
+		{`bottomk by (job) (1, requests)`, 300000, map[string]float64{
+			`{__name__="requests",instance="a",job="api"}`: 20,
+			`{__name__="requests",instance="c",job="web"}`: 80,
+		}},
+		{`max_over_time(requests{instance="a"}[1m])`, 300000, map[string]float64{`{instance="a",job="api"}`: 20}},
+		{`min_over_time(requests{instance="a"}[1m])`, 300000, map[string]float64{`{instance="a",job="api"}`: 16}},
+		{`avg_over_time(requests{instance="a"}[1m])`, 300000, map[string]float64{`{instance="a",job="api"}`: 18}},
+		{`sum_over_time(requests{instance="a"}[1m])`, 300000, map[string]float64{`{instance="a",job="api"}`: 90}},
+		{`count_over_time(requests{instance="a"}[1m])`, 300000, map[string]float64{`{instance="a",job="api"}`: 5}},
+		{`errors / requests`, 300000, map[string]float64{`{instance="a",job="api"}`: 3}},
+		{`requests{instance="a"} * 2 + 1`, 300000, map[string]float64{`{instance="a",job="api"}`: 41}},
+		{`-requests{instance="a"}`, 300000, map[string]float64{`{instance="a",job="api"}`: -20}},
+		{`vector(time())`, 300000, map[string]float64{`{}`: 300}},
+	}
+	for _, c := range cases {
+		v := evalInstant(t, c.query, c.ts)
+		vec, ok := v.(Vector)
+		if !ok {
+			t.Fatalf("%q: expected a vector, got %s", c.query, v.Type())
+		}
+		if len(vec) != len(c.exp) {
+			t.Fatalf("%q: expected %d samples, got %d: %v", c.query, len(c.exp), len(vec), vec)
+		}
+		for _, s := range vec {
+			exp, ok := c.exp[s.Metric.String()]
+			if !ok || math.Abs(s.V-exp) > 1e-9 || s.T != c.ts {
+				t.Fatalf("%q: unexpected sample %s %v at %d", c.query, s.Metric, s.V, s.T)
+			}
+		}
+	}
+}
+
+func TestEvalInstantScalarAndMatrix(t *testing.T) {
+	v := evalInstant(t, "scalar(sum(requests)) / 2", 300000)
+	if s, ok := v.(Scalar); !ok || s.V != 70 {
+		t.Fatalf("expected scalar 70, got %v", v)
+	}
+	v = evalInstant(t, `requests{instance="a"}[1m]`, 300000)
+	if m, ok := v.(Matrix); !ok || len(m) != 1 || len(m[0].Points) != 5 {
+		t.Fatalf("expected matrix with 5 points, got %v", v)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	for _, q := range []string{
+		// the series have the same labels once their names are dropped
+		`rate({job="api",instance="a"}[1m])`,
+		// two series on the right match the same series on the left
+		`errors / {job="api",instance="a"}`,
+	} {
+		e, err := Parse(q)
+		if err != nil {
+			t.Fatalf("%q: unexpected parse error %s", q, err)
+		}
+		if _, err := EvalInstant(context.Background(), testData, e, 300000); err == nil {
+			t.Fatalf("%q: expected error", q)
+		}
+	}
+}
+
+func TestExtrapolatedRate(t *testing.T) {
+	// a counter reset between the 3rd and 4th point
+	points := []Point{{0, 10}, {15000, 11}, {30000, 12}, {45000, 1}, {60000, 2}}
+	v, ok := extrapolatedRate(points, 0, 60000, true, false)
+	if !ok || v != 4 {
+		t.Fatalf("expected increase of 4, got %v", v)
+	}
+	// the points don't cover the edges of the range: extrapolate by half an interval on each side
+	points = []Point{{20000, 10}, {35000, 11}, {50000, 12}}
+	v, ok = extrapolatedRate(points, 0, 90000, false, false)
+	if !ok || math.Abs(v-3) > 1e-9 {
+		t.Fatalf("expected delta of 3, got %v", v)
+	}
+	if _, ok := extrapolatedRate(points[:1], 0, 60000, true, true); ok {
+		t.Fatalf("expected no rate for a single point")
+	}
+}
+
+func TestEvalRange(t *testing.T) {
+	e, err := Parse(`sum by (job) (rate(requests[1m]))`)
+	if err != nil {
+		t.Fatalf("unexpected parse error %s", err)
+	}
+	m, err := EvalRange(context.Background(), testData, e, 60000, 120000, 30*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if len(m) != 2 || m[0].Metric.String() != `{job="api"}` || m[1].Metric.String() != `{job="web"}` {
+		t.Fatalf("unexpected series %v", m)
+	}
+	for _, s := range m {
+		if len(s.Points) != 3 || s.Points[0].T != 60000 || s.Points[2].T != 120000 {
+			t.Fatalf("expected a point for each step, got %v", s.Points)
+		}
+	}
+	if math.Abs(m[0].Points[1].V-0.2) > 1e-9 {
+		t.Fatalf("expected rate of 0.2, got %v", m[0].Points[1].V)
+	}
+
+	e, _ = Parse(`requests[1m]`)
+	if _, err := EvalRange(context.Background(), testData, e, 60000, 120000, 30*time.Second); err == nil {
+		t.Fatalf("expected error for range query of a range vector")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e, _ = Parse(`requests`)
+	if _, err := EvalRange(ctx, testData, e, 60000, 120000, 30*time.Second); err != context.Canceled {
+		t.Fatalf("expected canceled error, got %v", err)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	m := Matrix{
+		{Metric: NewLabels(map[string]string{"job": "api"}), Points: []Point{{T: 1500, V: 1.5}, {T: 3000, V: math.Inf(1)}}},
+	}
+	buf, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	exp := `[{"metric":{"job":"api"},"values":[[1.5,"1.5"],[3,"+Inf"]]}]`
+	if string(buf) != exp {
+		t.Fatalf("expected %s, got %s", exp, buf)
+	}
+
+	buf, _ = json.Marshal(Vector{{Metric: Labels{}, Point: Point{T: 1000, V: math.NaN()}}})
+	exp = `[{"metric":{},"value":[1,"NaN"]}]`
+	if string(buf) != exp {
+		t.Fatalf("expected %s, got %s", exp, buf)
+	}
+}
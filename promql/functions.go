@@ -0,0 +1,235 @@
+package promql
+
+import (
+	"math"
+)
+
+// Function is a PromQL function
+type Function struct {
+	Name       string
+	ArgTypes   []ValueType
+	ReturnType ValueType
+
+	// call evaluates the function at the given time, with its evaluated arguments
+	call func(args []Value, e *Call, ts int64) Value
+}
+
+var functions = map[string]*Function{}
+
+func init() {
+	register := func(name string, argType, returnType ValueType, call func(args []Value, e *Call, ts int64) Value) {
+		f := &Function{Name: name, ReturnType: returnType, call: call}
+		if argType != ValueTypeNone {
+			f.ArgTypes = []ValueType{argType}
+		}
+		functions[name] = f
+	}
+
+	register("rate", ValueTypeMatrix, ValueTypeVector, rangeFunc(func(points []Point, start, end int64) (float64, bool) {
+		return extrapolatedRate(points, start, end, true, true)
+	}))
+	register("increase", ValueTypeMatrix, ValueTypeVector, rangeFunc(func(points []Point, start, end int64) (float64, bool) {
+		return extrapolatedRate(points, start, end, true, false)
+	}))
+	register("delta", ValueTypeMatrix, ValueTypeVector, rangeFunc(func(points []Point, start, end int64) (float64, bool) {
+		return extrapolatedRate(points, start, end, false, false)
+	}))
+	register("irate", ValueTypeMatrix, ValueTypeVector, rangeFunc(func(points []Point, start, end int64) (float64, bool) {
+		return instantValue(points, true)
+	}))
+	register("idelta", ValueTypeMatrix, ValueTypeVector, rangeFunc(func(points []Point, start, end int64) (float64, bool) {
+		return instantValue(points, false)
+	}))
+
+	register("avg_over_time", ValueTypeMatrix, ValueTypeVector, overTime(func(points []Point) float64 {
+		var mean float64
+		for i, p := range points {
+			mean += (p.V - mean) / float64(i+1)
+		}
+		return mean
+	}))
+	register("sum_over_time", ValueTypeMatrix, ValueTypeVector, overTime(func(points []Point) float64 {
+		var sum float64
+		for _, p := range points {
+			sum += p.V
+		}
+		return sum
+	}))
+	register("min_over_time", ValueTypeMatrix, ValueTypeVector, overTime(func(points []Point) float64 {
+		min := points[0].V
+		for _, p := range points {
+			if p.V < min || math.IsNaN(min) {
+				min = p.V
+			}
+		}
+		return min
+	}))
+	register("max_over_time", ValueTypeMatrix, ValueTypeVector, overTime(func(points []Point) float64 {
+		max := points[0].V
+		for _, p := range points {
+			if p.V > max || math.IsNaN(max) {
+				max = p.V
+			}
+		}
+		return max
+	}))
+	register("count_over_time", ValueTypeMatrix, ValueTypeVector, overTime(func(points []Point) float64 {
+		return float64(len(points))
+	}))
+	register("last_over_time", ValueTypeMatrix, ValueTypeVector, overTime(func(points []Point) float64 {
+		return points[len(points)-1].V
+	}))
+	register("stddev_over_time", ValueTypeMatrix, ValueTypeVector, overTime(func(points []Point) float64 {
+		return math.Sqrt(variance(points))
+	}))
+	register("stdvar_over_time", ValueTypeMatrix, ValueTypeVector, overTime(variance))
+
+	for name, fn := range map[string]func(float64) float64{
+		"abs":   math.Abs,
+		"ceil":  math.Ceil,
+		"floor": math.Floor,
+		"sqrt":  math.Sqrt,
+		"exp":   math.Exp,
+		"ln":    math.Log,
+		"log2":  math.Log2,
+		"log10": math.Log10,
+	} {
+		register(name, ValueTypeVector, ValueTypeVector, mathFunc(fn))
+	}
+
+	register("time", ValueTypeNone, ValueTypeScalar, func(args []Value, e *Call, ts int64) Value {
+		return Scalar{T: ts, V: float64(ts) / 1000}
+	})
+	register("vector", ValueTypeScalar, ValueTypeVector, func(args []Value, e *Call, ts int64) Value {
+		return Vector{{Metric: Labels{}, Point: Point{T: ts, V: args[0].(Scalar).V}}}
+	})
+	register("scalar", ValueTypeVector, ValueTypeScalar, func(args []Value, e *Call, ts int64) Value {
+		vec := args[0].(Vector)
+		if len(vec) != 1 {
+			return Scalar{T: ts, V: math.NaN()}
+		}
+		return Scalar{T: ts, V: vec[0].V}
+	})
+}
+
+// rangeFunc returns the call of a function that computes a value for each series of a range vector, given the range
+// it was selected over. the series for which fn returns false are left out of the result
+func rangeFunc(fn func(points []Point, start, end int64) (float64, bool)) func(args []Value, e *Call, ts int64) Value {
+	return func(args []Value, e *Call, ts int64) Value {
+		ms := e.Args[0].(*MatrixSelector)
+		end := ts - durationMs(ms.Offset)
+		start := end - durationMs(ms.Range)
+		out := Vector{}
+		for _, s := range args[0].(Matrix) {
+			v, ok := fn(s.Points, start, end)
+			if !ok {
+				continue
+			}
+			out = append(out, Sample{Metric: s.Metric.Without(MetricNameLabel), Point: Point{T: ts, V: v}})
+		}
+		return out
+	}
+}
+
+// overTime returns the call of a function that aggregates the points of each series of a range vector
+func overTime(fn func(points []Point) float64) func(args []Value, e *Call, ts int64) Value {
+	return rangeFunc(func(points []Point, start, end int64) (float64, bool) {
+		return fn(points), true
+	})
+}
+
+// mathFunc returns the call of a function that applies fn to each sample of a vector
+func mathFunc(fn func(float64) float64) func(args []Value, e *Call, ts int64) Value {
+	return func(args []Value, e *Call, ts int64) Value {
+		vec := args[0].(Vector)
+		out := make(Vector, 0, len(vec))
+		for _, s := range vec {
+			out = append(out, Sample{Metric: s.Metric.Without(MetricNameLabel), Point: Point{T: ts, V: fn(s.V)}})
+		}
+		return out
+	}
+}
+
+// extrapolatedRate computes the rate, increase or delta of the points over the range between start and end, in milliseconds.
+// like prometheus, it extrapolates the change between the first and last point to the edges of the range,
+// unless those are too far away from the points, and for counters, corrects for counter resets.
+func extrapolatedRate(points []Point, start, end int64, isCounter, isRate bool) (float64, bool) {
+	if len(points) < 2 {
+		return 0, false
+	}
+	first, last := points[0], points[len(points)-1]
+	result := last.V - first.V
+	if isCounter {
+		var prev float64
+		for _, p := range points {
+			if p.V < prev {
+				result += prev
+			}
+			prev = p.V
+		}
+	}
+
+	durationToStart := float64(first.T-start) / 1000
+	durationToEnd := float64(end-last.T) / 1000
+	sampledInterval := float64(last.T-first.T) / 1000
+	averageInterval := sampledInterval / float64(len(points)-1)
+
+	if isCounter && result > 0 && first.V >= 0 {
+		// a counter can't go below zero, so don't extrapolate further back than where it would have been zero
+		durationToZero := sampledInterval * (first.V / result)
+		if durationToZero < durationToStart {
+			durationToStart = durationToZero
+		}
+	}
+
+	// extrapolate to the edge of the range if it's close enough to the points, by at most half an interval otherwise
+	threshold := averageInterval * 1.1
+	extrapolateTo := sampledInterval
+	if durationToStart < threshold {
+		extrapolateTo += durationToStart
+	} else {
+		extrapolateTo += averageInterval / 2
+	}
+	if durationToEnd < threshold {
+		extrapolateTo += durationToEnd
+	} else {
+		extrapolateTo += averageInterval / 2
+	}
+	result = result * (extrapolateTo / sampledInterval)
+	if isRate {
+		result = result / (float64(end-start) / 1000)
+	}
+	return result, true
+}
+
+// instantValue computes the per-second rate or the difference between the last two points
+func instantValue(points []Point, isRate bool) (float64, bool) {
+	if len(points) < 2 {
+		return 0, false
+	}
+	prev, last := points[len(points)-2], points[len(points)-1]
+	result := last.V - prev.V
+	if isRate && last.V < prev.V {
+		// counter reset
+		result = last.V
+	}
+	if !isRate {
+		return result, true
+	}
+	interval := last.T - prev.T
+	if interval == 0 {
+		return 0, false
+	}
+	return result / (float64(interval) / 1000), true
+}
+
+// variance returns the population variance of the values of the points
+func variance(points []Point) float64 {
+	var mean, sum float64
+	for i, p := range points {
+		delta := p.V - mean
+		mean += delta / float64(i+1)
+		sum += delta * (p.V - mean)
+	}
+	return sum / float64(len(points))
+}
@@ -0,0 +1,57 @@
+package promql
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+)
+
+// the values are encoded the way the prometheus http api does: timestamps as seconds,
+// and values as strings, so that NaN and Inf can be represented
+
+func (ls Labels) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ls.Map())
+}
+
+func (p Point) MarshalJSON() ([]byte, error) {
+	return appendPoint(nil, p), nil
+}
+
+func (s Scalar) MarshalJSON() ([]byte, error) {
+	return Point(s).MarshalJSON()
+}
+
+func (s Sample) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Metric Labels `json:"metric"`
+		Value  Point  `json:"value"`
+	}{s.Metric, s.Point})
+}
+
+func (s Series) MarshalJSON() ([]byte, error) {
+	points := s.Points
+	if points == nil {
+		points = []Point{}
+	}
+	return json.Marshal(struct {
+		Metric Labels  `json:"metric"`
+		Values []Point `json:"values"`
+	}{s.Metric, points})
+}
+
+func appendPoint(b []byte, p Point) []byte {
+	b = append(b, '[')
+	b = strconv.AppendFloat(b, float64(p.T)/1000, 'f', -1, 64)
+	b = append(b, ',', '"')
+	switch {
+	case math.IsInf(p.V, 1):
+		b = append(b, "+Inf"...)
+	case math.IsInf(p.V, -1):
+		b = append(b, "-Inf"...)
+	case math.IsNaN(p.V):
+		b = append(b, "NaN"...)
+	default:
+		b = strconv.AppendFloat(b, p.V, 'f', -1, 64)
+	}
+	return append(b, '"', ']')
+}
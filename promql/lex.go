@@ -0,0 +1,251 @@
+package promql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type itemType int
+
+const (
+	itemEOF itemType = iota
+	itemIdentifier
+	itemNumber
+	itemDuration
+	itemString
+	itemLeftParen
+	itemRightParen
+	itemLeftBrace
+	itemRightBrace
+	itemLeftBracket
+	itemRightBracket
+	itemComma
+	itemEQL
+	itemNEQ
+	itemEQLRegex
+	itemNEQRegex
+	itemADD
+	itemSUB
+	itemMUL
+	itemDIV
+	itemMOD
+	itemPOW
+)
+
+// item is a token of the input, along with its position
+type item struct {
+	typ itemType
+	pos int
+	val string
+}
+
+func (i item) String() string {
+	if i.typ == itemEOF {
+		return "end of input"
+	}
+	return strconv.Quote(i.val)
+}
+
+var operators = map[string]itemType{
+	"(":  itemLeftParen,
+	")":  itemRightParen,
+	"{":  itemLeftBrace,
+	"}":  itemRightBrace,
+	"[":  itemLeftBracket,
+	"]":  itemRightBracket,
+	",":  itemComma,
+	"=":  itemEQL,
+	"!=": itemNEQ,
+	"=~": itemEQLRegex,
+	"!~": itemNEQRegex,
+	"+":  itemADD,
+	"-":  itemSUB,
+	"*":  itemMUL,
+	"/":  itemDIV,
+	"%":  itemMOD,
+	"^":  itemPOW,
+}
+
+var durationUnits = []struct {
+	unit string
+	ms   int64
+}{
+	{"y", 365 * 24 * 3600 * 1000},
+	{"w", 7 * 24 * 3600 * 1000},
+	{"d", 24 * 3600 * 1000},
+	{"h", 3600 * 1000},
+	{"m", 60 * 1000},
+	{"s", 1000},
+	{"ms", 1},
+}
+
+// lex splits the input into items, ending with an itemEOF
+func lex(input string) ([]item, error) {
+	var items []item
+	pos := 0
+	for {
+		for pos < len(input) && unicode.IsSpace(rune(input[pos])) {
+			pos++
+		}
+		if pos == len(input) {
+			return append(items, item{typ: itemEOF, pos: pos}), nil
+		}
+		start := pos
+		c := input[pos]
+		switch {
+		case c == '#':
+			// comment until the end of the line
+			for pos < len(input) && input[pos] != '\n' {
+				pos++
+			}
+			continue
+		case isDigit(c) || (c == '.' && pos+1 < len(input) && isDigit(input[pos+1])):
+			typ, end, err := lexNumberOrDuration(input, pos)
+			if err != nil {
+				return nil, ParseError{Pos: start, Err: err.Error()}
+			}
+			pos = end
+			items = append(items, item{typ: typ, pos: start, val: input[start:pos]})
+		case isAlpha(c) || c == ':':
+			for pos < len(input) && (isAlpha(input[pos]) || isDigit(input[pos]) || input[pos] == ':') {
+				pos++
+			}
+			items = append(items, item{typ: itemIdentifier, pos: start, val: input[start:pos]})
+		case c == '"' || c == '\'' || c == '`':
+			pos++
+			for pos < len(input) && input[pos] != c {
+				if input[pos] == '\\' && c != '`' {
+					pos++
+				}
+				pos++
+			}
+			if pos >= len(input) {
+				return nil, ParseError{Pos: start, Err: "unterminated quoted string"}
+			}
+			pos++
+			s, err := unquote(input[start:pos])
+			if err != nil {
+				return nil, ParseError{Pos: start, Err: fmt.Sprintf("invalid quoted string %s: %s", input[start:pos], err)}
+			}
+			items = append(items, item{typ: itemString, pos: start, val: s})
+		default:
+			if pos+1 < len(input) {
+				if typ, ok := operators[input[pos:pos+2]]; ok {
+					pos += 2
+					items = append(items, item{typ: typ, pos: start, val: input[start:pos]})
+					continue
+				}
+			}
+			typ, ok := operators[input[pos:pos+1]]
+			if !ok {
+				return nil, ParseError{Pos: pos, Err: fmt.Sprintf("unexpected character %q", c)}
+			}
+			pos++
+			items = append(items, item{typ: typ, pos: start, val: input[start:pos]})
+		}
+	}
+}
+
+// lexNumberOrDuration scans a number, like 1, 2.5 or 1e-3, or a duration, like 5m or 1h30m.
+// it returns the type of the item and the position right after it
+func lexNumberOrDuration(input string, pos int) (itemType, int, error) {
+	start := pos
+	digits := func() {
+		for pos < len(input) && isDigit(input[pos]) {
+			pos++
+		}
+	}
+	digits()
+	if pos < len(input) && unitStart(input[pos]) {
+		for pos < len(input) && unitStart(input[pos]) {
+			for pos < len(input) && isAlpha(input[pos]) {
+				pos++
+			}
+			digits()
+		}
+		if _, err := ParseDuration(input[start:pos]); err != nil {
+			return 0, 0, err
+		}
+		return itemDuration, pos, nil
+	}
+	if pos < len(input) && input[pos] == '.' {
+		pos++
+		digits()
+	}
+	if pos < len(input) && (input[pos] == 'e' || input[pos] == 'E') {
+		pos++
+		if pos < len(input) && (input[pos] == '+' || input[pos] == '-') {
+			pos++
+		}
+		digits()
+	}
+	if pos < len(input) && (isAlpha(input[pos]) || input[pos] == '.') {
+		return 0, 0, fmt.Errorf("bad number or duration syntax %q", input[start:pos+1])
+	}
+	return itemNumber, pos, nil
+}
+
+// ParseDuration parses a PromQL duration, which is a sequence of integers with a unit, from largest to smallest unit. e.g. 1h30m
+func ParseDuration(s string) (time.Duration, error) {
+	orig := s
+	var ms int64
+	last := -1
+	for s != "" {
+		i := 0
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		n, err := strconv.ParseInt(s[:i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		s = s[i:]
+		j := 0
+		for j < len(s) && isAlpha(s[j]) {
+			j++
+		}
+		unit := -1
+		for k, u := range durationUnits {
+			if u.unit == s[:j] {
+				unit = k
+			}
+		}
+		if unit <= last {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		last = unit
+		ms += n * durationUnits[unit].ms
+		s = s[j:]
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// unquote returns the value of a quoted string. single and double quoted strings use go's escaping rules, backquoted strings are raw
+func unquote(s string) (string, error) {
+	if s[0] == '\'' {
+		s = s[1 : len(s)-1]
+		s = strings.Replace(s, `\'`, `'`, -1)
+		s = strings.Replace(s, `\"`, `"`, -1)
+		s = strings.Replace(s, `"`, `\"`, -1)
+		s = `"` + s + `"`
+	}
+	return strconv.Unquote(s)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func unitStart(c byte) bool {
+	return strings.IndexByte("smhdwy", c) != -1
+}
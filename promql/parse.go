@@ -0,0 +1,352 @@
+package promql
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseError is returned for expressions that are invalid or that we don't support
+type ParseError struct {
+	Pos int
+	Err string
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("parse error at position %d: %s", e.Pos, e.Err)
+}
+
+var aggregators = map[string]bool{
+	"sum":     false,
+	"avg":     false,
+	"min":     false,
+	"max":     false,
+	"count":   false,
+	"stddev":  false,
+	"stdvar":  false,
+	"topk":    true,
+	"bottomk": true,
+}
+
+// precedence of the binary operators. ^ is right associative, the others are left associative
+var precedence = map[itemType]int{
+	itemADD: 1,
+	itemSUB: 1,
+	itemMUL: 2,
+	itemDIV: 2,
+	itemMOD: 2,
+	itemPOW: 3,
+}
+
+type parser struct {
+	items []item
+	pos   int
+}
+
+// Parse parses a PromQL expression
+func Parse(input string) (expr Expr, err error) {
+	items, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{items: items}
+	defer p.recover(&err)
+	expr = p.expr(0)
+	p.expect(itemEOF, "end of input")
+	return expr, nil
+}
+
+// ParseMetricSelector parses a vector selector, like foo{job="api"}, into its matchers
+func ParseMetricSelector(input string) (matchers []*LabelMatcher, err error) {
+	items, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{items: items}
+	defer p.recover(&err)
+	var name string
+	if p.peek().typ == itemIdentifier {
+		name = p.next().val
+	}
+	vs := p.vectorSelector(name)
+	p.expect(itemEOF, "end of input")
+	return vs.Matchers, nil
+}
+
+func (p *parser) peek() item {
+	return p.items[p.pos]
+}
+
+func (p *parser) next() item {
+	i := p.items[p.pos]
+	if i.typ != itemEOF {
+		p.pos++
+	}
+	return i
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) {
+	panic(ParseError{Pos: pos, Err: fmt.Sprintf(format, args...)})
+}
+
+func (p *parser) expect(typ itemType, desc string) item {
+	i := p.next()
+	if i.typ != typ {
+		p.errorf(i.pos, "unexpected %s, expected %s", i, desc)
+	}
+	return i
+}
+
+// recover turns the panics of errorf into an error
+func (p *parser) recover(err *error) {
+	if r := recover(); r != nil {
+		if e, ok := r.(ParseError); ok {
+			*err = e
+			return
+		}
+		panic(r)
+	}
+}
+
+// expr parses a sequence of binary operations with at least the given precedence
+func (p *parser) expr(minPrec int) Expr {
+	lhs := p.unary()
+	for {
+		op := p.peek()
+		prec, ok := precedence[op.typ]
+		if !ok || prec < minPrec {
+			return lhs
+		}
+		p.next()
+		if next := p.peek(); next.typ == itemIdentifier && (next.val == "on" || next.val == "ignoring" || next.val == "bool") {
+			p.errorf(next.pos, "%s is not supported", next.val)
+		}
+		if prec != precedence[itemPOW] {
+			prec++
+		}
+		rhs := p.expr(prec)
+		for _, e := range []Expr{lhs, rhs} {
+			if t := e.Type(); t != ValueTypeScalar && t != ValueTypeVector {
+				p.errorf(op.pos, "binary expression must contain only scalar and instant vector types")
+			}
+		}
+		lhs = &BinaryExpr{Op: op.val, LHS: lhs, RHS: rhs}
+	}
+}
+
+// unary parses an expression that may be preceded by a sign. like in prometheus, -2^2 is -(2^2)
+func (p *parser) unary() Expr {
+	op := p.peek()
+	if op.typ != itemADD && op.typ != itemSUB {
+		return p.postfix(p.primary())
+	}
+	p.next()
+	e := p.expr(precedence[itemPOW])
+	if t := e.Type(); t != ValueTypeScalar && t != ValueTypeVector {
+		p.errorf(op.pos, "unary expression only allowed on expressions of type scalar or instant vector")
+	}
+	if op.typ == itemADD {
+		return e
+	}
+	if n, ok := e.(*NumberLiteral); ok {
+		return &NumberLiteral{Val: -n.Val}
+	}
+	return &UnaryExpr{Op: op.val, Expr: e}
+}
+
+// postfix parses the range and offset that may follow a vector selector
+func (p *parser) postfix(e Expr) Expr {
+	if p.peek().typ == itemLeftBracket {
+		open := p.next()
+		vs, ok := e.(*VectorSelector)
+		if !ok || vs.Offset != 0 {
+			p.errorf(open.pos, "ranges only allowed for vector selectors")
+		}
+		rng := p.duration()
+		if p.peek().typ != itemRightBracket {
+			p.errorf(p.peek().pos, "unexpected %s in range, expected \"]\". note that subqueries are not supported", p.peek())
+		}
+		p.next()
+		e = &MatrixSelector{VectorSelector: vs, Range: rng}
+	}
+	if p.peek().typ == itemIdentifier && p.peek().val == "offset" {
+		kw := p.next()
+		offset := p.duration()
+		switch e := e.(type) {
+		case *VectorSelector:
+			e.Offset = offset
+		case *MatrixSelector:
+			e.Offset = offset
+		default:
+			p.errorf(kw.pos, "offset modifier must be preceded by a vector or range selector")
+		}
+	}
+	return e
+}
+
+func (p *parser) primary() Expr {
+	i := p.next()
+	switch i.typ {
+	case itemNumber:
+		v, err := strconv.ParseFloat(i.val, 64)
+		if err != nil {
+			p.errorf(i.pos, "invalid number %s", i)
+		}
+		return &NumberLiteral{Val: v}
+	case itemLeftParen:
+		e := p.expr(0)
+		p.expect(itemRightParen, "\")\"")
+		return &ParenExpr{Expr: e}
+	case itemLeftBrace:
+		p.pos--
+		return p.vectorSelector("")
+	case itemIdentifier:
+		next := p.peek().typ
+		if _, ok := aggregators[i.val]; ok && (next == itemLeftParen || (next == itemIdentifier && (p.peek().val == "by" || p.peek().val == "without"))) {
+			return p.aggregateExpr(i)
+		}
+		if next == itemLeftParen {
+			return p.call(i)
+		}
+		switch strings.ToLower(i.val) {
+		case "inf":
+			return &NumberLiteral{Val: math.Inf(1)}
+		case "nan":
+			return &NumberLiteral{Val: math.NaN()}
+		}
+		return p.vectorSelector(i.val)
+	case itemString:
+		p.errorf(i.pos, "string literals are only supported as label values")
+	}
+	p.errorf(i.pos, "unexpected %s", i)
+	return nil
+}
+
+// vectorSelector parses the label matchers that may follow the name of a selector
+func (p *parser) vectorSelector(name string) *VectorSelector {
+	start := p.peek().pos
+	vs := &VectorSelector{Name: name}
+	if name != "" {
+		m, _ := NewLabelMatcher(MatchEqual, MetricNameLabel, name)
+		vs.Matchers = append(vs.Matchers, m)
+	}
+	if p.peek().typ == itemLeftBrace {
+		p.next()
+		for p.peek().typ != itemRightBrace {
+			label := p.expect(itemIdentifier, "label name")
+			op := p.next()
+			var t MatchType
+			switch op.typ {
+			case itemEQL:
+				t = MatchEqual
+			case itemNEQ:
+				t = MatchNotEqual
+			case itemEQLRegex:
+				t = MatchRegexp
+			case itemNEQRegex:
+				t = MatchNotRegexp
+			default:
+				p.errorf(op.pos, "unexpected %s in label matching, expected one of \"=\", \"!=\", \"=~\" or \"!~\"", op)
+			}
+			value := p.expect(itemString, "label value string")
+			m, err := NewLabelMatcher(t, label.val, value.val)
+			if err != nil {
+				p.errorf(value.pos, "invalid regex %s: %s", value, err)
+			}
+			vs.Matchers = append(vs.Matchers, m)
+			if p.peek().typ != itemComma {
+				break
+			}
+			p.next()
+		}
+		p.expect(itemRightBrace, "\"}\"")
+	}
+	// like prometheus, refuse selectors that would match every series
+	for _, m := range vs.Matchers {
+		if !m.Matches("") {
+			return vs
+		}
+	}
+	p.errorf(start, "vector selector must contain at least one non-empty matcher")
+	return nil
+}
+
+func (p *parser) duration() time.Duration {
+	i := p.expect(itemDuration, "duration")
+	d, err := ParseDuration(i.val)
+	if err != nil {
+		p.errorf(i.pos, "%s", err)
+	}
+	return d
+}
+
+func (p *parser) aggregateExpr(op item) Expr {
+	e := &AggregateExpr{Op: op.val}
+	grouping := false
+	if p.peek().typ == itemIdentifier {
+		e.Without = p.next().val == "without"
+		e.Grouping = p.labels()
+		grouping = true
+	}
+	p.expect(itemLeftParen, "\"(\"")
+	if aggregators[op.val] {
+		e.Param = p.expr(0)
+		if e.Param.Type() != ValueTypeScalar {
+			p.errorf(op.pos, "expected type scalar in aggregation parameter, got %s", e.Param.Type())
+		}
+		p.expect(itemComma, "\",\"")
+	}
+	e.Expr = p.expr(0)
+	p.expect(itemRightParen, "\")\"")
+	if e.Expr.Type() != ValueTypeVector {
+		p.errorf(op.pos, "expected type instant vector in aggregation expression, got %s", e.Expr.Type())
+	}
+	if !grouping && p.peek().typ == itemIdentifier && (p.peek().val == "by" || p.peek().val == "without") {
+		e.Without = p.next().val == "without"
+		e.Grouping = p.labels()
+	}
+	return e
+}
+
+// labels parses a parenthesized list of label names, like (job, instance)
+func (p *parser) labels() []string {
+	p.expect(itemLeftParen, "\"(\"")
+	labels := []string{}
+	for p.peek().typ != itemRightParen {
+		labels = append(labels, p.expect(itemIdentifier, "label name").val)
+		if p.peek().typ != itemComma {
+			break
+		}
+		p.next()
+	}
+	p.expect(itemRightParen, "\")\"")
+	return labels
+}
+
+func (p *parser) call(name item) Expr {
+	fn, ok := functions[name.val]
+	if !ok {
+		p.errorf(name.pos, "unknown function with name %q", name.val)
+	}
+	p.next()
+	e := &Call{Func: fn}
+	for p.peek().typ != itemRightParen {
+		e.Args = append(e.Args, p.expr(0))
+		if p.peek().typ != itemComma {
+			break
+		}
+		p.next()
+	}
+	p.expect(itemRightParen, "\")\"")
+	if len(e.Args) != len(fn.ArgTypes) {
+		p.errorf(name.pos, "expected %d argument(s) in call to %q, got %d", len(fn.ArgTypes), fn.Name, len(e.Args))
+	}
+	for i, arg := range e.Args {
+		if arg.Type() != fn.ArgTypes[i] {
+			p.errorf(name.pos, "expected type %s in call to function %q, got %s", fn.ArgTypes[i], fn.Name, arg.Type())
+		}
+	}
+	return e
+}
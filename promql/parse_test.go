@@ -0,0 +1,141 @@
+package promql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		in  string
+		out string // the String() of the parsed expression
+		typ ValueType
+	}{
+		{"1", "1", ValueTypeScalar},
+		{"-2^2", "-(2 ^ 2)", ValueTypeScalar},
+		{"-foo", "-foo", ValueTypeVector},
+		{"foo", "foo", ValueTypeVector},
+		{`foo{job="api",instance=~'host.*'}`, `foo{job="api",instance=~"host.*"}`, ValueTypeVector},
+		{`{__name__="foo"}`, `{__name__="foo"}`, ValueTypeVector},
+		{"foo offset 5m", "foo offset 5m", ValueTypeVector},
+		{"foo[90s] offset 1h", "foo[90s] offset 1h", ValueTypeMatrix},
+		{"rate(foo[5m])", "rate(foo[5m])", ValueTypeVector},
+		{"sum by (job) (rate(foo[1m]))", "sum by (job) (rate(foo[1m]))", ValueTypeVector},
+		{"sum(rate(foo[1m])) without (instance)", "sum without (instance) (rate(foo[1m]))", ValueTypeVector},
+		{"topk(3, foo)", "topk (3, foo)", ValueTypeVector},
+		{"foo + bar * 2", "foo + bar * 2", ValueTypeVector},
+		{"(foo + bar) * 2", "(foo + bar) * 2", ValueTypeVector},
+		{"2 ^ 3 ^ 2", "2 ^ 3 ^ 2", ValueTypeScalar},
+		{"time() - 1", "time() - 1", ValueTypeScalar},
+		{"avg_over_time(foo[1h30m])", "avg_over_time(foo[90m])", ValueTypeVector},
+	}
+	for _, c := range cases {
+		e, err := Parse(c.in)
+		if err != nil {
+			t.Fatalf("%q: unexpected error %s", c.in, err)
+		}
+		if e.String() != c.out || e.Type() != c.typ {
+			t.Fatalf("%q: expected %q of type %s, got %q of type %s", c.in, c.out, c.typ, e.String(), e.Type())
+		}
+	}
+}
+
+func TestParsePrecedence(t *testing.T) {
+	e, err := Parse("a + b * c - d")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	// (a + (b * c)) - d
+	sub, ok := e.(*BinaryExpr)
+	if !ok || sub.Op != "-" {
+		t.Fatalf("expected subtraction at the root, got %s", e)
+	}
+	add, ok := sub.LHS.(*BinaryExpr)
+	if !ok || add.Op != "+" {
+		t.Fatalf("expected addition on the left of the subtraction, got %s", sub.LHS)
+	}
+	if mul, ok := add.RHS.(*BinaryExpr); !ok || mul.Op != "*" {
+		t.Fatalf("expected multiplication on the right of the addition, got %s", add.RHS)
+	}
+
+	e, err = Parse("2 ^ 3 ^ 2")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if pow := e.(*BinaryExpr); pow.RHS.String() != "3 ^ 2" {
+		t.Fatalf("expected ^ to be right associative, got %s", e)
+	}
+
+	e, err = Parse("foo[5m] offset 1m")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if ms := e.(*MatrixSelector); ms.Range != 5*time.Minute || ms.Offset != time.Minute {
+		t.Fatalf("unexpected range %s and offset %s", ms.Range, ms.Offset)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []string{
+		"",
+		"foo{",
+		`foo{job="api"`,
+		`foo{job=api}`,
+		`{job=""}`,
+		`{job=~".*"}`,
+		`foo{job=~"("}`,
+		"foo[5]",
+		"foo[5m:1m]",
+		"rate(foo)",
+		"rate(foo[5m], 1)",
+		"unknown(foo)",
+		"sum(foo[5m])",
+		"topk(foo, bar)",
+		"foo[5m] + 1",
+		"foo / on(job) bar",
+		"1.5m",
+		`"foo"`,
+		"foo )",
+	}
+	for _, c := range cases {
+		if _, err := Parse(c); err == nil {
+			t.Fatalf("%q: expected error", c)
+		} else if _, ok := err.(ParseError); !ok {
+			t.Fatalf("%q: expected a ParseError, got %T", c, err)
+		}
+	}
+}
+
+func TestParseMetricSelector(t *testing.T) {
+	matchers, err := ParseMetricSelector(`up{job!="api"}`)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if len(matchers) != 2 || matchers[0].String() != `__name__="up"` || matchers[1].String() != `job!="api"` {
+		t.Fatalf("unexpected matchers %v", matchers)
+	}
+	if _, err := ParseMetricSelector("rate(up[5m])"); err == nil {
+		t.Fatalf("expected error for expression that is not a selector")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"5m":      5 * time.Minute,
+		"1h30m":   90 * time.Minute,
+		"2d":      48 * time.Hour,
+		"1w":      7 * 24 * time.Hour,
+		"1s500ms": 1500 * time.Millisecond,
+	}
+	for in, exp := range cases {
+		d, err := ParseDuration(in)
+		if err != nil || d != exp {
+			t.Fatalf("%q: expected %s, got %s (err %v)", in, exp, d, err)
+		}
+	}
+	for _, in := range []string{"5", "m", "30m1h", "5min"} {
+		if _, err := ParseDuration(in); err == nil {
+			t.Fatalf("%q: expected error", in)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package promql
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MetricNameLabel is the label holding the name of a series
+const MetricNameLabel = "__name__"
+
+// ValueType is the type of the value an expression evaluates to
+type ValueType string
+
+const (
+	ValueTypeNone   ValueType = "none"
+	ValueTypeScalar ValueType = "scalar"
+	ValueTypeVector ValueType = "vector"
+	ValueTypeMatrix ValueType = "matrix"
+)
+
+// Label is a name/value pair identifying a series
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Labels is a set of labels, sorted by name
+type Labels []Label
+
+// NewLabels returns the labels of the given map, sorted by name
+func NewLabels(m map[string]string) Labels {
+	ls := make(Labels, 0, len(m))
+	for name, value := range m {
+		ls = append(ls, Label{Name: name, Value: value})
+	}
+	sort.Slice(ls, func(i, j int) bool { return ls[i].Name < ls[j].Name })
+	return ls
+}
+
+// Get returns the value of the label with the given name, or "" if there is no such label
+func (ls Labels) Get(name string) string {
+	for _, l := range ls {
+		if l.Name == name {
+			return l.Value
+		}
+	}
+	return ""
+}
+
+// Map returns the labels as a map
+func (ls Labels) Map() map[string]string {
+	m := make(map[string]string, len(ls))
+	for _, l := range ls {
+		m[l.Name] = l.Value
+	}
+	return m
+}
+
+// Without returns the labels, without the ones with the given names
+func (ls Labels) Without(names ...string) Labels {
+	out := make(Labels, 0, len(ls))
+	for _, l := range ls {
+		if !contains(names, l.Name) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// Only returns the labels with the given names
+func (ls Labels) Only(names ...string) Labels {
+	out := make(Labels, 0, len(names))
+	for _, l := range ls {
+		if contains(names, l.Name) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// String returns the labels in selector syntax, e.g. {job="api",le="0.5"}.
+// since labels are sorted, it also serves as a key to identify a series by.
+func (ls Labels) String() string {
+	parts := make([]string, 0, len(ls))
+	for _, l := range ls {
+		parts = append(parts, l.Name+"="+strconv.Quote(l.Value))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Point is a value at a timestamp in milliseconds
+type Point struct {
+	T int64
+	V float64
+}
+
+// Series is a series along with its points, sorted by time
+type Series struct {
+	Metric Labels
+	Points []Point
+}
+
+// Sample is the value of a series at a given time
+type Sample struct {
+	Metric Labels
+	Point
+}
+
+// Value is the result of evaluating an expression
+type Value interface {
+	Type() ValueType
+}
+
+// Scalar is a single number at a timestamp
+type Scalar Point
+
+func (Scalar) Type() ValueType { return ValueTypeScalar }
+
+// Vector is a set of samples at the same time, one per series
+type Vector []Sample
+
+func (Vector) Type() ValueType { return ValueTypeVector }
+
+// Matrix is a set of series
+type Matrix []Series
+
+func (Matrix) Type() ValueType { return ValueTypeMatrix }